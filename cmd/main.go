@@ -4,6 +4,7 @@ import (
 	"context"
 	"log"
 	"os"
+	"time"
 
 	"invoice_project/pkg/infrastructure"
 	"invoice_project/pkg/middleware"
@@ -36,7 +37,10 @@ import (
 	productRepo "invoice_project/internal/product/repository"
 	productUC "invoice_project/internal/product/usecase"
 
+	"invoice_project/pkg/mailer"
 	"invoice_project/pkg/otp"
+	"invoice_project/pkg/pdf/fonts"
+	"invoice_project/pkg/secret"
 
 	locationHTTP "invoice_project/internal/location/delivery/http"
 	locationModel "invoice_project/internal/location/domain"
@@ -48,6 +52,15 @@ import (
 	feedbackRepo "invoice_project/internal/feedback/repository"
 	feedbackUC "invoice_project/internal/feedback/usecase"
 
+	purchaseHTTP "invoice_project/internal/purchase/delivery/http"
+	purchaseModel "invoice_project/internal/purchase/domain"
+	purchaseRepo "invoice_project/internal/purchase/repository"
+	purchaseUC "invoice_project/internal/purchase/usecase"
+
+	reportHTTP "invoice_project/internal/report/delivery/http"
+	reportRepo "invoice_project/internal/report/repository"
+	reportUC "invoice_project/internal/report/usecase"
+
 	logModel "invoice_project/internal/log/domain"
 )
 
@@ -68,6 +81,9 @@ func main() {
 		log.Fatalf("Cannot connect to DB: %v", err)
 	}
 
+	// Convert legacy int/float money columns before AutoMigrate touches them
+	infrastructure.MigrateMoneyColumns(db)
+
 	// Migrate tables: User, RefreshToken, Invoice
 	infrastructure.Migrate(db,
 		&authModel.User{},
@@ -80,6 +96,20 @@ func main() {
 		&invModel.InvoiceDocument{},
 		&invModel.InvoiceItem{},
 		&invModel.DocumentTimeline{},
+		&invModel.DocumentSequence{},
+		&invModel.StoreDocumentTemplate{},
+		&invModel.StoreEmailTemplate{},
+		&invModel.DocumentDelivery{},
+		&invModel.DocumentShare{},
+		&invModel.StorePromptPay{},
+		&invModel.ExchangeRate{},
+		&invModel.BankStatement{},
+		&invModel.BankStatementLine{},
+		&invModel.BankStatementMatch{},
+		&invModel.Payment{},
+		&invModel.PaymentAllocation{},
+		&invModel.RecurringSchedule{},
+		&invModel.RecurringRun{},
 		&merchModel.MerchantType{},
 		&merchModel.Merchant{},
 		&merchModel.Store{},
@@ -87,6 +117,10 @@ func main() {
 		&merchModel.MerchantContact{},
 		&merchModel.PersonMerchant{},
 		&merchModel.CompanyMerchant{},
+		&merchModel.MerchantCertificate{},
+		&purchaseModel.Supplier{},
+		&purchaseModel.PurchaseDocument{},
+		&purchaseModel.PurchaseItem{},
 		&customerModel.Customer{},
 		&customerModel.CompanyCustomer{},
 		&customerModel.PersonCustomer{},
@@ -101,6 +135,12 @@ func main() {
 		&logModel.UserLog{},
 	)
 
+	// Documents saved before multi-currency support are in baht
+	infrastructure.BackfillDocumentCurrency(db)
+
+	// Documents saved before their VAT breakdown was stored
+	infrastructure.BackfillDocumentVatBreakdown(db)
+
 	// Seed default roles and merchant types
 	infrastructure.SeedRoles(db)
 	infrastructure.SeedMerchantTypes(db)
@@ -123,15 +163,32 @@ func main() {
 
 	// Logger middleware
 	app.Use(middleware.Logger(db))
-	// Global JWT middleware except for auth routes
-	app.Use(middleware.JWTMiddlewareExcept(cfg.Auth.JWTSecret, "/auth"))
+	// Global JWT middleware except for auth routes and shared documents
+	app.Use(middleware.JWTMiddlewareExcept(cfg.Auth.JWTSecret, "/auth", "/public/"))
 
 	// Merchant module
 	merchRepository := merchRepo.NewMerchantRepository(db)
 	merchUsecase := merchUC.NewMerchantUsecase(merchRepository)
-	merchantHandler := merchantHTTP.NewMerchantHandler(merchUsecase)
+	storeAccess := merchUC.NewStoreAccess(merchRepository)
+	merchantHandler := merchantHTTP.NewMerchantHandler(merchUsecase, storeAccess)
 	merchantHandler.RegisterRoutes(app)
 
+	var certBox *secret.Box
+	passphrases := map[string]string{}
+	if cfg.Signing.SecretsFile != "" {
+		secrets, err := infrastructure.LoadSigningSecrets(cfg.Signing.SecretsFile)
+		if err != nil {
+			log.Fatalf("Cannot load signing secrets: %v", err)
+		}
+		if certBox, err = secret.NewBoxFromBase64(secrets.EncryptionKey); err != nil {
+			log.Fatalf("Invalid certificate encryption key: %v", err)
+		}
+		passphrases = secrets.Passphrases
+	}
+	certUsecase := merchUC.NewCertificateUsecase(merchRepository, certBox, passphrases)
+	certHandler := merchantHTTP.NewCertificateHandler(certUsecase, storeAccess)
+	certHandler.RegisterRoutes(app)
+
 	// ตระเตรียม Auth module
 	authRepository := authRepo.NewAuthRepository(db)
 	authUsecase := authUC.NewAuthUsecase(
@@ -140,10 +197,14 @@ func main() {
 		cfg.Auth.JWTExpiryAccessMin,
 		cfg.Auth.JWTExpiryRefreshHours,
 	)
+	// OTP codes and documents are emailed through the same transport;
+	// without one, documents cannot be sent.
 	var otpService otp.Service
+	var docMailer mailer.Mailer
 	switch {
 	case cfg.SMTP.Host != "" && cfg.SMTP.FromEmail != "":
-		otpService = otp.NewSMTPOTPService(cfg.SMTP.Host, cfg.SMTP.Port, cfg.SMTP.Username, cfg.SMTP.Password, cfg.SMTP.FromEmail)
+		docMailer = mailer.NewSMTPMailer(cfg.SMTP.Host, cfg.SMTP.Port, cfg.SMTP.Username, cfg.SMTP.Password, cfg.SMTP.FromEmail)
+		otpService = otp.NewSMTPOTPService(docMailer)
 	case cfg.Gmail.CredentialsFile != "" && cfg.Gmail.TokenFile != "" && cfg.Gmail.FromEmail != "":
 		creds, err := os.ReadFile(cfg.Gmail.CredentialsFile)
 		if err != nil {
@@ -153,11 +214,12 @@ func main() {
 		if err != nil {
 			log.Fatalf("Cannot read gmail token: %v", err)
 		}
-		svc, err := otp.NewGmailOTPService(context.Background(), creds, token, cfg.Gmail.FromEmail)
+		gm, err := mailer.NewGmailMailer(context.Background(), creds, token, cfg.Gmail.FromEmail)
 		if err != nil {
-			log.Fatalf("Cannot init gmail otp service: %v", err)
+			log.Fatalf("Cannot init gmail mailer: %v", err)
 		}
-		otpService = svc
+		docMailer = gm
+		otpService = otp.NewGmailOTPService(gm)
 	default:
 		otpService = otp.NewInMemoryOTPService()
 	}
@@ -166,6 +228,12 @@ func main() {
 	authHandler := http.NewAuthHandler(authUsecase, merchUsecase, cfg.Auth.JWTSecret, otpUsecase)
 	authHandler.RegisterRoutes(app)
 
+	// Location module
+	locationRepository := locationRepo.NewLocationRepository(db)
+	locationUsecase := locationUC.NewLocationUseCase(locationRepository)
+	locationHandler := locationHTTP.NewLocationHandler(locationUsecase)
+	locationHandler.RegisterRoutes(app)
+
 	// ตระเตรียม Invoice module
 	invoiceRepository := invRepo.NewInvoiceRepository(db)
 	invoiceUsecase := invUC.NewInvoiceUsecase(invoiceRepository)
@@ -174,28 +242,97 @@ func main() {
 
 	// Invoice document module
 	docRepo := invRepo.NewInvoiceDocumentRepository(db)
-	docUC := invUC.NewInvoiceDocumentUsecase(docRepo)
-	docHandler := invHandler.NewDocumentHandler(docUC)
+	parties := invUC.NewPartySnapshotter(invRepo.NewPartyRepository(db), locationUsecase)
+	rateUC := invUC.NewExchangeRateUsecase(invRepo.NewExchangeRateRepository(db))
+	docUC := invUC.NewInvoiceDocumentUsecase(docRepo, invUC.ParsePricingMode(cfg.Invoice.PricingMode), parties, rateUC)
+	// The bundled Sarabun is used unless the config names other fonts
+	var pdfFonts invUC.PDFFonts
+	pdfFonts.Regular, pdfFonts.Bold = fonts.Sarabun()
+	if cfg.PDF.FontRegular != "" {
+		if pdfFonts.Regular, err = os.ReadFile(cfg.PDF.FontRegular); err != nil {
+			log.Fatalf("Cannot read pdf font: %v", err)
+		}
+	}
+	if cfg.PDF.FontBold != "" {
+		if pdfFonts.Bold, err = os.ReadFile(cfg.PDF.FontBold); err != nil {
+			log.Fatalf("Cannot read pdf font: %v", err)
+		}
+	}
+	templateRepo := invRepo.NewDocumentTemplateRepository(db)
+	paymentRepo := invRepo.NewPaymentRepository(db)
+	promptPayUC := invUC.NewPromptPayUsecase(invRepo.NewPromptPayRepository(db), docRepo, paymentRepo)
+	pdfUC := invUC.NewDocumentPDFUsecase(docRepo, templateRepo, promptPayUC, pdfFonts)
+	etaxUC := invUC.NewETaxUsecase(docRepo, certUsecase)
+	sendUC := invUC.NewDocumentSendUsecase(docRepo, invRepo.NewPartyRepository(db), templateRepo, pdfUC, docMailer)
+	docHandler := invHandler.NewDocumentHandler(docUC, pdfUC, etaxUC, sendUC, storeAccess)
 	docHandler.RegisterRoutes(app)
 
+	rateHandler := invHandler.NewExchangeRateHandler(rateUC, storeAccess)
+	rateHandler.RegisterRoutes(app)
+
+	quotationUC := invUC.NewQuotationUsecase(docRepo, rateUC)
+	quotationHandler := invHandler.NewQuotationHandler(quotationUC, docUC, storeAccess)
+	quotationHandler.RegisterRoutes(app)
+	go invUC.RunQuotationExpiry(context.Background(), quotationUC, time.Hour)
+
+	recurringRepo := invRepo.NewRecurringRepository(db)
+	recurringUC := invUC.NewRecurringUsecase(recurringRepo, docRepo, docUC)
+	recurringHandler := invHandler.NewRecurringHandler(recurringUC, storeAccess)
+	recurringHandler.RegisterRoutes(app)
+	go invUC.RunRecurringSchedules(context.Background(), recurringUC, time.Minute)
+
+	paymentUC := invUC.NewPaymentUsecase(paymentRepo, docRepo)
+	paymentHandler := invHandler.NewPaymentHandler(paymentUC, docUC, storeAccess)
+	paymentHandler.RegisterRoutes(app)
+	promptPayHandler := invHandler.NewPromptPayHandler(promptPayUC, docUC, storeAccess)
+	promptPayHandler.RegisterRoutes(app)
+	statementUC := invUC.NewBankStatementUsecase(invRepo.NewBankStatementRepository(db), paymentUC)
+	statementHandler := invHandler.NewStatementHandler(statementUC, storeAccess)
+	statementHandler.RegisterRoutes(app)
+
+	if cfg.Invoice.ShareSecret != "" && cfg.Invoice.ShareSecret == cfg.Auth.JWTSecret {
+		log.Fatalf("Share secret must differ from the JWT secret")
+	}
+	shareUC := invUC.NewDocumentShareUsecase(invRepo.NewDocumentShareRepository(db), docRepo, pdfUC, promptPayUC, cfg.Invoice.ShareSecret, cfg.Invoice.PublicURL)
+	shareHandler := invHandler.NewShareHandler(shareUC, docUC, storeAccess)
+	shareHandler.RegisterRoutes(app)
+
+	templateHandler := invHandler.NewTemplateHandler(pdfUC, sendUC, storeAccess)
+	templateHandler.RegisterRoutes(app)
+
+	seqRepo := invRepo.NewDocumentSequenceRepository(db)
+	seqUC := invUC.NewDocumentSequenceUsecase(seqRepo)
+	seqHandler := invHandler.NewSequenceHandler(seqUC, storeAccess)
+	seqHandler.RegisterRoutes(app)
+
+	// Purchase module
+	supplierRepository := purchaseRepo.NewSupplierRepository(db)
+	supplierUsecase := purchaseUC.NewSupplierUsecase(supplierRepository)
+	supplierHandler := purchaseHTTP.NewSupplierHandler(supplierUsecase, storeAccess)
+	supplierHandler.RegisterRoutes(app)
+	purchaseUsecase := purchaseUC.NewPurchaseUsecase(purchaseRepo.NewPurchaseRepository(db), supplierRepository)
+	purchaseHandler := purchaseHTTP.NewPurchaseHandler(purchaseUsecase, storeAccess)
+	purchaseHandler.RegisterRoutes(app)
+
+	// Report module
+	reportRepository := reportRepo.NewReportRepository(db)
+	vatReportUC := reportUC.NewVatReportUsecase(reportRepository, invRepo.NewPartyRepository(db), pdfFonts)
+	analyticsUC := reportUC.NewAnalyticsUsecase(reportRepo.NewAnalyticsRepository(db))
+	reportHandler := reportHTTP.NewReportHandler(vatReportUC, analyticsUC, storeAccess)
+	reportHandler.RegisterRoutes(app)
+
 	// Customer module
 	customerRepository := customerRepo.NewCustomerRepository(db)
 	customerUseCase := customerUC.NewCustomerUseCase(customerRepository)
-	customerHandler := customerHTTP.NewCustomerHandler(customerUseCase)
+	customerHandler := customerHTTP.NewCustomerHandler(customerUseCase, storeAccess)
 	customerHandler.RegisterRoutes(app)
 
 	// Product module
 	productRepository := productRepo.NewProductRepository(db)
 	productUsecase := productUC.NewProductUseCase(productRepository)
-	productHandler := productHTTP.NewProductHandler(productUsecase)
+	productHandler := productHTTP.NewProductHandler(productUsecase, storeAccess)
 	productHandler.RegisterRoutes(app)
 
-	// Location module
-	locationRepository := locationRepo.NewLocationRepository(db)
-	locationUsecase := locationUC.NewLocationUseCase(locationRepository)
-	locationHandler := locationHTTP.NewLocationHandler(locationUsecase)
-	locationHandler.RegisterRoutes(app)
-
 	// Feedback module
 	feedbackRepository := feedbackRepo.NewFeedbackRepository(db)
 	feedbackUsecase := feedbackUC.NewFeedbackUsecase(feedbackRepository)
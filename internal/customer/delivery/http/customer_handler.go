@@ -3,6 +3,7 @@ package http
 import (
 	"invoice_project/internal/customer/domain"
 	"invoice_project/internal/customer/usecase"
+	"invoice_project/pkg/apperror"
 	"invoice_project/pkg/middleware"
 	"strconv"
 
@@ -12,6 +13,7 @@ import (
 
 type CustomerHandler struct {
 	usecase usecase.CustomerUseCase
+	access  middleware.StoreAuthorizer
 }
 
 type CustomerResponse struct {
@@ -22,8 +24,8 @@ type CustomerResponse struct {
 }
 
 
-func NewCustomerHandler(uc usecase.CustomerUseCase) *CustomerHandler {
-	return &CustomerHandler{usecase: uc}
+func NewCustomerHandler(uc usecase.CustomerUseCase, access middleware.StoreAuthorizer) *CustomerHandler {
+	return &CustomerHandler{usecase: uc, access: access}
 }
 
 func (h *CustomerHandler) CreateCustomer(c *fiber.Ctx) error {
@@ -151,11 +153,30 @@ func (h *CustomerHandler) DeleteCustomer(c *fiber.Ctx) error {
 }
 
 
+// customerStore resolves the store of the customer in the :id param.
+func (h *CustomerHandler) customerStore(c *fiber.Ctx) (string, error) {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return "", apperror.New(fiber.StatusBadRequest)
+	}
+	customer, _, _, _, err := h.usecase.GetCustomerByID(c.Context(), uint(id))
+	if err != nil || customer == nil {
+		return "", apperror.New(fiber.StatusNotFound)
+	}
+	return customer.StoreID.String(), nil
+}
+
 func (h *CustomerHandler) RegisterRoutes(app *fiber.App) {
 	api := app.Group("/customers", middleware.RequireRoles("user", "admin"))
-	api.Post("/", h.CreateCustomer)
-	api.Get("/:id", h.GetCustomerByID)
-	api.Get("/store/:store_id", h.ListCustomer)
-	api.Put("/:id", h.UpdateCustomer)
-	api.Delete("/:id", h.DeleteCustomer)
+	byCustomer := middleware.RequireStoreAccess(h.access, h.customerStore)
+	api.Post("/", middleware.RequireStoreAccess(h.access, middleware.FromBody(func(r *CreateCustomerRequest) string {
+		return r.Customer.StoreID.String()
+	})), h.CreateCustomer)
+	api.Get("/:id", byCustomer, h.GetCustomerByID)
+	api.Get("/store/:store_id", middleware.RequireStoreAccess(h.access, middleware.FromParam("store_id")), h.ListCustomer)
+	// An update may not move the customer to a store the user does not own.
+	api.Put("/:id", middleware.RequireStoreAccess(h.access, h.customerStore, middleware.FromBody(func(r *UpdateCustomerRequest) string {
+		return r.Customer.StoreID.String()
+	})), h.UpdateCustomer)
+	api.Delete("/:id", byCustomer, h.DeleteCustomer)
 }
\ No newline at end of file
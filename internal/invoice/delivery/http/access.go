@@ -0,0 +1,102 @@
+package http
+
+import (
+	"strconv"
+
+	"invoice_project/internal/invoice/usecase"
+	"invoice_project/pkg/apperror"
+	"invoice_project/pkg/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// paramID parses the numeric :id route parameter.
+func paramID(c *fiber.Ctx) (uint, error) {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return 0, apperror.New(fiber.StatusBadRequest)
+	}
+	return uint(id), nil
+}
+
+// documentStore resolves the store of the document in the :id param.
+// Documents without a store are reported as not found.
+func documentStore(docs usecase.InvoiceDocumentUsecase) middleware.IDResolver {
+	return func(c *fiber.Ctx) (string, error) {
+		id, err := paramID(c)
+		if err != nil {
+			return "", err
+		}
+		doc, err := docs.GetDocument(c.Context(), id)
+		if err != nil {
+			return "", err
+		}
+		if doc == nil || doc.StoreID == nil {
+			return "", apperror.New(fiber.StatusNotFound)
+		}
+		return *doc.StoreID, nil
+	}
+}
+
+// paymentStore resolves the store of the payment in the :id param.
+func paymentStore(payments usecase.PaymentUsecase) middleware.IDResolver {
+	return func(c *fiber.Ctx) (string, error) {
+		id, err := paramID(c)
+		if err != nil {
+			return "", err
+		}
+		p, err := payments.GetPayment(c.Context(), id)
+		if err != nil {
+			return "", err
+		}
+		return p.StoreID, nil
+	}
+}
+
+// scheduleStore resolves the store of the recurring schedule in the :id
+// param.
+func scheduleStore(schedules usecase.RecurringUsecase) middleware.IDResolver {
+	return func(c *fiber.Ctx) (string, error) {
+		id, err := paramID(c)
+		if err != nil {
+			return "", err
+		}
+		s, err := schedules.GetSchedule(c.Context(), id)
+		if err != nil {
+			return "", err
+		}
+		return s.StoreID, nil
+	}
+}
+
+// statementStore resolves the store of the bank statement in the :id
+// param.
+func statementStore(statements usecase.BankStatementUsecase) middleware.IDResolver {
+	return func(c *fiber.Ctx) (string, error) {
+		id, err := paramID(c)
+		if err != nil {
+			return "", err
+		}
+		s, err := statements.GetStatement(c.Context(), id)
+		if err != nil {
+			return "", err
+		}
+		return s.StoreID, nil
+	}
+}
+
+// statementLineStore resolves the store of the bank statement line in the
+// :id param.
+func statementLineStore(statements usecase.BankStatementUsecase) middleware.IDResolver {
+	return func(c *fiber.Ctx) (string, error) {
+		id, err := paramID(c)
+		if err != nil {
+			return "", err
+		}
+		l, err := statements.GetLine(c.Context(), id)
+		if err != nil {
+			return "", err
+		}
+		return l.StoreID, nil
+	}
+}
@@ -1,20 +1,27 @@
 package http
 
 import (
+	"errors"
 	"invoice_project/internal/invoice/usecase"
 	"invoice_project/pkg/apperror"
 	"invoice_project/pkg/middleware"
+	"invoice_project/pkg/xsd"
 	"strconv"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 )
 
 type DocumentHandler struct {
-	uc usecase.InvoiceDocumentUsecase
+	uc     usecase.InvoiceDocumentUsecase
+	pdfUC  usecase.DocumentPDFUsecase
+	etaxUC usecase.ETaxUsecase
+	sendUC usecase.DocumentSendUsecase
+	access middleware.StoreAuthorizer
 }
 
-func NewDocumentHandler(uc usecase.InvoiceDocumentUsecase) *DocumentHandler {
-	return &DocumentHandler{uc: uc}
+func NewDocumentHandler(uc usecase.InvoiceDocumentUsecase, pdfUC usecase.DocumentPDFUsecase, etaxUC usecase.ETaxUsecase, sendUC usecase.DocumentSendUsecase, access middleware.StoreAuthorizer) *DocumentHandler {
+	return &DocumentHandler{uc: uc, pdfUC: pdfUC, etaxUC: etaxUC, sendUC: sendUC, access: access}
 }
 
 func (h *DocumentHandler) Create(c *fiber.Ctx) error {
@@ -22,12 +29,39 @@ func (h *DocumentHandler) Create(c *fiber.Ctx) error {
 	if err := c.BodyParser(&req); err != nil {
 		return apperror.New(fiber.StatusBadRequest)
 	}
-	if err := h.uc.CreateDocument(c.Context(), &req.Document, req.Items); err != nil {
+	userID := c.Locals("user_id").(uuid.UUID)
+	if err := h.uc.CreateDocument(c.Context(), &req.Document, req.Items, userID.String()); err != nil {
 		return err
 	}
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"message": "created"})
 }
 
+// List returns a page of a store's documents with the totals of every
+// document matching the filters, e.g.
+// ?store_id=<uuid>&status=issued,sent&issued_from=2026-10-01&q=acme&sort=-grand_total.
+func (h *DocumentHandler) List(c *fiber.Ctx) error {
+	list, err := h.uc.ListDocuments(c.Context(), usecase.DocumentListInput{
+		StoreID:      c.Query("store_id"),
+		Status:       c.Query("status"),
+		DocumentType: c.Query("document_type"),
+		CustomerID:   c.Query("customer_id"),
+		IssuedFrom:   c.Query("issued_from"),
+		IssuedTo:     c.Query("issued_to"),
+		MinTotal:     c.Query("min_total"),
+		MaxTotal:     c.Query("max_total"),
+		BuyerTaxID:   c.Query("buyer_tax_id"),
+		Currency:     c.Query("currency"),
+		Search:       c.Query("q"),
+		Sort:         c.Query("sort"),
+		Cursor:       c.Query("cursor"),
+		Limit:        c.QueryInt("limit"),
+	})
+	if err != nil {
+		return err
+	}
+	return c.JSON(list)
+}
+
 func (h *DocumentHandler) Get(c *fiber.Ctx) error {
 	idParam := c.Params("id")
 	id, err := strconv.ParseUint(idParam, 10, 64)
@@ -44,8 +78,222 @@ func (h *DocumentHandler) Get(c *fiber.Ctx) error {
 	return c.JSON(doc)
 }
 
+// Update revises a draft. Issued documents cannot be changed.
+func (h *DocumentHandler) Update(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	var req UpdateDraftRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	userID := c.Locals("user_id").(uuid.UUID)
+	doc, err := h.uc.UpdateDraft(c.Context(), uint(id), req.Version, &req.Document, req.Items, userID.String())
+	if err != nil {
+		return err
+	}
+	return c.JSON(doc)
+}
+
+func (h *DocumentHandler) AddItem(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	var req DraftItemRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	userID := c.Locals("user_id").(uuid.UUID)
+	doc, err := h.uc.AddItem(c.Context(), uint(id), req.Version, req.Item, userID.String())
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(doc)
+}
+
+func (h *DocumentHandler) UpdateItem(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	itemID, err := strconv.ParseUint(c.Params("itemId"), 10, 64)
+	if err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	var req DraftItemRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	userID := c.Locals("user_id").(uuid.UUID)
+	doc, err := h.uc.UpdateItem(c.Context(), uint(id), uint(itemID), req.Version, req.Item, userID.String())
+	if err != nil {
+		return err
+	}
+	return c.JSON(doc)
+}
+
+// RemoveItem deletes an item from a draft; the version goes in the query,
+// e.g. ?version=3.
+func (h *DocumentHandler) RemoveItem(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	itemID, err := strconv.ParseUint(c.Params("itemId"), 10, 64)
+	if err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	userID := c.Locals("user_id").(uuid.UUID)
+	doc, err := h.uc.RemoveItem(c.Context(), uint(id), uint(itemID), c.QueryInt("version"), userID.String())
+	if err != nil {
+		return err
+	}
+	return c.JSON(doc)
+}
+
+func (h *DocumentHandler) Transition(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	var req TransitionDocumentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	userID := c.Locals("user_id").(uuid.UUID)
+	doc, err := h.uc.TransitionDocument(c.Context(), uint(id), req.Status, userID.String(), req.Note)
+	if err != nil {
+		return err
+	}
+	return c.JSON(doc)
+}
+
+// Void voids an issued document and, when asked, issues its replacement.
+func (h *DocumentHandler) Void(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	var req VoidDocumentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	userID := c.Locals("user_id").(uuid.UUID)
+	res, err := h.uc.VoidDocument(c.Context(), uint(id), usecase.VoidInput{
+		Reason:   req.Reason,
+		Replace:  req.Replace,
+		Document: req.Document,
+		Items:    req.Items,
+	}, userID.String())
+	if err != nil {
+		return err
+	}
+	return c.JSON(res)
+}
+
+// PDF renders the document as an A4 PDF. ?copy=true prints the copy
+// watermark instead of the original one.
+func (h *DocumentHandler) PDF(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	b, doc, err := h.pdfUC.RenderPDF(c.Context(), uint(id), c.QueryBool("copy"))
+	if err != nil {
+		return err
+	}
+	name := doc.DocumentNo
+	if name == "" {
+		name = "document-" + c.Params("id")
+	}
+	c.Set(fiber.HeaderContentType, "application/pdf")
+	c.Set(fiber.HeaderContentDisposition, `inline; filename="`+name+`.pdf"`)
+	return c.Send(b)
+}
+
+// ETaxXML exports an issued document as ETDA e-Tax XML. Documents that do
+// not pass the bundled schema check are rejected with the list of problems.
+func (h *DocumentHandler) ETaxXML(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	b, doc, err := h.etaxUC.ExportXML(c.Context(), uint(id))
+	if err != nil {
+		var verr *xsd.ValidationError
+		if errors.As(err, &verr) {
+			resp := middleware.ErrorResponse(apperror.StatusMessage(fiber.StatusUnprocessableEntity))
+			resp["details"] = verr.Errors
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(resp)
+		}
+		return err
+	}
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationXMLCharsetUTF8)
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="`+doc.DocumentNo+`.xml"`)
+	return c.Send(b)
+}
+
+// Send emails the document as a PDF to its buyer, or to the addresses
+// given, and returns the delivery.
+func (h *DocumentHandler) Send(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	var req SendDocumentRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return apperror.New(fiber.StatusBadRequest)
+		}
+	}
+	userID := c.Locals("user_id").(uuid.UUID)
+	d, err := h.sendUC.SendDocument(c.Context(), uint(id), usecase.SendInput{
+		To:      req.To,
+		Cc:      req.Cc,
+		Bcc:     req.Bcc,
+		Message: req.Message,
+	}, userID.String())
+	if err != nil {
+		return err
+	}
+	return c.JSON(d)
+}
+
+// Deliveries lists the attempts to send the document, newest first.
+func (h *DocumentHandler) Deliveries(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	deliveries, err := h.sendUC.ListDeliveries(c.Context(), uint(id))
+	if err != nil {
+		return err
+	}
+	return c.JSON(deliveries)
+}
+
 func (h *DocumentHandler) RegisterRoutes(app *fiber.App) {
 	api := app.Group("/invoice-documents", middleware.RequireRoles("user", "admin"))
-	api.Post("/", h.Create)
-	api.Get("/:id", h.Get)
+	byBody := middleware.RequireStoreAccess(h.access, middleware.FromBody(func(r *CreateInvoiceDocumentRequest) string {
+		if r.Document.StoreID == nil {
+			return ""
+		}
+		return *r.Document.StoreID
+	}))
+	byDocument := middleware.RequireStoreAccess(h.access, documentStore(h.uc))
+	api.Post("/", byBody, h.Create)
+	api.Get("/", middleware.RequireStoreAccess(h.access, middleware.FromQuery("store_id")), h.List)
+	api.Get("/:id", byDocument, h.Get)
+	api.Put("/:id", byDocument, h.Update)
+	api.Post("/:id/items", byDocument, h.AddItem)
+	api.Put("/:id/items/:itemId", byDocument, h.UpdateItem)
+	api.Delete("/:id/items/:itemId", byDocument, h.RemoveItem)
+	api.Post("/:id/transitions", byDocument, h.Transition)
+	api.Post("/:id/void", byDocument, h.Void)
+	api.Get("/:id/pdf", byDocument, h.PDF)
+	api.Get("/:id/etax.xml", byDocument, h.ETaxXML)
+	api.Post("/:id/send", byDocument, h.Send)
+	api.Get("/:id/deliveries", byDocument, h.Deliveries)
 }
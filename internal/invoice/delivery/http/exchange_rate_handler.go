@@ -0,0 +1,85 @@
+package http
+
+import (
+	"invoice_project/internal/invoice/domain"
+	"invoice_project/internal/invoice/usecase"
+	"invoice_project/pkg/apperror"
+	"invoice_project/pkg/middleware"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type ExchangeRateHandler struct {
+	uc     usecase.ExchangeRateUsecase
+	access middleware.StoreAuthorizer
+}
+
+func NewExchangeRateHandler(uc usecase.ExchangeRateUsecase, access middleware.StoreAuthorizer) *ExchangeRateHandler {
+	return &ExchangeRateHandler{uc: uc, access: access}
+}
+
+// List returns the rates that apply to a store, its own and the published
+// ones, e.g. ?store_id=<uuid>&currency=USD&from=2026-10-01&to=2026-10-31.
+func (h *ExchangeRateHandler) List(c *fiber.Ctx) error {
+	rates, err := h.uc.ListRates(c.Context(), usecase.RateListInput{
+		StoreID:  c.Query("store_id"),
+		Currency: c.Query("currency"),
+		From:     c.Query("from"),
+		To:       c.Query("to"),
+	})
+	if err != nil {
+		return err
+	}
+	return c.JSON(rates)
+}
+
+// Set records a store's own rate for a currency and date.
+func (h *ExchangeRateHandler) Set(c *fiber.Ctx) error {
+	var req SetExchangeRateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	userID := c.Locals("user_id").(uuid.UUID)
+	rate, err := h.uc.SetRate(c.Context(), &domain.ExchangeRate{
+		StoreID:   &req.StoreID,
+		Currency:  req.Currency,
+		RateDate:  req.RateDate,
+		Rate:      req.Rate,
+		CreatedBy: userID.String(),
+	})
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(rate)
+}
+
+// ImportBOT reads the Bank of Thailand rates sent as the multipart field
+// "file". "column" picks the rate used, buying_transfer by default.
+func (h *ExchangeRateHandler) ImportBOT(c *fiber.Ctx) error {
+	fh, err := c.FormFile("file")
+	if err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	f, err := fh.Open()
+	if err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	defer f.Close()
+	userID := c.Locals("user_id").(uuid.UUID)
+	res, err := h.uc.ImportRates(c.Context(), f, c.FormValue("column"), userID.String())
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(res)
+}
+
+func (h *ExchangeRateHandler) RegisterRoutes(app *fiber.App) {
+	api := app.Group("/exchange-rates", middleware.RequireRoles("user", "admin"))
+	api.Get("/", middleware.RequireStoreAccess(h.access, middleware.FromQuery("store_id")), h.List)
+	api.Post("/", middleware.RequireStoreAccess(h.access, middleware.FromBody(func(r *SetExchangeRateRequest) string {
+		return r.StoreID
+	})), h.Set)
+	// published rates apply to every store
+	api.Post("/bot", middleware.RequireRoles("admin"), h.ImportBOT)
+}
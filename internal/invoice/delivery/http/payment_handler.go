@@ -0,0 +1,131 @@
+package http
+
+import (
+	"strconv"
+
+	"invoice_project/internal/invoice/usecase"
+	"invoice_project/pkg/apperror"
+	"invoice_project/pkg/middleware"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type PaymentHandler struct {
+	uc     usecase.PaymentUsecase
+	docs   usecase.InvoiceDocumentUsecase
+	access middleware.StoreAuthorizer
+}
+
+func NewPaymentHandler(uc usecase.PaymentUsecase, docs usecase.InvoiceDocumentUsecase, access middleware.StoreAuthorizer) *PaymentHandler {
+	return &PaymentHandler{uc: uc, docs: docs, access: access}
+}
+
+func (h *PaymentHandler) Record(c *fiber.Ctx) error {
+	var req RecordPaymentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	userID := c.Locals("user_id").(uuid.UUID)
+	p, err := h.uc.RecordPayment(c.Context(), &req.Payment, req.IssueReceipt, userID.String())
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(p)
+}
+
+func (h *PaymentHandler) Get(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	p, err := h.uc.GetPayment(c.Context(), uint(id))
+	if err != nil {
+		return err
+	}
+	return c.JSON(p)
+}
+
+// Receipt issues the receipt of a payment that does not have one yet.
+func (h *PaymentHandler) Receipt(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	userID := c.Locals("user_id").(uuid.UUID)
+	doc, err := h.uc.IssueReceipt(c.Context(), uint(id), userID.String())
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(doc)
+}
+
+// WhtCertificate records the buyer's withholding tax certificate (50 ทวิ)
+// for a payment.
+func (h *PaymentHandler) WhtCertificate(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	var req WhtCertificateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	p, err := h.uc.SetWhtCertificate(c.Context(), uint(id), req.CertificateNo, req.CertificateDate)
+	if err != nil {
+		return err
+	}
+	return c.JSON(p)
+}
+
+// WithholdingReport lists the tax withheld from a merchant's payments in a
+// month, e.g. ?merchant_id=<uuid>&month=2026-10.
+func (h *PaymentHandler) WithholdingReport(c *fiber.Ctx) error {
+	report, err := h.uc.WithholdingReport(c.Context(), c.Query("merchant_id"), c.Query("month"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(report)
+}
+
+func (h *PaymentHandler) ListForDocument(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	payments, err := h.uc.ListPayments(c.Context(), uint(id))
+	if err != nil {
+		return err
+	}
+	return c.JSON(payments)
+}
+
+func (h *PaymentHandler) Balance(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	b, err := h.uc.GetBalance(c.Context(), uint(id))
+	if err != nil {
+		return err
+	}
+	return c.JSON(b)
+}
+
+func (h *PaymentHandler) RegisterRoutes(app *fiber.App) {
+	api := app.Group("/payments", middleware.RequireRoles("user", "admin"))
+	byBody := middleware.RequireStoreAccess(h.access, middleware.FromBody(func(r *RecordPaymentRequest) string {
+		return r.Payment.StoreID
+	}))
+	byPayment := middleware.RequireStoreAccess(h.access, paymentStore(h.uc))
+	api.Post("/", byBody, h.Record)
+	api.Get("/withholding-tax", middleware.RequireMerchantAccess(h.access, middleware.FromQuery("merchant_id")), h.WithholdingReport)
+	api.Get("/:id", byPayment, h.Get)
+	api.Post("/:id/receipt", byPayment, h.Receipt)
+	api.Put("/:id/wht-certificate", byPayment, h.WhtCertificate)
+
+	docs := app.Group("/invoice-documents", middleware.RequireRoles("user", "admin"))
+	byDocument := middleware.RequireStoreAccess(h.access, documentStore(h.docs))
+	docs.Get("/:id/payments", byDocument, h.ListForDocument)
+	docs.Get("/:id/balance", byDocument, h.Balance)
+}
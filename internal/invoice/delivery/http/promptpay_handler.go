@@ -0,0 +1,88 @@
+package http
+
+import (
+	"strconv"
+
+	"invoice_project/internal/invoice/domain"
+	"invoice_project/internal/invoice/usecase"
+	"invoice_project/pkg/apperror"
+	"invoice_project/pkg/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type PromptPayHandler struct {
+	uc     usecase.PromptPayUsecase
+	docs   usecase.InvoiceDocumentUsecase
+	access middleware.StoreAuthorizer
+}
+
+func NewPromptPayHandler(uc usecase.PromptPayUsecase, docs usecase.InvoiceDocumentUsecase, access middleware.StoreAuthorizer) *PromptPayHandler {
+	return &PromptPayHandler{uc: uc, docs: docs, access: access}
+}
+
+// Get returns the PromptPay ID of the store given by store_id.
+func (h *PromptPayHandler) Get(c *fiber.Ctx) error {
+	p, err := h.uc.GetStorePromptPay(c.Context(), c.Query("store_id"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(p)
+}
+
+func (h *PromptPayHandler) Set(c *fiber.Ctx) error {
+	var req SetPromptPayRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	p, err := h.uc.SetStorePromptPay(c.Context(), &domain.StorePromptPay{
+		StoreID:     req.StoreID,
+		IDType:      req.IDType,
+		PromptPayID: req.PromptPayID,
+	})
+	if err != nil {
+		return err
+	}
+	return c.JSON(p)
+}
+
+// Payload returns the PromptPay payload for what is outstanding on a
+// document.
+func (h *PromptPayHandler) Payload(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	code, err := h.uc.PaymentQR(c.Context(), uint(id))
+	if err != nil {
+		return err
+	}
+	return c.JSON(code)
+}
+
+// PNG returns the PromptPay QR code for what is outstanding on a document.
+func (h *PromptPayHandler) PNG(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	b, err := h.uc.PaymentPNG(c.Context(), uint(id))
+	if err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderContentType, "image/png")
+	return c.Send(b)
+}
+
+func (h *PromptPayHandler) RegisterRoutes(app *fiber.App) {
+	api := app.Group("/promptpay", middleware.RequireRoles("user", "admin"))
+	api.Get("/", middleware.RequireStoreAccess(h.access, middleware.FromQuery("store_id")), h.Get) // ?store_id=<uuid>
+	api.Put("/", middleware.RequireStoreAccess(h.access, middleware.FromBody(func(r *SetPromptPayRequest) string {
+		return r.StoreID
+	})), h.Set)
+
+	docs := app.Group("/invoice-documents", middleware.RequireRoles("user", "admin"))
+	byDocument := middleware.RequireStoreAccess(h.access, documentStore(h.docs))
+	docs.Get("/:id/promptpay", byDocument, h.Payload)
+	docs.Get("/:id/promptpay.png", byDocument, h.PNG)
+}
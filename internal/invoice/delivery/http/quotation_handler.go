@@ -0,0 +1,48 @@
+package http
+
+import (
+	"strconv"
+
+	"invoice_project/internal/invoice/usecase"
+	"invoice_project/pkg/apperror"
+	"invoice_project/pkg/middleware"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type QuotationHandler struct {
+	uc     usecase.QuotationUsecase
+	docs   usecase.InvoiceDocumentUsecase
+	access middleware.StoreAuthorizer
+}
+
+func NewQuotationHandler(uc usecase.QuotationUsecase, docs usecase.InvoiceDocumentUsecase, access middleware.StoreAuthorizer) *QuotationHandler {
+	return &QuotationHandler{uc: uc, docs: docs, access: access}
+}
+
+// Convert creates an invoice document from a quotation. An empty body
+// creates a draft invoice.
+func (h *QuotationHandler) Convert(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	var req ConvertQuotationRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return apperror.New(fiber.StatusBadRequest)
+		}
+	}
+	userID := c.Locals("user_id").(uuid.UUID)
+	doc, err := h.uc.ConvertQuotation(c.Context(), uint(id), req.DocumentType, req.Status, userID.String())
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(doc)
+}
+
+func (h *QuotationHandler) RegisterRoutes(app *fiber.App) {
+	api := app.Group("/quotations", middleware.RequireRoles("user", "admin"))
+	api.Post("/:id/convert", middleware.RequireStoreAccess(h.access, documentStore(h.docs)), h.Convert)
+}
@@ -0,0 +1,111 @@
+package http
+
+import (
+	"strconv"
+
+	"invoice_project/internal/invoice/domain"
+	"invoice_project/internal/invoice/usecase"
+	"invoice_project/pkg/apperror"
+	"invoice_project/pkg/middleware"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type RecurringHandler struct {
+	uc     usecase.RecurringUsecase
+	access middleware.StoreAuthorizer
+}
+
+func NewRecurringHandler(uc usecase.RecurringUsecase, access middleware.StoreAuthorizer) *RecurringHandler {
+	return &RecurringHandler{uc: uc, access: access}
+}
+
+func (h *RecurringHandler) Create(c *fiber.Ctx) error {
+	var s domain.RecurringSchedule
+	if err := c.BodyParser(&s); err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	userID := c.Locals("user_id").(uuid.UUID)
+	created, err := h.uc.CreateSchedule(c.Context(), &s, userID.String())
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(created)
+}
+
+func (h *RecurringHandler) List(c *fiber.Ctx) error {
+	schedules, err := h.uc.ListSchedules(c.Context(), c.Query("store_id"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(schedules)
+}
+
+// Get returns a schedule with the history of its runs.
+func (h *RecurringHandler) Get(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	s, err := h.uc.GetSchedule(c.Context(), uint(id))
+	if err != nil {
+		return err
+	}
+	return c.JSON(s)
+}
+
+func (h *RecurringHandler) Pause(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	s, err := h.uc.PauseSchedule(c.Context(), uint(id))
+	if err != nil {
+		return err
+	}
+	return c.JSON(s)
+}
+
+func (h *RecurringHandler) Resume(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	s, err := h.uc.ResumeSchedule(c.Context(), uint(id))
+	if err != nil {
+		return err
+	}
+	return c.JSON(s)
+}
+
+// EndDate sets or, with a null end_date, clears the last billing date.
+func (h *RecurringHandler) EndDate(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	var req ScheduleEndDateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	s, err := h.uc.SetEndDate(c.Context(), uint(id), req.EndDate)
+	if err != nil {
+		return err
+	}
+	return c.JSON(s)
+}
+
+func (h *RecurringHandler) RegisterRoutes(app *fiber.App) {
+	api := app.Group("/recurring-schedules", middleware.RequireRoles("user", "admin"))
+	byBody := middleware.RequireStoreAccess(h.access, middleware.FromBody(func(s *domain.RecurringSchedule) string {
+		return s.StoreID
+	}))
+	bySchedule := middleware.RequireStoreAccess(h.access, scheduleStore(h.uc))
+	api.Post("/", byBody, h.Create)
+	api.Get("/", middleware.RequireStoreAccess(h.access, middleware.FromQuery("store_id")), h.List) // ?store_id=<uuid>
+	api.Get("/:id", bySchedule, h.Get)
+	api.Post("/:id/pause", bySchedule, h.Pause)
+	api.Post("/:id/resume", bySchedule, h.Resume)
+	api.Put("/:id/end-date", bySchedule, h.EndDate)
+}
@@ -1,11 +1,16 @@
 package http
 
-import "invoice_project/internal/invoice/domain"
+import (
+	"time"
+
+	"invoice_project/internal/invoice/domain"
+	"invoice_project/pkg/money"
+)
 
 // CreateInvoiceRequest represents the expected payload for creating an invoice.
 type CreateInvoiceRequest struct {
-	Customer string  `json:"customer"`
-	Amount   float64 `json:"amount"`
+	Customer string       `json:"customer"`
+	Amount   money.Amount `json:"amount"`
 }
 
 // CreateInvoiceDocumentRequest payload for creating invoice document
@@ -14,3 +19,134 @@ type CreateInvoiceDocumentRequest struct {
 	Document domain.InvoiceDocument `json:"document"`
 	Items    []domain.InvoiceItem   `json:"items"`
 }
+
+// UpdateDraftRequest revises a draft made against Version. Items, when
+// present, replace the draft's items: those with an ID are updated, those
+// without one added and the others removed.
+type UpdateDraftRequest struct {
+	Version  int                    `json:"version"`
+	Document domain.InvoiceDocument `json:"document"`
+	Items    []domain.InvoiceItem   `json:"items"`
+}
+
+// DraftItemRequest adds or replaces one item of a draft made against
+// Version.
+type DraftItemRequest struct {
+	Version int                `json:"version"`
+	Item    domain.InvoiceItem `json:"item"`
+}
+
+// TransitionDocumentRequest moves a document to a new status.
+type TransitionDocumentRequest struct {
+	Status string `json:"status"`
+	Note   string `json:"note"`
+}
+
+// VoidDocumentRequest voids an issued document for Reason. With Replace
+// set, or a Document given, a replacement of the same type is issued:
+// Document and Items give its content, otherwise the voided document is
+// copied.
+type VoidDocumentRequest struct {
+	Reason   string                  `json:"reason"`
+	Replace  bool                    `json:"replace"`
+	Document *domain.InvoiceDocument `json:"document"`
+	Items    []domain.InvoiceItem    `json:"items"`
+}
+
+// ConfigureSequenceRequest updates the numbering settings of a store's
+// document sequence. Omitted fields keep their current value.
+type ConfigureSequenceRequest struct {
+	StoreID      string  `json:"store_id"`
+	DocumentType string  `json:"document_type"`
+	FiscalYear   int     `json:"fiscal_year"`
+	Prefix       *string `json:"prefix"`
+	Format       *string `json:"format"`
+	Padding      *int    `json:"padding"`
+	StartNumber  *int    `json:"start_number"`
+}
+
+// SetTemplateRequest selects the PDF template a store prints with.
+type SetTemplateRequest struct {
+	StoreID  string `json:"store_id"`
+	Template string `json:"template"`
+}
+
+// SetEmailTemplateRequest sets the subject and HTML body a store emails
+// its documents with.
+type SetEmailTemplateRequest struct {
+	StoreID string `json:"store_id"`
+	Subject string `json:"subject"`
+	HTML    string `json:"html"`
+}
+
+// SetPromptPayRequest registers the PromptPay ID a store is paid to.
+// IDType is one of mobile, national_id or tax_id.
+type SetPromptPayRequest struct {
+	StoreID     string `json:"store_id"`
+	IDType      string `json:"id_type"`
+	PromptPayID string `json:"promptpay_id"`
+}
+
+// SetExchangeRateRequest records the baht value of one unit of Currency
+// a store converts at on RateDate.
+type SetExchangeRateRequest struct {
+	StoreID  string     `json:"store_id"`
+	Currency string     `json:"currency"`
+	RateDate time.Time  `json:"rate_date"`
+	Rate     money.Rate `json:"rate"`
+}
+
+// SendDocumentRequest addresses a document email. Without To the document
+// goes to the email contacts of its customer.
+type SendDocumentRequest struct {
+	To      []string `json:"to"`
+	Cc      []string `json:"cc"`
+	Bcc     []string `json:"bcc"`
+	Message string   `json:"message"`
+}
+
+// ShareDocumentRequest sets when a share link expires; without it the
+// link lasts domain.DefaultShareDays.
+type ShareDocumentRequest struct {
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// ConvertQuotationRequest selects the kind of document a quotation is
+// converted into and whether it is issued straight away.
+type ConvertQuotationRequest struct {
+	DocumentType string `json:"document_type"`
+	Status       string `json:"status"`
+}
+
+// RecordPaymentRequest records a payment and its allocations, optionally
+// issuing its receipt at the same time.
+type RecordPaymentRequest struct {
+	Payment      domain.Payment `json:"payment"`
+	IssueReceipt bool           `json:"issue_receipt"`
+}
+
+// ConfirmStatementLineRequest settles a bank statement line, either in
+// full on DocumentID or spread over Allocations.
+type ConfirmStatementLineRequest struct {
+	DocumentID   uint                       `json:"document_id"`
+	Allocations  []domain.PaymentAllocation `json:"allocations"`
+	IssueReceipt bool                       `json:"issue_receipt"`
+}
+
+// MatchStatementRequest proposes documents again for a statement's open
+// lines.
+type MatchStatementRequest struct {
+	ToleranceDays int `json:"tolerance_days"`
+}
+
+// WhtCertificateRequest records the withholding tax certificate of a
+// payment.
+type WhtCertificateRequest struct {
+	CertificateNo   string     `json:"certificate_no"`
+	CertificateDate *time.Time `json:"certificate_date"`
+}
+
+// ScheduleEndDateRequest sets the end date of a recurring schedule.
+type ScheduleEndDateRequest struct {
+	EndDate *time.Time `json:"end_date"`
+}
@@ -0,0 +1,54 @@
+package http
+
+import (
+	"invoice_project/internal/invoice/usecase"
+	"invoice_project/pkg/apperror"
+	"invoice_project/pkg/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type SequenceHandler struct {
+	uc     usecase.DocumentSequenceUsecase
+	access middleware.StoreAuthorizer
+}
+
+func NewSequenceHandler(uc usecase.DocumentSequenceUsecase, access middleware.StoreAuthorizer) *SequenceHandler {
+	return &SequenceHandler{uc: uc, access: access}
+}
+
+func (h *SequenceHandler) List(c *fiber.Ctx) error {
+	seqs, err := h.uc.ListSequences(c.Context(), c.Query("store_id"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(seqs)
+}
+
+func (h *SequenceHandler) Configure(c *fiber.Ctx) error {
+	var req ConfigureSequenceRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	seq, err := h.uc.ConfigureSequence(c.Context(), usecase.SequenceConfigInput{
+		StoreID:      req.StoreID,
+		DocumentType: req.DocumentType,
+		FiscalYear:   req.FiscalYear,
+		Prefix:       req.Prefix,
+		Format:       req.Format,
+		Padding:      req.Padding,
+		StartNumber:  req.StartNumber,
+	})
+	if err != nil {
+		return err
+	}
+	return c.JSON(seq)
+}
+
+func (h *SequenceHandler) RegisterRoutes(app *fiber.App) {
+	api := app.Group("/document-sequences", middleware.RequireRoles("user", "admin"))
+	api.Get("/", middleware.RequireStoreAccess(h.access, middleware.FromQuery("store_id")), h.List) // ?store_id=<uuid>
+	api.Put("/", middleware.RequireStoreAccess(h.access, middleware.FromBody(func(r *ConfigureSequenceRequest) string {
+		return r.StoreID
+	})), h.Configure)
+}
@@ -0,0 +1,118 @@
+package http
+
+import (
+	"strconv"
+
+	"invoice_project/internal/invoice/usecase"
+	"invoice_project/pkg/apperror"
+	"invoice_project/pkg/middleware"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type ShareHandler struct {
+	uc     usecase.DocumentShareUsecase
+	docs   usecase.InvoiceDocumentUsecase
+	access middleware.StoreAuthorizer
+}
+
+func NewShareHandler(uc usecase.DocumentShareUsecase, docs usecase.InvoiceDocumentUsecase, access middleware.StoreAuthorizer) *ShareHandler {
+	return &ShareHandler{uc: uc, docs: docs, access: access}
+}
+
+// Create makes a public link to the document. The token is only returned
+// here.
+func (h *ShareHandler) Create(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	var req ShareDocumentRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return apperror.New(fiber.StatusBadRequest)
+		}
+	}
+	userID := c.Locals("user_id").(uuid.UUID)
+	link, err := h.uc.CreateShare(c.Context(), uint(id), req.ExpiresAt, userID.String())
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(link)
+}
+
+// List returns the document's links with when they were first and last
+// viewed.
+func (h *ShareHandler) List(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	shares, err := h.uc.ListShares(c.Context(), uint(id))
+	if err != nil {
+		return err
+	}
+	return c.JSON(shares)
+}
+
+func (h *ShareHandler) Revoke(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	userID := c.Locals("user_id").(uuid.UUID)
+	if err := h.uc.RevokeShare(c.Context(), uint(id), c.Params("shareId"), userID.String()); err != nil {
+		return err
+	}
+	return c.JSON(fiber.Map{"message": "revoked"})
+}
+
+// View returns a shared document to anyone holding its token.
+func (h *ShareHandler) View(c *fiber.Ctx) error {
+	doc, err := h.uc.OpenShare(c.Context(), c.Params("token"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(doc)
+}
+
+// PDF renders a shared document to anyone holding its token.
+func (h *ShareHandler) PDF(c *fiber.Ctx) error {
+	b, doc, err := h.uc.SharedPDF(c.Context(), c.Params("token"))
+	if err != nil {
+		return err
+	}
+	name := doc.DocumentNo
+	if name == "" {
+		name = "document"
+	}
+	c.Set(fiber.HeaderContentType, "application/pdf")
+	c.Set(fiber.HeaderContentDisposition, `inline; filename="`+name+`.pdf"`)
+	return c.Send(b)
+}
+
+// PromptPay returns the PromptPay code of a shared unpaid document.
+func (h *ShareHandler) PromptPay(c *fiber.Ctx) error {
+	b, err := h.uc.SharedPromptPayPNG(c.Context(), c.Params("token"))
+	if err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderContentType, "image/png")
+	return c.Send(b)
+}
+
+// RegisterRoutes registers the link management routes and the public
+// routes, which must be left out of the JWT middleware.
+func (h *ShareHandler) RegisterRoutes(app *fiber.App) {
+	docs := app.Group("/invoice-documents", middleware.RequireRoles("user", "admin"))
+	byDocument := middleware.RequireStoreAccess(h.access, documentStore(h.docs))
+	docs.Post("/:id/share", byDocument, h.Create)
+	docs.Get("/:id/shares", byDocument, h.List)
+	docs.Delete("/:id/shares/:shareId", byDocument, h.Revoke)
+
+	public := app.Group(usecase.SharePath)
+	public.Get("/:token", h.View)
+	public.Get("/:token/pdf", h.PDF)
+	public.Get("/:token/promptpay.png", h.PromptPay)
+}
@@ -0,0 +1,171 @@
+package http
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+
+	"invoice_project/internal/invoice/usecase"
+	"invoice_project/pkg/apperror"
+	"invoice_project/pkg/bankstatement"
+	"invoice_project/pkg/middleware"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type StatementHandler struct {
+	uc     usecase.BankStatementUsecase
+	access middleware.StoreAuthorizer
+}
+
+func NewStatementHandler(uc usecase.BankStatementUsecase, access middleware.StoreAuthorizer) *StatementHandler {
+	return &StatementHandler{uc: uc, access: access}
+}
+
+// Import reads a CSV statement sent as the multipart field "file" with
+// "store_id" and "bank". The generic format takes its column mapping as
+// JSON in "mapping"; "tolerance_days" is optional.
+func (h *StatementHandler) Import(c *fiber.Ctx) error {
+	fh, err := c.FormFile("file")
+	if err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	f, err := fh.Open()
+	if err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	in := usecase.ImportStatementInput{
+		StoreID:  c.FormValue("store_id"),
+		Bank:     c.FormValue("bank"),
+		FileName: fh.Filename,
+		Data:     data,
+	}
+	if v := c.FormValue("mapping"); v != "" {
+		in.Mapping = &bankstatement.Mapping{}
+		if err := json.Unmarshal([]byte(v), in.Mapping); err != nil {
+			return apperror.New(fiber.StatusBadRequest)
+		}
+	}
+	if v := c.FormValue("tolerance_days"); v != "" {
+		if in.ToleranceDays, err = strconv.Atoi(v); err != nil {
+			return apperror.New(fiber.StatusBadRequest)
+		}
+	}
+	userID := c.Locals("user_id").(uuid.UUID)
+	s, err := h.uc.ImportStatement(c.Context(), in, userID.String())
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(s)
+}
+
+// Banks lists the banks whose statements are read without a mapping.
+func (h *StatementHandler) Banks(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"banks": bankstatement.Banks(), "generic": bankstatement.Generic})
+}
+
+func (h *StatementHandler) List(c *fiber.Ctx) error {
+	statements, err := h.uc.ListStatements(c.Context(), c.Query("store_id"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(statements)
+}
+
+func (h *StatementHandler) Get(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	s, err := h.uc.GetStatement(c.Context(), uint(id))
+	if err != nil {
+		return err
+	}
+	return c.JSON(s)
+}
+
+// Match proposes documents again for the statement's open lines.
+func (h *StatementHandler) Match(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	var req MatchStatementRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return apperror.New(fiber.StatusBadRequest)
+		}
+	}
+	s, err := h.uc.MatchStatement(c.Context(), uint(id), req.ToleranceDays)
+	if err != nil {
+		return err
+	}
+	return c.JSON(s)
+}
+
+// Lines lists a store's statement lines, e.g.
+// ?store_id=<uuid>&status=unmatched. Without status the lines awaiting
+// review are returned.
+func (h *StatementHandler) Lines(c *fiber.Ctx) error {
+	lines, err := h.uc.ListLines(c.Context(), c.Query("store_id"), c.Query("status"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(lines)
+}
+
+// Confirm records the payment of a line.
+func (h *StatementHandler) Confirm(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	var req ConfirmStatementLineRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	userID := c.Locals("user_id").(uuid.UUID)
+	l, err := h.uc.ConfirmLine(c.Context(), uint(id), usecase.ConfirmLineInput{
+		DocumentID:   req.DocumentID,
+		Allocations:  req.Allocations,
+		IssueReceipt: req.IssueReceipt,
+	}, userID.String())
+	if err != nil {
+		return err
+	}
+	return c.JSON(l)
+}
+
+func (h *StatementHandler) Ignore(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	userID := c.Locals("user_id").(uuid.UUID)
+	l, err := h.uc.IgnoreLine(c.Context(), uint(id), userID.String())
+	if err != nil {
+		return err
+	}
+	return c.JSON(l)
+}
+
+func (h *StatementHandler) RegisterRoutes(app *fiber.App) {
+	api := app.Group("/bank-statements", middleware.RequireRoles("user", "admin"))
+	byQuery := middleware.RequireStoreAccess(h.access, middleware.FromQuery("store_id"))
+	byStatement := middleware.RequireStoreAccess(h.access, statementStore(h.uc))
+	byLine := middleware.RequireStoreAccess(h.access, statementLineStore(h.uc))
+	api.Post("/", middleware.RequireStoreAccess(h.access, middleware.FromForm("store_id")), h.Import)
+	api.Get("/", byQuery, h.List) // ?store_id=<uuid>
+	api.Get("/banks", h.Banks)
+	api.Get("/lines", byQuery, h.Lines) // ?store_id=<uuid>&status=
+	api.Post("/lines/:id/confirm", byLine, h.Confirm)
+	api.Post("/lines/:id/ignore", byLine, h.Ignore)
+	api.Get("/:id", byStatement, h.Get)
+	api.Post("/:id/match", byStatement, h.Match)
+}
@@ -0,0 +1,83 @@
+package http
+
+import (
+	"invoice_project/internal/invoice/domain"
+	"invoice_project/internal/invoice/usecase"
+	"invoice_project/pkg/apperror"
+	"invoice_project/pkg/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type TemplateHandler struct {
+	uc     usecase.DocumentPDFUsecase
+	sendUC usecase.DocumentSendUsecase
+	access middleware.StoreAuthorizer
+}
+
+func NewTemplateHandler(uc usecase.DocumentPDFUsecase, sendUC usecase.DocumentSendUsecase, access middleware.StoreAuthorizer) *TemplateHandler {
+	return &TemplateHandler{uc: uc, sendUC: sendUC, access: access}
+}
+
+// Get returns the available templates and, when store_id is given, the
+// one the store currently uses.
+func (h *TemplateHandler) Get(c *fiber.Ctx) error {
+	resp := fiber.Map{"templates": usecase.PDFTemplates()}
+	if storeID := c.Query("store_id"); storeID != "" {
+		t, err := h.uc.GetStoreTemplate(c.Context(), storeID)
+		if err != nil {
+			return err
+		}
+		resp["store"] = t
+	}
+	return c.JSON(resp)
+}
+
+func (h *TemplateHandler) Set(c *fiber.Ctx) error {
+	var req SetTemplateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	t, err := h.uc.SetStoreTemplate(c.Context(), req.StoreID, req.Template)
+	if err != nil {
+		return err
+	}
+	return c.JSON(t)
+}
+
+// GetEmail returns the email template of the store given by store_id.
+func (h *TemplateHandler) GetEmail(c *fiber.Ctx) error {
+	t, err := h.sendUC.GetEmailTemplate(c.Context(), c.Query("store_id"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(t)
+}
+
+func (h *TemplateHandler) SetEmail(c *fiber.Ctx) error {
+	var req SetEmailTemplateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	t, err := h.sendUC.SetEmailTemplate(c.Context(), &domain.StoreEmailTemplate{
+		StoreID: req.StoreID,
+		Subject: req.Subject,
+		HTML:    req.HTML,
+	})
+	if err != nil {
+		return err
+	}
+	return c.JSON(t)
+}
+
+func (h *TemplateHandler) RegisterRoutes(app *fiber.App) {
+	api := app.Group("/document-templates", middleware.RequireRoles("user", "admin"))
+	api.Get("/", middleware.RequireStoreAccess(h.access, middleware.FromQuery("store_id")), h.Get) // ?store_id=<uuid>
+	api.Put("/", middleware.RequireStoreAccess(h.access, middleware.FromBody(func(r *SetTemplateRequest) string {
+		return r.StoreID
+	})), h.Set)
+	api.Get("/email", middleware.RequireStoreAccess(h.access, middleware.FromQuery("store_id")), h.GetEmail) // ?store_id=<uuid>
+	api.Put("/email", middleware.RequireStoreAccess(h.access, middleware.FromBody(func(r *SetEmailTemplateRequest) string {
+		return r.StoreID
+	})), h.SetEmail)
+}
@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"time"
+
+	"invoice_project/pkg/money"
+)
+
+// Statuses of a BankStatementLine. A line is proposed while it has
+// candidate documents awaiting confirmation and matched once a payment
+// was recorded for it. Ignored lines are not receivables, such as
+// interest or refunds.
+const (
+	StatementLineUnmatched = "unmatched"
+	StatementLineProposed  = "proposed"
+	StatementLineMatched   = "matched"
+	StatementLineIgnored   = "ignored"
+)
+
+// Reasons a document is proposed for a statement line.
+const (
+	MatchReasonAmount    = "amount"
+	MatchReasonReference = "reference"
+	MatchReasonDate      = "date"
+)
+
+// DefaultMatchToleranceDays is how many days a transfer may precede the
+// issue date of the document it pays.
+const DefaultMatchToleranceDays = 7
+
+// BankStatement is an uploaded bank statement of a store. Only the money
+// received is kept, as Lines.
+type BankStatement struct {
+	ID         uint                `gorm:"primaryKey;autoIncrement" json:"id"`
+	StoreID    string              `gorm:"type:uuid;not null;index" json:"store_id"`
+	Bank       string              `gorm:"size:20;not null" json:"bank"`
+	FileName   string              `gorm:"size:255" json:"file_name"`
+	Skipped    int                 `gorm:"not null;default:0" json:"skipped"`
+	ImportedBy string              `gorm:"size:100" json:"imported_by"`
+	CreatedAt  time.Time           `gorm:"autoCreateTime" json:"created_at"`
+	Lines      []BankStatementLine `gorm:"foreignKey:StatementID" json:"lines,omitempty"`
+}
+
+// BankStatementLine is one credit of a statement. PaymentID is the payment
+// recorded when the line was matched.
+type BankStatementLine struct {
+	ID              uint                 `gorm:"primaryKey;autoIncrement" json:"id"`
+	StatementID     uint                 `gorm:"not null;index" json:"statement_id"`
+	StoreID         string               `gorm:"type:uuid;not null;index:idx_statement_lines_store_status,priority:1" json:"store_id"`
+	Row             int                  `json:"row"`
+	TransactionDate time.Time            `gorm:"type:date;not null" json:"transaction_date"`
+	Description     string               `gorm:"type:text" json:"description"`
+	Reference       string               `gorm:"size:255" json:"reference"`
+	Amount          money.Amount         `gorm:"type:numeric(14,2);not null" json:"amount"`
+	Status          string               `gorm:"size:20;not null;index:idx_statement_lines_store_status,priority:2" json:"status"`
+	PaymentID       *uint                `json:"payment_id"`
+	ReviewedBy      string               `gorm:"size:100" json:"reviewed_by,omitempty"`
+	ReviewedAt      *time.Time           `json:"reviewed_at,omitempty"`
+	Matches         []BankStatementMatch `gorm:"foreignKey:LineID" json:"matches"`
+}
+
+// BankStatementMatch proposes a document a statement line may pay. Amount
+// is what the line would settle on it and Score ranks the proposals of a
+// line, highest first.
+type BankStatementMatch struct {
+	ID         uint         `gorm:"primaryKey;autoIncrement" json:"id"`
+	LineID     uint         `gorm:"not null;index" json:"line_id"`
+	DocumentID uint         `gorm:"not null" json:"document_id"`
+	DocumentNo string       `gorm:"size:50" json:"document_no"`
+	Amount     money.Amount `gorm:"type:numeric(14,2);not null" json:"amount"`
+	Score      int          `json:"score"`
+	Reasons    []string     `gorm:"serializer:json" json:"reasons"`
+}
+
+// OpenDocument is a document awaiting payment with what is outstanding on
+// it, as matched against bank statements.
+type OpenDocument struct {
+	ID          uint         `json:"id"`
+	DocumentNo  string       `json:"document_no"`
+	IssueDate   time.Time    `json:"issue_date"`
+	Outstanding money.Amount `json:"outstanding"`
+}
@@ -0,0 +1,57 @@
+package domain
+
+import "time"
+
+// EventSent is recorded on a document each time it is emailed to the
+// buyer. The first delivery of an issued document also moves it to
+// StatusSent.
+const EventSent = "sent"
+
+// ContactTypeEmail is the CustomerContact.ContactType of an email address.
+const ContactTypeEmail = "email"
+
+// Channels and statuses of a DocumentDelivery.
+const (
+	DeliveryChannelEmail = "email"
+
+	DeliveryStatusSent   = "sent"
+	DeliveryStatusFailed = "failed"
+)
+
+// DocumentDelivery records an attempt to send a document to its buyer.
+// Failed attempts keep the transport's error.
+type DocumentDelivery struct {
+	ID         uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	DocumentID uint      `gorm:"not null;index" json:"document_id"`
+	Channel    string    `gorm:"size:20;not null" json:"channel"`
+	Recipients []string  `gorm:"type:text;serializer:json" json:"recipients"`
+	Cc         []string  `gorm:"type:text;serializer:json" json:"cc,omitempty"`
+	Bcc        []string  `gorm:"type:text;serializer:json" json:"bcc,omitempty"`
+	Subject    string    `gorm:"type:text" json:"subject"`
+	Status     string    `gorm:"size:20;not null" json:"status"`
+	Error      string    `gorm:"type:text" json:"error,omitempty"`
+	SentBy     string    `gorm:"size:100" json:"sent_by"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// StoreEmailTemplate is the subject and HTML body a store emails its
+// documents with. Both are Go templates executed with the document's
+// details.
+type StoreEmailTemplate struct {
+	StoreID   string    `gorm:"type:uuid;primaryKey" json:"store_id"`
+	Subject   string    `gorm:"type:text;not null" json:"subject"`
+	HTML      string    `gorm:"type:text;not null" json:"html"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// IsSendable reports whether a document in status s can be sent to its
+// buyer. Drafts are not final yet and voided or cancelled documents are
+// no longer in force.
+func IsSendable(s string) bool {
+	switch s {
+	case StatusDraft, StatusVoid, StatusCancelled:
+		return false
+	default:
+		return IsValidStatus(s)
+	}
+}
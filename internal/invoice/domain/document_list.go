@@ -0,0 +1,43 @@
+package domain
+
+import "invoice_project/pkg/money"
+
+// Sort keys of the document list. A leading "-" sorts descending.
+const (
+	SortIssueDate  = "issue_date"
+	SortCreatedAt  = "created_at"
+	SortDocumentNo = "document_no"
+	SortGrandTotal = "grand_total"
+)
+
+// IsDocumentSort reports whether key is a known document list sort key.
+func IsDocumentSort(key string) bool {
+	switch key {
+	case SortIssueDate, SortCreatedAt, SortDocumentNo, SortGrandTotal:
+		return true
+	}
+	return false
+}
+
+// DocumentList is one page of a store's documents together with the totals
+// of every document matching the filters, not only those on the page.
+type DocumentList struct {
+	Documents  []InvoiceDocument  `json:"documents"`
+	NextCursor string             `json:"next_cursor,omitempty"`
+	Totals     DocumentListTotals `json:"totals"`
+}
+
+// DocumentListTotals sums the documents matching a list's filters. The
+// amounts add up documents in their own currency and are only meaningful
+// for lists of one currency; ThbGrandTotal and ThbVatAmount are always in
+// baht.
+type DocumentListTotals struct {
+	Count         int64        `json:"count"`
+	Subtotal      money.Amount `json:"subtotal"`
+	Discount      money.Amount `json:"discount_amount"`
+	VatAmount     money.Amount `json:"vat_amount"`
+	GrandTotal    money.Amount `json:"grand_total"`
+	WhtAmount     money.Amount `json:"wht_amount"`
+	ThbGrandTotal money.Amount `json:"thb_grand_total"`
+	ThbVatAmount  money.Amount `json:"thb_vat_amount"`
+}
@@ -0,0 +1,98 @@
+package domain
+
+import (
+	"time"
+
+	"invoice_project/pkg/money"
+)
+
+// Timeline events recorded when a document is shared through a public
+// link and when the buyer first opens one.
+const (
+	EventShared       = "shared"
+	EventShareViewed  = "share_viewed"
+	EventShareRevoked = "share_revoked"
+)
+
+// Lifetime of a share link, in days, when none is given and at most.
+const (
+	DefaultShareDays = 30
+	MaxShareDays     = 365
+)
+
+// DocumentShare is a public, read-only link to a document. The link
+// carries a signed token naming the share; it stops working when it
+// expires or is revoked. Views through the link are counted.
+type DocumentShare struct {
+	ID            string     `gorm:"type:uuid;primaryKey" json:"id"`
+	DocumentID    uint       `gorm:"not null;index" json:"document_id"`
+	ExpiresAt     time.Time  `gorm:"not null" json:"expires_at"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty"`
+	CreatedBy     string     `gorm:"size:100" json:"created_by"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	ViewCount     int        `gorm:"not null;default:0" json:"view_count"`
+	FirstViewedAt *time.Time `json:"first_viewed_at,omitempty"`
+	LastViewedAt  *time.Time `json:"last_viewed_at,omitempty"`
+}
+
+// IsActive reports whether the link still grants access at t.
+func (s *DocumentShare) IsActive(t time.Time) bool {
+	return s.RevokedAt == nil && t.Before(s.ExpiresAt)
+}
+
+// ShareLink is a newly created share with its token and the public URL
+// built from it. The token is only handed out once.
+type ShareLink struct {
+	DocumentShare
+	Token string `json:"token"`
+	URL   string `json:"url"`
+}
+
+// SharedDocument is what a share link shows: what is printed on the
+// document and, while it is awaiting payment, the PromptPay code paying
+// it. IDs, versions, the timeline and other internal fields are left out.
+type SharedDocument struct {
+	DocumentType     string         `json:"document_type"`
+	DocumentNo       string         `json:"document_no"`
+	IssueDate        time.Time      `json:"issue_date"`
+	ValidUntil       *time.Time     `json:"valid_until,omitempty"`
+	Status           string         `json:"status"`
+	Seller           SharedParty    `json:"seller"`
+	Buyer            SharedParty    `json:"buyer"`
+	Currency         string         `json:"currency"`
+	Subtotal         money.Amount   `json:"subtotal"`
+	DiscountAmount   money.Amount   `json:"discount_amount"`
+	VatAmount        money.Amount   `json:"vat_amount"`
+	GrandTotal       money.Amount   `json:"grand_total"`
+	WhtAmount        money.Amount   `json:"wht_amount"`
+	AdjustmentReason string         `json:"adjustment_reason,omitempty"`
+	Remarks          string         `json:"remarks"`
+	Items            []SharedItem   `json:"items"`
+	Payment          *SharedPayment `json:"payment,omitempty"`
+}
+
+// SharedParty is the seller or buyer of a shared document.
+type SharedParty struct {
+	Name     string `json:"name"`
+	TaxID    string `json:"tax_id"`
+	BranchNo string `json:"branch_no,omitempty"`
+	Address  string `json:"address"`
+}
+
+// SharedItem is a line of a shared document.
+type SharedItem struct {
+	ProductName string       `json:"product_name"`
+	Sku         string       `json:"sku,omitempty"`
+	Qty         int          `json:"qty"`
+	UnitPrice   money.Amount `json:"unit_price"`
+	Discount    money.Amount `json:"discount"`
+	VatType     string       `json:"vat_type"`
+	VatRate     float64      `json:"vat_rate"`
+	LineTotal   money.Amount `json:"line_total"`
+}
+
+// SharedPayment is the PromptPay code paying a shared document.
+type SharedPayment struct {
+	Amount  money.Amount `json:"amount"`
+	Payload string       `json:"payload"`
+}
@@ -0,0 +1,84 @@
+package domain
+
+// Statuses an InvoiceDocument moves through during its lifecycle.
+const (
+	StatusDraft         = "draft"
+	StatusIssued        = "issued"
+	StatusSent          = "sent"
+	StatusPartiallyPaid = "partially_paid"
+	StatusPaid          = "paid"
+	StatusVoid          = "void"
+	StatusCancelled     = "cancelled"
+
+	// Quotations are answered by the customer instead of being paid.
+	StatusAccepted = "accepted"
+	StatusRejected = "rejected"
+	StatusExpired  = "expired"
+)
+
+// Timeline event types recorded in DocumentTimeline.EventType.
+const (
+	EventCreated       = "created"
+	EventStatusChanged = "status_changed"
+	EventDraftRevised  = "draft_revised"
+)
+
+// statusTransitions lists the statuses a document can be moved to by hand
+// from each status. Drafts can be cancelled outright; anything issued must
+// be voided instead. partially_paid and paid are never reached this way:
+// recording a payment or issuing a note sets them from the document's
+// balance.
+var statusTransitions = map[string][]string{
+	StatusDraft:         {StatusIssued, StatusCancelled},
+	StatusIssued:        {StatusSent, StatusVoid},
+	StatusSent:          {StatusVoid},
+	StatusPartiallyPaid: {StatusVoid},
+	StatusPaid:          {StatusVoid},
+	StatusVoid:          {},
+	StatusCancelled:     {},
+}
+
+// quotationTransitions is the lifecycle of a quotation. Once issued it
+// waits for the customer's answer or expires at the end of its validity.
+var quotationTransitions = map[string][]string{
+	StatusDraft:     {StatusIssued, StatusCancelled},
+	StatusIssued:    {StatusSent, StatusAccepted, StatusRejected, StatusExpired, StatusCancelled},
+	StatusSent:      {StatusAccepted, StatusRejected, StatusExpired, StatusCancelled},
+	StatusAccepted:  {},
+	StatusRejected:  {},
+	StatusExpired:   {},
+	StatusCancelled: {},
+}
+
+func transitionsFor(documentType string) map[string][]string {
+	if documentType == DocumentTypeQuotation {
+		return quotationTransitions
+	}
+	return statusTransitions
+}
+
+// IsValidStatus reports whether s is a known document status.
+func IsValidStatus(s string) bool {
+	_, ok := statusTransitions[s]
+	if !ok {
+		_, ok = quotationTransitions[s]
+	}
+	return ok
+}
+
+// CanTransition reports whether a document of the given type may move
+// from one status to another.
+func CanTransition(documentType, from, to string) bool {
+	for _, s := range transitionsFor(documentType)[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowedTransitions returns the statuses a document of the given type
+// can reach from s.
+func AllowedTransitions(documentType, s string) []string {
+	return append([]string(nil), transitionsFor(documentType)[s]...)
+}
@@ -0,0 +1,11 @@
+package domain
+
+import "time"
+
+// StoreDocumentTemplate records which PDF template a store prints its
+// documents with.
+type StoreDocumentTemplate struct {
+	StoreID   string    `gorm:"type:uuid;primaryKey" json:"store_id"`
+	Template  string    `gorm:"size:50;not null" json:"template"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
@@ -0,0 +1,135 @@
+package domain
+
+// Document types stored in InvoiceDocument.DocumentType.
+const (
+	DocumentTypeInvoice            = "invoice"
+	DocumentTypeTaxInvoice         = "tax_invoice"
+	DocumentTypeReceipt            = "receipt"
+	DocumentTypeReceiptTaxInvoice  = "receipt_tax_invoice"
+	DocumentTypeDeliveryTaxInvoice = "delivery_tax_invoice"
+	DocumentTypeCreditNote         = "credit_note"
+	DocumentTypeDebitNote          = "debit_note"
+	DocumentTypeQuotation          = "quotation"
+)
+
+// Timeline events recorded on an invoice when a note adjusts it.
+const (
+	EventCreditNoteIssued = "credit_note_issued"
+	EventDebitNoteIssued  = "debit_note_issued"
+)
+
+// Timeline events recorded when a quotation is converted, on the
+// quotation and on the document created from it.
+const (
+	EventQuotationConverted     = "quotation_converted"
+	EventConvertedFromQuotation = "converted_from_quotation"
+)
+
+// DefaultQuotationValidityDays is how long a quotation stays valid when
+// no ValidUntil date is given.
+const DefaultQuotationValidityDays = 30
+
+// IsQuotationOpen reports whether a quotation in status s is still waiting
+// for the customer's answer.
+func IsQuotationOpen(s string) bool {
+	return s == StatusIssued || s == StatusSent
+}
+
+// IsConvertible reports whether a quotation can be converted into an
+// invoice document of the given type.
+func IsConvertible(documentType string) bool {
+	switch documentType {
+	case DocumentTypeInvoice, DocumentTypeTaxInvoice, DocumentTypeReceiptTaxInvoice, DocumentTypeDeliveryTaxInvoice:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsAdjustmentNote reports whether the document type is a credit or debit
+// note, which must reference an issued invoice.
+func IsAdjustmentNote(documentType string) bool {
+	return documentType == DocumentTypeCreditNote || documentType == DocumentTypeDebitNote
+}
+
+// IsAdjustable reports whether a document of this type can be referenced
+// by a credit or debit note.
+func IsAdjustable(documentType string) bool {
+	switch documentType {
+	case DocumentTypeInvoice, DocumentTypeTaxInvoice, DocumentTypeReceiptTaxInvoice, DocumentTypeDeliveryTaxInvoice:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsTaxInvoice reports whether a document of this type is a tax invoice or
+// a note adjusting one, which must show the seller's tax ID and the
+// buyer's address.
+func IsTaxInvoice(documentType string) bool {
+	switch documentType {
+	case DocumentTypeTaxInvoice, DocumentTypeReceiptTaxInvoice, DocumentTypeDeliveryTaxInvoice,
+		DocumentTypeCreditNote, DocumentTypeDebitNote:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsIssuedStatus reports whether a document in status s has been issued
+// and is still in force.
+func IsIssuedStatus(s string) bool {
+	switch s {
+	case StatusIssued, StatusSent, StatusPartiallyPaid, StatusPaid:
+		return true
+	default:
+		return false
+	}
+}
+
+// IssuedStatuses returns the statuses accepted by IsIssuedStatus, for
+// queries. Tax reports only count documents in these statuses, leaving
+// out drafts and voided or cancelled documents.
+func IssuedStatuses() []string {
+	return []string{StatusIssued, StatusSent, StatusPartiallyPaid, StatusPaid}
+}
+
+// TaxInvoiceTypes returns the document types accepted by IsTaxInvoice.
+func TaxInvoiceTypes() []string {
+	return []string{DocumentTypeTaxInvoice, DocumentTypeReceiptTaxInvoice, DocumentTypeDeliveryTaxInvoice,
+		DocumentTypeCreditNote, DocumentTypeDebitNote}
+}
+
+// SalesTypes returns the document types that record a sale: the types
+// accepted by IsPayable. Receipts only acknowledge payment of these and
+// quotations are offers, so neither counts as a sale.
+func SalesTypes() []string {
+	return []string{DocumentTypeInvoice, DocumentTypeTaxInvoice, DocumentTypeReceiptTaxInvoice, DocumentTypeDeliveryTaxInvoice}
+}
+
+// OpenStatuses returns the statuses of an issued document that is not
+// fully paid yet.
+func OpenStatuses() []string {
+	return []string{StatusIssued, StatusSent, StatusPartiallyPaid}
+}
+
+// documentTitles holds the Thai and English headings printed on each type
+// of document.
+var documentTitles = map[string][2]string{
+	DocumentTypeInvoice:            {"ใบแจ้งหนี้", "INVOICE"},
+	DocumentTypeTaxInvoice:         {"ใบกำกับภาษี", "TAX INVOICE"},
+	DocumentTypeReceipt:            {"ใบเสร็จรับเงิน", "RECEIPT"},
+	DocumentTypeReceiptTaxInvoice:  {"ใบเสร็จรับเงิน/ใบกำกับภาษี", "RECEIPT/TAX INVOICE"},
+	DocumentTypeDeliveryTaxInvoice: {"ใบส่งของ/ใบกำกับภาษี", "DELIVERY ORDER/TAX INVOICE"},
+	DocumentTypeCreditNote:         {"ใบลดหนี้", "CREDIT NOTE"},
+	DocumentTypeDebitNote:          {"ใบเพิ่มหนี้", "DEBIT NOTE"},
+	DocumentTypeQuotation:          {"ใบเสนอราคา", "QUOTATION"},
+}
+
+// DocumentTitle returns the Thai and English heading of a document type.
+func DocumentTitle(documentType string) (th, en string) {
+	if t, ok := documentTitles[documentType]; ok {
+		return t[0], t[1]
+	}
+	return "เอกสาร", "DOCUMENT"
+}
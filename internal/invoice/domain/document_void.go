@@ -0,0 +1,37 @@
+package domain
+
+// Timeline events recorded when a document is voided, on the voided
+// document, and when it is replaced, on the replacement.
+const (
+	EventVoided   = "voided"
+	EventReplaces = "replaces_document"
+)
+
+// VoidResult is a voided document and, when one was issued, the document
+// replacing it.
+type VoidResult struct {
+	Voided      *InvoiceDocument `json:"voided"`
+	Replacement *InvoiceDocument `json:"replacement,omitempty"`
+}
+
+// IsReplaceable reports whether a voided document of this type can be
+// replaced by a new document of the same type. Credit and debit notes
+// reference the invoice they adjust and are reissued as new notes instead.
+func IsReplaceable(documentType string) bool {
+	switch documentType {
+	case DocumentTypeInvoice, DocumentTypeTaxInvoice, DocumentTypeReceipt,
+		DocumentTypeReceiptTaxInvoice, DocumentTypeDeliveryTaxInvoice:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsReplacement reports whether doc was issued to replace ref, the
+// document its ReferenceID points at. Documents converted from a quotation
+// reference it too, but only a replacement references a voided document
+// of its own type.
+func IsReplacement(doc, ref *InvoiceDocument) bool {
+	return ref != nil && doc.ReferenceID != nil && *doc.ReferenceID == ref.ID &&
+		ref.DocumentType == doc.DocumentType && ref.Status == StatusVoid
+}
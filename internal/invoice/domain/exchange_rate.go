@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"time"
+
+	"invoice_project/pkg/money"
+)
+
+// CurrencyTHB is the currency of documents that do not name one, and the
+// currency every document is reported in.
+const CurrencyTHB = "THB"
+
+// Sources of an ExchangeRate.
+const (
+	RateSourceManual = "manual"
+	RateSourceBOT    = "bot"
+)
+
+// MaxRateAgeDays is how many days before the issue date of a document the
+// rate it is converted at may have been published. Rates are not published
+// on weekends and bank holidays.
+const MaxRateAgeDays = 7
+
+// ExchangeRate is the baht value of one unit of Currency on RateDate.
+// Rates imported from the Bank of Thailand apply to every store and have
+// no StoreID; a store may enter its own rate, which takes precedence on
+// the same date.
+type ExchangeRate struct {
+	ID        uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	StoreID   *string    `gorm:"type:uuid;index:idx_exchange_rates_lookup,priority:1" json:"store_id"`
+	Currency  string     `gorm:"size:3;not null;index:idx_exchange_rates_lookup,priority:2" json:"currency"`
+	RateDate  time.Time  `gorm:"type:date;not null;index:idx_exchange_rates_lookup,priority:3" json:"rate_date"`
+	Rate      money.Rate `gorm:"type:numeric(18,6);not null" json:"rate"`
+	Source    string     `gorm:"size:20;not null" json:"source"`
+	CreatedBy string     `gorm:"size:100" json:"created_by"`
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// RateImport is the outcome of importing published rates. Rates already
+// on record for their currency and date are replaced.
+type RateImport struct {
+	Imported   int      `json:"imported"`
+	Currencies []string `json:"currencies"`
+}
@@ -3,13 +3,15 @@ package domain
 import (
 	"time"
 
+	"invoice_project/pkg/money"
+
 	"github.com/google/uuid"
 )
 
 type Invoice struct {
-	ID          uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
-	Customer    string    `gorm:"not null" json:"customer"`
-	Amount      float64   `gorm:"not null" json:"amount"`
-	CreatedAt   time.Time `json:"created_at"`
-	CreatedByID uuid.UUID `json:"created_by"`
+	ID          uuid.UUID    `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	Customer    string       `gorm:"not null" json:"customer"`
+	Amount      money.Amount `gorm:"type:numeric(14,2);not null" json:"amount"`
+	CreatedAt   time.Time    `json:"created_at"`
+	CreatedByID uuid.UUID    `json:"created_by"`
 }
@@ -1,55 +1,106 @@
 package domain
 
-import "time"
+import (
+	"time"
+
+	"invoice_project/pkg/money"
+)
 
 // InvoiceDocument represents an issued invoice with summary information.
 type InvoiceDocument struct {
-	ID                uint      `gorm:"primaryKey;autoIncrement" json:"id"`
-	DocumentType      string    `gorm:"size:50" json:"document_type"`
-	DocumentNumber    int       `json:"document_number"`
-	ReferenceID       *uint     `json:"reference_id"`
-	StoreID           *string   `gorm:"type:uuid" json:"store_id"`
-	CustomerID        *uint     `json:"customer_id"`
-	IssueDate         time.Time `gorm:"type:date" json:"issue_date"`
-	Status            string    `gorm:"size:50" json:"status"`
-	BuyerType         string    `gorm:"size:20" json:"buyer_type"`
-	BuyerFirstName    string    `gorm:"size:100" json:"buyer_first_name,omitempty"`
-	BuyerLastName     string    `gorm:"size:100" json:"buyer_last_name,omitempty"`
-	BuyerCompanyName  string    `gorm:"size:255" json:"buyer_company_name,omitempty"`
-	BuyerTaxID        string    `gorm:"size:100" json:"buyer_tax_id"`
-	BuyerAddress      string    `gorm:"type:text" json:"buyer_address"`
-	SellerType        string    `gorm:"size:20" json:"seller_type"`
-	SellerFirstName   string    `gorm:"size:100" json:"seller_first_name,omitempty"`
-	SellerLastName    string    `gorm:"size:100" json:"seller_last_name,omitempty"`
-	SellerCompanyName string    `gorm:"size:255" json:"seller_company_name,omitempty"`
-	SellerTaxID       string    `gorm:"size:100" json:"seller_tax_id"`
-	SellerAddress     string    `gorm:"type:text" json:"seller_address"`
-	Subtotal          int       `json:"subtotal"`
-	DiscountType      int       `json:"discount_type"`
-	DiscountValue     int       `json:"discount_value"`
-	DiscountAmount    int       `json:"discount_amount"`
-	VatAmount         int       `json:"vat_amount"`
-	GrandTotal        int       `json:"grand_total"`
-	Remarks           string    `gorm:"type:text" json:"remarks"`
-	CreatedAt         time.Time `gorm:"autoCreateTime" json:"created_at"`
+	ID                uint         `gorm:"primaryKey;autoIncrement" json:"id"`
+	DocumentType      string       `gorm:"size:50" json:"document_type"`
+	DocumentNumber    int          `json:"document_number"`
+	DocumentNo        string       `gorm:"size:50;index" json:"document_no"`
+	ReferenceID       *uint        `gorm:"index" json:"reference_id"`
+	StoreID           *string      `gorm:"type:uuid;index:idx_invoice_documents_store_issue,priority:1" json:"store_id"`
+	CustomerID        *uint        `json:"customer_id"`
+	IssueDate         time.Time    `gorm:"type:date;index:idx_invoice_documents_store_issue,priority:2" json:"issue_date"`
+	ValidUntil        *time.Time   `gorm:"type:date" json:"valid_until,omitempty"`
+	Status            string       `gorm:"size:50" json:"status"`
+	BuyerType         string       `gorm:"size:20" json:"buyer_type"`
+	BuyerFirstName    string       `gorm:"size:100" json:"buyer_first_name,omitempty"`
+	BuyerLastName     string       `gorm:"size:100" json:"buyer_last_name,omitempty"`
+	BuyerCompanyName  string       `gorm:"size:255" json:"buyer_company_name,omitempty"`
+	BuyerTaxID        string       `gorm:"size:100" json:"buyer_tax_id"`
+	BuyerBranchNo     string       `gorm:"size:10" json:"buyer_branch_no,omitempty"`
+	BuyerAddress      string       `gorm:"type:text" json:"buyer_address"`
+	SellerType        string       `gorm:"size:20" json:"seller_type"`
+	SellerFirstName   string       `gorm:"size:100" json:"seller_first_name,omitempty"`
+	SellerLastName    string       `gorm:"size:100" json:"seller_last_name,omitempty"`
+	SellerCompanyName string       `gorm:"size:255" json:"seller_company_name,omitempty"`
+	SellerTaxID       string       `gorm:"size:100" json:"seller_tax_id"`
+	SellerBranchNo    string       `gorm:"size:10" json:"seller_branch_no,omitempty"`
+	SellerAddress     string       `gorm:"type:text" json:"seller_address"`
+	Subtotal          money.Amount `gorm:"type:numeric(14,2)" json:"subtotal"`
+	DiscountType      int          `json:"discount_type"`
+	DiscountValue     money.Amount `gorm:"type:numeric(14,2)" json:"discount_value"`
+	DiscountAmount    money.Amount `gorm:"type:numeric(14,2)" json:"discount_amount"`
+	VatAmount         money.Amount `gorm:"type:numeric(14,2)" json:"vat_amount"`
+	GrandTotal        money.Amount `gorm:"type:numeric(14,2)" json:"grand_total"`
+	Remarks           string       `gorm:"type:text" json:"remarks"`
+	CreatedAt         time.Time    `gorm:"autoCreateTime" json:"created_at"`
+
+	// VatableAmount, ZeroRatedAmount and ExemptAmount split the total after
+	// the document discount by VAT treatment, before VAT. They are saved
+	// with the totals so reports don't have to recompute the items.
+	VatableAmount   money.Amount `gorm:"type:numeric(14,2);not null;default:0" json:"vatable_amount"`
+	ZeroRatedAmount money.Amount `gorm:"type:numeric(14,2);not null;default:0" json:"zero_rated_amount"`
+	ExemptAmount    money.Amount `gorm:"type:numeric(14,2);not null;default:0" json:"exempt_amount"`
+
+	// Version is raised by every revision of a draft. Edits must send the
+	// version they were made against and are rejected when it is stale.
+	Version int `gorm:"not null;default:1" json:"version"`
+
+	// Credit and debit notes carry the pre-VAT value of the referenced
+	// invoice, the corrected value after this note, the difference and
+	// the reason for the adjustment as required by the Revenue Department.
+	OriginalAmount   money.Amount `gorm:"type:numeric(14,2)" json:"original_amount,omitempty"`
+	CorrectedAmount  money.Amount `gorm:"type:numeric(14,2)" json:"corrected_amount,omitempty"`
+	DifferenceAmount money.Amount `gorm:"type:numeric(14,2)" json:"difference_amount,omitempty"`
+	AdjustmentReason string       `gorm:"type:text" json:"adjustment_reason,omitempty"`
+
+	// A voided document keeps its number and records why and when it was
+	// voided. A replacement issued for it references it by ReferenceID.
+	VoidReason string     `gorm:"type:text" json:"void_reason,omitempty"`
+	VoidedAt   *time.Time `json:"voided_at,omitempty"`
+
+	// WhtRate is the withholding tax rate in percent applied to items
+	// without a rate of their own; WhtAmount is the tax the buyer is
+	// expected to withhold, computed on the pre-VAT amount.
+	WhtRate   float64      `gorm:"type:numeric(5,2)" json:"wht_rate"`
+	WhtAmount money.Amount `gorm:"type:numeric(14,2)" json:"wht_amount"`
+
+	// Amounts are in Currency. ExchangeRate is the baht value of one unit,
+	// frozen when the document is issued from the rate published on
+	// RateDate unless one was agreed on the draft; ThbGrandTotal and
+	// ThbVatAmount are the totals in baht at that rate, as reported to the
+	// Revenue Department. Baht documents have a rate of 1.
+	Currency      string       `gorm:"size:3;not null;default:THB" json:"currency"`
+	ExchangeRate  money.Rate   `gorm:"type:numeric(18,6);not null;default:0" json:"exchange_rate"`
+	RateDate      *time.Time   `gorm:"type:date" json:"rate_date,omitempty"`
+	ThbGrandTotal money.Amount `gorm:"type:numeric(14,2);not null;default:0" json:"thb_grand_total"`
+	ThbVatAmount  money.Amount `gorm:"type:numeric(14,2);not null;default:0" json:"thb_vat_amount"`
 
 	Items     []InvoiceItem      `gorm:"foreignKey:DocumentID" json:"items,omitempty"`
 	Timelines []DocumentTimeline `gorm:"foreignKey:DocumentID" json:"timelines,omitempty"`
 }
 
-// InvoiceItem is a line item within an invoice document.
+// InvoiceItem is a line item within an invoice document. A nil WhtRate
+// takes the rate of the document; zero opts the line out of withholding.
 type InvoiceItem struct {
-	ID          uint    `gorm:"primaryKey;autoIncrement" json:"id"`
-	DocumentID  uint    `gorm:"not null" json:"document_id"`
-	ProductID   *uint   `json:"product_id"`
-	ProductName string  `gorm:"size:255" json:"product_name"`
-	Sku         string  `gorm:"size:100" json:"sku"`
-	Qty         int     `json:"qty"`
-	UnitPrice   int     `json:"unit_price"`
-	Discount    int     `json:"discount"`
-	VatType     string  `gorm:"size:50" json:"vat_type"`
-	VatRate     float64 `gorm:"type:numeric(5,2)" json:"vat_rate"`
-	LineTotal   float64 `gorm:"type:numeric(12,2)" json:"line_total"`
+	ID          uint         `gorm:"primaryKey;autoIncrement" json:"id"`
+	DocumentID  uint         `gorm:"not null;index" json:"document_id"`
+	ProductID   *uint        `json:"product_id"`
+	ProductName string       `gorm:"size:255" json:"product_name"`
+	Sku         string       `gorm:"size:100" json:"sku"`
+	Qty         int          `json:"qty"`
+	UnitPrice   money.Amount `gorm:"type:numeric(14,2)" json:"unit_price"`
+	Discount    money.Amount `gorm:"type:numeric(14,2)" json:"discount"`
+	VatType     string       `gorm:"size:50" json:"vat_type"`
+	VatRate     float64      `gorm:"type:numeric(5,2)" json:"vat_rate"`
+	WhtRate     *float64     `gorm:"type:numeric(5,2)" json:"wht_rate"`
+	LineTotal   money.Amount `gorm:"type:numeric(14,2)" json:"line_total"`
 }
 
 // DocumentTimeline records status changes for a document.
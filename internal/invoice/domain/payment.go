@@ -0,0 +1,169 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+
+	"invoice_project/pkg/money"
+)
+
+// Payment methods stored in Payment.Method.
+const (
+	PaymentMethodCash      = "cash"
+	PaymentMethodTransfer  = "transfer"
+	PaymentMethodCheque    = "cheque"
+	PaymentMethodPromptPay = "promptpay"
+	PaymentMethodCard      = "card"
+)
+
+// Timeline events recorded on a document when it is paid.
+const (
+	EventPaymentRecorded = "payment_recorded"
+	EventReceiptIssued   = "receipt_issued"
+)
+
+// IsValidPaymentMethod reports whether m is a known payment method.
+func IsValidPaymentMethod(m string) bool {
+	switch m {
+	case PaymentMethodCash, PaymentMethodTransfer, PaymentMethodCheque, PaymentMethodPromptPay, PaymentMethodCard:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsPayable reports whether payments can be recorded against a document
+// of this type and status. Debit notes are settled through the invoice
+// they adjust.
+func IsPayable(documentType, status string) bool {
+	switch documentType {
+	case DocumentTypeInvoice, DocumentTypeTaxInvoice, DocumentTypeReceiptTaxInvoice, DocumentTypeDeliveryTaxInvoice:
+	default:
+		return false
+	}
+	switch status {
+	case StatusIssued, StatusSent, StatusPartiallyPaid:
+		return true
+	default:
+		return false
+	}
+}
+
+// Payment is money received from a customer, allocated to one or more
+// invoice documents of the same store. Amount is the gross amount settled;
+// when the buyer withholds tax only NetAmount (Amount - WhtAmount) is
+// actually received and the buyer issues a withholding tax certificate
+// (หนังสือรับรองการหักภาษี ณ ที่จ่าย, 50 ทวิ).
+type Payment struct {
+	ID                 uint                `gorm:"primaryKey;autoIncrement" json:"id"`
+	StoreID            string              `gorm:"type:uuid;not null;index" json:"store_id"`
+	Method             string              `gorm:"size:20;not null" json:"method"`
+	Amount             money.Amount        `gorm:"type:numeric(14,2);not null" json:"amount"`
+	WhtAmount          money.Amount        `gorm:"type:numeric(14,2);not null;default:0" json:"wht_amount"`
+	NetAmount          money.Amount        `gorm:"type:numeric(14,2);not null;default:0" json:"net_amount"`
+	WhtCertificateNo   string              `gorm:"size:100" json:"wht_certificate_no"`
+	WhtCertificateDate *time.Time          `gorm:"type:date" json:"wht_certificate_date"`
+	PaymentDate        time.Time           `gorm:"type:date;not null" json:"payment_date"`
+	Reference          string              `gorm:"size:255" json:"reference"`
+	AttachmentURL      string              `gorm:"type:text" json:"attachment_url"`
+	ReceiptID          *uint               `json:"receipt_id"`
+	CreatedBy          string              `gorm:"size:100" json:"created_by"`
+	CreatedAt          time.Time           `gorm:"autoCreateTime" json:"created_at"`
+	Allocations        []PaymentAllocation `gorm:"foreignKey:PaymentID" json:"allocations"`
+}
+
+// PaymentAllocation is the part of a payment settling one document.
+// Amount counts against the document's balance in full, including the
+// WhtAmount withheld from it.
+type PaymentAllocation struct {
+	ID         uint         `gorm:"primaryKey;autoIncrement" json:"id"`
+	PaymentID  uint         `gorm:"not null;index" json:"payment_id"`
+	DocumentID uint         `gorm:"not null;index" json:"document_id"`
+	Amount     money.Amount `gorm:"type:numeric(14,2);not null" json:"amount"`
+	WhtAmount  money.Amount `gorm:"type:numeric(14,2);not null;default:0" json:"wht_amount"`
+}
+
+// WithholdingReport lists the tax withheld from a merchant's payments in
+// one month: the credits the merchant can claim against its income tax.
+// Totals are in baht.
+type WithholdingReport struct {
+	MerchantID string             `json:"merchant_id"`
+	Month      string             `json:"month"`
+	Gross      money.Amount       `json:"gross"`
+	Withheld   money.Amount       `json:"withheld"`
+	Net        money.Amount       `json:"net"`
+	Missing    int                `json:"missing_certificates"`
+	Payments   []WithholdingEntry `json:"payments"`
+}
+
+// WithholdingEntry is one payment in a WithholdingReport. Gross, Withheld
+// and Net are in baht at the exchange rate of the documents the payment
+// settles; the Currency amounts are those received, in their currency.
+type WithholdingEntry struct {
+	PaymentID          uint         `json:"payment_id"`
+	StoreID            string       `json:"store_id"`
+	PaymentDate        time.Time    `json:"payment_date"`
+	Method             string       `json:"method"`
+	Reference          string       `json:"reference"`
+	Gross              money.Amount `json:"gross"`
+	Withheld           money.Amount `json:"withheld"`
+	Net                money.Amount `json:"net"`
+	Currency           string       `json:"currency"`
+	ExchangeRate       money.Rate   `json:"exchange_rate"`
+	CurrencyGross      money.Amount `json:"currency_gross"`
+	CurrencyWithheld   money.Amount `json:"currency_withheld"`
+	CurrencyNet        money.Amount `json:"currency_net"`
+	WhtCertificateNo   string       `json:"wht_certificate_no"`
+	WhtCertificateDate *time.Time   `json:"wht_certificate_date"`
+	DocumentNos        []string     `json:"document_nos"`
+}
+
+// DocumentBalance is what a customer owes on a document: its total,
+// adjusted by the credit and debit notes issued against it, less what
+// has been paid.
+type DocumentBalance struct {
+	DocumentID uint         `json:"document_id"`
+	Total      money.Amount `json:"total"`
+	Credited   money.Amount `json:"credited"`
+	Debited    money.Amount `json:"debited"`
+	Paid       money.Amount `json:"paid"`
+}
+
+// Outstanding returns the amount still to be paid.
+func (b DocumentBalance) Outstanding() money.Amount {
+	return b.Total.Add(b.Debited).Sub(b.Credited).Sub(b.Paid)
+}
+
+// MarshalJSON includes the outstanding amount.
+func (b DocumentBalance) MarshalJSON() ([]byte, error) {
+	type balance DocumentBalance
+	return json.Marshal(struct {
+		balance
+		Outstanding money.Amount `json:"outstanding"`
+	}{balance(b), b.Outstanding()})
+}
+
+// PaidStatus returns the status a payable document should have for its
+// balance: paid once nothing is outstanding, partially paid otherwise.
+func (b DocumentBalance) PaidStatus() string {
+	if b.Outstanding() <= 0 {
+		return StatusPaid
+	}
+	return StatusPartiallyPaid
+}
+
+// AdjustedStatus returns the status a payable document in status should
+// have once a credit or debit note has changed its balance. A document
+// credited down to nothing is settled even without a payment.
+func (b DocumentBalance) AdjustedStatus(status string) string {
+	switch {
+	case b.Outstanding() <= 0:
+		return StatusPaid
+	case b.Paid > 0:
+		return StatusPartiallyPaid
+	case status == StatusPaid || status == StatusPartiallyPaid:
+		return StatusIssued
+	default:
+		return status
+	}
+}
@@ -0,0 +1,25 @@
+package domain
+
+import (
+	"time"
+
+	"invoice_project/pkg/money"
+)
+
+// StorePromptPay is the PromptPay account a store is paid to: a mobile
+// number, national ID or tax ID, with IDType one of the promptpay.Type*
+// kinds.
+type StorePromptPay struct {
+	StoreID     string    `gorm:"type:uuid;primaryKey" json:"store_id"`
+	IDType      string    `gorm:"size:20;not null" json:"id_type"`
+	PromptPayID string    `gorm:"size:20;not null" json:"promptpay_id"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// PaymentQR is the PromptPay code paying what is outstanding on a
+// document. Payload is the EMVCo string encoded in the QR code.
+type PaymentQR struct {
+	DocumentID uint         `json:"document_id"`
+	Amount     money.Amount `json:"amount"`
+	Payload    string       `json:"payload"`
+}
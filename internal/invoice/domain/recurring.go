@@ -0,0 +1,81 @@
+package domain
+
+import "time"
+
+// Frequencies of a RecurringSchedule. FrequencyCron uses CronExpr.
+const (
+	FrequencyMonthly   = "monthly"
+	FrequencyQuarterly = "quarterly"
+	FrequencyYearly    = "yearly"
+	FrequencyCron      = "cron"
+)
+
+// Statuses of a RecurringSchedule.
+const (
+	ScheduleActive = "active"
+	SchedulePaused = "paused"
+	ScheduleEnded  = "ended"
+)
+
+// FrequencyMonths returns the number of months between runs of a calendar
+// frequency, or 0 for cron and unknown frequencies.
+func FrequencyMonths(frequency string) int {
+	switch frequency {
+	case FrequencyMonthly:
+		return 1
+	case FrequencyQuarterly:
+		return 3
+	case FrequencyYearly:
+		return 12
+	default:
+		return 0
+	}
+}
+
+// IsRecurrable reports whether documents of this type can be generated by
+// a recurring schedule.
+func IsRecurrable(documentType string) bool {
+	switch documentType {
+	case DocumentTypeInvoice, DocumentTypeTaxInvoice, DocumentTypeReceiptTaxInvoice, DocumentTypeDeliveryTaxInvoice:
+		return true
+	default:
+		return false
+	}
+}
+
+// RecurringSchedule generates a copy of a template document for a
+// customer on every due date. Period counts the periods since StartDate;
+// calendar frequencies compute each due date from StartDate and Period so
+// a schedule starting on the 31st bills on the last day of shorter months
+// without drifting.
+type RecurringSchedule struct {
+	ID                 uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	StoreID            string         `gorm:"type:uuid;not null;index" json:"store_id"`
+	TemplateDocumentID uint           `gorm:"not null" json:"template_document_id"`
+	CustomerID         *uint          `json:"customer_id"`
+	Frequency          string         `gorm:"size:20;not null" json:"frequency"`
+	CronExpr           string         `gorm:"size:100" json:"cron_expr,omitempty"`
+	StartDate          time.Time      `gorm:"type:date;not null" json:"start_date"`
+	EndDate            *time.Time     `gorm:"type:date" json:"end_date"`
+	IssueStatus        string         `gorm:"size:50;not null" json:"issue_status"`
+	Status             string         `gorm:"size:20;not null;index" json:"status"`
+	Period             int            `gorm:"not null;default:0" json:"period"`
+	NextRunAt          *time.Time     `gorm:"index" json:"next_run_at"`
+	LastRunAt          *time.Time     `json:"last_run_at"`
+	CreatedBy          string         `gorm:"size:100" json:"created_by"`
+	CreatedAt          time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt          time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	Runs               []RecurringRun `gorm:"foreignKey:ScheduleID" json:"runs,omitempty"`
+}
+
+// RecurringRun records one due date of a schedule and the document
+// generated for it, or why generating failed. A schedule has at most one
+// run per due date.
+type RecurringRun struct {
+	ID           uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	ScheduleID   uint      `gorm:"not null;uniqueIndex:idx_recurring_run_due" json:"schedule_id"`
+	ScheduledFor time.Time `gorm:"not null;uniqueIndex:idx_recurring_run_due" json:"scheduled_for"`
+	DocumentID   *uint     `json:"document_id"`
+	Error        string    `gorm:"type:text" json:"error,omitempty"`
+	CreatedAt    time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
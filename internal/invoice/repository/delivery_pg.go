@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"invoice_project/internal/invoice/domain"
+	"invoice_project/pkg/apperror"
+)
+
+// RecordDelivery stores a delivery attempt. A successful delivery is also
+// recorded on the document's timeline and moves an issued document to
+// sent, in the same transaction.
+func (r *documentPG) RecordDelivery(ctx context.Context, d *domain.DocumentDelivery) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(d).Error; err != nil {
+			return err
+		}
+		if d.Status != domain.DeliveryStatusSent {
+			return nil
+		}
+
+		var doc domain.InvoiceDocument
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id, document_type, status").First(&doc, d.DocumentID).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return apperror.New(fiber.StatusNotFound)
+			}
+			return err
+		}
+		status := doc.Status
+		if doc.Status == domain.StatusIssued && domain.CanTransition(doc.DocumentType, doc.Status, domain.StatusSent) {
+			status = domain.StatusSent
+			if err := tx.Model(&doc).Update("status", status).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Create(&domain.DocumentTimeline{
+			DocumentID: doc.ID,
+			EventType:  domain.EventSent,
+			OldStatus:  doc.Status,
+			NewStatus:  status,
+			ChangedBy:  d.SentBy,
+			ChangedAt:  time.Now(),
+			Note:       strings.Join(d.Recipients, ", "),
+		}).Error
+	})
+}
+
+// ListDeliveries returns the delivery attempts of a document, newest
+// first.
+func (r *documentPG) ListDeliveries(ctx context.Context, documentID uint) ([]domain.DocumentDelivery, error) {
+	var deliveries []domain.DocumentDelivery
+	err := conn(ctx, r.db).Where("document_id = ?", documentID).Order("created_at DESC, id DESC").Find(&deliveries).Error
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
@@ -3,17 +3,81 @@ package repository
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
+	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"invoice_project/internal/invoice/domain"
+	"invoice_project/pkg/apperror"
+	"invoice_project/pkg/money"
 )
 
 type InvoiceDocumentRepository interface {
-	CreateDocument(ctx context.Context, doc *domain.InvoiceDocument, items []domain.InvoiceItem) error
+	CreateDocument(ctx context.Context, doc *domain.InvoiceDocument, items []domain.InvoiceItem, changedBy string) error
 	GetDocument(ctx context.Context, id uint) (*domain.InvoiceDocument, error)
+	UpdateStatus(ctx context.Context, id uint, from, to string, tl *domain.DocumentTimeline) error
+	IssueDocument(ctx context.Context, doc *domain.InvoiceDocument, tl *domain.DocumentTimeline, check AdjustmentCheck) error
+	UpdateDraft(ctx context.Context, id uint, version int, tl *domain.DocumentTimeline, edit DraftEdit) error
+	CreateAdjustmentNote(ctx context.Context, doc *domain.InvoiceDocument, items []domain.InvoiceItem, changedBy string, check AdjustmentCheck) error
+	VoidDocument(ctx context.Context, id uint, reason, changedBy string, build VoidBuild) error
+	ConvertQuotation(ctx context.Context, quotationID uint, doc *domain.InvoiceDocument, changedBy string, check QuotationCheck) error
+	ExpireQuotations(ctx context.Context, before time.Time, changedBy string) (int, error)
+	ListDocuments(ctx context.Context, f DocumentFilter, page DocumentPage) ([]domain.InvoiceDocument, error)
+	SumDocuments(ctx context.Context, f DocumentFilter) (domain.DocumentListTotals, error)
+	RecordDelivery(ctx context.Context, d *domain.DocumentDelivery) error
+	ListDeliveries(ctx context.Context, documentID uint) ([]domain.DocumentDelivery, error)
 }
 
+// DocumentFilter selects the documents of a store. Empty fields do not
+// filter; IssuedTo is inclusive and Search matches the document number and
+// the buyer's name case-insensitively.
+type DocumentFilter struct {
+	StoreID       string
+	Statuses      []string
+	DocumentTypes []string
+	CustomerID    uint
+	IssuedFrom    *time.Time
+	IssuedTo      *time.Time
+	MinTotal      *money.Amount
+	MaxTotal      *money.Amount
+	BuyerTaxID    string
+	Currency      string
+	Search        string
+}
+
+// DocumentPage selects a page of a filtered list ordered by Sort, a
+// domain.Sort* key, and then by ID. After and AfterID are the sort value
+// and ID of the last document of the previous page; After is nil on the
+// first page.
+type DocumentPage struct {
+	Sort    string
+	Desc    bool
+	Limit   int
+	After   interface{}
+	AfterID uint
+}
+
+// AdjustmentTotals sums the credit and debit notes already issued against
+// an invoice, both including VAT and before VAT.
+type AdjustmentTotals struct {
+	Credited    money.Amount
+	Debited     money.Amount
+	CreditedNet money.Amount
+	DebitedNet  money.Amount
+}
+
+// AdjustmentCheck validates a credit or debit note against the locked
+// original invoice and the notes that already reference it.
+type AdjustmentCheck func(original *domain.InvoiceDocument, prior AdjustmentTotals) error
+
+// DraftEdit changes a locked draft, using the transaction carried by ctx,
+// and returns every item the draft keeps: items with an ID are updated,
+// items without one are added and the draft's other items are removed.
+type DraftEdit func(ctx context.Context, doc *domain.InvoiceDocument, items []domain.InvoiceItem) ([]domain.InvoiceItem, error)
+
 type documentPG struct {
 	db *gorm.DB
 }
@@ -22,35 +86,161 @@ func NewInvoiceDocumentRepository(db *gorm.DB) InvoiceDocumentRepository {
 	return &documentPG{db: db}
 }
 
-func (r *documentPG) CreateDocument(ctx context.Context, doc *domain.InvoiceDocument, items []domain.InvoiceItem) error {
-	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		if err := tx.Create(doc).Error; err != nil {
+func (r *documentPG) CreateDocument(ctx context.Context, doc *domain.InvoiceDocument, items []domain.InvoiceItem, changedBy string) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		return insertDocument(tx, doc, items, domain.DocumentTimeline{ChangedBy: changedBy})
+	})
+}
+
+// CreateAdjustmentNote saves a credit or debit note that references
+// doc.ReferenceID. The original invoice row is locked for the duration of
+// the transaction and check is called with it and the totals of the notes
+// already issued against it, so concurrent notes cannot together exceed
+// the invoice. A note saved as a draft is checked again when it is issued.
+func (r *documentPG) CreateAdjustmentNote(ctx context.Context, doc *domain.InvoiceDocument, items []domain.InvoiceItem, changedBy string, check AdjustmentCheck) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		original, err := lockAdjusted(tx, doc, check)
+		if err != nil {
+			return err
+		}
+		tl := domain.DocumentTimeline{RelatedDocumentID: &original.ID, ChangedBy: changedBy}
+		if err := insertDocument(tx, doc, items, tl); err != nil {
 			return err
 		}
-		for i := range items {
-			items[i].DocumentID = doc.ID
+		if doc.Status == domain.StatusDraft {
+			return nil
+		}
+		return recordAdjustment(tx, original, doc, changedBy)
+	})
+}
+
+// lockAdjusted locks the invoice a note references inside tx and calls
+// check with it and the totals of the other notes issued against it.
+func lockAdjusted(tx *gorm.DB, note *domain.InvoiceDocument, check AdjustmentCheck) (*domain.InvoiceDocument, error) {
+	if note.ReferenceID == nil {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	var original domain.InvoiceDocument
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&original, *note.ReferenceID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.New(fiber.StatusBadRequest)
+		}
+		return nil, err
+	}
+
+	var sums []struct {
+		DocumentType string
+		Total        money.Amount
+		Net          money.Amount
+	}
+	err = tx.Model(&domain.InvoiceDocument{}).
+		Select("document_type, COALESCE(SUM(grand_total), 0) AS total, COALESCE(SUM(grand_total - vat_amount), 0) AS net").
+		Where("reference_id = ? AND id <> ? AND document_type IN ? AND status IN ?",
+			original.ID,
+			note.ID,
+			[]string{domain.DocumentTypeCreditNote, domain.DocumentTypeDebitNote},
+			domain.IssuedStatuses()).
+		Group("document_type").
+		Scan(&sums).Error
+	if err != nil {
+		return nil, err
+	}
+	var prior AdjustmentTotals
+	for _, s := range sums {
+		if s.DocumentType == domain.DocumentTypeCreditNote {
+			prior.Credited, prior.CreditedNet = s.Total, s.Net
+		} else {
+			prior.Debited, prior.DebitedNet = s.Total, s.Net
 		}
-		if len(items) > 0 {
-			if err := tx.Create(&items).Error; err != nil {
+	}
+	if err := check(&original, prior); err != nil {
+		return nil, err
+	}
+	return &original, nil
+}
+
+// recordAdjustment adds the timeline entry of an issued note to the
+// invoice it adjusts, and marks the invoice paid or no longer paid when
+// the note settles or reopens its balance.
+func recordAdjustment(tx *gorm.DB, original, note *domain.InvoiceDocument, changedBy string) error {
+	status := original.Status
+	if domain.IsIssuedStatus(original.Status) {
+		balances, err := documentBalances(tx, []domain.InvoiceDocument{*original})
+		if err != nil {
+			return err
+		}
+		status = balances[original.ID].AdjustedStatus(original.Status)
+		if status != original.Status {
+			err := tx.Model(&domain.InvoiceDocument{}).Where("id = ?", original.ID).Update("status", status).Error
+			if err != nil {
 				return err
 			}
 		}
-		tl := domain.DocumentTimeline{
-			DocumentID: doc.ID,
-			EventType:  "created",
-			NewStatus:  doc.Status,
-			ChangedAt:  time.Now(),
+	}
+
+	event := domain.EventCreditNoteIssued
+	if note.DocumentType == domain.DocumentTypeDebitNote {
+		event = domain.EventDebitNoteIssued
+	}
+	return tx.Create(&domain.DocumentTimeline{
+		DocumentID:        original.ID,
+		RelatedDocumentID: &note.ID,
+		EventType:         event,
+		OldStatus:         original.Status,
+		NewStatus:         status,
+		ChangedBy:         changedBy,
+		ChangedAt:         time.Now(),
+		Note:              note.AdjustmentReason,
+	}).Error
+}
+
+// insertDocument saves a document with its items and the "created"
+// timeline entry inside tx. Documents created past the draft stage are
+// numbered; drafts get their number when they are issued.
+func insertDocument(tx *gorm.DB, doc *domain.InvoiceDocument, items []domain.InvoiceItem, tl domain.DocumentTimeline) error {
+	if doc.Status != domain.StatusDraft {
+		if err := numberDocument(tx, doc); err != nil {
+			return err
 		}
-		if err := tx.Create(&tl).Error; err != nil {
+	}
+	doc.Version = 1
+	if err := tx.Create(doc).Error; err != nil {
+		return err
+	}
+	for i := range items {
+		items[i].DocumentID = doc.ID
+	}
+	if len(items) > 0 {
+		if err := tx.Create(&items).Error; err != nil {
 			return err
 		}
+	}
+	tl.DocumentID = doc.ID
+	tl.EventType = domain.EventCreated
+	tl.NewStatus = doc.Status
+	tl.ChangedAt = time.Now()
+	return tx.Create(&tl).Error
+}
+
+// numberDocument gives doc the next number of its store's sequence inside
+// tx, so a number is only used up by a document that keeps it.
+func numberDocument(tx *gorm.DB, doc *domain.InvoiceDocument) error {
+	if doc.StoreID == nil {
 		return nil
-	})
+	}
+	seq, n, err := nextSequenceNumber(tx, *doc.StoreID, doc.DocumentType, domain.FiscalYearOf(doc.IssueDate))
+	if err != nil {
+		return err
+	}
+	doc.DocumentNumber = n
+	doc.DocumentNo = seq.FormatNumber(n, doc.IssueDate)
+	return nil
 }
 
 func (r *documentPG) GetDocument(ctx context.Context, id uint) (*domain.InvoiceDocument, error) {
 	var doc domain.InvoiceDocument
-	err := r.db.WithContext(ctx).
+	err := conn(ctx, r.db).
 		Preload("Items").
 		Preload("Timelines").
 		First(&doc, id).Error
@@ -62,3 +252,262 @@ func (r *documentPG) GetDocument(ctx context.Context, id uint) (*domain.InvoiceD
 	}
 	return &doc, nil
 }
+
+// UpdateStatus moves a document from one status to another and records the
+// timeline entry in the same transaction. The update only applies while the
+// document is still in the expected status, so two concurrent transitions
+// cannot both succeed.
+func (r *documentPG) UpdateStatus(ctx context.Context, id uint, from, to string, tl *domain.DocumentTimeline) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&domain.InvoiceDocument{}).
+			Where("id = ? AND status = ?", id, from).
+			Update("status", to)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return apperror.New(fiber.StatusConflict)
+		}
+		tl.DocumentID = id
+		tl.OldStatus = from
+		tl.NewStatus = to
+		if tl.EventType == "" {
+			tl.EventType = domain.EventStatusChanged
+		}
+		if tl.ChangedAt.IsZero() {
+			tl.ChangedAt = time.Now()
+		}
+		return tx.Create(tl).Error
+	})
+}
+
+// IssueDocument issues a draft with the exchange rate and baht totals
+// frozen on doc, numbers it and records the timeline entry in the same
+// transaction. Like UpdateStatus it only applies while the document is
+// still a draft; otherwise the number taken is rolled back. A credit or
+// debit note is checked against the invoice it adjusts with check under
+// the same lock as CreateAdjustmentNote; other documents pass nil.
+func (r *documentPG) IssueDocument(ctx context.Context, doc *domain.InvoiceDocument, tl *domain.DocumentTimeline, check AdjustmentCheck) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var original *domain.InvoiceDocument
+		if check != nil {
+			var err error
+			if original, err = lockAdjusted(tx, doc, check); err != nil {
+				return err
+			}
+		}
+		if err := numberDocument(tx, doc); err != nil {
+			return err
+		}
+		res := tx.Model(&domain.InvoiceDocument{}).
+			Where("id = ? AND status = ?", doc.ID, domain.StatusDraft).
+			Updates(map[string]interface{}{
+				"status":            domain.StatusIssued,
+				"document_number":   doc.DocumentNumber,
+				"document_no":       doc.DocumentNo,
+				"exchange_rate":     doc.ExchangeRate,
+				"rate_date":         doc.RateDate,
+				"thb_grand_total":   doc.ThbGrandTotal,
+				"thb_vat_amount":    doc.ThbVatAmount,
+				"currency":          doc.Currency,
+				"original_amount":   doc.OriginalAmount,
+				"corrected_amount":  doc.CorrectedAmount,
+				"difference_amount": doc.DifferenceAmount,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return apperror.New(fiber.StatusConflict)
+		}
+		tl.DocumentID = doc.ID
+		tl.EventType = domain.EventStatusChanged
+		tl.OldStatus = domain.StatusDraft
+		tl.NewStatus = domain.StatusIssued
+		tl.ChangedAt = time.Now()
+		if err := tx.Create(tl).Error; err != nil {
+			return err
+		}
+		if original == nil {
+			return nil
+		}
+		return recordAdjustment(tx, original, doc, tl.ChangedBy)
+	})
+}
+
+// UpdateDraft locks a document and lets edit revise it. Only drafts can be
+// revised, and only at the version the edit was made against; anything
+// issued is immutable and must be voided or corrected with a credit note.
+// The revision raises the version and is recorded as tl.
+func (r *documentPG) UpdateDraft(ctx context.Context, id uint, version int, tl *domain.DocumentTimeline, edit DraftEdit) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var doc domain.InvoiceDocument
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&doc, id).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return apperror.New(fiber.StatusNotFound)
+			}
+			return err
+		}
+		if doc.Status != domain.StatusDraft || doc.Version != version {
+			return apperror.New(fiber.StatusConflict)
+		}
+		var current []domain.InvoiceItem
+		if err := tx.Where("document_id = ?", id).Order("id").Find(&current).Error; err != nil {
+			return err
+		}
+
+		items, err := edit(WithTx(ctx, tx), &doc, current)
+		if err != nil {
+			return err
+		}
+		// the edit may not move the draft out of its lifecycle
+		doc.ID = id
+		doc.Status = domain.StatusDraft
+		doc.Version = version + 1
+
+		if err := syncItems(tx, id, current, items); err != nil {
+			return err
+		}
+		if err := tx.Omit("Items", "Timelines").Save(&doc).Error; err != nil {
+			return err
+		}
+		tl.DocumentID = id
+		tl.EventType = domain.EventDraftRevised
+		tl.OldStatus = domain.StatusDraft
+		tl.NewStatus = domain.StatusDraft
+		tl.ChangedAt = time.Now()
+		return tx.Create(tl).Error
+	})
+}
+
+// syncItems saves the items of a draft: existing ones are updated, new
+// ones added and those no longer listed deleted.
+func syncItems(tx *gorm.DB, documentID uint, current, items []domain.InvoiceItem) error {
+	existing := make(map[uint]bool, len(current))
+	for _, it := range current {
+		existing[it.ID] = true
+	}
+	var keep []uint
+	for i := range items {
+		items[i].DocumentID = documentID
+		if items[i].ID == 0 {
+			continue
+		}
+		if !existing[items[i].ID] {
+			return apperror.New(fiber.StatusBadRequest)
+		}
+		// each existing item may be listed once
+		delete(existing, items[i].ID)
+		keep = append(keep, items[i].ID)
+	}
+
+	del := tx.Where("document_id = ?", documentID)
+	if len(keep) > 0 {
+		del = del.Where("id NOT IN ?", keep)
+	}
+	if err := del.Delete(&domain.InvoiceItem{}).Error; err != nil {
+		return err
+	}
+	for i := range items {
+		var err error
+		if items[i].ID == 0 {
+			err = tx.Create(&items[i]).Error
+		} else {
+			err = tx.Save(&items[i]).Error
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sortColumns maps the list sort keys to their columns.
+var sortColumns = map[string]string{
+	domain.SortIssueDate:  "issue_date",
+	domain.SortCreatedAt:  "created_at",
+	domain.SortDocumentNo: "document_no",
+	domain.SortGrandTotal: "grand_total",
+}
+
+// likeEscaper escapes the LIKE wildcards in a search term.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+func (f DocumentFilter) apply(db *gorm.DB) *gorm.DB {
+	db = db.Where("store_id = ?", f.StoreID)
+	if len(f.Statuses) > 0 {
+		db = db.Where("status IN ?", f.Statuses)
+	}
+	if len(f.DocumentTypes) > 0 {
+		db = db.Where("document_type IN ?", f.DocumentTypes)
+	}
+	if f.CustomerID != 0 {
+		db = db.Where("customer_id = ?", f.CustomerID)
+	}
+	if f.IssuedFrom != nil {
+		db = db.Where("issue_date >= ?", f.IssuedFrom.Format("2006-01-02"))
+	}
+	if f.IssuedTo != nil {
+		db = db.Where("issue_date <= ?", f.IssuedTo.Format("2006-01-02"))
+	}
+	if f.MinTotal != nil {
+		db = db.Where("grand_total >= ?", *f.MinTotal)
+	}
+	if f.MaxTotal != nil {
+		db = db.Where("grand_total <= ?", *f.MaxTotal)
+	}
+	if f.BuyerTaxID != "" {
+		db = db.Where("buyer_tax_id = ?", f.BuyerTaxID)
+	}
+	if f.Currency != "" {
+		db = db.Where("currency = ?", f.Currency)
+	}
+	if f.Search != "" {
+		like := "%" + likeEscaper.Replace(f.Search) + "%"
+		db = db.Where("(document_no ILIKE ? OR buyer_company_name ILIKE ? OR CONCAT_WS(' ', buyer_first_name, buyer_last_name) ILIKE ?)", like, like, like)
+	}
+	return db
+}
+
+// ListDocuments returns one page of the documents matching f, without
+// their items and timelines. Pages are read by keyset on the sort column
+// and ID, so deep pages cost the same as the first one.
+func (r *documentPG) ListDocuments(ctx context.Context, f DocumentFilter, page DocumentPage) ([]domain.InvoiceDocument, error) {
+	col, ok := sortColumns[page.Sort]
+	if !ok {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	op, dir := ">", "ASC"
+	if page.Desc {
+		op, dir = "<", "DESC"
+	}
+	db := f.apply(conn(ctx, r.db))
+	if page.After != nil {
+		db = db.Where(fmt.Sprintf("(%s, id) %s (?, ?)", col, op), page.After, page.AfterID)
+	}
+	var docs []domain.InvoiceDocument
+	err := db.Order(fmt.Sprintf("%s %s, id %s", col, dir, dir)).
+		Limit(page.Limit).
+		Find(&docs).Error
+	if err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+// SumDocuments counts and sums every document matching f.
+func (r *documentPG) SumDocuments(ctx context.Context, f DocumentFilter) (domain.DocumentListTotals, error) {
+	var t domain.DocumentListTotals
+	err := f.apply(conn(ctx, r.db).Model(&domain.InvoiceDocument{})).
+		Select(`COUNT(*) AS count,
+			COALESCE(SUM(subtotal), 0) AS subtotal,
+			COALESCE(SUM(discount_amount), 0) AS discount,
+			COALESCE(SUM(vat_amount), 0) AS vat_amount,
+			COALESCE(SUM(grand_total), 0) AS grand_total,
+			COALESCE(SUM(wht_amount), 0) AS wht_amount,
+			COALESCE(SUM(thb_grand_total), 0) AS thb_grand_total,
+			COALESCE(SUM(thb_vat_amount), 0) AS thb_vat_amount`).
+		Scan(&t).Error
+	return t, err
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"invoice_project/internal/invoice/domain"
+)
+
+type ExchangeRateRepository interface {
+	SaveRates(ctx context.Context, rates []domain.ExchangeRate) error
+	ListRates(ctx context.Context, f RateFilter) ([]domain.ExchangeRate, error)
+	FindRate(ctx context.Context, storeID, currency string, on time.Time) (*domain.ExchangeRate, error)
+}
+
+// RateFilter selects the rates that apply to a store: its own and the
+// published ones. Empty fields do not filter; To is inclusive.
+type RateFilter struct {
+	StoreID  string
+	Currency string
+	From     *time.Time
+	To       *time.Time
+}
+
+type exchangeRatePG struct {
+	db *gorm.DB
+}
+
+func NewExchangeRateRepository(db *gorm.DB) ExchangeRateRepository {
+	return &exchangeRatePG{db: db}
+}
+
+// SaveRates records rates, replacing the rate already on record for the
+// same store, currency and date.
+func (r *exchangeRatePG) SaveRates(ctx context.Context, rates []domain.ExchangeRate) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		for i := range rates {
+			rate := &rates[i]
+			var existing domain.ExchangeRate
+			err := rateOwner(tx.Clauses(clause.Locking{Strength: "UPDATE"}), rate.StoreID).
+				Where("currency = ? AND rate_date = ?", rate.Currency, rate.RateDate.Format("2006-01-02")).
+				First(&existing).Error
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				rate.ID = 0
+				err = tx.Create(rate).Error
+			case err == nil:
+				rate.ID = existing.ID
+				rate.CreatedAt = existing.CreatedAt
+				err = tx.Save(rate).Error
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// rateOwner selects the rates of a store, or the published rates when
+// storeID is nil.
+func rateOwner(db *gorm.DB, storeID *string) *gorm.DB {
+	if storeID == nil {
+		return db.Where("store_id IS NULL")
+	}
+	return db.Where("store_id = ?", *storeID)
+}
+
+// ListRates returns the rates matching f, newest first.
+func (r *exchangeRatePG) ListRates(ctx context.Context, f RateFilter) ([]domain.ExchangeRate, error) {
+	db := conn(ctx, r.db).Where("(store_id = ? OR store_id IS NULL)", f.StoreID)
+	if f.Currency != "" {
+		db = db.Where("currency = ?", f.Currency)
+	}
+	if f.From != nil {
+		db = db.Where("rate_date >= ?", f.From.Format("2006-01-02"))
+	}
+	if f.To != nil {
+		db = db.Where("rate_date <= ?", f.To.Format("2006-01-02"))
+	}
+	var rates []domain.ExchangeRate
+	if err := db.Order("rate_date DESC, currency, store_id NULLS LAST").Find(&rates).Error; err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+// FindRate returns the latest rate of currency on or before on that
+// applies to a store. Its own rate wins over the published one of the
+// same date.
+func (r *exchangeRatePG) FindRate(ctx context.Context, storeID, currency string, on time.Time) (*domain.ExchangeRate, error) {
+	var rate domain.ExchangeRate
+	err := conn(ctx, r.db).
+		Where("(store_id = ? OR store_id IS NULL) AND currency = ? AND rate_date <= ?", storeID, currency, on.Format("2006-01-02")).
+		Order("rate_date DESC, store_id NULLS LAST").
+		First(&rate).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &rate, nil
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	customerDomain "invoice_project/internal/customer/domain"
+	merchantDomain "invoice_project/internal/merchant/domain"
+)
+
+// Seller is a store with the merchant that owns it and the merchant's
+// person or company details.
+type Seller struct {
+	Store    merchantDomain.Store
+	Merchant merchantDomain.Merchant
+	Person   *merchantDomain.PersonMerchant
+	Company  *merchantDomain.CompanyMerchant
+}
+
+// PartyRepository loads the customer and merchant records the buyer and
+// seller of a document are copied from.
+type PartyRepository interface {
+	GetCustomer(ctx context.Context, id uint) (*customerDomain.Customer, error)
+	GetSeller(ctx context.Context, storeID string) (*Seller, error)
+}
+
+type partyPG struct {
+	db *gorm.DB
+}
+
+func NewPartyRepository(db *gorm.DB) PartyRepository {
+	return &partyPG{db: db}
+}
+
+func (r *partyPG) GetCustomer(ctx context.Context, id uint) (*customerDomain.Customer, error) {
+	var c customerDomain.Customer
+	err := conn(ctx, r.db).
+		Preload("CompanyCustomer").
+		Preload("PersonCustomer").
+		Preload("CustomerAddress").
+		Preload("CustomerContact").
+		First(&c, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *partyPG) GetSeller(ctx context.Context, storeID string) (*Seller, error) {
+	db := conn(ctx, r.db)
+	var s Seller
+	if err := db.Preload("Address").First(&s.Store, "id = ?", storeID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if err := db.Preload("MerchantType").First(&s.Merchant, "id = ?", s.Store.MerchantID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var person merchantDomain.PersonMerchant
+	res := db.Where("merchant_id = ?", s.Merchant.ID).Limit(1).Find(&person)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected > 0 {
+		s.Person = &person
+	}
+	var company merchantDomain.CompanyMerchant
+	res = db.Where("merchant_id = ?", s.Merchant.ID).Limit(1).Find(&company)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected > 0 {
+		s.Company = &company
+	}
+	return &s, nil
+}
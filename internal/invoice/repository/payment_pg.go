@@ -0,0 +1,375 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"invoice_project/internal/invoice/domain"
+	"invoice_project/pkg/apperror"
+	"invoice_project/pkg/money"
+)
+
+// PaymentCheck validates a payment against the locked documents it is
+// allocated to and their balances before the payment.
+type PaymentCheck func(docs []domain.InvoiceDocument, balances map[uint]domain.DocumentBalance) error
+
+// ReceiptBuild fills a receipt in from a payment and the documents it
+// paid and returns the receipt's items.
+type ReceiptBuild func(p *domain.Payment, docs []domain.InvoiceDocument) ([]domain.InvoiceItem, error)
+
+type PaymentRepository interface {
+	Transaction(ctx context.Context, fn func(ctx context.Context) error) error
+	RecordPayment(ctx context.Context, p *domain.Payment, changedBy string, check PaymentCheck) error
+	GetPayment(ctx context.Context, id uint) (*domain.Payment, error)
+	ListPayments(ctx context.Context, documentID uint) ([]domain.Payment, error)
+	GetBalance(ctx context.Context, documentID uint) (*domain.DocumentBalance, error)
+	IssueReceipt(ctx context.Context, paymentID uint, receipt *domain.InvoiceDocument, changedBy string, build ReceiptBuild) error
+	SetWhtCertificate(ctx context.Context, paymentID uint, certificateNo string, certificateDate *time.Time) error
+	ListWithholding(ctx context.Context, merchantID string, from, to time.Time) ([]domain.WithholdingEntry, error)
+}
+
+type paymentPG struct {
+	db *gorm.DB
+}
+
+func NewPaymentRepository(db *gorm.DB) PaymentRepository {
+	return &paymentPG{db: db}
+}
+
+// Transaction runs fn in one transaction, carried by the context fn is
+// given, so a payment and its receipt are saved together or not at all.
+func (r *paymentPG) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		return fn(WithTx(ctx, tx))
+	})
+}
+
+// RecordPayment saves p with its allocations. The documents are locked in
+// ID order so concurrent payments on the same documents are serialised,
+// and each one moves to partially_paid or paid with a timeline entry.
+func (r *paymentPG) RecordPayment(ctx context.Context, p *domain.Payment, changedBy string, check PaymentCheck) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		ids := allocatedDocumentIDs(p.Allocations)
+		var docs []domain.InvoiceDocument
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ?", ids).
+			Order("id").
+			Find(&docs).Error
+		if err != nil {
+			return err
+		}
+		if len(docs) != len(ids) {
+			return apperror.New(fiber.StatusBadRequest)
+		}
+		balances, err := documentBalances(tx, docs)
+		if err != nil {
+			return err
+		}
+		if err := check(docs, balances); err != nil {
+			return err
+		}
+
+		p.ID = 0
+		p.CreatedBy = changedBy
+		if err := tx.Create(p).Error; err != nil {
+			return err
+		}
+
+		paid := map[uint]money.Amount{}
+		withheld := map[uint]money.Amount{}
+		for _, a := range p.Allocations {
+			paid[a.DocumentID] = paid[a.DocumentID].Add(a.Amount)
+			withheld[a.DocumentID] = withheld[a.DocumentID].Add(a.WhtAmount)
+		}
+		now := time.Now()
+		for _, doc := range docs {
+			b := balances[doc.ID]
+			b.Paid = b.Paid.Add(paid[doc.ID])
+			status := b.PaidStatus()
+			if status != doc.Status {
+				err := tx.Model(&domain.InvoiceDocument{}).Where("id = ?", doc.ID).Update("status", status).Error
+				if err != nil {
+					return err
+				}
+			}
+			err := tx.Create(&domain.DocumentTimeline{
+				DocumentID: doc.ID,
+				EventType:  domain.EventPaymentRecorded,
+				OldStatus:  doc.Status,
+				NewStatus:  status,
+				ChangedBy:  changedBy,
+				ChangedAt:  now,
+				Note:       paymentNote(p, paid[doc.ID], withheld[doc.ID]),
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func paymentNote(p *domain.Payment, amount, withheld money.Amount) string {
+	note := p.Method + " " + amount.String()
+	if !withheld.IsZero() {
+		note += " WHT " + withheld.String()
+	}
+	if p.Reference != "" {
+		note += " (" + p.Reference + ")"
+	}
+	return note
+}
+
+func allocatedDocumentIDs(allocs []domain.PaymentAllocation) []uint {
+	seen := map[uint]bool{}
+	var ids []uint
+	for _, a := range allocs {
+		if !seen[a.DocumentID] {
+			seen[a.DocumentID] = true
+			ids = append(ids, a.DocumentID)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// documentBalances sums the notes issued against and the payments
+// allocated to each document.
+func documentBalances(tx *gorm.DB, docs []domain.InvoiceDocument) (map[uint]domain.DocumentBalance, error) {
+	balances := make(map[uint]domain.DocumentBalance, len(docs))
+	ids := make([]uint, len(docs))
+	for i, d := range docs {
+		ids[i] = d.ID
+		balances[d.ID] = domain.DocumentBalance{DocumentID: d.ID, Total: d.GrandTotal}
+	}
+
+	var notes []struct {
+		ReferenceID  uint
+		DocumentType string
+		Total        money.Amount
+	}
+	err := tx.Model(&domain.InvoiceDocument{}).
+		Select("reference_id, document_type, COALESCE(SUM(grand_total), 0) AS total").
+		Where("reference_id IN ? AND document_type IN ? AND status NOT IN ?",
+			ids,
+			[]string{domain.DocumentTypeCreditNote, domain.DocumentTypeDebitNote},
+			[]string{domain.StatusDraft, domain.StatusVoid, domain.StatusCancelled}).
+		Group("reference_id, document_type").
+		Scan(&notes).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range notes {
+		b := balances[n.ReferenceID]
+		if n.DocumentType == domain.DocumentTypeCreditNote {
+			b.Credited = n.Total
+		} else {
+			b.Debited = n.Total
+		}
+		balances[n.ReferenceID] = b
+	}
+
+	var paid []struct {
+		DocumentID uint
+		Total      money.Amount
+	}
+	err = tx.Model(&domain.PaymentAllocation{}).
+		Select("document_id, COALESCE(SUM(amount), 0) AS total").
+		Where("document_id IN ?", ids).
+		Group("document_id").
+		Scan(&paid).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range paid {
+		b := balances[p.DocumentID]
+		b.Paid = p.Total
+		balances[p.DocumentID] = b
+	}
+	return balances, nil
+}
+
+func (r *paymentPG) GetPayment(ctx context.Context, id uint) (*domain.Payment, error) {
+	var p domain.Payment
+	err := conn(ctx, r.db).Preload("Allocations").First(&p, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
+// ListPayments returns the payments with an allocation to a document,
+// newest first.
+func (r *paymentPG) ListPayments(ctx context.Context, documentID uint) ([]domain.Payment, error) {
+	var payments []domain.Payment
+	db := conn(ctx, r.db)
+	err := db.Preload("Allocations").
+		Where("id IN (?)", db.Model(&domain.PaymentAllocation{}).Select("payment_id").Where("document_id = ?", documentID)).
+		Order("payment_date desc, id desc").
+		Find(&payments).Error
+	if err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
+
+func (r *paymentPG) GetBalance(ctx context.Context, documentID uint) (*domain.DocumentBalance, error) {
+	var doc domain.InvoiceDocument
+	db := conn(ctx, r.db)
+	if err := db.First(&doc, documentID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	balances, err := documentBalances(db, []domain.InvoiceDocument{doc})
+	if err != nil {
+		return nil, err
+	}
+	b := balances[doc.ID]
+	return &b, nil
+}
+
+// IssueReceipt creates the receipt of a payment. The payment is locked so
+// it gets at most one receipt; every paid document records the receipt in
+// its timeline.
+func (r *paymentPG) IssueReceipt(ctx context.Context, paymentID uint, receipt *domain.InvoiceDocument, changedBy string, build ReceiptBuild) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var p domain.Payment
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&p, paymentID).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return apperror.New(fiber.StatusNotFound)
+			}
+			return err
+		}
+		if p.ReceiptID != nil {
+			return apperror.New(fiber.StatusConflict)
+		}
+		if err := tx.Where("payment_id = ?", p.ID).Order("id").Find(&p.Allocations).Error; err != nil {
+			return err
+		}
+		var docs []domain.InvoiceDocument
+		err = tx.Preload("Items").
+			Where("id IN ?", allocatedDocumentIDs(p.Allocations)).
+			Order("id").
+			Find(&docs).Error
+		if err != nil {
+			return err
+		}
+
+		items, err := build(&p, docs)
+		if err != nil {
+			return err
+		}
+		if err := insertDocument(tx, receipt, items, domain.DocumentTimeline{ChangedBy: changedBy}); err != nil {
+			return err
+		}
+		if err := tx.Model(&domain.Payment{}).Where("id = ?", p.ID).Update("receipt_id", receipt.ID).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for _, doc := range docs {
+			err := tx.Create(&domain.DocumentTimeline{
+				DocumentID:        doc.ID,
+				RelatedDocumentID: &receipt.ID,
+				EventType:         domain.EventReceiptIssued,
+				OldStatus:         doc.Status,
+				NewStatus:         doc.Status,
+				ChangedBy:         changedBy,
+				ChangedAt:         now,
+				Note:              receipt.DocumentNo,
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// SetWhtCertificate records the withholding tax certificate the buyer
+// issued for a payment.
+func (r *paymentPG) SetWhtCertificate(ctx context.Context, paymentID uint, certificateNo string, certificateDate *time.Time) error {
+	res := conn(ctx, r.db).Model(&domain.Payment{}).
+		Where("id = ?", paymentID).
+		Updates(map[string]interface{}{
+			"wht_certificate_no":   certificateNo,
+			"wht_certificate_date": certificateDate,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return apperror.New(fiber.StatusNotFound)
+	}
+	return nil
+}
+
+// ListWithholding returns the payments with tax withheld received by the
+// stores of a merchant between from and to (exclusive), oldest first, in
+// the currency and at the exchange rate of the documents they settle.
+func (r *paymentPG) ListWithholding(ctx context.Context, merchantID string, from, to time.Time) ([]domain.WithholdingEntry, error) {
+	db := conn(ctx, r.db)
+	var payments []domain.Payment
+	err := db.Preload("Allocations").
+		Where("store_id IN (?)", db.Table("stores").Select("id").Where("merchant_id = ?", merchantID)).
+		Where("wht_amount > 0 AND payment_date >= ? AND payment_date < ?", from.Format("2006-01-02"), to.Format("2006-01-02")).
+		Order("payment_date, id").
+		Find(&payments).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []uint
+	for _, p := range payments {
+		ids = append(ids, allocatedDocumentIDs(p.Allocations)...)
+	}
+	docs := map[uint]domain.InvoiceDocument{}
+	if len(ids) > 0 {
+		var found []domain.InvoiceDocument
+		if err := db.Select("id, document_no, currency, exchange_rate").Where("id IN ?", ids).Find(&found).Error; err != nil {
+			return nil, err
+		}
+		for _, d := range found {
+			docs[d.ID] = d
+		}
+	}
+
+	entries := make([]domain.WithholdingEntry, len(payments))
+	for i, p := range payments {
+		e := domain.WithholdingEntry{
+			PaymentID:          p.ID,
+			StoreID:            p.StoreID,
+			PaymentDate:        p.PaymentDate,
+			Method:             p.Method,
+			Reference:          p.Reference,
+			Currency:           domain.CurrencyTHB,
+			ExchangeRate:       money.RateScale,
+			CurrencyGross:      p.Amount,
+			CurrencyWithheld:   p.WhtAmount,
+			CurrencyNet:        p.NetAmount,
+			WhtCertificateNo:   p.WhtCertificateNo,
+			WhtCertificateDate: p.WhtCertificateDate,
+		}
+		// A payment only settles documents in one currency.
+		for j, a := range p.Allocations {
+			d := docs[a.DocumentID]
+			if j == 0 && d.Currency != "" {
+				e.Currency, e.ExchangeRate = d.Currency, d.ExchangeRate
+			}
+			e.DocumentNos = append(e.DocumentNos, d.DocumentNo)
+		}
+		entries[i] = e
+	}
+	return entries, nil
+}
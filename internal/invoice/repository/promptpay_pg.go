@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"invoice_project/internal/invoice/domain"
+)
+
+type PromptPayRepository interface {
+	GetStorePromptPay(ctx context.Context, storeID string) (*domain.StorePromptPay, error)
+	SaveStorePromptPay(ctx context.Context, p *domain.StorePromptPay) error
+}
+
+type promptPayPG struct {
+	db *gorm.DB
+}
+
+func NewPromptPayRepository(db *gorm.DB) PromptPayRepository {
+	return &promptPayPG{db: db}
+}
+
+func (r *promptPayPG) GetStorePromptPay(ctx context.Context, storeID string) (*domain.StorePromptPay, error) {
+	var p domain.StorePromptPay
+	err := conn(ctx, r.db).Where("store_id = ?", storeID).First(&p).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *promptPayPG) SaveStorePromptPay(ctx context.Context, p *domain.StorePromptPay) error {
+	return conn(ctx, r.db).Save(p).Error
+}
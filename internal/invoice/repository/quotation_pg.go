@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"invoice_project/internal/invoice/domain"
+	"invoice_project/pkg/apperror"
+)
+
+// QuotationCheck validates a quotation, locked for the conversion, fills
+// the new document in from it and returns the items to copy.
+type QuotationCheck func(quotation *domain.InvoiceDocument) ([]domain.InvoiceItem, error)
+
+// ConvertQuotation creates doc from a quotation. The quotation row is
+// locked so it cannot be converted twice; once doc is saved the quotation
+// is marked accepted and both timelines record the link.
+func (r *documentPG) ConvertQuotation(ctx context.Context, quotationID uint, doc *domain.InvoiceDocument, changedBy string, check QuotationCheck) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var q domain.InvoiceDocument
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&q, quotationID).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return apperror.New(fiber.StatusNotFound)
+			}
+			return err
+		}
+		if err := tx.Where("document_id = ?", q.ID).Find(&q.Items).Error; err != nil {
+			return err
+		}
+
+		var converted int64
+		err = tx.Model(&domain.InvoiceDocument{}).
+			Where("reference_id = ? AND document_type NOT IN ? AND status NOT IN ?",
+				q.ID,
+				[]string{domain.DocumentTypeCreditNote, domain.DocumentTypeDebitNote},
+				[]string{domain.StatusVoid, domain.StatusCancelled}).
+			Count(&converted).Error
+		if err != nil {
+			return err
+		}
+		if converted > 0 {
+			return apperror.New(fiber.StatusConflict)
+		}
+		items, err := check(&q)
+		if err != nil {
+			return err
+		}
+
+		doc.ReferenceID = &q.ID
+		tl := domain.DocumentTimeline{RelatedDocumentID: &q.ID, ChangedBy: changedBy}
+		if err := insertDocument(tx, doc, items, tl); err != nil {
+			return err
+		}
+		now := time.Now()
+		err = tx.Create(&domain.DocumentTimeline{
+			DocumentID:        doc.ID,
+			RelatedDocumentID: &q.ID,
+			EventType:         domain.EventConvertedFromQuotation,
+			OldStatus:         doc.Status,
+			NewStatus:         doc.Status,
+			ChangedBy:         changedBy,
+			ChangedAt:         now,
+			Note:              q.DocumentNo,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		if q.Status != domain.StatusAccepted {
+			err := tx.Model(&domain.InvoiceDocument{}).Where("id = ?", q.ID).Update("status", domain.StatusAccepted).Error
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Create(&domain.DocumentTimeline{
+			DocumentID:        q.ID,
+			RelatedDocumentID: &doc.ID,
+			EventType:         domain.EventQuotationConverted,
+			OldStatus:         q.Status,
+			NewStatus:         domain.StatusAccepted,
+			ChangedBy:         changedBy,
+			ChangedAt:         now,
+			Note:              doc.DocumentNo,
+		}).Error
+	})
+}
+
+// ExpireQuotations moves open quotations whose validity ended before the
+// date of before to expired, recording a timeline entry for each. It returns
+// the number of quotations expired.
+func (r *documentPG) ExpireQuotations(ctx context.Context, before time.Time, changedBy string) (int, error) {
+	var expired int
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var quotations []domain.InvoiceDocument
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Select("id", "status").
+			Where("document_type = ? AND status IN ? AND valid_until < ?",
+				domain.DocumentTypeQuotation,
+				[]string{domain.StatusIssued, domain.StatusSent},
+				before.Format("2006-01-02")).
+			Find(&quotations).Error
+		if err != nil || len(quotations) == 0 {
+			return err
+		}
+
+		ids := make([]uint, len(quotations))
+		timelines := make([]domain.DocumentTimeline, len(quotations))
+		now := time.Now()
+		for i, q := range quotations {
+			ids[i] = q.ID
+			timelines[i] = domain.DocumentTimeline{
+				DocumentID: q.ID,
+				EventType:  domain.EventStatusChanged,
+				OldStatus:  q.Status,
+				NewStatus:  domain.StatusExpired,
+				ChangedBy:  changedBy,
+				ChangedAt:  now,
+				Note:       "validity period ended",
+			}
+		}
+		err = tx.Model(&domain.InvoiceDocument{}).Where("id IN ?", ids).Update("status", domain.StatusExpired).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Create(&timelines).Error; err != nil {
+			return err
+		}
+		expired = len(quotations)
+		return nil
+	})
+	return expired, err
+}
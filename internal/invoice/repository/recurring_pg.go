@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"invoice_project/internal/invoice/domain"
+	"invoice_project/pkg/apperror"
+)
+
+// RecurringGenerate generates the document of a due schedule using the
+// transaction carried by ctx, advances or pauses the schedule and returns
+// the run to record.
+type RecurringGenerate func(ctx context.Context, s *domain.RecurringSchedule) (*domain.RecurringRun, error)
+
+// ScheduleUpdate changes a locked schedule before it is saved.
+type ScheduleUpdate func(s *domain.RecurringSchedule) error
+
+type RecurringRepository interface {
+	CreateSchedule(ctx context.Context, s *domain.RecurringSchedule) error
+	GetSchedule(ctx context.Context, id uint) (*domain.RecurringSchedule, error)
+	ListSchedules(ctx context.Context, storeID string) ([]domain.RecurringSchedule, error)
+	UpdateSchedule(ctx context.Context, id uint, update ScheduleUpdate) error
+	RunNext(ctx context.Context, now time.Time, generate RecurringGenerate) (bool, error)
+}
+
+type recurringPG struct {
+	db *gorm.DB
+}
+
+func NewRecurringRepository(db *gorm.DB) RecurringRepository {
+	return &recurringPG{db: db}
+}
+
+func (r *recurringPG) CreateSchedule(ctx context.Context, s *domain.RecurringSchedule) error {
+	return r.db.WithContext(ctx).Omit("Runs").Create(s).Error
+}
+
+// GetSchedule returns a schedule with its runs, newest first.
+func (r *recurringPG) GetSchedule(ctx context.Context, id uint) (*domain.RecurringSchedule, error) {
+	var s domain.RecurringSchedule
+	err := r.db.WithContext(ctx).
+		Preload("Runs", func(db *gorm.DB) *gorm.DB { return db.Order("scheduled_for desc") }).
+		First(&s, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *recurringPG) ListSchedules(ctx context.Context, storeID string) ([]domain.RecurringSchedule, error) {
+	var schedules []domain.RecurringSchedule
+	err := r.db.WithContext(ctx).Where("store_id = ?", storeID).Order("id").Find(&schedules).Error
+	if err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+// UpdateSchedule locks a schedule, so it cannot change while it is being
+// run, and saves it after update.
+func (r *recurringPG) UpdateSchedule(ctx context.Context, id uint, update ScheduleUpdate) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var s domain.RecurringSchedule
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&s, id).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return apperror.New(fiber.StatusNotFound)
+			}
+			return err
+		}
+		if err := update(&s); err != nil {
+			return err
+		}
+		return tx.Omit("Runs").Save(&s).Error
+	})
+}
+
+// RunNext runs the active schedule that has been due the longest. The row
+// is locked with SKIP LOCKED so replicas running the scheduler at the same
+// time each pick a different schedule, and the generated document, the run
+// and the advanced schedule are committed together, so a due date is never
+// billed twice. It reports false when no schedule is due.
+func (r *recurringPG) RunNext(ctx context.Context, now time.Time, generate RecurringGenerate) (bool, error) {
+	found := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var s domain.RecurringSchedule
+		res := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_run_at <= ?", domain.ScheduleActive, now).
+			Order("next_run_at, id").
+			Limit(1).
+			Find(&s)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return nil
+		}
+		found = true
+
+		run, err := generate(WithTx(ctx, tx), &s)
+		if err != nil {
+			return err
+		}
+		run.ScheduleID = s.ID
+		if err := tx.Create(run).Error; err != nil {
+			return err
+		}
+		return tx.Omit("Runs").Save(&s).Error
+	})
+	return found, err
+}
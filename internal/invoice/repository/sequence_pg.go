@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"invoice_project/internal/invoice/domain"
+	"invoice_project/pkg/apperror"
+)
+
+// SequenceUpdate changes the settings of a locked sequence. Only its
+// prefix, format and padding are saved; LastNumber is saved only while no
+// number has been issued.
+type SequenceUpdate func(seq *domain.DocumentSequence) error
+
+type DocumentSequenceRepository interface {
+	ListSequences(ctx context.Context, storeID string) ([]domain.DocumentSequence, error)
+	GetSequence(ctx context.Context, storeID, documentType string, fiscalYear int) (*domain.DocumentSequence, error)
+	ConfigureSequence(ctx context.Context, storeID, documentType string, fiscalYear int, update SequenceUpdate) (*domain.DocumentSequence, error)
+}
+
+type sequencePG struct {
+	db *gorm.DB
+}
+
+func NewDocumentSequenceRepository(db *gorm.DB) DocumentSequenceRepository {
+	return &sequencePG{db: db}
+}
+
+func (r *sequencePG) ListSequences(ctx context.Context, storeID string) ([]domain.DocumentSequence, error) {
+	var seqs []domain.DocumentSequence
+	err := r.db.WithContext(ctx).
+		Where("store_id = ?", storeID).
+		Order("fiscal_year desc, document_type asc").
+		Find(&seqs).Error
+	if err != nil {
+		return nil, err
+	}
+	return seqs, nil
+}
+
+func (r *sequencePG) GetSequence(ctx context.Context, storeID, documentType string, fiscalYear int) (*domain.DocumentSequence, error) {
+	var seq domain.DocumentSequence
+	err := r.db.WithContext(ctx).
+		Where("store_id = ? AND document_type = ? AND fiscal_year = ?", storeID, documentType, fiscalYear).
+		First(&seq).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &seq, nil
+}
+
+// ConfigureSequence locks the sequence, creating it when missing, and
+// saves the settings changed by update. The lock serialises it with the
+// documents being numbered, so an issued number is never overwritten.
+func (r *sequencePG) ConfigureSequence(ctx context.Context, storeID, documentType string, fiscalYear int, update SequenceUpdate) (*domain.DocumentSequence, error) {
+	var seq domain.DocumentSequence
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		locked, err := lockSequence(tx, storeID, documentType, fiscalYear)
+		if err != nil {
+			return err
+		}
+		seq = *locked
+		if err := update(&seq); err != nil {
+			return err
+		}
+		err = tx.Model(locked).Updates(map[string]interface{}{
+			"prefix":  seq.Prefix,
+			"format":  seq.Format,
+			"padding": seq.Padding,
+		}).Error
+		if err != nil {
+			return err
+		}
+		if seq.LastNumber == locked.LastNumber {
+			return nil
+		}
+		// only a sequence that has issued no number may be moved
+		res := tx.Model(&domain.DocumentSequence{}).
+			Where("id = ? AND last_number = 0", locked.ID).
+			Update("last_number", seq.LastNumber)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return apperror.New(fiber.StatusConflict)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &seq, nil
+}
+
+// nextSequenceNumber reserves the next number of the (store, document type,
+// fiscal year) sequence. It must run inside the transaction that inserts the
+// document: the sequence row stays locked until that transaction finishes,
+// so concurrent requests are serialised and a rollback returns the number.
+func nextSequenceNumber(tx *gorm.DB, storeID, documentType string, fiscalYear int) (*domain.DocumentSequence, int, error) {
+	locked, err := lockSequence(tx, storeID, documentType, fiscalYear)
+	if err != nil {
+		return nil, 0, err
+	}
+	locked.LastNumber++
+	if err := tx.Model(locked).Update("last_number", locked.LastNumber).Error; err != nil {
+		return nil, 0, err
+	}
+	return locked, locked.LastNumber, nil
+}
+
+// lockSequence locks the (store, document type, fiscal year) sequence for
+// the rest of tx, creating it first when missing.
+func lockSequence(tx *gorm.DB, storeID, documentType string, fiscalYear int) (*domain.DocumentSequence, error) {
+	seq := domain.DocumentSequence{
+		StoreID:      storeID,
+		DocumentType: documentType,
+		FiscalYear:   fiscalYear,
+		Prefix:       domain.DefaultSequencePrefix(documentType),
+		Format:       domain.DefaultSequenceFormat,
+		Padding:      domain.DefaultSequencePadding,
+	}
+
+	// carry the settings of the previous fiscal year over to a new year
+	var prev domain.DocumentSequence
+	err := tx.Where("store_id = ? AND document_type = ? AND fiscal_year < ?", storeID, documentType, fiscalYear).
+		Order("fiscal_year desc").
+		First(&prev).Error
+	if err == nil {
+		seq.Prefix = prev.Prefix
+		seq.Format = prev.Format
+		seq.Padding = prev.Padding
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&seq).Error; err != nil {
+		return nil, err
+	}
+
+	var locked domain.DocumentSequence
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("store_id = ? AND document_type = ? AND fiscal_year = ?", storeID, documentType, fiscalYear).
+		First(&locked).Error
+	if err != nil {
+		return nil, err
+	}
+	return &locked, nil
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"invoice_project/internal/invoice/domain"
+	"invoice_project/pkg/apperror"
+)
+
+type DocumentShareRepository interface {
+	CreateShare(ctx context.Context, s *domain.DocumentShare) error
+	GetShare(ctx context.Context, id string) (*domain.DocumentShare, error)
+	ListShares(ctx context.Context, documentID uint) ([]domain.DocumentShare, error)
+	RevokeShare(ctx context.Context, documentID uint, id, revokedBy string, at time.Time) error
+	RecordView(ctx context.Context, id string, at time.Time) error
+}
+
+type sharePG struct {
+	db *gorm.DB
+}
+
+func NewDocumentShareRepository(db *gorm.DB) DocumentShareRepository {
+	return &sharePG{db: db}
+}
+
+// CreateShare stores a share link and records it on the document's
+// timeline.
+func (r *sharePG) CreateShare(ctx context.Context, s *domain.DocumentShare) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(s).Error; err != nil {
+			return err
+		}
+		return tx.Create(&domain.DocumentTimeline{
+			DocumentID: s.DocumentID,
+			EventType:  domain.EventShared,
+			ChangedBy:  s.CreatedBy,
+			ChangedAt:  time.Now(),
+			Note:       s.ID,
+		}).Error
+	})
+}
+
+func (r *sharePG) GetShare(ctx context.Context, id string) (*domain.DocumentShare, error) {
+	var s domain.DocumentShare
+	if err := conn(ctx, r.db).First(&s, "id = ?", id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &s, nil
+}
+
+// ListShares returns the share links of a document, newest first.
+func (r *sharePG) ListShares(ctx context.Context, documentID uint) ([]domain.DocumentShare, error) {
+	var shares []domain.DocumentShare
+	err := conn(ctx, r.db).Where("document_id = ?", documentID).Order("created_at DESC").Find(&shares).Error
+	if err != nil {
+		return nil, err
+	}
+	return shares, nil
+}
+
+// RevokeShare ends a share link of a document. Revoking a link twice is
+// a no-op.
+func (r *sharePG) RevokeShare(ctx context.Context, documentID uint, id, revokedBy string, at time.Time) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var s domain.DocumentShare
+		if err := tx.First(&s, "id = ? AND document_id = ?", id, documentID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return apperror.New(fiber.StatusNotFound)
+			}
+			return err
+		}
+		res := tx.Model(&domain.DocumentShare{}).Where("id = ? AND revoked_at IS NULL", id).Update("revoked_at", at)
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		return tx.Create(&domain.DocumentTimeline{
+			DocumentID: documentID,
+			EventType:  domain.EventShareRevoked,
+			ChangedBy:  revokedBy,
+			ChangedAt:  at,
+			Note:       id,
+		}).Error
+	})
+}
+
+// RecordView counts a view through a share link. The first view is also
+// recorded on the document's timeline, so the merchant can tell when the
+// buyer opened the document.
+func (r *sharePG) RecordView(ctx context.Context, id string, at time.Time) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&domain.DocumentShare{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"view_count":     gorm.Expr("view_count + 1"),
+			"last_viewed_at": at,
+		}).Error
+		if err != nil {
+			return err
+		}
+		res := tx.Model(&domain.DocumentShare{}).Where("id = ? AND first_viewed_at IS NULL", id).Update("first_viewed_at", at)
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		var s domain.DocumentShare
+		if err := tx.Select("document_id").First(&s, "id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Create(&domain.DocumentTimeline{
+			DocumentID: s.DocumentID,
+			EventType:  domain.EventShareViewed,
+			ChangedAt:  at,
+			Note:       id,
+		}).Error
+	})
+}
@@ -0,0 +1,263 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"invoice_project/internal/invoice/domain"
+	"invoice_project/pkg/apperror"
+)
+
+// StatementConfirm records the payment of a locked statement line and
+// returns its ID. It runs in the transaction carried by ctx.
+type StatementConfirm func(ctx context.Context, line *domain.BankStatementLine) (uint, error)
+
+type BankStatementRepository interface {
+	CreateStatement(ctx context.Context, s *domain.BankStatement) error
+	GetStatement(ctx context.Context, id uint) (*domain.BankStatement, error)
+	ListStatements(ctx context.Context, storeID string) ([]domain.BankStatement, error)
+	GetLine(ctx context.Context, id uint) (*domain.BankStatementLine, error)
+	ListLines(ctx context.Context, storeID string, statuses []string) ([]domain.BankStatementLine, error)
+	SaveMatches(ctx context.Context, lines []domain.BankStatementLine) error
+	ConfirmLine(ctx context.Context, id uint, reviewedBy string, confirm StatementConfirm) error
+	IgnoreLine(ctx context.Context, id uint, reviewedBy string) error
+	ListOpenDocuments(ctx context.Context, storeID string) ([]domain.OpenDocument, error)
+}
+
+type statementPG struct {
+	db *gorm.DB
+}
+
+func NewBankStatementRepository(db *gorm.DB) BankStatementRepository {
+	return &statementPG{db: db}
+}
+
+// openLineStatuses are the statuses of lines still awaiting review.
+var openLineStatuses = []string{domain.StatementLineUnmatched, domain.StatementLineProposed}
+
+// lineKey identifies a transaction across overlapping statements.
+type lineKey struct {
+	date        string
+	amount      int64
+	description string
+	reference   string
+}
+
+func keyOf(l *domain.BankStatementLine) lineKey {
+	return lineKey{l.TransactionDate.Format("2006-01-02"), l.Amount.Satang(), l.Description, l.Reference}
+}
+
+// CreateStatement saves s with its lines and their matches. Lines already
+// imported for the store by an earlier statement are left out and counted
+// in Skipped, so overlapping statements can be uploaded. Identical
+// transactions on the same day are told apart by how often they occur.
+func (r *statementPG) CreateStatement(ctx context.Context, s *domain.BankStatement) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if len(s.Lines) > 0 {
+			from, to := s.Lines[0].TransactionDate, s.Lines[0].TransactionDate
+			for _, l := range s.Lines {
+				if l.TransactionDate.Before(from) {
+					from = l.TransactionDate
+				}
+				if l.TransactionDate.After(to) {
+					to = l.TransactionDate
+				}
+			}
+			var existing []domain.BankStatementLine
+			err := tx.Select("transaction_date, amount, description, reference").
+				Where("store_id = ? AND transaction_date BETWEEN ? AND ?", s.StoreID, from, to).
+				Find(&existing).Error
+			if err != nil {
+				return err
+			}
+			seen := map[lineKey]int{}
+			for i := range existing {
+				seen[keyOf(&existing[i])]++
+			}
+			lines := s.Lines[:0]
+			for _, l := range s.Lines {
+				if k := keyOf(&l); seen[k] > 0 {
+					seen[k]--
+					s.Skipped++
+					continue
+				}
+				lines = append(lines, l)
+			}
+			s.Lines = lines
+		}
+		return tx.Create(s).Error
+	})
+}
+
+func (r *statementPG) GetStatement(ctx context.Context, id uint) (*domain.BankStatement, error) {
+	var s domain.BankStatement
+	err := conn(ctx, r.db).
+		Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("transaction_date, row, id") }).
+		Preload("Lines.Matches", func(db *gorm.DB) *gorm.DB { return db.Order("score DESC, id") }).
+		First(&s, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &s, nil
+}
+
+// ListStatements returns the statements of a store without their lines,
+// newest first.
+func (r *statementPG) ListStatements(ctx context.Context, storeID string) ([]domain.BankStatement, error) {
+	var statements []domain.BankStatement
+	err := conn(ctx, r.db).Where("store_id = ?", storeID).Order("created_at DESC, id DESC").Find(&statements).Error
+	if err != nil {
+		return nil, err
+	}
+	return statements, nil
+}
+
+func (r *statementPG) GetLine(ctx context.Context, id uint) (*domain.BankStatementLine, error) {
+	var l domain.BankStatementLine
+	err := conn(ctx, r.db).
+		Preload("Matches", func(db *gorm.DB) *gorm.DB { return db.Order("score DESC, id") }).
+		First(&l, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &l, nil
+}
+
+// ListLines returns the lines of a store in the given statuses, oldest
+// first.
+func (r *statementPG) ListLines(ctx context.Context, storeID string, statuses []string) ([]domain.BankStatementLine, error) {
+	var lines []domain.BankStatementLine
+	err := conn(ctx, r.db).
+		Preload("Matches", func(db *gorm.DB) *gorm.DB { return db.Order("score DESC, id") }).
+		Where("store_id = ? AND status IN ?", storeID, statuses).
+		Order("transaction_date, id").
+		Find(&lines).Error
+	if err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+// SaveMatches replaces the matches and status of lines still awaiting
+// review. Lines confirmed or ignored in the meantime are left alone.
+func (r *statementPG) SaveMatches(ctx context.Context, lines []domain.BankStatementLine) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		for i := range lines {
+			l := &lines[i]
+			res := tx.Model(&domain.BankStatementLine{}).
+				Where("id = ? AND status IN ?", l.ID, openLineStatuses).
+				Update("status", l.Status)
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 0 {
+				continue
+			}
+			if err := tx.Where("line_id = ?", l.ID).Delete(&domain.BankStatementMatch{}).Error; err != nil {
+				return err
+			}
+			for j := range l.Matches {
+				l.Matches[j].ID = 0
+				l.Matches[j].LineID = l.ID
+			}
+			if len(l.Matches) > 0 {
+				if err := tx.Create(&l.Matches).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// ConfirmLine locks a line awaiting review, records its payment through
+// confirm and marks it matched, all in one transaction so a line is never
+// paid twice.
+func (r *statementPG) ConfirmLine(ctx context.Context, id uint, reviewedBy string, confirm StatementConfirm) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		l, err := lockOpenLine(tx, id)
+		if err != nil {
+			return err
+		}
+		paymentID, err := confirm(WithTx(ctx, tx), l)
+		if err != nil {
+			return err
+		}
+		return tx.Model(l).Updates(map[string]interface{}{
+			"status":      domain.StatementLineMatched,
+			"payment_id":  paymentID,
+			"reviewed_by": reviewedBy,
+			"reviewed_at": time.Now(),
+		}).Error
+	})
+}
+
+// IgnoreLine sets a line awaiting review aside.
+func (r *statementPG) IgnoreLine(ctx context.Context, id uint, reviewedBy string) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		l, err := lockOpenLine(tx, id)
+		if err != nil {
+			return err
+		}
+		return tx.Model(l).Updates(map[string]interface{}{
+			"status":      domain.StatementLineIgnored,
+			"reviewed_by": reviewedBy,
+			"reviewed_at": time.Now(),
+		}).Error
+	})
+}
+
+func lockOpenLine(tx *gorm.DB, id uint) (*domain.BankStatementLine, error) {
+	var l domain.BankStatementLine
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&l, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperror.New(fiber.StatusNotFound)
+		}
+		return nil, err
+	}
+	if l.Status != domain.StatementLineUnmatched && l.Status != domain.StatementLineProposed {
+		return nil, apperror.New(fiber.StatusConflict)
+	}
+	return &l, nil
+}
+
+// ListOpenDocuments returns the baht documents of a store awaiting
+// payment with something outstanding, oldest first. Statements are in
+// baht, so documents in other currencies are never matched.
+func (r *statementPG) ListOpenDocuments(ctx context.Context, storeID string) ([]domain.OpenDocument, error) {
+	db := conn(ctx, r.db)
+	var docs []domain.InvoiceDocument
+	err := db.Where("store_id = ? AND document_type IN ? AND status IN ? AND currency = ?",
+		storeID, domain.SalesTypes(), domain.OpenStatuses(), domain.CurrencyTHB).
+		Order("issue_date, id").
+		Find(&docs).Error
+	if err != nil || len(docs) == 0 {
+		return nil, err
+	}
+	balances, err := documentBalances(db, docs)
+	if err != nil {
+		return nil, err
+	}
+	var open []domain.OpenDocument
+	for _, d := range docs {
+		if out := balances[d.ID].Outstanding(); out > 0 {
+			open = append(open, domain.OpenDocument{
+				ID:          d.ID,
+				DocumentNo:  d.DocumentNo,
+				IssueDate:   d.IssueDate,
+				Outstanding: out,
+			})
+		}
+	}
+	return open, nil
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"invoice_project/internal/invoice/domain"
+)
+
+type DocumentTemplateRepository interface {
+	GetStoreTemplate(ctx context.Context, storeID string) (*domain.StoreDocumentTemplate, error)
+	SaveStoreTemplate(ctx context.Context, t *domain.StoreDocumentTemplate) error
+	GetEmailTemplate(ctx context.Context, storeID string) (*domain.StoreEmailTemplate, error)
+	SaveEmailTemplate(ctx context.Context, t *domain.StoreEmailTemplate) error
+}
+
+type templatePG struct {
+	db *gorm.DB
+}
+
+func NewDocumentTemplateRepository(db *gorm.DB) DocumentTemplateRepository {
+	return &templatePG{db: db}
+}
+
+func (r *templatePG) GetStoreTemplate(ctx context.Context, storeID string) (*domain.StoreDocumentTemplate, error) {
+	var t domain.StoreDocumentTemplate
+	err := r.db.WithContext(ctx).Where("store_id = ?", storeID).First(&t).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *templatePG) SaveStoreTemplate(ctx context.Context, t *domain.StoreDocumentTemplate) error {
+	return r.db.WithContext(ctx).Save(t).Error
+}
+
+func (r *templatePG) GetEmailTemplate(ctx context.Context, storeID string) (*domain.StoreEmailTemplate, error) {
+	var t domain.StoreEmailTemplate
+	err := r.db.WithContext(ctx).Where("store_id = ?", storeID).First(&t).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *templatePG) SaveEmailTemplate(ctx context.Context, t *domain.StoreEmailTemplate) error {
+	return r.db.WithContext(ctx).Save(t).Error
+}
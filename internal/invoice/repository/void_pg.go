@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"invoice_project/internal/invoice/domain"
+	"invoice_project/pkg/apperror"
+	"invoice_project/pkg/money"
+)
+
+// VoidBuild is called with the locked document being voided, its items and
+// the amount of the payments allocated to it. It returns the replacement
+// to issue with its items, or nil to void the document without one. ctx
+// carries the transaction.
+type VoidBuild func(ctx context.Context, original *domain.InvoiceDocument, paid money.Amount) (*domain.InvoiceDocument, []domain.InvoiceItem, error)
+
+// VoidDocument voids a document for reason and, when build returns one,
+// issues its replacement referencing it. The voided document keeps its
+// number, which is never handed out again. Payments allocated to it and a
+// receipt's payments move to the replacement; without one a receipt's
+// payments can be receipted again. Invoices with credit or debit notes
+// against them cannot be voided. Both documents get a timeline entry
+// recording the link.
+func (r *documentPG) VoidDocument(ctx context.Context, id uint, reason, changedBy string, build VoidBuild) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var original domain.InvoiceDocument
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&original, id).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return apperror.New(fiber.StatusNotFound)
+			}
+			return err
+		}
+		if !domain.CanTransition(original.DocumentType, original.Status, domain.StatusVoid) {
+			return apperror.New(fiber.StatusConflict)
+		}
+
+		var notes int64
+		err = tx.Model(&domain.InvoiceDocument{}).
+			Where("reference_id = ? AND document_type IN ? AND status NOT IN ?",
+				original.ID,
+				[]string{domain.DocumentTypeCreditNote, domain.DocumentTypeDebitNote},
+				[]string{domain.StatusVoid, domain.StatusCancelled}).
+			Count(&notes).Error
+		if err != nil {
+			return err
+		}
+		if notes > 0 {
+			return apperror.New(fiber.StatusConflict)
+		}
+		var paid money.Amount
+		err = tx.Model(&domain.PaymentAllocation{}).
+			Where("document_id = ?", original.ID).
+			Select("COALESCE(SUM(amount), 0)").
+			Scan(&paid).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("document_id = ?", original.ID).Order("id").Find(&original.Items).Error; err != nil {
+			return err
+		}
+
+		replacement, items, err := build(WithTx(ctx, tx), &original, paid)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		var replacementID *uint
+		if replacement != nil {
+			if err := insertReplacement(tx, &original, replacement, items, changedBy, paid > 0); err != nil {
+				return err
+			}
+			replacementID = &replacement.ID
+		}
+
+		if original.DocumentType == domain.DocumentTypeReceipt {
+			err := tx.Model(&domain.Payment{}).Where("receipt_id = ?", original.ID).Update("receipt_id", replacementID).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		err = tx.Model(&domain.InvoiceDocument{}).
+			Where("id = ?", original.ID).
+			Updates(map[string]interface{}{
+				"status":      domain.StatusVoid,
+				"void_reason": reason,
+				"voided_at":   now,
+			}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(&domain.DocumentTimeline{
+			DocumentID:        original.ID,
+			RelatedDocumentID: replacementID,
+			EventType:         domain.EventVoided,
+			OldStatus:         original.Status,
+			NewStatus:         domain.StatusVoid,
+			ChangedBy:         changedBy,
+			ChangedAt:         now,
+			Note:              reason,
+		}).Error
+	})
+}
+
+// insertReplacement issues replacement for original inside tx and moves
+// the payments allocated to original over to it, updating its paid status.
+// The payments must be in the replacement's currency and may not exceed
+// its total.
+func insertReplacement(tx *gorm.DB, original, replacement *domain.InvoiceDocument, items []domain.InvoiceItem, changedBy string, paid bool) error {
+	replacement.ReferenceID = &original.ID
+	tl := domain.DocumentTimeline{RelatedDocumentID: &original.ID, ChangedBy: changedBy}
+	if err := insertDocument(tx, replacement, items, tl); err != nil {
+		return err
+	}
+	status := replacement.Status
+	if paid {
+		err := tx.Model(&domain.PaymentAllocation{}).
+			Where("document_id = ?", original.ID).
+			Update("document_id", replacement.ID).Error
+		if err != nil {
+			return err
+		}
+		if replacement.Currency != original.Currency {
+			return apperror.New(fiber.StatusUnprocessableEntity)
+		}
+		balances, err := documentBalances(tx, []domain.InvoiceDocument{*replacement})
+		if err != nil {
+			return err
+		}
+		if balances[replacement.ID].Outstanding().IsNegative() {
+			return apperror.New(fiber.StatusUnprocessableEntity)
+		}
+		status = balances[replacement.ID].PaidStatus()
+		err = tx.Model(&domain.InvoiceDocument{}).Where("id = ?", replacement.ID).Update("status", status).Error
+		if err != nil {
+			return err
+		}
+	}
+	err := tx.Create(&domain.DocumentTimeline{
+		DocumentID:        replacement.ID,
+		RelatedDocumentID: &original.ID,
+		EventType:         domain.EventReplaces,
+		OldStatus:         replacement.Status,
+		NewStatus:         status,
+		ChangedBy:         changedBy,
+		ChangedAt:         time.Now(),
+		Note:              original.DocumentNo,
+	}).Error
+	replacement.Status = status
+	return err
+}
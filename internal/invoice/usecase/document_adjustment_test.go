@@ -0,0 +1,157 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"invoice_project/internal/invoice/domain"
+	"invoice_project/internal/invoice/repository"
+	"invoice_project/pkg/money"
+)
+
+const adjustmentStore = "6f1c2d4e-8a7b-4c3d-9e2f-1a2b3c4d5e6f"
+
+// adjustedInvoice is an issued tax invoice of 1,000 baht plus 7% VAT.
+func adjustedInvoice() *domain.InvoiceDocument {
+	store := adjustmentStore
+	return &domain.InvoiceDocument{
+		ID:           3,
+		DocumentType: domain.DocumentTypeTaxInvoice,
+		StoreID:      &store,
+		Status:       domain.StatusIssued,
+		Currency:     domain.CurrencyTHB,
+		ExchangeRate: money.RateScale,
+		GrandTotal:   money.FromBaht(1070),
+		VatAmount:    money.FromBaht(70),
+	}
+}
+
+// adjustmentNote is a note of 100 baht plus 7% VAT.
+func adjustmentNote(docType string) *domain.InvoiceDocument {
+	store := adjustmentStore
+	return &domain.InvoiceDocument{
+		DocumentType: docType,
+		StoreID:      &store,
+		Currency:     domain.CurrencyTHB,
+		GrandTotal:   money.FromBaht(107),
+		VatAmount:    money.FromBaht(7),
+	}
+}
+
+func TestAdjustmentCheck_Values(t *testing.T) {
+	tests := []struct {
+		name                            string
+		docType                         string
+		prior                           repository.AdjustmentTotals
+		original, difference, corrected money.Amount
+	}{
+		{
+			name:     "first credit note",
+			docType:  domain.DocumentTypeCreditNote,
+			original: money.FromBaht(1000), difference: money.FromBaht(100), corrected: money.FromBaht(900),
+		},
+		{
+			name:    "credit note after earlier notes",
+			docType: domain.DocumentTypeCreditNote,
+			prior: repository.AdjustmentTotals{
+				Credited: money.FromBaht(535), CreditedNet: money.FromBaht(500),
+				Debited: money.FromBaht(214), DebitedNet: money.FromBaht(200),
+			},
+			original: money.FromBaht(1000), difference: money.FromBaht(100), corrected: money.FromBaht(600),
+		},
+		{
+			name:     "debit note",
+			docType:  domain.DocumentTypeDebitNote,
+			prior:    repository.AdjustmentTotals{Credited: money.FromBaht(107), CreditedNet: money.FromBaht(100)},
+			original: money.FromBaht(1000), difference: money.FromBaht(100), corrected: money.FromBaht(1000),
+		},
+	}
+	for _, tt := range tests {
+		note := adjustmentNote(tt.docType)
+		if err := adjustmentCheck(note)(adjustedInvoice(), tt.prior); err != nil {
+			t.Errorf("%s: returned error: %v", tt.name, err)
+			continue
+		}
+		if note.OriginalAmount != tt.original || note.DifferenceAmount != tt.difference || note.CorrectedAmount != tt.corrected {
+			t.Errorf("%s: original %s, difference %s, corrected %s; want %s, %s, %s", tt.name,
+				note.OriginalAmount, note.DifferenceAmount, note.CorrectedAmount, tt.original, tt.difference, tt.corrected)
+		}
+		if note.ThbGrandTotal != note.GrandTotal || note.ThbVatAmount != note.VatAmount {
+			t.Errorf("%s: baht totals %s/%s", tt.name, note.ThbGrandTotal, note.ThbVatAmount)
+		}
+	}
+}
+
+func TestAdjustmentCheck_CreditCap(t *testing.T) {
+	tests := []struct {
+		name  string
+		prior repository.AdjustmentTotals
+		ok    bool
+	}{
+		{"within the invoice", repository.AdjustmentTotals{Credited: money.FromBaht(500)}, true},
+		{"exactly the invoice", repository.AdjustmentTotals{Credited: money.FromBaht(963)}, true},
+		{"beyond the invoice", repository.AdjustmentTotals{Credited: money.FromBaht(1000)}, false},
+		{"within the invoice plus debits", repository.AdjustmentTotals{Credited: money.FromBaht(1000), Debited: money.FromBaht(107)}, true},
+		{"beyond the invoice plus debits", repository.AdjustmentTotals{Credited: money.FromBaht(1100), Debited: money.FromBaht(107)}, false},
+	}
+	for _, tt := range tests {
+		err := adjustmentCheck(adjustmentNote(domain.DocumentTypeCreditNote))(adjustedInvoice(), tt.prior)
+		if tt.ok && err != nil {
+			t.Errorf("%s: returned error: %v", tt.name, err)
+		}
+		if !tt.ok && statusCode(err) != 422 {
+			t.Errorf("%s: expected 422, got %v", tt.name, err)
+		}
+	}
+
+	// debit notes are not capped
+	prior := repository.AdjustmentTotals{Credited: money.FromBaht(1070)}
+	if err := adjustmentCheck(adjustmentNote(domain.DocumentTypeDebitNote))(adjustedInvoice(), prior); err != nil {
+		t.Errorf("debit note returned error: %v", err)
+	}
+}
+
+func TestAdjustmentCheck_Rejects(t *testing.T) {
+	otherStore := "0a1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d"
+	tests := []struct {
+		name   string
+		modify func(note, original *domain.InvoiceDocument)
+		code   int
+	}{
+		{"draft original", func(n, o *domain.InvoiceDocument) { o.Status = domain.StatusDraft }, 409},
+		{"void original", func(n, o *domain.InvoiceDocument) { o.Status = domain.StatusVoid }, 409},
+		{"note on a note", func(n, o *domain.InvoiceDocument) { o.DocumentType = domain.DocumentTypeCreditNote }, 409},
+		{"quotation", func(n, o *domain.InvoiceDocument) { o.DocumentType = domain.DocumentTypeQuotation }, 409},
+		{"other store", func(n, o *domain.InvoiceDocument) { o.StoreID = &otherStore }, 400},
+		{"original without store", func(n, o *domain.InvoiceDocument) { o.StoreID = nil }, 400},
+		{"other currency", func(n, o *domain.InvoiceDocument) { n.Currency = "USD" }, 400},
+	}
+	for _, tt := range tests {
+		note, original := adjustmentNote(domain.DocumentTypeCreditNote), adjustedInvoice()
+		tt.modify(note, original)
+		if err := adjustmentCheck(note)(original, repository.AdjustmentTotals{}); statusCode(err) != tt.code {
+			t.Errorf("%s: expected %d, got %v", tt.name, tt.code, err)
+		}
+	}
+}
+
+func TestAdjustmentCheck_InheritsCurrency(t *testing.T) {
+	original := adjustedInvoice()
+	rateDate := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	original.Currency = "USD"
+	original.ExchangeRate = money.MustParseRate("33.5")
+	original.RateDate = &rateDate
+
+	note := adjustmentNote(domain.DocumentTypeCreditNote)
+	note.Currency = ""
+	if err := adjustmentCheck(note)(original, repository.AdjustmentTotals{}); err != nil {
+		t.Fatalf("returned error: %v", err)
+	}
+	if note.Currency != "USD" || note.ExchangeRate != original.ExchangeRate || note.RateDate != original.RateDate {
+		t.Errorf("rate not inherited: %s %s %v", note.Currency, note.ExchangeRate, note.RateDate)
+	}
+	// 107 USD at 33.5
+	if note.ThbGrandTotal != money.MustParse("3584.50") || note.ThbVatAmount != money.MustParse("234.50") {
+		t.Errorf("baht totals %s/%s", note.ThbGrandTotal, note.ThbVatAmount)
+	}
+}
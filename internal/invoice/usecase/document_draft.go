@@ -0,0 +1,189 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"invoice_project/internal/invoice/domain"
+	"invoice_project/pkg/apperror"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// draftChange edits a locked draft and returns the items it keeps.
+type draftChange func(doc *domain.InvoiceDocument, items []domain.InvoiceItem) ([]domain.InvoiceItem, error)
+
+// UpdateDraft replaces the editable fields of a draft made against version
+// and, when items is not nil, its items: listed items with an ID are
+// updated, those without one are added and the others removed.
+func (u *documentUC) UpdateDraft(ctx context.Context, id uint, version int, in *domain.InvoiceDocument, items []domain.InvoiceItem, changedBy string) (*domain.InvoiceDocument, error) {
+	if in == nil {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	return u.revise(ctx, id, version, changedBy, "document updated", func(doc *domain.InvoiceDocument, current []domain.InvoiceItem) ([]domain.InvoiceItem, error) {
+		if err := applyDraftFields(doc, in); err != nil {
+			return nil, err
+		}
+		if items == nil {
+			return current, nil
+		}
+		return items, nil
+	})
+}
+
+// AddItem appends an item to a draft.
+func (u *documentUC) AddItem(ctx context.Context, id uint, version int, item domain.InvoiceItem, changedBy string) (*domain.InvoiceDocument, error) {
+	return u.revise(ctx, id, version, changedBy, "item added", func(doc *domain.InvoiceDocument, items []domain.InvoiceItem) ([]domain.InvoiceItem, error) {
+		item.ID = 0
+		return append(items, item), nil
+	})
+}
+
+// UpdateItem replaces an item of a draft.
+func (u *documentUC) UpdateItem(ctx context.Context, id, itemID uint, version int, item domain.InvoiceItem, changedBy string) (*domain.InvoiceDocument, error) {
+	note := fmt.Sprintf("item %d updated", itemID)
+	return u.revise(ctx, id, version, changedBy, note, func(doc *domain.InvoiceDocument, items []domain.InvoiceItem) ([]domain.InvoiceItem, error) {
+		i := itemIndex(items, itemID)
+		if i < 0 {
+			return nil, apperror.New(fiber.StatusNotFound)
+		}
+		item.ID = itemID
+		items[i] = item
+		return items, nil
+	})
+}
+
+// RemoveItem deletes an item from a draft.
+func (u *documentUC) RemoveItem(ctx context.Context, id, itemID uint, version int, changedBy string) (*domain.InvoiceDocument, error) {
+	note := fmt.Sprintf("item %d removed", itemID)
+	return u.revise(ctx, id, version, changedBy, note, func(doc *domain.InvoiceDocument, items []domain.InvoiceItem) ([]domain.InvoiceItem, error) {
+		i := itemIndex(items, itemID)
+		if i < 0 {
+			return nil, apperror.New(fiber.StatusNotFound)
+		}
+		return append(items[:i], items[i+1:]...), nil
+	})
+}
+
+// revise applies change to a draft under the repository lock and
+// recomputes what depends on it: the buyer and seller, the validity of a
+// quotation and the totals. Credit and debit notes cannot be revised, as
+// their amounts were checked against the invoice they adjust; cancel the
+// draft and create a new note instead.
+func (u *documentUC) revise(ctx context.Context, id uint, version int, changedBy, note string, change draftChange) (*domain.InvoiceDocument, error) {
+	if id == 0 || version <= 0 {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	tl := &domain.DocumentTimeline{ChangedBy: changedBy, Note: fmt.Sprintf("revision %d: %s", version+1, note)}
+	err := u.repo.UpdateDraft(ctx, id, version, tl, func(ctx context.Context, doc *domain.InvoiceDocument, items []domain.InvoiceItem) ([]domain.InvoiceItem, error) {
+		if domain.IsAdjustmentNote(doc.DocumentType) {
+			return nil, apperror.New(fiber.StatusConflict)
+		}
+		// stored totals are recomputed; only values sent with this
+		// revision are compared in strict mode
+		clearTotals(doc)
+		for i := range items {
+			items[i].LineTotal = 0
+		}
+
+		items, err := change(doc, items)
+		if err != nil {
+			return nil, err
+		}
+		if u.parties != nil {
+			if err := u.parties.Snapshot(ctx, doc); err != nil {
+				return nil, err
+			}
+		}
+		sanitizeParties(doc)
+		if err := applyValidity(doc); err != nil {
+			return nil, err
+		}
+		totals, err := CalculateTotals(doc, items)
+		if err != nil {
+			return nil, err
+		}
+		if err := applyTotals(u.pricingMode, doc, items, totals); err != nil {
+			return nil, err
+		}
+		return items, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return u.repo.GetDocument(ctx, id)
+}
+
+// applyDraftFields copies the editable fields of in onto a draft. The
+// type, store, number and status of a document never change; a new issue
+// date must stay in the fiscal year the draft was numbered in. An
+// exchange rate on a draft is one agreed with the buyer and is kept when
+// the draft is issued.
+func applyDraftFields(doc, in *domain.InvoiceDocument) error {
+	if !in.IssueDate.IsZero() {
+		if domain.FiscalYearOf(in.IssueDate) != domain.FiscalYearOf(doc.IssueDate) {
+			return apperror.New(fiber.StatusUnprocessableEntity)
+		}
+		doc.IssueDate = in.IssueDate
+	}
+	doc.ValidUntil = in.ValidUntil
+	doc.CustomerID = in.CustomerID
+
+	doc.BuyerType = in.BuyerType
+	doc.BuyerFirstName = in.BuyerFirstName
+	doc.BuyerLastName = in.BuyerLastName
+	doc.BuyerCompanyName = in.BuyerCompanyName
+	doc.BuyerTaxID = in.BuyerTaxID
+	doc.BuyerBranchNo = in.BuyerBranchNo
+	doc.BuyerAddress = in.BuyerAddress
+	doc.SellerType = in.SellerType
+	doc.SellerFirstName = in.SellerFirstName
+	doc.SellerLastName = in.SellerLastName
+	doc.SellerCompanyName = in.SellerCompanyName
+	doc.SellerTaxID = in.SellerTaxID
+	doc.SellerBranchNo = in.SellerBranchNo
+	doc.SellerAddress = in.SellerAddress
+
+	currency, err := normalizeCurrency(in.Currency)
+	if err != nil {
+		return err
+	}
+	if in.ExchangeRate < 0 {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	doc.Currency = currency
+	doc.ExchangeRate = in.ExchangeRate
+	doc.RateDate = nil
+	if in.ExchangeRate > 0 {
+		doc.RateDate = in.RateDate
+	}
+
+	doc.DiscountType = in.DiscountType
+	doc.DiscountValue = in.DiscountValue
+	doc.WhtRate = in.WhtRate
+	doc.Remarks = in.Remarks
+
+	doc.Subtotal = in.Subtotal
+	doc.DiscountAmount = in.DiscountAmount
+	doc.VatAmount = in.VatAmount
+	doc.GrandTotal = in.GrandTotal
+	doc.WhtAmount = in.WhtAmount
+	return nil
+}
+
+func clearTotals(doc *domain.InvoiceDocument) {
+	doc.Subtotal = 0
+	doc.DiscountAmount = 0
+	doc.VatAmount = 0
+	doc.GrandTotal = 0
+	doc.WhtAmount = 0
+}
+
+func itemIndex(items []domain.InvoiceItem, id uint) int {
+	for i := range items {
+		if items[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
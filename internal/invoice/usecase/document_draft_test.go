@@ -0,0 +1,186 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"invoice_project/internal/invoice/domain"
+	"invoice_project/internal/invoice/repository"
+	"invoice_project/pkg/apperror"
+	"invoice_project/pkg/money"
+)
+
+// draftRepo keeps a single draft in memory. Only the methods used when
+// revising a draft are implemented.
+type draftRepo struct {
+	repository.InvoiceDocumentRepository
+	doc   domain.InvoiceDocument
+	items []domain.InvoiceItem
+	tl    *domain.DocumentTimeline
+}
+
+func (r *draftRepo) UpdateDraft(ctx context.Context, id uint, version int, tl *domain.DocumentTimeline, edit repository.DraftEdit) error {
+	doc := r.doc
+	current := append([]domain.InvoiceItem(nil), r.items...)
+	items, err := edit(ctx, &doc, current)
+	if err != nil {
+		return err
+	}
+	doc.Version = version + 1
+	nextID := uint(100)
+	for i := range items {
+		if items[i].ID == 0 {
+			items[i].ID = nextID
+			nextID++
+		}
+	}
+	r.doc, r.items, r.tl = doc, items, tl
+	return nil
+}
+
+func (r *draftRepo) GetDocument(ctx context.Context, id uint) (*domain.InvoiceDocument, error) {
+	doc := r.doc
+	doc.Items = r.items
+	return &doc, nil
+}
+
+func newDraftRepo() *draftRepo {
+	store := "6f1c2d4e-8a7b-4c3d-9e2f-1a2b3c4d5e6f"
+	return &draftRepo{
+		doc: domain.InvoiceDocument{
+			ID:           1,
+			DocumentType: domain.DocumentTypeInvoice,
+			StoreID:      &store,
+			IssueDate:    time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+			Status:       domain.StatusDraft,
+			Version:      1,
+			GrandTotal:   money.FromBaht(100),
+		},
+		items: []domain.InvoiceItem{
+			{ID: 1, DocumentID: 1, ProductName: "A", Qty: 1, UnitPrice: money.FromBaht(100), VatType: VatTypeExempt, LineTotal: money.FromBaht(100)},
+		},
+	}
+}
+
+func statusCode(err error) int {
+	if e, ok := err.(*apperror.StatusError); ok {
+		return e.Code
+	}
+	return 0
+}
+
+func TestDraftItems_RecomputeTotals(t *testing.T) {
+	repo := newDraftRepo()
+	uc := &documentUC{repo: repo, pricingMode: PricingStrict}
+	ctx := context.Background()
+
+	doc, err := uc.AddItem(ctx, 1, 1, domain.InvoiceItem{ProductName: "B", Qty: 2, UnitPrice: money.FromBaht(50), VatType: VatTypeExempt}, "u")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Items) != 2 || doc.GrandTotal != money.FromBaht(200) || doc.Version != 2 {
+		t.Fatalf("after add: total %s, version %d, items %+v", doc.GrandTotal, doc.Version, doc.Items)
+	}
+	if repo.tl.Note != "revision 2: item added" {
+		t.Fatalf("timeline note = %q", repo.tl.Note)
+	}
+
+	doc, err = uc.UpdateItem(ctx, 1, 1, 2, domain.InvoiceItem{ProductName: "A", Qty: 3, UnitPrice: money.FromBaht(100), VatType: VatTypeExempt}, "u")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.GrandTotal != money.FromBaht(400) || doc.Items[0].ID != 1 {
+		t.Fatalf("after update: total %s, items %+v", doc.GrandTotal, doc.Items)
+	}
+
+	doc, err = uc.RemoveItem(ctx, 1, 1, 3, "u")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Items) != 1 || doc.GrandTotal != money.FromBaht(100) {
+		t.Fatalf("after remove: total %s, items %+v", doc.GrandTotal, doc.Items)
+	}
+
+	if _, err := uc.RemoveItem(ctx, 1, 42, 4, "u"); statusCode(err) != 404 {
+		t.Fatalf("removing a missing item: %v", err)
+	}
+}
+
+func TestUpdateDraft_StrictModeChecksSentTotals(t *testing.T) {
+	uc := &documentUC{repo: newDraftRepo(), pricingMode: PricingStrict}
+	in := &domain.InvoiceDocument{BuyerType: "company", BuyerCompanyName: "ACME", GrandTotal: money.FromBaht(99)}
+	if _, err := uc.UpdateDraft(context.Background(), 1, 1, in, nil, "u"); statusCode(err) != 422 {
+		t.Fatalf("expected 422 for a wrong total, got %v", err)
+	}
+
+	in.GrandTotal = money.FromBaht(100)
+	doc, err := uc.UpdateDraft(context.Background(), 1, 1, in, nil, "u")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.BuyerCompanyName != "ACME" || len(doc.Items) != 1 || doc.DocumentType != domain.DocumentTypeInvoice {
+		t.Fatalf("unexpected draft %+v", doc)
+	}
+}
+
+func TestUpdateDraft_Rejections(t *testing.T) {
+	ctx := context.Background()
+
+	uc := &documentUC{repo: newDraftRepo()}
+	in := &domain.InvoiceDocument{IssueDate: time.Date(2027, 1, 5, 0, 0, 0, 0, time.UTC)}
+	if _, err := uc.UpdateDraft(ctx, 1, 1, in, nil, "u"); statusCode(err) != 422 {
+		t.Fatalf("moving the issue date to another fiscal year: %v", err)
+	}
+
+	repo := newDraftRepo()
+	repo.doc.DocumentType = domain.DocumentTypeCreditNote
+	uc = &documentUC{repo: repo}
+	if _, err := uc.UpdateDraft(ctx, 1, 1, &domain.InvoiceDocument{}, nil, "u"); statusCode(err) != 409 {
+		t.Fatalf("revising a credit note: %v", err)
+	}
+
+	if _, err := uc.AddItem(ctx, 1, 0, domain.InvoiceItem{}, "u"); statusCode(err) != 400 {
+		t.Fatalf("missing version: %v", err)
+	}
+}
+
+func TestReadyToIssue(t *testing.T) {
+	doc := &domain.InvoiceDocument{
+		DocumentType:     domain.DocumentTypeTaxInvoice,
+		IssueDate:        time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+		BuyerType:        "company",
+		BuyerCompanyName: "ACME",
+		BuyerAddress:     "1 Silom Rd",
+		SellerType:       "person",
+		SellerFirstName:  "Somchai",
+		SellerTaxID:      "1234567890123",
+		SellerAddress:    "2 Sathorn Rd",
+	}
+	items := []domain.InvoiceItem{{ProductName: "A", Qty: 1}}
+	if err := readyToIssue(doc, items); err != nil {
+		t.Fatalf("complete document rejected: %v", err)
+	}
+	if err := readyToIssue(doc, nil); err == nil {
+		t.Error("document without items accepted")
+	}
+	if err := readyToIssue(doc, []domain.InvoiceItem{{ProductName: "A"}}); err == nil {
+		t.Error("item without quantity accepted")
+	}
+
+	noTaxID := *doc
+	noTaxID.SellerTaxID = ""
+	if err := readyToIssue(&noTaxID, items); err == nil {
+		t.Error("tax invoice without the seller's tax ID accepted")
+	}
+	noTaxID.DocumentType = domain.DocumentTypeInvoice
+	if err := readyToIssue(&noTaxID, items); err != nil {
+		t.Errorf("plain invoice needs no tax ID: %v", err)
+	}
+
+	noBuyer := *doc
+	noBuyer.BuyerCompanyName = ""
+	if err := readyToIssue(&noBuyer, items); err == nil {
+		t.Error("document without a buyer accepted")
+	}
+}
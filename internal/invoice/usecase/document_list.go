@@ -0,0 +1,257 @@
+package usecase
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"invoice_project/internal/invoice/domain"
+	"invoice_project/internal/invoice/repository"
+	"invoice_project/pkg/apperror"
+	"invoice_project/pkg/money"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// Page sizes of the document list.
+const (
+	defaultDocumentListLimit = 50
+	maxDocumentListLimit     = 200
+)
+
+// defaultDocumentSort lists the most recently issued documents first.
+const defaultDocumentSort = "-" + domain.SortIssueDate
+
+// DocumentListInput is the query of a document list request as sent by the
+// client. Status and DocumentType take comma-separated lists, dates are
+// YYYY-MM-DD, amounts are in baht and Sort is a domain.Sort* key, prefixed
+// with "-" to sort descending. Cursor is the NextCursor of the previous
+// page and is only valid with the same sort.
+type DocumentListInput struct {
+	StoreID      string
+	Status       string
+	DocumentType string
+	CustomerID   string
+	IssuedFrom   string
+	IssuedTo     string
+	MinTotal     string
+	MaxTotal     string
+	BuyerTaxID   string
+	Currency     string
+	Search       string
+	Sort         string
+	Cursor       string
+	Limit        int
+}
+
+// documentCursor is the position after the last document of a page.
+type documentCursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    uint   `json:"id"`
+}
+
+func (u *documentUC) ListDocuments(ctx context.Context, in DocumentListInput) (*domain.DocumentList, error) {
+	f, page, err := parseDocumentList(in)
+	if err != nil {
+		return nil, err
+	}
+	// read one extra document to learn whether there is a next page
+	limit := page.Limit
+	page.Limit++
+	docs, err := u.repo.ListDocuments(ctx, f, page)
+	if err != nil {
+		return nil, err
+	}
+	totals, err := u.repo.SumDocuments(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &domain.DocumentList{Documents: docs, Totals: totals}
+	if len(docs) > limit {
+		list.Documents = docs[:limit]
+		list.NextCursor = encodeDocumentCursor(page, &docs[limit-1])
+	}
+	if list.Documents == nil {
+		list.Documents = []domain.InvoiceDocument{}
+	}
+	return list, nil
+}
+
+// parseDocumentList validates a list query and turns it into the filter
+// and page the repository reads.
+func parseDocumentList(in DocumentListInput) (repository.DocumentFilter, repository.DocumentPage, error) {
+	bad := apperror.New(fiber.StatusBadRequest)
+	var f repository.DocumentFilter
+	var page repository.DocumentPage
+
+	if _, err := uuid.Parse(in.StoreID); err != nil {
+		return f, page, bad
+	}
+	f.StoreID = in.StoreID
+
+	f.Statuses = splitList(in.Status)
+	for _, s := range f.Statuses {
+		if !domain.IsValidStatus(s) {
+			return f, page, bad
+		}
+	}
+	f.DocumentTypes = splitList(in.DocumentType)
+
+	if in.CustomerID != "" {
+		id, err := strconv.ParseUint(in.CustomerID, 10, 64)
+		if err != nil || id == 0 {
+			return f, page, bad
+		}
+		f.CustomerID = uint(id)
+	}
+
+	var err error
+	if f.IssuedFrom, err = parseListDate(in.IssuedFrom); err != nil {
+		return f, page, bad
+	}
+	if f.IssuedTo, err = parseListDate(in.IssuedTo); err != nil {
+		return f, page, bad
+	}
+	if f.IssuedFrom != nil && f.IssuedTo != nil && f.IssuedTo.Before(*f.IssuedFrom) {
+		return f, page, bad
+	}
+	if f.MinTotal, err = parseListAmount(in.MinTotal); err != nil {
+		return f, page, bad
+	}
+	if f.MaxTotal, err = parseListAmount(in.MaxTotal); err != nil {
+		return f, page, bad
+	}
+	if f.MinTotal != nil && f.MaxTotal != nil && *f.MaxTotal < *f.MinTotal {
+		return f, page, bad
+	}
+	f.BuyerTaxID = strings.TrimSpace(in.BuyerTaxID)
+	if in.Currency != "" {
+		if f.Currency, err = normalizeCurrency(in.Currency); err != nil {
+			return f, page, bad
+		}
+	}
+	f.Search = strings.TrimSpace(in.Search)
+
+	sort := in.Sort
+	if sort == "" {
+		sort = defaultDocumentSort
+	}
+	page.Sort = strings.TrimPrefix(sort, "-")
+	page.Desc = page.Sort != sort
+	if !domain.IsDocumentSort(page.Sort) {
+		return f, page, bad
+	}
+
+	switch {
+	case in.Limit < 0:
+		return f, page, bad
+	case in.Limit == 0:
+		page.Limit = defaultDocumentListLimit
+	case in.Limit > maxDocumentListLimit:
+		page.Limit = maxDocumentListLimit
+	default:
+		page.Limit = in.Limit
+	}
+
+	if in.Cursor != "" {
+		c, err := decodeDocumentCursor(in.Cursor)
+		if err != nil || c.Sort != sort || c.ID == 0 {
+			return f, page, bad
+		}
+		if page.After, err = cursorValue(page.Sort, c.Value); err != nil {
+			return f, page, bad
+		}
+		page.AfterID = c.ID
+	}
+	return f, page, nil
+}
+
+// splitList splits a comma-separated query value, dropping empty entries.
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func parseListDate(s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func parseListAmount(s string) (*money.Amount, error) {
+	if s == "" {
+		return nil, nil
+	}
+	a, err := money.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// sortValue returns the value of the sort column of doc as kept in a
+// cursor.
+func sortValue(key string, doc *domain.InvoiceDocument) string {
+	switch key {
+	case domain.SortIssueDate:
+		return doc.IssueDate.Format("2006-01-02")
+	case domain.SortCreatedAt:
+		return doc.CreatedAt.Format(time.RFC3339Nano)
+	case domain.SortDocumentNo:
+		return doc.DocumentNo
+	case domain.SortGrandTotal:
+		return doc.GrandTotal.String()
+	}
+	return ""
+}
+
+// cursorValue converts a cursor value back to the type of its column.
+func cursorValue(key, v string) (interface{}, error) {
+	switch key {
+	case domain.SortIssueDate:
+		if _, err := time.Parse("2006-01-02", v); err != nil {
+			return nil, err
+		}
+		return v, nil
+	case domain.SortCreatedAt:
+		return time.Parse(time.RFC3339Nano, v)
+	case domain.SortGrandTotal:
+		return money.Parse(v)
+	}
+	return v, nil
+}
+
+func encodeDocumentCursor(page repository.DocumentPage, last *domain.InvoiceDocument) string {
+	sort := page.Sort
+	if page.Desc {
+		sort = "-" + sort
+	}
+	b, _ := json.Marshal(documentCursor{Sort: sort, Value: sortValue(page.Sort, last), ID: last.ID})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeDocumentCursor(s string) (documentCursor, error) {
+	var c documentCursor
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, err
+	}
+	err = json.Unmarshal(b, &c)
+	return c, err
+}
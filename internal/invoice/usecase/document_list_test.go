@@ -0,0 +1,121 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"invoice_project/internal/invoice/domain"
+	"invoice_project/pkg/money"
+)
+
+const listStore = "6f1c2d4e-8a7b-4c3d-9e2f-1a2b3c4d5e6f"
+
+func TestParseDocumentList_Defaults(t *testing.T) {
+	f, page, err := parseDocumentList(DocumentListInput{StoreID: listStore})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.StoreID != listStore || f.Statuses != nil || f.IssuedFrom != nil || f.MinTotal != nil {
+		t.Fatalf("unexpected filter %+v", f)
+	}
+	if page.Sort != domain.SortIssueDate || !page.Desc || page.Limit != defaultDocumentListLimit || page.After != nil {
+		t.Fatalf("unexpected page %+v", page)
+	}
+}
+
+func TestParseDocumentList_Filters(t *testing.T) {
+	f, page, err := parseDocumentList(DocumentListInput{
+		StoreID:      listStore,
+		Status:       "issued, sent,",
+		DocumentType: "tax_invoice",
+		CustomerID:   "42",
+		IssuedFrom:   "2026-10-01",
+		IssuedTo:     "2026-10-31",
+		MinTotal:     "100",
+		MaxTotal:     "2500.50",
+		Search:       "  acme ",
+		Sort:         "grand_total",
+		Limit:        1000,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.Statuses) != 2 || f.Statuses[1] != domain.StatusSent {
+		t.Fatalf("statuses = %v", f.Statuses)
+	}
+	if f.CustomerID != 42 || f.Search != "acme" {
+		t.Fatalf("unexpected filter %+v", f)
+	}
+	if *f.MinTotal != money.FromBaht(100) || *f.MaxTotal != money.MustParse("2500.50") {
+		t.Fatalf("amounts = %v..%v", *f.MinTotal, *f.MaxTotal)
+	}
+	if f.IssuedTo.Day() != 31 {
+		t.Fatalf("issued_to = %v", f.IssuedTo)
+	}
+	if page.Sort != domain.SortGrandTotal || page.Desc || page.Limit != maxDocumentListLimit {
+		t.Fatalf("unexpected page %+v", page)
+	}
+}
+
+func TestParseDocumentList_Invalid(t *testing.T) {
+	cases := map[string]DocumentListInput{
+		"store":        {StoreID: "nope"},
+		"status":       {StoreID: listStore, Status: "archived"},
+		"customer":     {StoreID: listStore, CustomerID: "x"},
+		"date":         {StoreID: listStore, IssuedFrom: "01/10/2026"},
+		"date range":   {StoreID: listStore, IssuedFrom: "2026-10-02", IssuedTo: "2026-10-01"},
+		"amount range": {StoreID: listStore, MinTotal: "10", MaxTotal: "5"},
+		"sort":         {StoreID: listStore, Sort: "buyer_tax_id"},
+		"limit":        {StoreID: listStore, Limit: -1},
+		"cursor":       {StoreID: listStore, Cursor: "!!"},
+	}
+	for name, in := range cases {
+		if _, _, err := parseDocumentList(in); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestDocumentCursor_RoundTrip(t *testing.T) {
+	last := &domain.InvoiceDocument{
+		ID:         7,
+		IssueDate:  time.Date(2026, 10, 5, 0, 0, 0, 0, time.UTC),
+		CreatedAt:  time.Date(2026, 10, 5, 9, 30, 0, 123456000, time.UTC),
+		GrandTotal: money.MustParse("1070.00"),
+	}
+	cases := []struct {
+		sort string
+		want interface{}
+	}{
+		{"-issue_date", "2026-10-05"},
+		{"created_at", last.CreatedAt},
+		{"-grand_total", last.GrandTotal},
+	}
+	for _, tc := range cases {
+		_, page, err := parseDocumentList(DocumentListInput{StoreID: listStore, Sort: tc.sort})
+		if err != nil {
+			t.Fatal(err)
+		}
+		cursor := encodeDocumentCursor(page, last)
+
+		_, next, err := parseDocumentList(DocumentListInput{StoreID: listStore, Sort: tc.sort, Cursor: cursor})
+		if err != nil {
+			t.Fatalf("%s: %v", tc.sort, err)
+		}
+		if next.AfterID != 7 {
+			t.Fatalf("%s: after id = %d", tc.sort, next.AfterID)
+		}
+		if tm, ok := tc.want.(time.Time); ok {
+			if !next.After.(time.Time).Equal(tm) {
+				t.Fatalf("%s: after = %v", tc.sort, next.After)
+			}
+		} else if next.After != tc.want {
+			t.Fatalf("%s: after = %v, want %v", tc.sort, next.After, tc.want)
+		}
+
+		// a cursor only continues the list it was issued for
+		if _, _, err := parseDocumentList(DocumentListInput{StoreID: listStore, Sort: "document_no", Cursor: cursor}); err == nil {
+			t.Fatalf("%s: cursor accepted with another sort", tc.sort)
+		}
+	}
+}
@@ -0,0 +1,301 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	htmltemplate "html/template"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+
+	"invoice_project/internal/invoice/domain"
+	"invoice_project/internal/invoice/repository"
+	"invoice_project/pkg/apperror"
+	"invoice_project/pkg/mailer"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type DocumentSendUsecase interface {
+	SendDocument(ctx context.Context, id uint, in SendInput, sentBy string) (*domain.DocumentDelivery, error)
+	ListDeliveries(ctx context.Context, id uint) ([]domain.DocumentDelivery, error)
+	GetEmailTemplate(ctx context.Context, storeID string) (*domain.StoreEmailTemplate, error)
+	SetEmailTemplate(ctx context.Context, t *domain.StoreEmailTemplate) (*domain.StoreEmailTemplate, error)
+}
+
+// SendInput addresses a document email. To replaces the buyer's email
+// contacts when given; Message is passed to the template as .Message.
+type SendInput struct {
+	To      []string
+	Cc      []string
+	Bcc     []string
+	Message string
+}
+
+// EmailData is what the subject and body templates of a document email
+// are executed with. Currency is the ISO 4217 code GrandTotal is in.
+type EmailData struct {
+	DocumentNo      string
+	DocumentTitle   string
+	DocumentTitleEn string
+	IssueDate       string
+	GrandTotal      string
+	Currency        string
+	BuyerName       string
+	SellerName      string
+	Message         string
+}
+
+// The email stores send their documents with until they set their own
+// template.
+const (
+	DefaultEmailSubject = `{{.DocumentTitle}} เลขที่ {{.DocumentNo}} จาก {{.SellerName}}`
+	DefaultEmailHTML    = `<p>เรียน {{.BuyerName}}</p>
+<p>{{.SellerName}} ขอนำส่ง{{.DocumentTitle}} เลขที่ {{.DocumentNo}} ลงวันที่ {{.IssueDate}} จำนวนเงิน {{.GrandTotal}} {{if eq .Currency "THB"}}บาท{{else}}{{.Currency}}{{end}} ตามไฟล์แนบ</p>
+{{if .Message}}<p>{{.Message}}</p>
+{{end}}<p>ขอแสดงความนับถือ<br>{{.SellerName}}</p>`
+)
+
+type documentSendUC struct {
+	docs      repository.InvoiceDocumentRepository
+	parties   repository.PartyRepository
+	templates repository.DocumentTemplateRepository
+	pdf       DocumentPDFUsecase
+	mailer    mailer.Mailer
+}
+
+// NewDocumentSendUsecase creates the usecase emailing documents to their
+// buyers. Sending is unavailable while m is nil.
+func NewDocumentSendUsecase(docs repository.InvoiceDocumentRepository, parties repository.PartyRepository, templates repository.DocumentTemplateRepository, pdf DocumentPDFUsecase, m mailer.Mailer) DocumentSendUsecase {
+	return &documentSendUC{docs: docs, parties: parties, templates: templates, pdf: pdf, mailer: m}
+}
+
+// SendDocument emails a document as a PDF attachment to the email
+// contacts of its customer, or to in.To, using its store's template. The
+// attempt is recorded whether or not the mail server accepts it; a
+// successful one is logged on the document's timeline and marks an issued
+// document as sent.
+func (u *documentSendUC) SendDocument(ctx context.Context, id uint, in SendInput, sentBy string) (*domain.DocumentDelivery, error) {
+	if id == 0 {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	if u.mailer == nil {
+		return nil, apperror.New(fiber.StatusServiceUnavailable)
+	}
+	doc, err := u.docs.GetDocument(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		return nil, apperror.New(fiber.StatusNotFound)
+	}
+	if !domain.IsSendable(doc.Status) {
+		return nil, apperror.New(fiber.StatusConflict)
+	}
+
+	to := in.To
+	if len(to) == 0 {
+		if to, err = u.buyerEmails(ctx, doc); err != nil {
+			return nil, err
+		}
+		if len(to) == 0 {
+			return nil, apperror.New(fiber.StatusUnprocessableEntity)
+		}
+	}
+	seen := map[string]bool{}
+	var lists [3][]string
+	for i, addrs := range [3][]string{to, in.Cc, in.Bcc} {
+		if lists[i], err = recipients(addrs, seen); err != nil {
+			return nil, err
+		}
+	}
+	if len(lists[0]) == 0 {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+
+	tpl, err := u.emailTemplate(ctx, doc.StoreID)
+	if err != nil {
+		return nil, err
+	}
+	subject, html, err := renderEmail(tpl, emailData(doc, in.Message))
+	if err != nil {
+		return nil, apperror.New(fiber.StatusUnprocessableEntity)
+	}
+	pdf, _, err := u.pdf.RenderPDF(ctx, id, false)
+	if err != nil {
+		return nil, err
+	}
+	name := doc.DocumentNo
+	if name == "" {
+		name = "document-" + strconv.FormatUint(uint64(doc.ID), 10)
+	}
+
+	d := &domain.DocumentDelivery{
+		DocumentID: doc.ID,
+		Channel:    domain.DeliveryChannelEmail,
+		Recipients: lists[0],
+		Cc:         lists[1],
+		Bcc:        lists[2],
+		Subject:    subject,
+		Status:     domain.DeliveryStatusSent,
+		SentBy:     sentBy,
+	}
+	sendErr := u.mailer.Send(ctx, &mailer.Message{
+		To:          d.Recipients,
+		Cc:          d.Cc,
+		Bcc:         d.Bcc,
+		Subject:     subject,
+		HTML:        html,
+		Attachments: []mailer.Attachment{{Filename: name + ".pdf", ContentType: "application/pdf", Data: pdf}},
+	})
+	if sendErr != nil {
+		d.Status = domain.DeliveryStatusFailed
+		d.Error = sendErr.Error()
+	}
+	if err := u.docs.RecordDelivery(ctx, d); err != nil {
+		return nil, err
+	}
+	if sendErr != nil {
+		return nil, apperror.New(fiber.StatusServiceUnavailable)
+	}
+	return d, nil
+}
+
+// ListDeliveries returns the delivery attempts of a document, newest
+// first.
+func (u *documentSendUC) ListDeliveries(ctx context.Context, id uint) ([]domain.DocumentDelivery, error) {
+	if id == 0 {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	return u.docs.ListDeliveries(ctx, id)
+}
+
+// GetEmailTemplate returns the email template of a store, falling back to
+// the default one.
+func (u *documentSendUC) GetEmailTemplate(ctx context.Context, storeID string) (*domain.StoreEmailTemplate, error) {
+	if storeID == "" {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	return u.emailTemplate(ctx, &storeID)
+}
+
+// SetEmailTemplate saves the email template of a store. Templates that do
+// not parse, or fail on a sample document, are rejected.
+func (u *documentSendUC) SetEmailTemplate(ctx context.Context, t *domain.StoreEmailTemplate) (*domain.StoreEmailTemplate, error) {
+	t.Subject = strings.TrimSpace(t.Subject)
+	if t.StoreID == "" || t.Subject == "" || strings.TrimSpace(t.HTML) == "" {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	sample := EmailData{
+		DocumentNo:    "INV-0001",
+		DocumentTitle: "ใบแจ้งหนี้",
+		IssueDate:     "01/01/2569",
+		GrandTotal:    "1,000.00",
+		BuyerName:     "ลูกค้า",
+		SellerName:    "ร้านค้า",
+		Message:       "ข้อความ",
+	}
+	if _, _, err := renderEmail(t, sample); err != nil {
+		return nil, apperror.New(fiber.StatusUnprocessableEntity)
+	}
+	if err := u.templates.SaveEmailTemplate(ctx, t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (u *documentSendUC) emailTemplate(ctx context.Context, storeID *string) (*domain.StoreEmailTemplate, error) {
+	t := &domain.StoreEmailTemplate{Subject: DefaultEmailSubject, HTML: DefaultEmailHTML}
+	if storeID == nil {
+		return t, nil
+	}
+	t.StoreID = *storeID
+	saved, err := u.templates.GetEmailTemplate(ctx, *storeID)
+	if err != nil {
+		return nil, err
+	}
+	if saved != nil {
+		t = saved
+	}
+	return t, nil
+}
+
+// buyerEmails returns the email contacts of the document's customer.
+// Contacts that are not valid addresses are skipped.
+func (u *documentSendUC) buyerEmails(ctx context.Context, doc *domain.InvoiceDocument) ([]string, error) {
+	if doc.CustomerID == nil {
+		return nil, nil
+	}
+	c, err := u.parties.GetCustomer(ctx, *doc.CustomerID)
+	if err != nil || c == nil {
+		return nil, err
+	}
+	var emails []string
+	for _, contact := range c.CustomerContact {
+		if !strings.EqualFold(strings.TrimSpace(contact.ContactType), domain.ContactTypeEmail) {
+			continue
+		}
+		if addr, err := mailer.ParseAddress(strings.TrimSpace(contact.ContactValue)); err == nil {
+			emails = append(emails, addr)
+		}
+	}
+	return emails, nil
+}
+
+// recipients validates addrs and returns their bare addresses, leaving
+// out blanks and addresses already in seen.
+func recipients(addrs []string, seen map[string]bool) ([]string, error) {
+	var out []string
+	for _, a := range addrs {
+		a = strings.TrimSpace(a)
+		if a == "" {
+			continue
+		}
+		addr, err := mailer.ParseAddress(a)
+		if err != nil {
+			return nil, apperror.New(fiber.StatusBadRequest)
+		}
+		if key := strings.ToLower(addr); !seen[key] {
+			seen[key] = true
+			out = append(out, addr)
+		}
+	}
+	return out, nil
+}
+
+func emailData(doc *domain.InvoiceDocument, message string) EmailData {
+	th, en := domain.DocumentTitle(doc.DocumentType)
+	return EmailData{
+		DocumentNo:      doc.DocumentNo,
+		DocumentTitle:   th,
+		DocumentTitleEn: en,
+		IssueDate:       ThaiDate(doc.IssueDate),
+		GrandTotal:      doc.GrandTotal.Format(),
+		Currency:        doc.Currency,
+		BuyerName:       PartyName(doc.BuyerType, doc.BuyerCompanyName, doc.BuyerFirstName, doc.BuyerLastName),
+		SellerName:      PartyName(doc.SellerType, doc.SellerCompanyName, doc.SellerFirstName, doc.SellerLastName),
+		Message:         strings.TrimSpace(message),
+	}
+}
+
+// renderEmail executes the subject of t as text and its body as HTML, so
+// values are escaped in the body. Line breaks in the subject are folded
+// into spaces.
+func renderEmail(t *domain.StoreEmailTemplate, data EmailData) (subject, html string, err error) {
+	st, err := texttemplate.New("subject").Option("missingkey=error").Parse(t.Subject)
+	if err != nil {
+		return "", "", err
+	}
+	ht, err := htmltemplate.New("body").Option("missingkey=error").Parse(t.HTML)
+	if err != nil {
+		return "", "", err
+	}
+	var sb, hb bytes.Buffer
+	if err := st.Execute(&sb, data); err != nil {
+		return "", "", err
+	}
+	if err := ht.Execute(&hb, data); err != nil {
+		return "", "", err
+	}
+	return strings.Join(strings.Fields(sb.String()), " "), hb.String(), nil
+}
@@ -0,0 +1,255 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	customerDomain "invoice_project/internal/customer/domain"
+	"invoice_project/internal/invoice/domain"
+	"invoice_project/internal/invoice/repository"
+	"invoice_project/pkg/mailer"
+	"invoice_project/pkg/mailer/mailertest"
+	"invoice_project/pkg/money"
+)
+
+// sendRepo holds one document and records its deliveries.
+type sendRepo struct {
+	repository.InvoiceDocumentRepository
+	doc        domain.InvoiceDocument
+	deliveries []domain.DocumentDelivery
+}
+
+func (r *sendRepo) GetDocument(ctx context.Context, id uint) (*domain.InvoiceDocument, error) {
+	if id != r.doc.ID {
+		return nil, nil
+	}
+	doc := r.doc
+	return &doc, nil
+}
+
+func (r *sendRepo) RecordDelivery(ctx context.Context, d *domain.DocumentDelivery) error {
+	r.deliveries = append(r.deliveries, *d)
+	if d.Status == domain.DeliveryStatusSent && r.doc.Status == domain.StatusIssued {
+		r.doc.Status = domain.StatusSent
+	}
+	return nil
+}
+
+type sendParties struct {
+	repository.PartyRepository
+	customer customerDomain.Customer
+}
+
+func (p *sendParties) GetCustomer(ctx context.Context, id uint) (*customerDomain.Customer, error) {
+	c := p.customer
+	return &c, nil
+}
+
+type sendTemplates struct {
+	repository.DocumentTemplateRepository
+	email *domain.StoreEmailTemplate
+}
+
+func (t *sendTemplates) GetEmailTemplate(ctx context.Context, storeID string) (*domain.StoreEmailTemplate, error) {
+	return t.email, nil
+}
+
+func (t *sendTemplates) SaveEmailTemplate(ctx context.Context, e *domain.StoreEmailTemplate) error {
+	t.email = e
+	return nil
+}
+
+type sendPDF struct {
+	DocumentPDFUsecase
+}
+
+func (sendPDF) RenderPDF(ctx context.Context, id uint, copy bool) ([]byte, *domain.InvoiceDocument, error) {
+	return []byte("%PDF-1.4 document"), nil, nil
+}
+
+func newSendUC(t *testing.T) (*documentSendUC, *sendRepo, *mailertest.Server) {
+	t.Helper()
+	srv, err := mailertest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { srv.Close() })
+
+	store := "6f1c2d4e-8a7b-4c3d-9e2f-1a2b3c4d5e6f"
+	customerID := uint(7)
+	repo := &sendRepo{doc: domain.InvoiceDocument{
+		ID:                1,
+		DocumentType:      domain.DocumentTypeTaxInvoice,
+		DocumentNo:        "TIV-2026-000001",
+		StoreID:           &store,
+		CustomerID:        &customerID,
+		IssueDate:         time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+		Status:            domain.StatusIssued,
+		BuyerType:         "company",
+		BuyerCompanyName:  "ACME <Thailand>",
+		SellerType:        "company",
+		SellerCompanyName: "Example Co., Ltd.",
+		Currency:          domain.CurrencyTHB,
+		GrandTotal:        money.FromBaht(1070),
+	}}
+	parties := &sendParties{customer: customerDomain.Customer{ID: customerID, CustomerContact: []customerDomain.CustomerContact{
+		{ContactType: "phone", ContactValue: "021234567"},
+		{ContactType: "Email", ContactValue: " billing@acme.example "},
+		{ContactType: "email", ContactValue: "not an address"},
+	}}}
+	uc := &documentSendUC{
+		docs:      repo,
+		parties:   parties,
+		templates: &sendTemplates{},
+		pdf:       sendPDF{},
+		mailer:    mailer.NewSMTPMailer(srv.Host, srv.Port, "", "", "Example <noreply@example.com>"),
+	}
+	return uc, repo, srv
+}
+
+func TestSendDocument_EmailsBuyerContacts(t *testing.T) {
+	uc, repo, srv := newSendUC(t)
+
+	d, err := uc.SendDocument(context.Background(), 1, SendInput{
+		Cc:  []string{"sales@example.com", "billing@acme.example"},
+		Bcc: []string{"archive@example.com"},
+	}, "u")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(d.Recipients, ",") != "billing@acme.example" || strings.Join(d.Cc, ",") != "sales@example.com" {
+		t.Fatalf("delivery = %+v", d)
+	}
+	if d.Subject != "ใบกำกับภาษี เลขที่ TIV-2026-000001 จาก Example Co., Ltd." {
+		t.Errorf("subject = %q", d.Subject)
+	}
+	if len(repo.deliveries) != 1 || repo.deliveries[0].Status != domain.DeliveryStatusSent || repo.doc.Status != domain.StatusSent {
+		t.Errorf("deliveries = %+v, status %s", repo.deliveries, repo.doc.Status)
+	}
+
+	mails := srv.Messages()
+	if len(mails) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(mails))
+	}
+	want := "billing@acme.example,sales@example.com,archive@example.com"
+	if got := strings.Join(mails[0].To, ","); got != want {
+		t.Errorf("envelope recipients = %s, want %s", got, want)
+	}
+	if !strings.Contains(string(mails[0].Data), `filename=TIV-2026-000001.pdf`) {
+		t.Errorf("PDF not attached:\n%s", mails[0].Data)
+	}
+}
+
+func TestSendDocument_StoreTemplateEscapesHTML(t *testing.T) {
+	uc, _, _ := newSendUC(t)
+	uc.templates = &sendTemplates{email: &domain.StoreEmailTemplate{
+		Subject: "{{.DocumentNo}}\n{{.BuyerName}}",
+		HTML:    "<p>{{.BuyerName}}: {{.Message}}</p>",
+	}}
+	tpl, err := uc.emailTemplate(context.Background(), uc.docs.(*sendRepo).doc.StoreID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := uc.docs.(*sendRepo).doc
+	subject, html, err := renderEmail(tpl, emailData(&doc, " thanks "))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subject != "TIV-2026-000001 ACME <Thailand>" {
+		t.Errorf("subject = %q", subject)
+	}
+	if html != "<p>ACME &lt;Thailand&gt;: thanks</p>" {
+		t.Errorf("html = %q", html)
+	}
+}
+
+func TestSendDocument_Errors(t *testing.T) {
+	ctx := context.Background()
+
+	uc, repo, _ := newSendUC(t)
+	repo.doc.Status = domain.StatusDraft
+	if _, err := uc.SendDocument(ctx, 1, SendInput{}, "u"); statusCode(err) != 409 {
+		t.Errorf("draft: got %v", err)
+	}
+
+	uc, repo, _ = newSendUC(t)
+	repo.doc.CustomerID = nil
+	if _, err := uc.SendDocument(ctx, 1, SendInput{}, "u"); statusCode(err) != 422 {
+		t.Errorf("no recipients: got %v", err)
+	}
+	if _, err := uc.SendDocument(ctx, 1, SendInput{To: []string{"nope"}}, "u"); statusCode(err) != 400 {
+		t.Errorf("invalid address: got %v", err)
+	}
+
+	uc, repo, srv := newSendUC(t)
+	srv.Reject("billing@acme.example")
+	if _, err := uc.SendDocument(ctx, 1, SendInput{}, "u"); statusCode(err) != 503 {
+		t.Errorf("rejected: got %v", err)
+	}
+	if len(repo.deliveries) != 1 || repo.deliveries[0].Status != domain.DeliveryStatusFailed || repo.deliveries[0].Error == "" {
+		t.Errorf("failed delivery not recorded: %+v", repo.deliveries)
+	}
+	if repo.doc.Status != domain.StatusIssued {
+		t.Errorf("status = %s, want issued", repo.doc.Status)
+	}
+
+	uc, _, _ = newSendUC(t)
+	uc.mailer = nil
+	if _, err := uc.SendDocument(ctx, 1, SendInput{}, "u"); statusCode(err) != 503 {
+		t.Errorf("no mailer: got %v", err)
+	}
+}
+
+func TestSetEmailTemplate(t *testing.T) {
+	uc, _, _ := newSendUC(t)
+	ctx := context.Background()
+	store := "6f1c2d4e-8a7b-4c3d-9e2f-1a2b3c4d5e6f"
+
+	bad := []*domain.StoreEmailTemplate{
+		{StoreID: store, Subject: "{{.DocumentNo", HTML: "<p></p>"},
+		{StoreID: store, Subject: "x", HTML: "{{.DueDate}}"},
+	}
+	for _, tpl := range bad {
+		if _, err := uc.SetEmailTemplate(ctx, tpl); statusCode(err) != 422 {
+			t.Errorf("%+v: got %v", tpl, err)
+		}
+	}
+	if _, err := uc.SetEmailTemplate(ctx, &domain.StoreEmailTemplate{StoreID: store, HTML: "x"}); statusCode(err) != 400 {
+		t.Errorf("empty subject: got %v", err)
+	}
+
+	got, err := uc.SetEmailTemplate(ctx, &domain.StoreEmailTemplate{StoreID: store, Subject: " {{.DocumentNo}} ", HTML: "<p>{{.GrandTotal}}</p>"})
+	if err != nil || got.Subject != "{{.DocumentNo}}" {
+		t.Fatalf("got %+v, %v", got, err)
+	}
+	if saved, _ := uc.GetEmailTemplate(ctx, store); saved.HTML != "<p>{{.GrandTotal}}</p>" {
+		t.Errorf("saved = %+v", saved)
+	}
+}
+
+func TestRenderEmail_DefaultTemplateCurrency(t *testing.T) {
+	tpl := &domain.StoreEmailTemplate{Subject: DefaultEmailSubject, HTML: DefaultEmailHTML}
+	doc := &domain.InvoiceDocument{
+		DocumentType: domain.DocumentTypeInvoice,
+		DocumentNo:   "INV-2026-000001",
+		Currency:     domain.CurrencyTHB,
+		GrandTotal:   money.FromBaht(1070),
+	}
+	_, html, err := renderEmail(tpl, emailData(doc, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(html, "1,070.00 บาท") {
+		t.Errorf("baht total missing:\n%s", html)
+	}
+
+	doc.Currency = "USD"
+	if _, html, err = renderEmail(tpl, emailData(doc, "")); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(html, "1,070.00 USD") || strings.Contains(html, "บาท") {
+		t.Errorf("USD total mislabelled:\n%s", html)
+	}
+}
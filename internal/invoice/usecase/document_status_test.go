@@ -0,0 +1,257 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"invoice_project/internal/invoice/domain"
+	"invoice_project/internal/invoice/repository"
+	"invoice_project/pkg/money"
+)
+
+func TestCanTransition(t *testing.T) {
+	inv, qt := domain.DocumentTypeInvoice, domain.DocumentTypeQuotation
+	tests := []struct {
+		docType, from, to string
+		want              bool
+	}{
+		{inv, domain.StatusDraft, domain.StatusIssued, true},
+		{inv, domain.StatusDraft, domain.StatusCancelled, true},
+		{inv, domain.StatusDraft, domain.StatusSent, false},
+		{inv, domain.StatusIssued, domain.StatusSent, true},
+		{inv, domain.StatusIssued, domain.StatusVoid, true},
+		{inv, domain.StatusIssued, domain.StatusCancelled, false},
+		{inv, domain.StatusIssued, domain.StatusDraft, false},
+		{inv, domain.StatusIssued, domain.StatusPaid, false},
+		{inv, domain.StatusSent, domain.StatusPartiallyPaid, false},
+		{inv, domain.StatusPartiallyPaid, domain.StatusPaid, false},
+		{inv, domain.StatusPartiallyPaid, domain.StatusVoid, true},
+		{inv, domain.StatusPaid, domain.StatusVoid, true},
+		{inv, domain.StatusVoid, domain.StatusIssued, false},
+		{inv, domain.StatusCancelled, domain.StatusDraft, false},
+		{inv, domain.StatusIssued, domain.StatusAccepted, false},
+		{qt, domain.StatusDraft, domain.StatusIssued, true},
+		{qt, domain.StatusIssued, domain.StatusAccepted, true},
+		{qt, domain.StatusSent, domain.StatusRejected, true},
+		{qt, domain.StatusSent, domain.StatusExpired, true},
+		{qt, domain.StatusIssued, domain.StatusCancelled, true},
+		{qt, domain.StatusIssued, domain.StatusPaid, false},
+		{qt, domain.StatusIssued, domain.StatusVoid, false},
+		{qt, domain.StatusAccepted, domain.StatusRejected, false},
+		{qt, domain.StatusExpired, domain.StatusAccepted, false},
+		{inv, "unknown", domain.StatusIssued, false},
+	}
+	for _, tt := range tests {
+		if got := domain.CanTransition(tt.docType, tt.from, tt.to); got != tt.want {
+			t.Errorf("CanTransition(%s, %s, %s) = %v, want %v", tt.docType, tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestAllowedTransitions_ReturnsCopy(t *testing.T) {
+	got := domain.AllowedTransitions(domain.DocumentTypeInvoice, domain.StatusDraft)
+	if len(got) != 2 {
+		t.Fatalf("AllowedTransitions(draft) = %v", got)
+	}
+	got[0] = domain.StatusPaid
+	if domain.CanTransition(domain.DocumentTypeInvoice, domain.StatusDraft, domain.StatusPaid) {
+		t.Error("changing the returned slice changed the lifecycle")
+	}
+}
+
+// statusRepo keeps a single document in memory for status changes. A
+// note is checked against original and the prior notes when issued.
+type statusRepo struct {
+	repository.InvoiceDocumentRepository
+	doc      domain.InvoiceDocument
+	tl       *domain.DocumentTimeline
+	original *domain.InvoiceDocument
+	prior    repository.AdjustmentTotals
+}
+
+func (r *statusRepo) GetDocument(ctx context.Context, id uint) (*domain.InvoiceDocument, error) {
+	if id != r.doc.ID {
+		return nil, nil
+	}
+	doc := r.doc
+	return &doc, nil
+}
+
+func (r *statusRepo) UpdateStatus(ctx context.Context, id uint, from, to string, tl *domain.DocumentTimeline) error {
+	tl.OldStatus, tl.NewStatus = from, to
+	r.doc.Status, r.tl = to, tl
+	return nil
+}
+
+func (r *statusRepo) IssueDocument(ctx context.Context, doc *domain.InvoiceDocument, tl *domain.DocumentTimeline, check repository.AdjustmentCheck) error {
+	if check != nil {
+		if err := check(r.original, r.prior); err != nil {
+			return err
+		}
+	}
+	tl.OldStatus, tl.NewStatus = r.doc.Status, domain.StatusIssued
+	r.doc = *doc
+	r.doc.Status, r.tl = domain.StatusIssued, tl
+	return nil
+}
+
+func newStatusRepo(docType string) *statusRepo {
+	store := "6f1c2d4e-8a7b-4c3d-9e2f-1a2b3c4d5e6f"
+	return &statusRepo{doc: domain.InvoiceDocument{
+		ID:                7,
+		DocumentType:      docType,
+		StoreID:           &store,
+		IssueDate:         time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+		Status:            domain.StatusDraft,
+		Currency:          domain.CurrencyTHB,
+		BuyerType:         "company",
+		BuyerCompanyName:  "บริษัท ลูกค้า จำกัด",
+		SellerType:        "company",
+		SellerCompanyName: "บริษัท ผู้ขาย จำกัด",
+		GrandTotal:        money.FromBaht(100),
+		Items: []domain.InvoiceItem{
+			{ID: 1, DocumentID: 7, ProductName: "A", Qty: 1, UnitPrice: money.FromBaht(100), VatType: VatTypeExempt, LineTotal: money.FromBaht(100)},
+		},
+	}}
+}
+
+func TestTransitionDocument(t *testing.T) {
+	ctx := context.Background()
+
+	repo := newStatusRepo(domain.DocumentTypeInvoice)
+	uc := &documentUC{repo: repo}
+	doc, err := uc.TransitionDocument(ctx, 7, domain.StatusIssued, "user-1", "ready")
+	if err != nil {
+		t.Fatalf("TransitionDocument returned error: %v", err)
+	}
+	if doc.Status != domain.StatusIssued || doc.ThbGrandTotal != money.FromBaht(100) {
+		t.Errorf("unexpected document: status %s, thb total %s", doc.Status, doc.ThbGrandTotal)
+	}
+	if repo.tl == nil || repo.tl.ChangedBy != "user-1" || repo.tl.Note != "ready" ||
+		repo.tl.OldStatus != domain.StatusDraft || repo.tl.NewStatus != domain.StatusIssued {
+		t.Errorf("unexpected timeline entry: %+v", repo.tl)
+	}
+
+	// issued documents cannot go back or be cancelled, and only payments
+	// mark them paid
+	for _, to := range []string{domain.StatusDraft, domain.StatusCancelled, domain.StatusAccepted, domain.StatusPartiallyPaid, domain.StatusPaid} {
+		repo.tl = nil
+		if _, err := uc.TransitionDocument(ctx, 7, to, "user-1", ""); statusCode(err) != 409 {
+			t.Errorf("issued -> %s: expected 409, got %v", to, err)
+		}
+		if repo.tl != nil {
+			t.Errorf("issued -> %s recorded a timeline entry", to)
+		}
+	}
+	if _, err := uc.TransitionDocument(ctx, 7, domain.StatusSent, "user-1", ""); err != nil {
+		t.Errorf("issued -> sent returned error: %v", err)
+	}
+
+	if _, err := uc.TransitionDocument(ctx, 7, "bogus", "user-1", ""); statusCode(err) != 400 {
+		t.Errorf("unknown status: expected 400, got %v", err)
+	}
+	if _, err := uc.TransitionDocument(ctx, 8, domain.StatusSent, "user-1", ""); statusCode(err) != 404 {
+		t.Errorf("missing document: expected 404, got %v", err)
+	}
+}
+
+func TestTransitionDocument_IncompleteDraft(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name   string
+		modify func(d *domain.InvoiceDocument)
+	}{
+		{"no items", func(d *domain.InvoiceDocument) { d.Items = nil }},
+		{"no issue date", func(d *domain.InvoiceDocument) { d.IssueDate = time.Time{} }},
+		{"unnamed item", func(d *domain.InvoiceDocument) { d.Items[0].ProductName = " " }},
+		{"no buyer", func(d *domain.InvoiceDocument) { d.BuyerCompanyName = "" }},
+		{"tax invoice without seller tax ID", func(d *domain.InvoiceDocument) { d.DocumentType = domain.DocumentTypeTaxInvoice }},
+	}
+	for _, tt := range tests {
+		repo := newStatusRepo(domain.DocumentTypeInvoice)
+		tt.modify(&repo.doc)
+		uc := &documentUC{repo: repo}
+		if _, err := uc.TransitionDocument(ctx, 7, domain.StatusIssued, "user-1", ""); statusCode(err) != 422 {
+			t.Errorf("%s: expected 422, got %v", tt.name, err)
+		}
+		if repo.doc.Status != domain.StatusDraft || repo.tl != nil {
+			t.Errorf("%s: draft was issued", tt.name)
+		}
+	}
+}
+
+func TestTransitionDocument_Quotation(t *testing.T) {
+	ctx := context.Background()
+	repo := newStatusRepo(domain.DocumentTypeQuotation)
+	uc := &documentUC{repo: repo}
+	if _, err := uc.TransitionDocument(ctx, 7, domain.StatusIssued, "user-1", ""); err != nil {
+		t.Fatalf("issuing quotation returned error: %v", err)
+	}
+	if _, err := uc.TransitionDocument(ctx, 7, domain.StatusPaid, "user-1", ""); statusCode(err) != 409 {
+		t.Errorf("quotation cannot be paid: expected 409, got %v", err)
+	}
+
+	// past its validity a quotation can no longer be accepted
+	yesterday := startOfDay(time.Now()).AddDate(0, 0, -1)
+	repo.doc.ValidUntil = &yesterday
+	if _, err := uc.TransitionDocument(ctx, 7, domain.StatusAccepted, "user-1", ""); statusCode(err) != 409 {
+		t.Errorf("expired quotation: expected 409, got %v", err)
+	}
+	if _, err := uc.TransitionDocument(ctx, 7, domain.StatusExpired, "user-1", ""); err != nil {
+		t.Errorf("expiring quotation returned error: %v", err)
+	}
+	if repo.doc.Status != domain.StatusExpired {
+		t.Errorf("status = %s", repo.doc.Status)
+	}
+}
+
+func TestTransitionDocument_AdjustmentNote(t *testing.T) {
+	ctx := context.Background()
+	newNoteRepo := func() *statusRepo {
+		repo := newStatusRepo(domain.DocumentTypeCreditNote)
+		ref := uint(3)
+		repo.doc.ReferenceID = &ref
+		repo.doc.Currency = "USD"
+		repo.doc.GrandTotal, repo.doc.VatAmount = money.FromBaht(107), money.FromBaht(7)
+		repo.doc.SellerTaxID, repo.doc.SellerAddress, repo.doc.BuyerAddress = "0105559999999", "กรุงเทพฯ", "เชียงใหม่"
+		repo.original = adjustedInvoice()
+		repo.original.Currency = "USD"
+		repo.original.ExchangeRate = money.MustParseRate("33.5")
+		return repo
+	}
+
+	// the note takes the rate of the invoice; without a rate repository
+	// issueRate would fail to find one
+	repo := newNoteRepo()
+	uc := &documentUC{repo: repo}
+	doc, err := uc.TransitionDocument(ctx, 7, domain.StatusIssued, "user-1", "")
+	if err != nil {
+		t.Fatalf("issuing credit note returned error: %v", err)
+	}
+	if doc.ExchangeRate != repo.original.ExchangeRate || doc.ThbGrandTotal != money.MustParse("3584.50") {
+		t.Errorf("note rate %s, thb total %s", doc.ExchangeRate, doc.ThbGrandTotal)
+	}
+	if doc.OriginalAmount != money.FromBaht(1000) || doc.CorrectedAmount != money.FromBaht(900) {
+		t.Errorf("note values: original %s, corrected %s", doc.OriginalAmount, doc.CorrectedAmount)
+	}
+
+	// the invoice was voided after the draft was saved
+	repo = newNoteRepo()
+	repo.original.Status = domain.StatusVoid
+	uc = &documentUC{repo: repo}
+	if _, err := uc.TransitionDocument(ctx, 7, domain.StatusIssued, "user-1", ""); statusCode(err) != 409 {
+		t.Errorf("voided invoice: expected 409, got %v", err)
+	}
+
+	// other notes issued in the meantime already credit nearly everything
+	repo = newNoteRepo()
+	repo.prior = repository.AdjustmentTotals{Credited: money.FromBaht(1000), CreditedNet: money.MustParse("934.58")}
+	uc = &documentUC{repo: repo}
+	if _, err := uc.TransitionDocument(ctx, 7, domain.StatusIssued, "user-1", ""); statusCode(err) != 422 {
+		t.Errorf("over-credit: expected 422, got %v", err)
+	}
+	if repo.doc.Status != domain.StatusDraft {
+		t.Errorf("rejected note was issued")
+	}
+}
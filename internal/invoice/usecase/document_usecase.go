@@ -5,30 +5,147 @@ import (
 	"invoice_project/internal/invoice/domain"
 	"invoice_project/internal/invoice/repository"
 	"invoice_project/pkg/apperror"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 type InvoiceDocumentUsecase interface {
-	CreateDocument(ctx context.Context, doc *domain.InvoiceDocument, items []domain.InvoiceItem) error
+	CreateDocument(ctx context.Context, doc *domain.InvoiceDocument, items []domain.InvoiceItem, changedBy string) error
 	GetDocument(ctx context.Context, id uint) (*domain.InvoiceDocument, error)
+	TransitionDocument(ctx context.Context, id uint, to, changedBy, note string) (*domain.InvoiceDocument, error)
+	ListDocuments(ctx context.Context, in DocumentListInput) (*domain.DocumentList, error)
+	UpdateDraft(ctx context.Context, id uint, version int, doc *domain.InvoiceDocument, items []domain.InvoiceItem, changedBy string) (*domain.InvoiceDocument, error)
+	AddItem(ctx context.Context, id uint, version int, item domain.InvoiceItem, changedBy string) (*domain.InvoiceDocument, error)
+	UpdateItem(ctx context.Context, id, itemID uint, version int, item domain.InvoiceItem, changedBy string) (*domain.InvoiceDocument, error)
+	RemoveItem(ctx context.Context, id, itemID uint, version int, changedBy string) (*domain.InvoiceDocument, error)
+	VoidDocument(ctx context.Context, id uint, in VoidInput, changedBy string) (*domain.VoidResult, error)
 }
 
 type documentUC struct {
-	repo repository.InvoiceDocumentRepository
+	repo        repository.InvoiceDocumentRepository
+	pricingMode PricingMode
+	parties     PartySnapshotter
+	rates       ExchangeRateUsecase
 }
 
-func NewInvoiceDocumentUsecase(repo repository.InvoiceDocumentRepository) InvoiceDocumentUsecase {
-	return &documentUC{repo: repo}
+// NewInvoiceDocumentUsecase creates the document usecase. When parties is
+// not nil new documents take their buyer and seller from the customer and
+// merchant records. Documents in a foreign currency are issued at a rate
+// looked up through rates, or only with a rate agreed on the draft when
+// rates is nil.
+func NewInvoiceDocumentUsecase(repo repository.InvoiceDocumentRepository, pricingMode PricingMode, parties PartySnapshotter, rates ExchangeRateUsecase) InvoiceDocumentUsecase {
+	return &documentUC{repo: repo, pricingMode: pricingMode, parties: parties, rates: rates}
 }
 
-func (u *documentUC) CreateDocument(ctx context.Context, doc *domain.InvoiceDocument, items []domain.InvoiceItem) error {
+func (u *documentUC) CreateDocument(ctx context.Context, doc *domain.InvoiceDocument, items []domain.InvoiceItem, changedBy string) error {
 	if doc == nil {
 		return apperror.New(fiber.StatusBadRequest)
 	}
+	if err := u.prepareDocument(ctx, doc, items); err != nil {
+		return err
+	}
+	if domain.IsAdjustmentNote(doc.DocumentType) {
+		return u.repo.CreateAdjustmentNote(ctx, doc, items, changedBy, adjustmentCheck(doc))
+	}
+	return u.repo.CreateDocument(ctx, doc, items, changedBy)
+}
+
+// prepareDocument validates a new document and fills in what the client
+// does not choose: its parties, validity and totals.
+func (u *documentUC) prepareDocument(ctx context.Context, doc *domain.InvoiceDocument, items []domain.InvoiceItem) error {
 	doc.ID = 0
+	if doc.StoreID == nil || *doc.StoreID == "" || doc.DocumentType == "" {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	// document numbers are assigned by the sequence, never by the client
+	doc.DocumentNumber = 0
+	doc.DocumentNo = ""
+	doc.VoidReason = ""
+	doc.VoidedAt = nil
+
+	// new documents start as a draft or are issued straight away
+	switch doc.Status {
+	case "":
+		doc.Status = domain.StatusDraft
+	case domain.StatusDraft, domain.StatusIssued:
+	default:
+		return apperror.New(fiber.StatusBadRequest)
+	}
+
+	if u.parties != nil {
+		if err := u.parties.Snapshot(ctx, doc); err != nil {
+			return err
+		}
+	}
+
+	sanitizeParties(doc)
+
+	if doc.ExchangeRate < 0 {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	// credit and debit notes are in the currency of the invoice they
+	// adjust unless the client names one
+	if doc.Currency != "" || !domain.IsAdjustmentNote(doc.DocumentType) {
+		currency, err := normalizeCurrency(doc.Currency)
+		if err != nil {
+			return err
+		}
+		doc.Currency = currency
+	}
+	if doc.ExchangeRate == 0 {
+		doc.RateDate = nil
+	}
+
+	if domain.IsAdjustmentNote(doc.DocumentType) {
+		if doc.ReferenceID == nil || *doc.ReferenceID == 0 || strings.TrimSpace(doc.AdjustmentReason) == "" {
+			return apperror.New(fiber.StatusBadRequest)
+		}
+	} else {
+		doc.OriginalAmount = 0
+		doc.CorrectedAmount = 0
+		doc.DifferenceAmount = 0
+		doc.AdjustmentReason = ""
+	}
 
-	// sanitize buyer fields based on buyer type
+	if err := applyValidity(doc); err != nil {
+		return err
+	}
+
+	totals, err := CalculateTotals(doc, items)
+	if err != nil {
+		return err
+	}
+	if err := applyTotals(u.pricingMode, doc, items, totals); err != nil {
+		return err
+	}
+	if doc.Status == domain.StatusIssued {
+		if err := readyToIssue(doc, items); err != nil {
+			return err
+		}
+		// notes take their rate from the invoice in adjustmentCheck
+		if !domain.IsAdjustmentNote(doc.DocumentType) {
+			return u.issueRate(ctx, doc)
+		}
+	}
+	return nil
+}
+
+// issueRate freezes the exchange rate of a document being issued.
+// Quotations are not reported and keep the rate agreed on them, if any,
+// so the invoice they become is converted when it is issued.
+func (u *documentUC) issueRate(ctx context.Context, doc *domain.InvoiceDocument) error {
+	if doc.DocumentType == domain.DocumentTypeQuotation {
+		convertTotals(doc)
+		return nil
+	}
+	return freezeRate(ctx, u.rates, doc)
+}
+
+// sanitizeParties clears the name fields that do not apply to the buyer's
+// and seller's party type.
+func sanitizeParties(doc *domain.InvoiceDocument) {
 	switch doc.BuyerType {
 	case "company":
 		doc.BuyerFirstName = ""
@@ -36,8 +153,6 @@ func (u *documentUC) CreateDocument(ctx context.Context, doc *domain.InvoiceDocu
 	case "person":
 		doc.BuyerCompanyName = ""
 	}
-
-	// sanitize seller fields based on seller type
 	switch doc.SellerType {
 	case "company":
 		doc.SellerFirstName = ""
@@ -45,8 +160,89 @@ func (u *documentUC) CreateDocument(ctx context.Context, doc *domain.InvoiceDocu
 	case "person":
 		doc.SellerCompanyName = ""
 	}
+}
+
+// applyValidity defaults the validity of a quotation; other documents have
+// no validity.
+func applyValidity(doc *domain.InvoiceDocument) error {
+	if doc.DocumentType != domain.DocumentTypeQuotation {
+		doc.ValidUntil = nil
+		return nil
+	}
+	if doc.IssueDate.IsZero() {
+		doc.IssueDate = time.Now()
+	}
+	if doc.ValidUntil == nil {
+		until := doc.IssueDate.AddDate(0, 0, domain.DefaultQuotationValidityDays)
+		doc.ValidUntil = &until
+	}
+	if doc.ValidUntil.Before(doc.IssueDate) {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	return nil
+}
+
+// readyToIssue rejects with 422 a document that lacks details printed on
+// an issued document. Drafts may be saved half-finished, but are checked
+// when they are issued.
+func readyToIssue(doc *domain.InvoiceDocument, items []domain.InvoiceItem) error {
+	incomplete := apperror.New(fiber.StatusUnprocessableEntity)
+	if doc.IssueDate.IsZero() || len(items) == 0 {
+		return incomplete
+	}
+	for _, it := range items {
+		if strings.TrimSpace(it.ProductName) == "" || it.Qty <= 0 {
+			return incomplete
+		}
+	}
+	if strings.TrimSpace(PartyName(doc.BuyerType, doc.BuyerCompanyName, doc.BuyerFirstName, doc.BuyerLastName)) == "" ||
+		strings.TrimSpace(PartyName(doc.SellerType, doc.SellerCompanyName, doc.SellerFirstName, doc.SellerLastName)) == "" {
+		return incomplete
+	}
+	if domain.IsTaxInvoice(doc.DocumentType) &&
+		(strings.TrimSpace(doc.SellerTaxID) == "" || strings.TrimSpace(doc.SellerAddress) == "" || strings.TrimSpace(doc.BuyerAddress) == "") {
+		return incomplete
+	}
+	return nil
+}
+
+// adjustmentCheck validates a credit or debit note against the invoice it
+// references and fills in the original, corrected and difference values,
+// all before VAT as printed on the note. Credits may never exceed the
+// invoice plus any debit notes issued against it.
+func adjustmentCheck(note *domain.InvoiceDocument) repository.AdjustmentCheck {
+	return func(original *domain.InvoiceDocument, prior repository.AdjustmentTotals) error {
+		if !domain.IsAdjustable(original.DocumentType) || !domain.IsIssuedStatus(original.Status) {
+			return apperror.New(fiber.StatusConflict)
+		}
+		if original.StoreID == nil || *original.StoreID != *note.StoreID {
+			return apperror.New(fiber.StatusBadRequest)
+		}
+		// a note is converted at the rate of the invoice it adjusts
+		if note.Currency == "" {
+			note.Currency = original.Currency
+		}
+		if note.Currency != original.Currency {
+			return apperror.New(fiber.StatusBadRequest)
+		}
+		note.ExchangeRate = original.ExchangeRate
+		note.RateDate = original.RateDate
+		convertTotals(note)
 
-	return u.repo.CreateDocument(ctx, doc, items)
+		note.OriginalAmount = original.GrandTotal.Sub(original.VatAmount)
+		note.DifferenceAmount = note.GrandTotal.Sub(note.VatAmount)
+		corrected := note.OriginalAmount.Sub(prior.CreditedNet).Add(prior.DebitedNet)
+		if note.DocumentType == domain.DocumentTypeCreditNote {
+			if prior.Credited.Add(note.GrandTotal) > original.GrandTotal.Add(prior.Debited) {
+				return apperror.New(fiber.StatusUnprocessableEntity)
+			}
+			corrected = corrected.Sub(note.DifferenceAmount)
+		} else {
+			corrected = corrected.Add(note.DifferenceAmount)
+		}
+		note.CorrectedAmount = corrected
+		return nil
+	}
 }
 
 func (u *documentUC) GetDocument(ctx context.Context, id uint) (*domain.InvoiceDocument, error) {
@@ -55,3 +251,57 @@ func (u *documentUC) GetDocument(ctx context.Context, id uint) (*domain.InvoiceD
 	}
 	return u.repo.GetDocument(ctx, id)
 }
+
+// TransitionDocument moves a document to a new status following the
+// lifecycle in domain.CanTransition and records who made the change.
+// Voiding requires note as the reason and issues no replacement.
+func (u *documentUC) TransitionDocument(ctx context.Context, id uint, to, changedBy, note string) (*domain.InvoiceDocument, error) {
+	if id == 0 || !domain.IsValidStatus(to) {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	if to == domain.StatusVoid {
+		res, err := u.VoidDocument(ctx, id, VoidInput{Reason: note}, changedBy)
+		if err != nil {
+			return nil, err
+		}
+		return res.Voided, nil
+	}
+	doc, err := u.repo.GetDocument(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		return nil, apperror.New(fiber.StatusNotFound)
+	}
+	if !domain.CanTransition(doc.DocumentType, doc.Status, to) {
+		return nil, apperror.New(fiber.StatusConflict)
+	}
+	var check repository.AdjustmentCheck
+	if to == domain.StatusIssued {
+		if err := readyToIssue(doc, doc.Items); err != nil {
+			return nil, err
+		}
+		// a note is checked again against the invoice it adjusts, which
+		// also gives it the invoice's rate
+		if domain.IsAdjustmentNote(doc.DocumentType) {
+			check = adjustmentCheck(doc)
+		} else if err := u.issueRate(ctx, doc); err != nil {
+			return nil, err
+		}
+	}
+	// a quotation past its validity can no longer be accepted
+	if doc.DocumentType == domain.DocumentTypeQuotation && to == domain.StatusAccepted &&
+		quotationExpired(doc, startOfDay(time.Now())) {
+		return nil, apperror.New(fiber.StatusConflict)
+	}
+	tl := &domain.DocumentTimeline{ChangedBy: changedBy, Note: note}
+	if to == domain.StatusIssued {
+		err = u.repo.IssueDocument(ctx, doc, tl, check)
+	} else {
+		err = u.repo.UpdateStatus(ctx, id, doc.Status, to, tl)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return u.repo.GetDocument(ctx, id)
+}
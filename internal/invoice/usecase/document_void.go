@@ -0,0 +1,113 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"invoice_project/internal/invoice/domain"
+	"invoice_project/pkg/apperror"
+	"invoice_project/pkg/money"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// VoidInput voids a document for Reason. With Replace set, or a Document
+// given, a replacement of the same type is issued in the same transaction
+// (ออกใบกำกับภาษีฉบับใหม่แทนฉบับเดิม): Document and Items give its content,
+// or the voided document and its items are copied when Document is nil.
+type VoidInput struct {
+	Reason   string
+	Replace  bool
+	Document *domain.InvoiceDocument
+	Items    []domain.InvoiceItem
+}
+
+// VoidDocument voids an issued document. Payments allocated to it move to
+// its replacement, so a paid document can only be voided with an issued
+// replacement in the same currency whose total covers them; otherwise
+// refund the customer with a credit note.
+func (u *documentUC) VoidDocument(ctx context.Context, id uint, in VoidInput, changedBy string) (*domain.VoidResult, error) {
+	reason := strings.TrimSpace(in.Reason)
+	if id == 0 || reason == "" {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	replace := in.Replace || in.Document != nil
+
+	var replacement *domain.InvoiceDocument
+	err := u.repo.VoidDocument(ctx, id, reason, changedBy, func(ctx context.Context, original *domain.InvoiceDocument, paid money.Amount) (*domain.InvoiceDocument, []domain.InvoiceItem, error) {
+		if !replace {
+			if paid > 0 {
+				return nil, nil, apperror.New(fiber.StatusConflict)
+			}
+			return nil, nil, nil
+		}
+		if !domain.IsReplaceable(original.DocumentType) {
+			return nil, nil, apperror.New(fiber.StatusConflict)
+		}
+		doc, items := replacementOf(original, in.Document, in.Items, startOfDay(time.Now()))
+		if paid > 0 && doc.Status != domain.StatusIssued {
+			return nil, nil, apperror.New(fiber.StatusConflict)
+		}
+		if err := u.prepareDocument(ctx, doc, items); err != nil {
+			return nil, nil, err
+		}
+		// the payments must still settle the replacement
+		if paid > 0 && (doc.Currency != original.Currency || paid > doc.GrandTotal) {
+			return nil, nil, apperror.New(fiber.StatusUnprocessableEntity)
+		}
+		replacement = doc
+		return doc, items, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	res := &domain.VoidResult{}
+	if res.Voided, err = u.repo.GetDocument(ctx, id); err != nil {
+		return nil, err
+	}
+	if replacement != nil {
+		if res.Replacement, err = u.repo.GetDocument(ctx, replacement.ID); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// replacementOf returns the replacement of original: in with its items
+// when given, otherwise a copy of original and its items. The replacement
+// keeps the type and store of original, is issued unless in asks for a
+// draft and is dated issueDate unless in gives a date. Totals of a copy
+// are recomputed.
+func replacementOf(original, in *domain.InvoiceDocument, items []domain.InvoiceItem, issueDate time.Time) (*domain.InvoiceDocument, []domain.InvoiceItem) {
+	var doc domain.InvoiceDocument
+	if in != nil {
+		doc = *in
+	} else {
+		doc = *original
+		doc.IssueDate = time.Time{}
+		doc.Status = ""
+		clearTotals(&doc)
+		items = make([]domain.InvoiceItem, len(original.Items))
+		for i, it := range original.Items {
+			it.ID = 0
+			it.DocumentID = 0
+			it.LineTotal = 0
+			items[i] = it
+		}
+	}
+	doc.DocumentType = original.DocumentType
+	doc.StoreID = original.StoreID
+	doc.ReferenceID = &original.ID
+	doc.CreatedAt = time.Time{}
+	doc.Items = nil
+	doc.Timelines = nil
+	if doc.IssueDate.IsZero() {
+		doc.IssueDate = issueDate
+	}
+	if doc.Status == "" {
+		doc.Status = domain.StatusIssued
+	}
+	return &doc, items
+}
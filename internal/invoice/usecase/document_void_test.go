@@ -0,0 +1,177 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"invoice_project/internal/invoice/domain"
+	"invoice_project/internal/invoice/repository"
+	"invoice_project/pkg/money"
+)
+
+// voidRepo voids a single issued document in memory.
+type voidRepo struct {
+	repository.InvoiceDocumentRepository
+	doc         domain.InvoiceDocument
+	paid        money.Amount
+	replacement *domain.InvoiceDocument
+	items       []domain.InvoiceItem
+}
+
+func (r *voidRepo) VoidDocument(ctx context.Context, id uint, reason, changedBy string, build repository.VoidBuild) error {
+	original := r.doc
+	replacement, items, err := build(ctx, &original, r.paid)
+	if err != nil {
+		return err
+	}
+	if replacement != nil {
+		replacement.ID = 2
+		r.replacement, r.items = replacement, items
+	}
+	r.doc.Status = domain.StatusVoid
+	r.doc.VoidReason = reason
+	return nil
+}
+
+func (r *voidRepo) GetDocument(ctx context.Context, id uint) (*domain.InvoiceDocument, error) {
+	if id == 2 {
+		return r.replacement, nil
+	}
+	doc := r.doc
+	return &doc, nil
+}
+
+func newVoidRepo() *voidRepo {
+	store := "6f1c2d4e-8a7b-4c3d-9e2f-1a2b3c4d5e6f"
+	return &voidRepo{doc: domain.InvoiceDocument{
+		ID:                1,
+		DocumentType:      domain.DocumentTypeInvoice,
+		DocumentNo:        "INV-2026-000001",
+		StoreID:           &store,
+		IssueDate:         time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+		Status:            domain.StatusSent,
+		Currency:          domain.CurrencyTHB,
+		BuyerType:         "company",
+		BuyerCompanyName:  "ACME",
+		SellerType:        "company",
+		SellerCompanyName: "Example Co., Ltd.",
+		GrandTotal:        money.FromBaht(100),
+		Items: []domain.InvoiceItem{
+			{ID: 5, DocumentID: 1, ProductName: "A", Qty: 1, UnitPrice: money.FromBaht(100), VatType: VatTypeExempt, LineTotal: money.FromBaht(100)},
+		},
+	}}
+}
+
+func TestVoidDocument_CopiesReplacement(t *testing.T) {
+	repo := newVoidRepo()
+	repo.paid = money.FromBaht(100)
+	uc := &documentUC{repo: repo, pricingMode: PricingStrict}
+
+	res, err := uc.VoidDocument(context.Background(), 1, VoidInput{Reason: " wrong buyer ", Replace: true}, "u")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Voided.Status != domain.StatusVoid || res.Voided.VoidReason != "wrong buyer" {
+		t.Fatalf("voided = %+v", res.Voided)
+	}
+	r := res.Replacement
+	if r == nil || r.Status != domain.StatusIssued || r.DocumentNo != "" || *r.ReferenceID != 1 {
+		t.Fatalf("replacement = %+v", r)
+	}
+	if r.GrandTotal != money.FromBaht(100) || r.IssueDate.IsZero() {
+		t.Fatalf("replacement total %s, dated %v", r.GrandTotal, r.IssueDate)
+	}
+	if len(repo.items) != 1 || repo.items[0].ID != 0 || repo.items[0].DocumentID != 0 {
+		t.Fatalf("replacement items = %+v", repo.items)
+	}
+}
+
+func TestVoidDocument_Rejections(t *testing.T) {
+	ctx := context.Background()
+	uc := &documentUC{repo: newVoidRepo()}
+	if _, err := uc.VoidDocument(ctx, 1, VoidInput{Reason: "  "}, "u"); statusCode(err) != 400 {
+		t.Fatalf("missing reason: %v", err)
+	}
+	if _, err := uc.TransitionDocument(ctx, 1, domain.StatusVoid, "u", ""); statusCode(err) != 400 {
+		t.Fatalf("transition to void without a reason: %v", err)
+	}
+
+	repo := newVoidRepo()
+	repo.paid = money.FromBaht(100)
+	uc = &documentUC{repo: repo}
+	if _, err := uc.VoidDocument(ctx, 1, VoidInput{Reason: "duplicate"}, "u"); statusCode(err) != 409 {
+		t.Fatalf("paid document voided without a replacement: %v", err)
+	}
+	draft := &domain.InvoiceDocument{Status: domain.StatusDraft}
+	if _, err := uc.VoidDocument(ctx, 1, VoidInput{Reason: "typo", Document: draft}, "u"); statusCode(err) != 409 {
+		t.Fatalf("payments moved to a draft replacement: %v", err)
+	}
+
+	repo = newVoidRepo()
+	repo.doc.DocumentType = domain.DocumentTypeCreditNote
+	uc = &documentUC{repo: repo}
+	if _, err := uc.VoidDocument(ctx, 1, VoidInput{Reason: "typo", Replace: true}, "u"); statusCode(err) != 409 {
+		t.Fatalf("credit note replaced: %v", err)
+	}
+}
+
+func TestVoidDocument_ReplacementMustCoverPayments(t *testing.T) {
+	ctx := context.Background()
+	smaller := []domain.InvoiceItem{{ProductName: "A", Qty: 1, UnitPrice: money.FromBaht(60), VatType: VatTypeExempt}}
+	replacement := func(currency string) *domain.InvoiceDocument {
+		doc := newVoidRepo().doc
+		doc.ID, doc.Status, doc.DocumentNo, doc.Items = 0, "", "", nil
+		doc.Currency = currency
+		doc.ExchangeRate = money.MustParseRate("33.5")
+		clearTotals(&doc)
+		return &doc
+	}
+
+	repo := newVoidRepo()
+	repo.paid = money.FromBaht(100)
+	uc := &documentUC{repo: repo, pricingMode: PricingLenient}
+	if _, err := uc.VoidDocument(ctx, 1, VoidInput{Reason: "price", Document: replacement(domain.CurrencyTHB), Items: smaller}, "u"); statusCode(err) != 422 {
+		t.Fatalf("replacement smaller than its payments: %v", err)
+	}
+	larger := []domain.InvoiceItem{{ProductName: "A", Qty: 1, UnitPrice: money.FromBaht(120), VatType: VatTypeExempt}}
+	if _, err := uc.VoidDocument(ctx, 1, VoidInput{Reason: "currency", Document: replacement("USD"), Items: larger}, "u"); statusCode(err) != 422 {
+		t.Fatalf("replacement in another currency: %v", err)
+	}
+	if repo.replacement != nil {
+		t.Fatal("replacement issued")
+	}
+
+	// a partly paid document may be replaced by one still covering the payments
+	repo.paid = money.FromBaht(60)
+	res, err := uc.VoidDocument(ctx, 1, VoidInput{Reason: "price", Document: replacement(domain.CurrencyTHB), Items: smaller}, "u")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Replacement == nil || res.Replacement.GrandTotal != money.FromBaht(60) {
+		t.Fatalf("replacement = %+v", res.Replacement)
+	}
+}
+
+func TestReplacementOf_KeepsTypeAndStore(t *testing.T) {
+	original := newVoidRepo().doc
+	other := "11111111-2222-3333-4444-555555555555"
+	in := &domain.InvoiceDocument{
+		ID:           9,
+		DocumentType: domain.DocumentTypeReceipt,
+		StoreID:      &other,
+		Status:       domain.StatusDraft,
+		IssueDate:    time.Date(2026, 10, 3, 0, 0, 0, 0, time.UTC),
+	}
+	today := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	doc, _ := replacementOf(&original, in, nil, today)
+	if doc.DocumentType != domain.DocumentTypeInvoice || *doc.StoreID != *original.StoreID || *doc.ReferenceID != 1 {
+		t.Fatalf("replacement = %+v", doc)
+	}
+	if doc.Status != domain.StatusDraft || !doc.IssueDate.Equal(in.IssueDate) {
+		t.Fatalf("status %s, dated %v", doc.Status, doc.IssueDate)
+	}
+	if in.DocumentType != domain.DocumentTypeReceipt {
+		t.Fatal("input modified")
+	}
+}
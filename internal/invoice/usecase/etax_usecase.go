@@ -0,0 +1,290 @@
+package usecase
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"invoice_project/internal/invoice/domain"
+	"invoice_project/internal/invoice/repository"
+	"invoice_project/pkg/apperror"
+	"invoice_project/pkg/etax"
+	"invoice_project/pkg/money"
+	"invoice_project/pkg/xades"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// etaxTypeCodes maps document types to the ETDA document type codes.
+var etaxTypeCodes = map[string]string{
+	domain.DocumentTypeReceipt:            etax.TypeReceipt,
+	domain.DocumentTypeInvoice:            etax.TypeInvoiceTaxInvoice,
+	domain.DocumentTypeReceiptTaxInvoice:  etax.TypeReceiptTaxInvoice,
+	domain.DocumentTypeDeliveryTaxInvoice: etax.TypeDeliveryTaxInvoice,
+	domain.DocumentTypeDebitNote:          etax.TypeDebitNote,
+	domain.DocumentTypeCreditNote:         etax.TypeCreditNote,
+	domain.DocumentTypeTaxInvoice:         etax.TypeTaxInvoice,
+}
+
+// ETaxTypeCode returns the ETDA type code of a document type.
+func ETaxTypeCode(documentType string) (string, bool) {
+	code, ok := etaxTypeCodes[documentType]
+	return code, ok
+}
+
+type ETaxUsecase interface {
+	ExportXML(ctx context.Context, id uint) ([]byte, *domain.InvoiceDocument, error)
+}
+
+// DocumentSigner provides the signing certificate of the merchant owning
+// a store, or nil when the merchant has none.
+type DocumentSigner interface {
+	SignerForStore(storeID string) (*xades.Signer, error)
+}
+
+type etaxUC struct {
+	repo   repository.InvoiceDocumentRepository
+	signer DocumentSigner
+}
+
+// NewETaxUsecase returns the e-Tax usecase. signer may be nil, in which
+// case documents are exported unsigned.
+func NewETaxUsecase(repo repository.InvoiceDocumentRepository, signer DocumentSigner) ETaxUsecase {
+	return &etaxUC{repo: repo, signer: signer}
+}
+
+// ExportXML renders an issued document as e-Tax XML and checks it against
+// the schemas bundled with pkg/etax. Schema violations are returned as
+// *xsd.ValidationError so the caller can report them. When the store's
+// merchant has a certificate the XML is signed with XAdES-BES.
+func (u *etaxUC) ExportXML(ctx context.Context, id uint) ([]byte, *domain.InvoiceDocument, error) {
+	if id == 0 {
+		return nil, nil, apperror.New(fiber.StatusBadRequest)
+	}
+	doc, err := u.repo.GetDocument(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if doc == nil {
+		return nil, nil, apperror.New(fiber.StatusNotFound)
+	}
+	if !domain.IsIssuedStatus(doc.Status) {
+		return nil, nil, apperror.New(fiber.StatusConflict)
+	}
+
+	var original *domain.InvoiceDocument
+	if doc.ReferenceID != nil && (domain.IsAdjustmentNote(doc.DocumentType) || domain.IsReplaceable(doc.DocumentType)) {
+		if original, err = u.repo.GetDocument(ctx, *doc.ReferenceID); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	inv, err := buildETaxInvoice(doc, original)
+	if err != nil {
+		return nil, nil, err
+	}
+	b, err := etax.Marshal(inv)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := etax.Validate(inv.Document.TypeCode, b); err != nil {
+		return nil, nil, err
+	}
+	if u.signer != nil && doc.StoreID != nil {
+		signer, err := u.signer.SignerForStore(*doc.StoreID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if signer != nil {
+			if b, err = signer.Sign(b); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	return b, doc, nil
+}
+
+// buildETaxInvoice maps a document and, for credit and debit notes, the
+// invoice it adjusts or, for a replacement, the voided document it
+// replaces onto the ETDA message structure.
+func buildETaxInvoice(doc *domain.InvoiceDocument, original *domain.InvoiceDocument) (*etax.Invoice, error) {
+	code, ok := ETaxTypeCode(doc.DocumentType)
+	if !ok {
+		return nil, apperror.New(fiber.StatusUnprocessableEntity)
+	}
+	inv, err := etax.NewInvoice(code)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]domain.InvoiceItem, len(doc.Items))
+	copy(items, doc.Items)
+	totals, err := CalculateTotals(doc, items)
+	if err != nil {
+		return nil, err
+	}
+
+	title, _ := domain.DocumentTitle(doc.DocumentType)
+	inv.Document.ID = etax.ID{Value: doc.DocumentNo}
+	inv.Document.Name = title
+	inv.Document.IssueDateTime = etax.DateTime(doc.IssueDate)
+	if doc.Remarks != "" {
+		inv.Document.Notes = []etax.Note{{Content: doc.Remarks}}
+	}
+
+	agreement := &inv.Transaction.Agreement
+	agreement.Seller = etax.Party{
+		Name:    PartyName(doc.SellerType, doc.SellerCompanyName, doc.SellerFirstName, doc.SellerLastName),
+		TaxID:   etaxTaxID(doc.SellerType, doc.SellerTaxID, doc.SellerBranchNo),
+		Address: etaxAddress(doc.SellerAddress),
+	}
+	agreement.Buyer = etax.Party{
+		Name:    PartyName(doc.BuyerType, doc.BuyerCompanyName, doc.BuyerFirstName, doc.BuyerLastName),
+		TaxID:   etaxTaxID(doc.BuyerType, doc.BuyerTaxID, doc.BuyerBranchNo),
+		Address: etaxAddress(doc.BuyerAddress),
+	}
+
+	settlement := &inv.Transaction.Settlement
+	summary := &settlement.Summation
+	summary.LineTotalAmount = doc.Subtotal
+	summary.AllowanceTotalAmount = doc.DiscountAmount
+	summary.TaxBasisTotalAmount = doc.GrandTotal.Sub(doc.VatAmount)
+	summary.TaxTotalAmount = doc.VatAmount
+	summary.GrandTotalAmount = doc.GrandTotal
+
+	if domain.IsAdjustmentNote(doc.DocumentType) {
+		inv.Document.Purpose = doc.AdjustmentReason
+		inv.Document.PurposeCode = etax.PurposeCreditNoteOther
+		if doc.DocumentType == domain.DocumentTypeDebitNote {
+			inv.Document.PurposeCode = etax.PurposeDebitNoteOther
+		}
+		if original != nil {
+			agreement.References = append(agreement.References, etaxReference(original))
+		}
+		// notes carry the original, corrected and difference values
+		originalAmount, difference := doc.OriginalAmount, doc.DifferenceAmount
+		summary.OriginalInformationAmount = &originalAmount
+		summary.LineTotalAmount = doc.CorrectedAmount
+		summary.DifferenceInformationAmount = &difference
+	} else if domain.IsReplacement(doc, original) {
+		inv.Document.Purpose = original.VoidReason
+		inv.Document.PurposeCode = etax.PurposeReplacementOther
+		agreement.References = append(agreement.References, etaxReference(original))
+	}
+
+	vatRate := DefaultVatRate
+	for _, it := range items {
+		if it.VatType == VatTypeInclude || it.VatType == VatTypeExclude {
+			vatRate = it.VatRate
+			break
+		}
+	}
+	if !totals.VatableAmount.IsZero() || (totals.ZeroRatedAmount.IsZero() && totals.ExemptAmount.IsZero()) {
+		basis, vat := totals.VatableAmount, doc.VatAmount
+		settlement.Taxes = append(settlement.Taxes, etax.TradeTax{
+			TypeCode: etax.TaxTypeVAT, CalculatedRate: etaxRate(vatRate),
+			BasisAmount: &basis, CalculatedAmount: &vat,
+		})
+	}
+	if !totals.ZeroRatedAmount.IsZero() {
+		basis, zero := totals.ZeroRatedAmount, money.Zero
+		settlement.Taxes = append(settlement.Taxes, etax.TradeTax{
+			TypeCode: etax.TaxTypeVAT, CalculatedRate: etaxRate(0),
+			BasisAmount: &basis, CalculatedAmount: &zero,
+		})
+	}
+	if !totals.ExemptAmount.IsZero() {
+		basis, zero := totals.ExemptAmount, money.Zero
+		settlement.Taxes = append(settlement.Taxes, etax.TradeTax{
+			TypeCode: etax.TaxTypeExempt, CalculatedRate: etaxRate(0),
+			BasisAmount: &basis, CalculatedAmount: &zero,
+		})
+	}
+	if !doc.DiscountAmount.IsZero() {
+		settlement.Allowances = []etax.AllowanceCharge{{ActualAmount: doc.DiscountAmount}}
+	}
+
+	for i, it := range items {
+		line := etax.LineItem{
+			LineID:       strconv.Itoa(i + 1),
+			Product:      etax.Product{Name: it.ProductName},
+			GrossPrice:   it.UnitPrice,
+			Quantity:     etax.NewQuantity(it.Qty),
+			NetLineTotal: totals.LineTotals[i],
+		}
+		if it.Sku != "" {
+			line.Product.ID = &etax.ID{Value: it.Sku}
+		}
+		tax := etax.TradeTax{TypeCode: etax.TaxTypeVAT, CalculatedRate: etaxRate(it.VatRate)}
+		if it.VatType == VatTypeExempt {
+			tax.TypeCode = etax.TaxTypeExempt
+		}
+		line.Tax = []etax.TradeTax{tax}
+		if !it.Discount.IsZero() {
+			line.Allowances = []etax.AllowanceCharge{{ActualAmount: it.Discount}}
+		}
+		inv.Transaction.Lines = append(inv.Transaction.Lines, line)
+	}
+	return inv, nil
+}
+
+// etaxReference refers to an earlier document.
+func etaxReference(doc *domain.InvoiceDocument) etax.ReferencedDocument {
+	ref := etax.ReferencedDocument{IssuerAssignedID: doc.DocumentNo}
+	ref.ReferenceTypeCode, _ = ETaxTypeCode(doc.DocumentType)
+	issued := etax.DateTime(doc.IssueDate)
+	ref.IssueDateTime = &issued
+	return ref
+}
+
+var (
+	thaiIDRe   = regexp.MustCompile(`^[0-9]{13}$`)
+	branchRe   = regexp.MustCompile(`^[0-9]{5}$`)
+	postcodeRe = regexp.MustCompile(`[0-9]{5}\s*$`)
+)
+
+// etaxTaxID picks the ETDA registration scheme for a party: companies with
+// a 13 digit tax ID are registered at their five digit branch, the head
+// office (00000) when none is known, people use their national ID or
+// passport number.
+func etaxTaxID(partyType, taxID, branch string) *etax.ID {
+	taxID = strings.ReplaceAll(strings.TrimSpace(taxID), "-", "")
+	switch {
+	case taxID == "":
+		return &etax.ID{Value: "N/A", SchemeID: etax.TaxSchemeOther}
+	case thaiIDRe.MatchString(taxID) && partyType == "person":
+		return &etax.ID{Value: taxID, SchemeID: etax.TaxSchemeNationalID}
+	case thaiIDRe.MatchString(taxID):
+		if !branchRe.MatchString(branch) {
+			branch = "00000"
+		}
+		return &etax.ID{Value: taxID + branch, SchemeID: etax.TaxSchemeTaxID}
+	case len(taxID) == 18 && thaiIDRe.MatchString(taxID[:13]):
+		return &etax.ID{Value: taxID, SchemeID: etax.TaxSchemeTaxID}
+	default:
+		return &etax.ID{Value: taxID, SchemeID: etax.TaxSchemePassport}
+	}
+}
+
+// etaxAddress splits a free text address over the two address lines and
+// picks up a trailing postcode.
+func etaxAddress(address string) *etax.Address {
+	address = strings.Join(strings.Fields(address), " ")
+	if address == "" {
+		return nil
+	}
+	postcode := strings.TrimSpace(postcodeRe.FindString(address))
+	lineOne, lineTwo := address, ""
+	if r := []rune(address); len(r) > 256 {
+		lineOne, lineTwo = string(r[:256]), string(r[256:])
+		if r := []rune(lineTwo); len(r) > 256 {
+			lineTwo = string(r[:256])
+		}
+	}
+	return etax.NewAddress(postcode, lineOne, lineTwo)
+}
+
+func etaxRate(rate float64) string {
+	return strconv.FormatFloat(rate, 'f', 2, 64)
+}
@@ -0,0 +1,149 @@
+package usecase
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"invoice_project/internal/invoice/domain"
+	"invoice_project/pkg/etax"
+	"invoice_project/pkg/money"
+	"invoice_project/pkg/xades"
+)
+
+func testCreditNote() (note, original *domain.InvoiceDocument) {
+	refID := uint(10)
+	original = &domain.InvoiceDocument{
+		ID:           refID,
+		DocumentType: domain.DocumentTypeTaxInvoice,
+		DocumentNo:   "TIV-2026-000010",
+		IssueDate:    time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+	}
+	note = &domain.InvoiceDocument{
+		DocumentType:      domain.DocumentTypeCreditNote,
+		DocumentNo:        "CN-2026-000001",
+		ReferenceID:       &refID,
+		IssueDate:         time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		Status:            domain.StatusIssued,
+		SellerType:        "company",
+		SellerCompanyName: "บริษัท ตัวอย่าง จำกัด",
+		SellerTaxID:       "0105555000001",
+		SellerAddress:     "1 ถนนสุขุมวิท กรุงเทพฯ 10110",
+		BuyerType:         "person",
+		BuyerFirstName:    "สมชาย",
+		BuyerLastName:     "ใจดี",
+		BuyerTaxID:        "1234567890123",
+		Subtotal:          money.MustParse("100"),
+		VatAmount:         money.MustParse("7"),
+		GrandTotal:        money.MustParse("107"),
+		OriginalAmount:    money.MustParse("1000"),
+		CorrectedAmount:   money.MustParse("900"),
+		DifferenceAmount:  money.MustParse("100"),
+		AdjustmentReason:  "สินค้าชำรุด",
+		Items: []domain.InvoiceItem{{
+			ProductName: "คืนสินค้า", Qty: 1, UnitPrice: money.MustParse("100"),
+			VatType: VatTypeExclude, LineTotal: money.MustParse("100"),
+		}},
+	}
+
+	return note, original
+}
+
+func TestBuildETaxCreditNote(t *testing.T) {
+	inv, err := buildETaxInvoice(testCreditNote())
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := etax.Marshal(inv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := etax.Validate(etax.TypeCreditNote, b); err != nil {
+		t.Fatalf("%v\n%s", err, b)
+	}
+	for _, want := range []string{
+		"<ram:TypeCode>81</ram:TypeCode>",
+		"<ram:PurposeCode>CDNG99</ram:PurposeCode>",
+		"<ram:IssuerAssignedID>TIV-2026-000010</ram:IssuerAssignedID>",
+		`<ram:ID schemeID="TXID">010555500000100000</ram:ID>`,
+		`<ram:ID schemeID="NIDN">1234567890123</ram:ID>`,
+		"<ram:PostcodeCode>10110</ram:PostcodeCode>",
+		"<ram:OriginalInformationAmount>1000.00</ram:OriginalInformationAmount>",
+		"<ram:DifferenceInformationAmount>100.00</ram:DifferenceInformationAmount>",
+	} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("missing %s", want)
+		}
+	}
+}
+
+func TestSignETaxXML(t *testing.T) {
+	p12, err := os.ReadFile("../../../pkg/xades/testdata/signer.p12")
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := xades.LoadPKCS12(p12, "test-passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	inv, err := buildETaxInvoice(testCreditNote())
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := etax.Marshal(inv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := signer.Sign(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := xades.Verify(signed, xades.VerifyOptions{})
+	if err != nil {
+		t.Fatalf("%v\n%s", err, signed)
+	}
+	if !res.Certificate.Equal(signer.Certificate) {
+		t.Error("verified with a different certificate")
+	}
+}
+
+func TestBuildETaxReplacement(t *testing.T) {
+	note, original := testCreditNote()
+	original.Status = domain.StatusVoid
+	original.VoidReason = "ชื่อผู้ซื้อไม่ถูกต้อง"
+	doc := *note
+	doc.DocumentType = domain.DocumentTypeTaxInvoice
+	doc.DocumentNo = "TIV-2026-000011"
+
+	inv, err := buildETaxInvoice(&doc, original)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := etax.Marshal(inv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := etax.Validate(etax.TypeTaxInvoice, b); err != nil {
+		t.Fatalf("%v\n%s", err, b)
+	}
+	for _, want := range []string{
+		"<ram:PurposeCode>TIVC99</ram:PurposeCode>",
+		"<ram:Purpose>ชื่อผู้ซื้อไม่ถูกต้อง</ram:Purpose>",
+		"<ram:IssuerAssignedID>TIV-2026-000010</ram:IssuerAssignedID>",
+	} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("missing %s", want)
+		}
+	}
+
+	// a document converted from a quotation is no replacement
+	original.Status = domain.StatusAccepted
+	original.DocumentType = domain.DocumentTypeQuotation
+	if inv, err = buildETaxInvoice(&doc, original); err != nil {
+		t.Fatal(err)
+	}
+	if inv.Document.PurposeCode != "" || len(inv.Transaction.Agreement.References) != 0 {
+		t.Fatalf("converted document exported as a replacement: %+v", inv.Document)
+	}
+}
@@ -0,0 +1,214 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"invoice_project/internal/invoice/domain"
+	"invoice_project/internal/invoice/repository"
+	"invoice_project/pkg/apperror"
+	"invoice_project/pkg/botrate"
+	"invoice_project/pkg/money"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type ExchangeRateUsecase interface {
+	SetRate(ctx context.Context, rate *domain.ExchangeRate) (*domain.ExchangeRate, error)
+	ImportRates(ctx context.Context, r io.Reader, column, importedBy string) (*domain.RateImport, error)
+	ListRates(ctx context.Context, in RateListInput) ([]domain.ExchangeRate, error)
+	FreezeRate(ctx context.Context, doc *domain.InvoiceDocument) error
+}
+
+// RateListInput is the query of a rate list request. Dates are
+// YYYY-MM-DD and To is inclusive.
+type RateListInput struct {
+	StoreID  string
+	Currency string
+	From     string
+	To       string
+}
+
+type exchangeRateUC struct {
+	repo repository.ExchangeRateRepository
+}
+
+func NewExchangeRateUsecase(repo repository.ExchangeRateRepository) ExchangeRateUsecase {
+	return &exchangeRateUC{repo: repo}
+}
+
+// SetRate records the rate a store converts a currency at on a date,
+// replacing its earlier rate of that date.
+func (u *exchangeRateUC) SetRate(ctx context.Context, rate *domain.ExchangeRate) (*domain.ExchangeRate, error) {
+	if rate == nil || rate.StoreID == nil {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	if _, err := uuid.Parse(*rate.StoreID); err != nil {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	currency, err := normalizeCurrency(rate.Currency)
+	if err != nil || currency == domain.CurrencyTHB || rate.Rate <= 0 || rate.RateDate.IsZero() {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	rate.Currency = currency
+	rate.RateDate = startOfDay(rate.RateDate)
+	rate.Source = domain.RateSourceManual
+	if err := u.repo.SaveRates(ctx, []domain.ExchangeRate{*rate}); err != nil {
+		return nil, err
+	}
+	return rate, nil
+}
+
+// ImportRates records the rates of a Bank of Thailand download for every
+// store, reading column (botrate.DefaultColumn when empty).
+func (u *exchangeRateUC) ImportRates(ctx context.Context, r io.Reader, column, importedBy string) (*domain.RateImport, error) {
+	parsed, err := botrate.Parse(r, column)
+	if err != nil {
+		if errors.Is(err, botrate.ErrUnknownColumn) {
+			return nil, apperror.New(fiber.StatusBadRequest)
+		}
+		return nil, apperror.New(fiber.StatusUnprocessableEntity)
+	}
+	var rates []domain.ExchangeRate
+	seen := map[string]bool{}
+	res := &domain.RateImport{Currencies: []string{}}
+	for _, p := range parsed {
+		if p.Currency == domain.CurrencyTHB {
+			continue
+		}
+		rates = append(rates, domain.ExchangeRate{
+			Currency:  p.Currency,
+			RateDate:  p.Date,
+			Rate:      p.Rate,
+			Source:    domain.RateSourceBOT,
+			CreatedBy: importedBy,
+		})
+		if !seen[p.Currency] {
+			seen[p.Currency] = true
+			res.Currencies = append(res.Currencies, p.Currency)
+		}
+	}
+	if len(rates) == 0 {
+		return nil, apperror.New(fiber.StatusUnprocessableEntity)
+	}
+	if err := u.repo.SaveRates(ctx, rates); err != nil {
+		return nil, err
+	}
+	sort.Strings(res.Currencies)
+	res.Imported = len(rates)
+	return res, nil
+}
+
+// ListRates returns the rates that apply to a store, newest first.
+func (u *exchangeRateUC) ListRates(ctx context.Context, in RateListInput) ([]domain.ExchangeRate, error) {
+	bad := apperror.New(fiber.StatusBadRequest)
+	if _, err := uuid.Parse(in.StoreID); err != nil {
+		return nil, bad
+	}
+	f := repository.RateFilter{StoreID: in.StoreID}
+	if in.Currency != "" {
+		currency, err := normalizeCurrency(in.Currency)
+		if err != nil {
+			return nil, bad
+		}
+		f.Currency = currency
+	}
+	var err error
+	if f.From, err = parseListDate(in.From); err != nil {
+		return nil, bad
+	}
+	if f.To, err = parseListDate(in.To); err != nil {
+		return nil, bad
+	}
+	rates, err := u.repo.ListRates(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	if rates == nil {
+		rates = []domain.ExchangeRate{}
+	}
+	return rates, nil
+}
+
+// FreezeRate sets the exchange rate of a document being issued and its
+// baht totals. A rate agreed on the draft is kept; otherwise the latest
+// rate published on or before the issue date is used, which must be at
+// most domain.MaxRateAgeDays old.
+func (u *exchangeRateUC) FreezeRate(ctx context.Context, doc *domain.InvoiceDocument) error {
+	if doc.Currency != domain.CurrencyTHB && doc.ExchangeRate <= 0 {
+		if doc.StoreID == nil || doc.IssueDate.IsZero() {
+			return apperror.New(fiber.StatusUnprocessableEntity)
+		}
+		rate, err := u.repo.FindRate(ctx, *doc.StoreID, doc.Currency, doc.IssueDate)
+		if err != nil {
+			return err
+		}
+		if rate == nil || rateTooOld(rate.RateDate, doc.IssueDate) {
+			return apperror.New(fiber.StatusUnprocessableEntity)
+		}
+		doc.ExchangeRate = rate.Rate
+		rateDate := rate.RateDate
+		doc.RateDate = &rateDate
+	}
+	convertTotals(doc)
+	return nil
+}
+
+// rateTooOld reports whether a rate of rateDate is too old to convert a
+// document issued on issueDate.
+func rateTooOld(rateDate, issueDate time.Time) bool {
+	y, m, d := issueDate.Date()
+	oldest := time.Date(y, m, d, 0, 0, 0, 0, time.UTC).AddDate(0, 0, -domain.MaxRateAgeDays)
+	y, m, d = rateDate.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Before(oldest)
+}
+
+// normalizeCurrency returns the upper case ISO 4217 code of a currency,
+// baht when code is empty.
+func normalizeCurrency(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return domain.CurrencyTHB, nil
+	}
+	if len(code) != 3 {
+		return "", apperror.New(fiber.StatusBadRequest)
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return "", apperror.New(fiber.StatusBadRequest)
+		}
+	}
+	return code, nil
+}
+
+// convertTotals fills in the baht totals of a document at its exchange
+// rate. Baht documents convert at 1; documents in another currency, and
+// credit and debit notes that take theirs from the invoice they adjust,
+// have no baht totals until they get a rate.
+func convertTotals(doc *domain.InvoiceDocument) {
+	if doc.Currency == domain.CurrencyTHB {
+		doc.ExchangeRate = money.RateScale
+		doc.RateDate = nil
+	}
+	doc.ThbGrandTotal = doc.ExchangeRate.Convert(doc.GrandTotal)
+	doc.ThbVatAmount = doc.ExchangeRate.Convert(doc.VatAmount)
+}
+
+// freezeRate freezes the exchange rate of a document being issued through
+// rates. Without rates only baht documents and documents with an agreed
+// rate can be issued.
+func freezeRate(ctx context.Context, rates ExchangeRateUsecase, doc *domain.InvoiceDocument) error {
+	if rates != nil {
+		return rates.FreezeRate(ctx, doc)
+	}
+	if doc.Currency != domain.CurrencyTHB && doc.ExchangeRate <= 0 {
+		return apperror.New(fiber.StatusUnprocessableEntity)
+	}
+	convertTotals(doc)
+	return nil
+}
@@ -4,13 +4,14 @@ import (
 	"invoice_project/internal/invoice/domain"
 	"invoice_project/internal/invoice/repository"
 	"invoice_project/pkg/apperror"
+	"invoice_project/pkg/money"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 )
 
 type InvoiceUsecase interface {
-	CreateInvoice(customer string, amount float64, createdBy uuid.UUID) (*domain.Invoice, error)
+	CreateInvoice(customer string, amount money.Amount, createdBy uuid.UUID) (*domain.Invoice, error)
 	GetInvoice(id uuid.UUID, userID uuid.UUID) (*domain.Invoice, error)
 	ListInvoices(userID uuid.UUID) ([]domain.Invoice, error)
 }
@@ -23,7 +24,7 @@ func NewInvoiceUsecase(repo repository.InvoiceRepository) InvoiceUsecase {
 	return &invoiceUC{repo: repo}
 }
 
-func (u *invoiceUC) CreateInvoice(customer string, amount float64, createdBy uuid.UUID) (*domain.Invoice, error) {
+func (u *invoiceUC) CreateInvoice(customer string, amount money.Amount, createdBy uuid.UUID) (*domain.Invoice, error) {
 	inv := &domain.Invoice{
 		Customer:    customer,
 		Amount:      amount,
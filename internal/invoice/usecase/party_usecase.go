@@ -0,0 +1,200 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+
+	customerDomain "invoice_project/internal/customer/domain"
+	"invoice_project/internal/invoice/domain"
+	"invoice_project/internal/invoice/repository"
+	locationUC "invoice_project/internal/location/usecase"
+	"invoice_project/pkg/apperror"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Party types stored in BuyerType and SellerType.
+const (
+	PartyTypePerson  = "person"
+	PartyTypeCompany = "company"
+)
+
+// bangkokProvince uses แขวง/เขต instead of ตำบล/อำเภอ/จังหวัด.
+const bangkokProvince = "กรุงเทพมหานคร"
+
+// PartySnapshotter copies the seller of a document from its store and
+// merchant, and the buyer from its customer, so the document keeps the
+// details as they were when it was created.
+type PartySnapshotter interface {
+	Snapshot(ctx context.Context, doc *domain.InvoiceDocument) error
+}
+
+type partySnapshotter struct {
+	repo      repository.PartyRepository
+	locations locationUC.LocationUsecase
+}
+
+func NewPartySnapshotter(repo repository.PartyRepository, locations locationUC.LocationUsecase) PartySnapshotter {
+	return &partySnapshotter{repo: repo, locations: locations}
+}
+
+// Snapshot overwrites the seller fields of doc from its store and, when
+// CustomerID is set, the buyer fields from the customer, which must belong
+// to the same store. Without a customer the buyer given by the client is
+// kept. A merchant that has not entered its person or company details
+// keeps the seller name and tax ID given by the client.
+func (s *partySnapshotter) Snapshot(ctx context.Context, doc *domain.InvoiceDocument) error {
+	seller, err := s.repo.GetSeller(ctx, *doc.StoreID)
+	if err != nil {
+		return err
+	}
+	if seller == nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	sellerAddress := ""
+	if a := seller.Store.Address; a != nil {
+		sellerAddress, err = s.address(ctx, a.AddressLine1, "", a.SubdistrictID, a.DistrictID, a.ProvinceID, a.PostalCode)
+		if err != nil {
+			return err
+		}
+	}
+	applySeller(doc, seller, sellerAddress)
+
+	if doc.CustomerID == nil || *doc.CustomerID == 0 {
+		doc.CustomerID = nil
+		return nil
+	}
+	c, err := s.repo.GetCustomer(ctx, *doc.CustomerID)
+	if err != nil {
+		return err
+	}
+	if c == nil || c.StoreID.String() != *doc.StoreID {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	buyerAddress := ""
+	if a := c.CustomerAddress; a != nil {
+		buyerAddress, err = s.address(ctx, a.AddressLine1, a.AddressLine2, a.SubdistrictsID, a.DistrictsID, a.ProvinceID, a.PostalCode)
+		if err != nil {
+			return err
+		}
+	}
+	return applyBuyer(doc, c, buyerAddress)
+}
+
+// address resolves the location names and formats a Thai address.
+func (s *partySnapshotter) address(ctx context.Context, line1, line2 string, subdistrictID, districtID, provinceID int, postalCode string) (string, error) {
+	var subdistrict, district, province string
+	if subdistrictID > 0 {
+		sd, err := s.locations.GetSubDistrictsById(ctx, uint(subdistrictID))
+		if err != nil {
+			return "", err
+		}
+		subdistrict = sd.NameTh
+	}
+	if districtID > 0 {
+		d, err := s.locations.GetDistrictById(ctx, uint(districtID))
+		if err != nil {
+			return "", err
+		}
+		district = d.NameTh
+	}
+	if provinceID > 0 {
+		p, err := s.locations.GetProvinceByID(ctx, uint(provinceID))
+		if err != nil {
+			return "", err
+		}
+		province = p.NameTh
+	}
+	return formatThaiAddress(line1, line2, subdistrict, district, province, postalCode), nil
+}
+
+func applySeller(doc *domain.InvoiceDocument, seller *repository.Seller, address string) {
+	doc.SellerAddress = address
+	doc.SellerBranchNo = PadBranchNo(seller.Store.BranchNo)
+
+	partyType := seller.Merchant.MerchantType.Name
+	switch {
+	case seller.Company != nil && partyType != PartyTypePerson:
+		doc.SellerType = PartyTypeCompany
+		doc.SellerCompanyName = seller.Company.CompanyName
+		doc.SellerFirstName = ""
+		doc.SellerLastName = ""
+		doc.SellerTaxID = seller.Company.VatNo
+	case seller.Person != nil:
+		doc.SellerType = PartyTypePerson
+		doc.SellerFirstName = seller.Person.FirstName
+		doc.SellerLastName = seller.Person.LastName
+		doc.SellerCompanyName = ""
+		doc.SellerTaxID = ""
+		if seller.Person.VatNo != nil {
+			doc.SellerTaxID = *seller.Person.VatNo
+		}
+	}
+}
+
+func applyBuyer(doc *domain.InvoiceDocument, c *customerDomain.Customer, address string) error {
+	doc.BuyerAddress = address
+	switch {
+	case c.CustomerType == PartyTypeCompany && c.CompanyCustomer != nil:
+		doc.BuyerType = PartyTypeCompany
+		doc.BuyerCompanyName = c.CompanyCustomer.CompanyName
+		doc.BuyerFirstName = ""
+		doc.BuyerLastName = ""
+		doc.BuyerTaxID = c.CompanyCustomer.Tin
+		doc.BuyerBranchNo = PadBranchNo(c.CompanyCustomer.BranchNo)
+	case c.CustomerType == PartyTypePerson && c.PersonCustomer != nil:
+		doc.BuyerType = PartyTypePerson
+		doc.BuyerFirstName = c.PersonCustomer.FirstName
+		doc.BuyerLastName = c.PersonCustomer.LastName
+		doc.BuyerCompanyName = ""
+		doc.BuyerTaxID = c.PersonCustomer.Tin
+		doc.BuyerBranchNo = ""
+	default:
+		return apperror.New(fiber.StatusUnprocessableEntity)
+	}
+	return nil
+}
+
+// formatThaiAddress joins the address lines with the subdistrict, district
+// and province names and the postal code, using the แขวง/เขต prefixes in
+// Bangkok and ตำบล/อำเภอ/จังหวัด elsewhere. Names that already carry their
+// prefix are left alone.
+func formatThaiAddress(line1, line2, subdistrict, district, province, postalCode string) string {
+	subPrefix, districtPrefix, provincePrefix := "ตำบล", "อำเภอ", "จังหวัด"
+	if province == bangkokProvince {
+		subPrefix, districtPrefix, provincePrefix = "แขวง", "เขต", ""
+	}
+	var parts []string
+	add := func(prefix, s string) {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			return
+		}
+		if prefix != "" && !strings.HasPrefix(s, prefix) {
+			s = prefix + s
+		}
+		parts = append(parts, s)
+	}
+	add("", line1)
+	add("", line2)
+	add(subPrefix, subdistrict)
+	add(districtPrefix, district)
+	add(provincePrefix, province)
+	add("", postalCode)
+	return strings.Join(parts, " ")
+}
+
+// PadBranchNo pads a numeric branch number to the five digits printed on tax
+// invoices; 00000 is the head office.
+func PadBranchNo(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" || len(s) >= 5 {
+		return s
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return s
+		}
+	}
+	return strings.Repeat("0", 5-len(s)) + s
+}
@@ -0,0 +1,72 @@
+package usecase
+
+import (
+	"testing"
+
+	customerDomain "invoice_project/internal/customer/domain"
+	"invoice_project/internal/invoice/domain"
+	"invoice_project/internal/invoice/repository"
+	merchantDomain "invoice_project/internal/merchant/domain"
+
+	"github.com/google/uuid"
+)
+
+func TestFormatThaiAddress(t *testing.T) {
+	got := formatThaiAddress("99/1 ถนนสุขุมวิท", "", "คลองเตยเหนือ", "วัฒนา", "กรุงเทพมหานคร", "10110")
+	if want := "99/1 ถนนสุขุมวิท แขวงคลองเตยเหนือ เขตวัฒนา กรุงเทพมหานคร 10110"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	got = formatThaiAddress("12 หมู่ 3", "อาคาร A", "สุเทพ", "อำเภอเมืองเชียงใหม่", "เชียงใหม่", "50200")
+	if want := "12 หมู่ 3 อาคาร A ตำบลสุเทพ อำเภอเมืองเชียงใหม่ จังหวัดเชียงใหม่ 50200"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestBranchNo(t *testing.T) {
+	for in, want := range map[string]string{"": "", "0": "00000", "12": "00012", "00003": "00003", "HQ": "HQ"} {
+		if got := PadBranchNo(in); got != want {
+			t.Errorf("PadBranchNo(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestApplyParties(t *testing.T) {
+	doc := &domain.InvoiceDocument{
+		BuyerType:         PartyTypePerson,
+		BuyerFirstName:    "typed",
+		SellerCompanyName: "typed",
+	}
+	vat := "1234567890123"
+	seller := &repository.Seller{
+		Store:    merchantDomain.Store{BranchNo: "1"},
+		Merchant: merchantDomain.Merchant{MerchantType: merchantDomain.MerchantType{Name: merchantDomain.MerchantTypePerson}},
+		Person:   &merchantDomain.PersonMerchant{FirstName: "สมชาย", LastName: "ใจดี", VatNo: &vat},
+	}
+	applySeller(doc, seller, "address")
+	if doc.SellerType != PartyTypePerson || doc.SellerFirstName != "สมชาย" || doc.SellerCompanyName != "" {
+		t.Errorf("unexpected seller: %+v", doc)
+	}
+	if doc.SellerTaxID != vat || doc.SellerBranchNo != "00001" || doc.SellerAddress != "address" {
+		t.Errorf("unexpected seller tax id, branch or address: %+v", doc)
+	}
+
+	customer := &customerDomain.Customer{
+		StoreID:         uuid.New(),
+		CustomerType:    PartyTypeCompany,
+		CompanyCustomer: &customerDomain.CompanyCustomer{CompanyName: "บริษัท ลูกค้า จำกัด", Tin: "0105551234567", BranchNo: "0"},
+	}
+	if err := applyBuyer(doc, customer, "buyer address"); err != nil {
+		t.Fatal(err)
+	}
+	if doc.BuyerType != PartyTypeCompany || doc.BuyerCompanyName != "บริษัท ลูกค้า จำกัด" || doc.BuyerFirstName != "" {
+		t.Errorf("unexpected buyer: %+v", doc)
+	}
+	if doc.BuyerTaxID != "0105551234567" || doc.BuyerBranchNo != "00000" {
+		t.Errorf("unexpected buyer tax id or branch: %+v", doc)
+	}
+
+	customer.CompanyCustomer = nil
+	if err := applyBuyer(doc, customer, ""); err == nil {
+		t.Error("expected a customer without details to be rejected")
+	}
+}
@@ -0,0 +1,338 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"invoice_project/internal/invoice/domain"
+	"invoice_project/internal/invoice/repository"
+	"invoice_project/pkg/apperror"
+	"invoice_project/pkg/money"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type PaymentUsecase interface {
+	RecordPayment(ctx context.Context, p *domain.Payment, issueReceipt bool, changedBy string) (*domain.Payment, error)
+	GetPayment(ctx context.Context, id uint) (*domain.Payment, error)
+	ListPayments(ctx context.Context, documentID uint) ([]domain.Payment, error)
+	GetBalance(ctx context.Context, documentID uint) (*domain.DocumentBalance, error)
+	IssueReceipt(ctx context.Context, paymentID uint, changedBy string) (*domain.InvoiceDocument, error)
+	SetWhtCertificate(ctx context.Context, paymentID uint, certificateNo string, certificateDate *time.Time) (*domain.Payment, error)
+	WithholdingReport(ctx context.Context, merchantID, month string) (*domain.WithholdingReport, error)
+}
+
+type paymentUC struct {
+	payments repository.PaymentRepository
+	docs     repository.InvoiceDocumentRepository
+}
+
+func NewPaymentUsecase(payments repository.PaymentRepository, docs repository.InvoiceDocumentRepository) PaymentUsecase {
+	return &paymentUC{payments: payments, docs: docs}
+}
+
+// RecordPayment saves a payment whose allocations add up to its amount.
+// Each allocation may settle at most the document's outstanding balance,
+// tax withheld by the buyer included. With issueReceipt a receipt is
+// created for the payment straight away, in the same transaction so a
+// failed receipt leaves no payment behind.
+func (u *paymentUC) RecordPayment(ctx context.Context, p *domain.Payment, issueReceipt bool, changedBy string) (*domain.Payment, error) {
+	if err := validatePayment(p); err != nil {
+		return nil, err
+	}
+	p.ReceiptID = nil
+	if p.PaymentDate.IsZero() {
+		p.PaymentDate = time.Now()
+	}
+	err := u.payments.Transaction(ctx, func(ctx context.Context) error {
+		if err := u.payments.RecordPayment(ctx, p, changedBy, paymentCheck(p)); err != nil {
+			return err
+		}
+		if issueReceipt {
+			if _, err := u.IssueReceipt(ctx, p.ID, changedBy); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return u.payments.GetPayment(ctx, p.ID)
+}
+
+func validatePayment(p *domain.Payment) error {
+	if p == nil || !domain.IsValidPaymentMethod(p.Method) || p.Amount <= 0 || len(p.Allocations) == 0 {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	if _, err := uuid.Parse(p.StoreID); err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	if p.WhtCertificateDate != nil && p.WhtCertificateNo == "" {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	var total, withheld money.Amount
+	seen := map[uint]bool{}
+	for i := range p.Allocations {
+		a := &p.Allocations[i]
+		if a.DocumentID == 0 || a.Amount <= 0 || seen[a.DocumentID] {
+			return apperror.New(fiber.StatusBadRequest)
+		}
+		if a.WhtAmount.IsNegative() || a.WhtAmount > a.Amount {
+			return apperror.New(fiber.StatusBadRequest)
+		}
+		seen[a.DocumentID] = true
+		a.ID = 0
+		a.PaymentID = 0
+		total = total.Add(a.Amount)
+		withheld = withheld.Add(a.WhtAmount)
+	}
+	if total != p.Amount {
+		return apperror.New(fiber.StatusUnprocessableEntity)
+	}
+	if !p.WhtAmount.IsZero() && p.WhtAmount != withheld {
+		return apperror.New(fiber.StatusUnprocessableEntity)
+	}
+	p.WhtAmount = withheld
+	p.NetAmount = p.Amount.Sub(withheld)
+	return nil
+}
+
+// paymentCheck verifies every allocated document belongs to the payment's
+// store, is awaiting payment and is not overpaid. A payment is in the
+// currency of the documents it pays, so they must share one.
+func paymentCheck(p *domain.Payment) repository.PaymentCheck {
+	return func(docs []domain.InvoiceDocument, balances map[uint]domain.DocumentBalance) error {
+		allocated := map[uint]money.Amount{}
+		for _, a := range p.Allocations {
+			allocated[a.DocumentID] = a.Amount
+		}
+		for _, doc := range docs {
+			if doc.StoreID == nil || *doc.StoreID != p.StoreID {
+				return apperror.New(fiber.StatusBadRequest)
+			}
+			if doc.Currency != docs[0].Currency {
+				return apperror.New(fiber.StatusUnprocessableEntity)
+			}
+			if !domain.IsPayable(doc.DocumentType, doc.Status) {
+				return apperror.New(fiber.StatusConflict)
+			}
+			if allocated[doc.ID] > balances[doc.ID].Outstanding() {
+				return apperror.New(fiber.StatusUnprocessableEntity)
+			}
+		}
+		return nil
+	}
+}
+
+func (u *paymentUC) GetPayment(ctx context.Context, id uint) (*domain.Payment, error) {
+	if id == 0 {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	p, err := u.payments.GetPayment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, apperror.New(fiber.StatusNotFound)
+	}
+	return p, nil
+}
+
+func (u *paymentUC) ListPayments(ctx context.Context, documentID uint) ([]domain.Payment, error) {
+	if documentID == 0 {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	return u.payments.ListPayments(ctx, documentID)
+}
+
+func (u *paymentUC) GetBalance(ctx context.Context, documentID uint) (*domain.DocumentBalance, error) {
+	if documentID == 0 {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	b, err := u.payments.GetBalance(ctx, documentID)
+	if err != nil {
+		return nil, err
+	}
+	if b == nil {
+		return nil, apperror.New(fiber.StatusNotFound)
+	}
+	return b, nil
+}
+
+// IssueReceipt creates the receipt (ใบเสร็จรับเงิน) of a payment, numbered
+// in the store's receipt sequence.
+func (u *paymentUC) IssueReceipt(ctx context.Context, paymentID uint, changedBy string) (*domain.InvoiceDocument, error) {
+	if paymentID == 0 {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	receipt := &domain.InvoiceDocument{}
+	build := func(p *domain.Payment, docs []domain.InvoiceDocument) ([]domain.InvoiceItem, error) {
+		return buildReceipt(receipt, p, docs)
+	}
+	if err := u.payments.IssueReceipt(ctx, paymentID, receipt, changedBy, build); err != nil {
+		return nil, err
+	}
+	return u.docs.GetDocument(ctx, receipt.ID)
+}
+
+// buildReceipt fills receipt in from a payment: the buyer, seller and
+// currency of the first paid document and one line per allocation, VAT
+// included at the rate of the document it pays. The receipt is converted
+// at the exchange rate of that document.
+func buildReceipt(receipt *domain.InvoiceDocument, p *domain.Payment, docs []domain.InvoiceDocument) ([]domain.InvoiceItem, error) {
+	if len(docs) == 0 {
+		return nil, apperror.New(fiber.StatusConflict)
+	}
+	first := docs[0]
+	storeID := p.StoreID
+	*receipt = domain.InvoiceDocument{
+		DocumentType:      domain.DocumentTypeReceipt,
+		StoreID:           &storeID,
+		CustomerID:        first.CustomerID,
+		IssueDate:         p.PaymentDate,
+		Status:            domain.StatusIssued,
+		BuyerType:         first.BuyerType,
+		BuyerFirstName:    first.BuyerFirstName,
+		BuyerLastName:     first.BuyerLastName,
+		BuyerCompanyName:  first.BuyerCompanyName,
+		BuyerTaxID:        first.BuyerTaxID,
+		BuyerBranchNo:     first.BuyerBranchNo,
+		BuyerAddress:      first.BuyerAddress,
+		SellerType:        first.SellerType,
+		SellerFirstName:   first.SellerFirstName,
+		SellerLastName:    first.SellerLastName,
+		SellerCompanyName: first.SellerCompanyName,
+		SellerTaxID:       first.SellerTaxID,
+		SellerBranchNo:    first.SellerBranchNo,
+		SellerAddress:     first.SellerAddress,
+		Remarks:           receiptRemarks(p),
+		Currency:          first.Currency,
+		ExchangeRate:      first.ExchangeRate,
+		RateDate:          first.RateDate,
+	}
+
+	byID := make(map[uint]domain.InvoiceDocument, len(docs))
+	for _, d := range docs {
+		byID[d.ID] = d
+	}
+	items := make([]domain.InvoiceItem, 0, len(p.Allocations))
+	for _, a := range p.Allocations {
+		doc := byID[a.DocumentID]
+		title, _ := domain.DocumentTitle(doc.DocumentType)
+		item := domain.InvoiceItem{
+			ProductName: "ชำระเงินตาม" + title + " " + doc.DocumentNo,
+			Qty:         1,
+			UnitPrice:   a.Amount,
+			VatType:     VatTypeExempt,
+		}
+		if !doc.VatAmount.IsZero() {
+			item.VatType = VatTypeInclude
+			item.VatRate = documentVatRate(doc)
+		}
+		items = append(items, item)
+	}
+	totals, err := CalculateTotals(receipt, items)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyTotals(PricingLenient, receipt, items, totals); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// documentVatRate returns the VAT rate of the first taxable item.
+func documentVatRate(doc domain.InvoiceDocument) float64 {
+	for _, it := range doc.Items {
+		if it.VatType != VatTypeExempt && it.VatRate > 0 {
+			return it.VatRate
+		}
+	}
+	return DefaultVatRate
+}
+
+var paymentMethodNames = map[string]string{
+	domain.PaymentMethodCash:      "เงินสด",
+	domain.PaymentMethodTransfer:  "โอนเงิน",
+	domain.PaymentMethodCheque:    "เช็ค",
+	domain.PaymentMethodPromptPay: "พร้อมเพย์",
+	domain.PaymentMethodCard:      "บัตรเครดิต",
+}
+
+func receiptRemarks(p *domain.Payment) string {
+	s := "ชำระโดย" + paymentMethodNames[p.Method]
+	if p.Reference != "" {
+		s += " อ้างอิง " + p.Reference
+	}
+	if !p.WhtAmount.IsZero() {
+		s += " หักภาษี ณ ที่จ่าย " + p.WhtAmount.Format() + " บาท รับสุทธิ " + p.NetAmount.Format() + " บาท"
+		if p.WhtCertificateNo != "" {
+			s += " (หนังสือรับรองเลขที่ " + p.WhtCertificateNo + ")"
+		}
+	}
+	return s
+}
+
+// SetWhtCertificate records the number and date of the withholding tax
+// certificate (50 ทวิ) the buyer issued for a payment.
+func (u *paymentUC) SetWhtCertificate(ctx context.Context, paymentID uint, certificateNo string, certificateDate *time.Time) (*domain.Payment, error) {
+	if paymentID == 0 || certificateNo == "" {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	p, err := u.GetPayment(ctx, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if p.WhtAmount.IsZero() {
+		return nil, apperror.New(fiber.StatusConflict)
+	}
+	if err := u.payments.SetWhtCertificate(ctx, paymentID, certificateNo, certificateDate); err != nil {
+		return nil, err
+	}
+	return u.payments.GetPayment(ctx, paymentID)
+}
+
+// WithholdingReport lists the tax withheld from payments received by a
+// merchant's stores in month ("2006-01"), with totals and the number of
+// payments still missing their certificate.
+func (u *paymentUC) WithholdingReport(ctx context.Context, merchantID, month string) (*domain.WithholdingReport, error) {
+	if _, err := uuid.Parse(merchantID); err != nil {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	from, err := time.Parse("2006-01", month)
+	if err != nil {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	entries, err := u.payments.ListWithholding(ctx, merchantID, from, from.AddDate(0, 1, 0))
+	if err != nil {
+		return nil, err
+	}
+	return withholdingReport(merchantID, month, entries), nil
+}
+
+// withholdingReport converts entries to baht and totals them.
+func withholdingReport(merchantID, month string, entries []domain.WithholdingEntry) *domain.WithholdingReport {
+	r := &domain.WithholdingReport{
+		MerchantID: merchantID,
+		Month:      month,
+		Payments:   entries,
+	}
+	if r.Payments == nil {
+		r.Payments = []domain.WithholdingEntry{}
+	}
+	for i := range entries {
+		e := &entries[i]
+		e.Gross = e.ExchangeRate.Convert(e.CurrencyGross)
+		e.Withheld = e.ExchangeRate.Convert(e.CurrencyWithheld)
+		e.Net = e.Gross.Sub(e.Withheld)
+		r.Gross = r.Gross.Add(e.Gross)
+		r.Withheld = r.Withheld.Add(e.Withheld)
+		r.Net = r.Net.Add(e.Net)
+		if e.WhtCertificateNo == "" {
+			r.Missing++
+		}
+	}
+	return r
+}
@@ -0,0 +1,225 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"invoice_project/internal/invoice/domain"
+	"invoice_project/internal/invoice/repository"
+	"invoice_project/pkg/apperror"
+	"invoice_project/pkg/money"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestValidatePayment(t *testing.T) {
+	store := "6f1c2a8e-3b7d-4c55-9a0e-2d4f5b6c7d8e"
+	valid := func() *domain.Payment {
+		return &domain.Payment{
+			StoreID: store,
+			Method:  domain.PaymentMethodTransfer,
+			Amount:  money.FromBaht(300),
+			Allocations: []domain.PaymentAllocation{
+				{DocumentID: 1, Amount: money.FromBaht(100)},
+				{DocumentID: 2, Amount: money.FromBaht(200)},
+			},
+		}
+	}
+	if err := validatePayment(valid()); err != nil {
+		t.Fatalf("valid payment rejected: %v", err)
+	}
+	cases := map[string]func(p *domain.Payment){
+		"method":    func(p *domain.Payment) { p.Method = "bitcoin" },
+		"store":     func(p *domain.Payment) { p.StoreID = "x" },
+		"no allocs": func(p *domain.Payment) { p.Allocations = nil },
+		"duplicate": func(p *domain.Payment) { p.Allocations[1].DocumentID = 1 },
+		"zero":      func(p *domain.Payment) { p.Allocations[0].Amount = 0 },
+		"sum":       func(p *domain.Payment) { p.Amount = money.FromBaht(250) },
+	}
+	for name, mutate := range cases {
+		p := valid()
+		mutate(p)
+		if err := validatePayment(p); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestPaymentCheck_RejectsOverpayment(t *testing.T) {
+	store := "6f1c2a8e-3b7d-4c55-9a0e-2d4f5b6c7d8e"
+	p := &domain.Payment{
+		StoreID:     store,
+		Allocations: []domain.PaymentAllocation{{DocumentID: 1, Amount: money.FromBaht(50)}},
+	}
+	docs := []domain.InvoiceDocument{{ID: 1, StoreID: &store, DocumentType: domain.DocumentTypeInvoice, Status: domain.StatusPartiallyPaid}}
+	balances := map[uint]domain.DocumentBalance{
+		1: {DocumentID: 1, Total: money.FromBaht(107), Credited: money.FromBaht(7), Paid: money.FromBaht(50)},
+	}
+	if err := paymentCheck(p)(docs, balances); err != nil {
+		t.Fatalf("payment within balance rejected: %v", err)
+	}
+	p.Allocations[0].Amount = money.MustParse("50.01")
+	if err := paymentCheck(p)(docs, balances); err == nil {
+		t.Error("expected overpayment to be rejected")
+	}
+	p.Allocations[0].Amount = money.FromBaht(10)
+	docs[0].Status = domain.StatusDraft
+	if err := paymentCheck(p)(docs, balances); err == nil {
+		t.Error("expected draft document to be rejected")
+	}
+}
+
+func TestBuildReceipt(t *testing.T) {
+	store := "6f1c2a8e-3b7d-4c55-9a0e-2d4f5b6c7d8e"
+	date := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	p := &domain.Payment{
+		StoreID:     store,
+		Method:      domain.PaymentMethodPromptPay,
+		Amount:      money.FromBaht(207),
+		PaymentDate: date,
+		Allocations: []domain.PaymentAllocation{
+			{DocumentID: 1, Amount: money.FromBaht(107)},
+			{DocumentID: 2, Amount: money.FromBaht(100)},
+		},
+	}
+	docs := []domain.InvoiceDocument{
+		{
+			ID: 1, DocumentType: domain.DocumentTypeTaxInvoice, DocumentNo: "TAX-2026-000001",
+			StoreID: &store, BuyerCompanyName: "บริษัท ลูกค้า จำกัด", VatAmount: money.FromBaht(7),
+			Items: []domain.InvoiceItem{{VatType: VatTypeExclude, VatRate: 7}},
+		},
+		{ID: 2, DocumentType: domain.DocumentTypeInvoice, DocumentNo: "INV-2026-000002", StoreID: &store},
+	}
+	receipt := &domain.InvoiceDocument{}
+	items, err := buildReceipt(receipt, p, docs)
+	if err != nil {
+		t.Fatalf("buildReceipt returned error: %v", err)
+	}
+	if receipt.DocumentType != domain.DocumentTypeReceipt || receipt.Status != domain.StatusIssued || !receipt.IssueDate.Equal(date) {
+		t.Errorf("unexpected receipt: %+v", receipt)
+	}
+	if receipt.BuyerCompanyName != docs[0].BuyerCompanyName {
+		t.Errorf("buyer not copied: %+v", receipt)
+	}
+	if len(items) != 2 || items[0].VatType != VatTypeInclude || items[1].VatType != VatTypeExempt {
+		t.Fatalf("unexpected items: %+v", items)
+	}
+	if receipt.GrandTotal != p.Amount || receipt.VatAmount != money.FromBaht(7) {
+		t.Errorf("grand total = %s, vat = %s", receipt.GrandTotal, receipt.VatAmount)
+	}
+}
+
+func TestAdjustedStatus(t *testing.T) {
+	total := money.FromBaht(1070)
+	tests := []struct {
+		name    string
+		balance domain.DocumentBalance
+		status  string
+		want    string
+	}{
+		{"credited in full", domain.DocumentBalance{Total: total, Credited: total}, domain.StatusSent, domain.StatusPaid},
+		{"credit settles the rest", domain.DocumentBalance{Total: total, Credited: money.FromBaht(70), Paid: money.FromBaht(1000)}, domain.StatusPartiallyPaid, domain.StatusPaid},
+		{"partial credit, unpaid", domain.DocumentBalance{Total: total, Credited: money.FromBaht(70)}, domain.StatusSent, domain.StatusSent},
+		{"debit note reopens paid", domain.DocumentBalance{Total: total, Debited: money.FromBaht(107), Paid: total}, domain.StatusPaid, domain.StatusPartiallyPaid},
+		{"debit note after full credit", domain.DocumentBalance{Total: total, Credited: total, Debited: money.FromBaht(107)}, domain.StatusPaid, domain.StatusIssued},
+	}
+	for _, tt := range tests {
+		if got := tt.balance.AdjustedStatus(tt.status); got != tt.want {
+			t.Errorf("%s: AdjustedStatus(%s) = %s, want %s", tt.name, tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestValidatePayment_WithholdingTax(t *testing.T) {
+	p := &domain.Payment{
+		StoreID: "6f1c2a8e-3b7d-4c55-9a0e-2d4f5b6c7d8e",
+		Method:  domain.PaymentMethodTransfer,
+		Amount:  money.FromBaht(1070),
+		Allocations: []domain.PaymentAllocation{
+			{DocumentID: 1, Amount: money.FromBaht(1070), WhtAmount: money.FromBaht(30)},
+		},
+	}
+	if err := validatePayment(p); err != nil {
+		t.Fatalf("validatePayment returned error: %v", err)
+	}
+	if p.WhtAmount != money.FromBaht(30) || p.NetAmount != money.FromBaht(1040) {
+		t.Errorf("wht = %s, net = %s", p.WhtAmount, p.NetAmount)
+	}
+
+	p.WhtAmount = money.FromBaht(20)
+	if err := validatePayment(p); err == nil {
+		t.Error("expected mismatching payment wht to be rejected")
+	}
+	p.WhtAmount = 0
+	p.Allocations[0].WhtAmount = money.FromBaht(2000)
+	if err := validatePayment(p); err == nil {
+		t.Error("expected wht above the allocation to be rejected")
+	}
+}
+
+func TestWithholdingReport(t *testing.T) {
+	entries := []domain.WithholdingEntry{
+		{PaymentID: 1, Currency: domain.CurrencyTHB, ExchangeRate: money.RateScale, CurrencyGross: money.FromBaht(1070), CurrencyWithheld: money.FromBaht(30), CurrencyNet: money.FromBaht(1040), WhtCertificateNo: "WHT-001"},
+		{PaymentID: 2, Currency: domain.CurrencyTHB, ExchangeRate: money.RateScale, CurrencyGross: money.FromBaht(535), CurrencyWithheld: money.FromBaht(15), CurrencyNet: money.FromBaht(520)},
+		// USD 107 less 3 withheld at 33.5 baht.
+		{PaymentID: 3, Currency: "USD", ExchangeRate: 33500000, CurrencyGross: money.FromBaht(107), CurrencyWithheld: money.FromBaht(3), CurrencyNet: money.FromBaht(104), WhtCertificateNo: "WHT-002"},
+	}
+	r := withholdingReport("m", "2026-10", entries)
+	if r.Gross != money.Amount(518950) || r.Withheld != money.Amount(14550) || r.Net != money.FromBaht(5044) {
+		t.Errorf("unexpected totals: %+v", r)
+	}
+	if usd := r.Payments[2]; usd.Gross != money.Amount(358450) || usd.Withheld != money.Amount(10050) || usd.Net != money.FromBaht(3484) {
+		t.Errorf("unexpected baht amounts: %+v", usd)
+	}
+	if r.Missing != 1 {
+		t.Errorf("missing certificates = %d", r.Missing)
+	}
+	if empty := withholdingReport("m", "2026-10", nil); empty.Payments == nil {
+		t.Error("expected an empty payment list")
+	}
+}
+
+// txPayments keeps payments in memory and drops those saved in a
+// transaction that fails.
+type txPayments struct {
+	repository.PaymentRepository
+	payments   []domain.Payment
+	receiptErr error
+}
+
+func (r *txPayments) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	saved := len(r.payments)
+	if err := fn(ctx); err != nil {
+		r.payments = r.payments[:saved]
+		return err
+	}
+	return nil
+}
+
+func (r *txPayments) RecordPayment(ctx context.Context, p *domain.Payment, changedBy string, check repository.PaymentCheck) error {
+	p.ID = uint(len(r.payments) + 1)
+	r.payments = append(r.payments, *p)
+	return nil
+}
+
+func (r *txPayments) IssueReceipt(ctx context.Context, paymentID uint, receipt *domain.InvoiceDocument, changedBy string, build repository.ReceiptBuild) error {
+	return r.receiptErr
+}
+
+func TestRecordPayment_ReceiptFailureKeepsNoPayment(t *testing.T) {
+	payments := &txPayments{receiptErr: apperror.New(fiber.StatusConflict)}
+	uc := NewPaymentUsecase(payments, nil)
+	p := &domain.Payment{
+		StoreID:     "6f1c2a8e-3b7d-4c55-9a0e-2d4f5b6c7d8e",
+		Method:      domain.PaymentMethodTransfer,
+		Amount:      money.FromBaht(100),
+		Allocations: []domain.PaymentAllocation{{DocumentID: 1, Amount: money.FromBaht(100)}},
+	}
+	if _, err := uc.RecordPayment(context.Background(), p, true, "user-1"); statusCode(err) != 409 {
+		t.Fatalf("expected the receipt error, got %v", err)
+	}
+	if len(payments.payments) != 0 {
+		t.Errorf("payment saved although its receipt failed: %+v", payments.payments)
+	}
+}
@@ -0,0 +1,588 @@
+package usecase
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"invoice_project/internal/invoice/domain"
+	"invoice_project/pkg/pdf"
+	"invoice_project/pkg/qr"
+)
+
+// DefaultPDFTemplate is used for stores that have not picked a template.
+const DefaultPDFTemplate = "classic"
+
+// pdfTemplate describes the look of a rendered document. Layout is shared,
+// templates only change colours and how the table header is drawn.
+type pdfTemplate struct {
+	Accent      pdf.Color
+	HeaderText  pdf.Color
+	FilledTable bool
+	Watermark   pdf.Color
+}
+
+var pdfTemplates = map[string]pdfTemplate{
+	"classic": {
+		Accent:     pdf.Black,
+		HeaderText: pdf.Black,
+		Watermark:  pdf.Color{R: 150, G: 150, B: 150},
+	},
+	"modern": {
+		Accent:      pdf.Color{R: 23, G: 92, B: 170},
+		HeaderText:  pdf.White,
+		FilledTable: true,
+		Watermark:   pdf.Color{R: 23, G: 92, B: 170},
+	},
+}
+
+// PDFTemplates returns the names of the available PDF templates.
+func PDFTemplates() []string {
+	names := make([]string, 0, len(pdfTemplates))
+	for name := range pdfTemplates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PDFFonts holds the TrueType font files embedded in rendered documents.
+// Bold is optional and falls back to Regular.
+type PDFFonts struct {
+	Regular []byte
+	Bold    []byte
+}
+
+const (
+	pdfMargin     = 40.0
+	pdfLineHeight = 14.0
+	pdfBodySize   = 10.0
+	pdfSmallSize  = 8.5
+	// pdfFooterTop is the lowest point the item table may reach before
+	// it continues on a new page.
+	pdfFooterTop = 770.0
+	// pdfQRSize is the printed width of a PromptPay code.
+	pdfQRSize = 96.0
+)
+
+// itemColumn is one column of the item table.
+type itemColumn struct {
+	th, en string
+	width  float64
+	align  pdf.Alignment
+}
+
+var itemColumns = []itemColumn{
+	{"ลำดับ", "No.", 35, pdf.AlignCenter},
+	{"รายการ", "Description", 0, pdf.AlignLeft},
+	{"จำนวน", "Qty", 45, pdf.AlignRight},
+	{"ราคาต่อหน่วย", "Unit Price", 75, pdf.AlignRight},
+	{"ส่วนลด", "Discount", 65, pdf.AlignRight},
+	{"จำนวนเงิน", "Amount", 80, pdf.AlignRight},
+}
+
+type pdfRenderer struct {
+	doc      *domain.InvoiceDocument
+	replaces *domain.InvoiceDocument
+	tpl      pdfTemplate
+	out      *pdf.Document
+	regular  *pdf.Font
+	bold     *pdf.Font
+	page     *pdf.Page
+	pages    []*pdf.Page
+	y        float64
+	copy     bool
+	payment  *domain.PaymentQR
+	qr       *qr.Code
+}
+
+// renderDocumentPDF lays out doc on A4 pages. replaces is the voided
+// document doc replaces, if any. copy selects the copy watermark instead
+// of the original one. payment, when set, is printed as a PromptPay code
+// below the totals.
+func renderDocumentPDF(doc, replaces *domain.InvoiceDocument, payment *domain.PaymentQR, tpl pdfTemplate, fonts PDFFonts, copy bool) ([]byte, error) {
+	var code *qr.Code
+	if payment != nil {
+		var err error
+		if code, err = qr.Encode([]byte(payment.Payload), qr.M); err != nil {
+			return nil, err
+		}
+	}
+	out := pdf.New()
+	regular, err := out.AddFont(fonts.Regular)
+	if err != nil {
+		return nil, err
+	}
+	bold := regular
+	if len(fonts.Bold) > 0 {
+		if bold, err = out.AddFont(fonts.Bold); err != nil {
+			return nil, err
+		}
+	}
+	titleTH, _ := domain.DocumentTitle(doc.DocumentType)
+	out.SetTitle(strings.TrimSpace(titleTH + " " + doc.DocumentNo))
+
+	r := &pdfRenderer{doc: doc, replaces: replaces, tpl: tpl, out: out, regular: regular, bold: bold, copy: copy, payment: payment, qr: code}
+	r.newPage()
+	r.parties()
+	r.itemTable()
+	r.summary()
+	r.promptPay()
+	r.signatures()
+	r.finishPages()
+	return out.Bytes()
+}
+
+func (r *pdfRenderer) contentWidth() float64 { return r.out.Width() - 2*pdfMargin }
+
+// newPage starts a page with the watermark and document header.
+func (r *pdfRenderer) newPage() {
+	r.page = r.out.AddPage()
+	r.pages = append(r.pages, r.page)
+	r.watermark()
+	r.header()
+}
+
+func (r *pdfRenderer) watermark() {
+	label := "ต้นฉบับ"
+	if r.copy {
+		label = "สำเนา"
+	}
+	if r.doc.Status == domain.StatusVoid || r.doc.Status == domain.StatusCancelled {
+		label = "ยกเลิก"
+	}
+	p := r.page
+	p.SetAlpha(0.12)
+	p.SetFont(r.bold, 110)
+	p.SetTextColor(r.tpl.Watermark)
+	p.RotatedText(r.out.Width()/2, r.out.Height()/2, 45, label)
+	p.SetAlpha(1)
+	p.SetTextColor(pdf.Black)
+}
+
+// header draws the seller on the left and the document title, number and
+// date on the right. It is repeated on every page.
+func (r *pdfRenderer) header() {
+	p := r.page
+	d := r.doc
+	right := r.out.Width() - pdfMargin
+	top := pdfMargin + 16
+
+	titleTH, titleEN := domain.DocumentTitle(d.DocumentType)
+	p.SetTextColor(r.tpl.Accent)
+	p.SetFont(r.bold, 18)
+	p.TextAligned(right-220, top, 220, titleTH, pdf.AlignRight)
+	p.SetFont(r.bold, 11)
+	p.TextAligned(right-220, top+16, 220, titleEN, pdf.AlignRight)
+	p.SetTextColor(pdf.Black)
+
+	label := "ต้นฉบับ / ORIGINAL"
+	if r.copy {
+		label = "สำเนา / COPY"
+	}
+	p.SetFont(r.regular, pdfSmallSize)
+	p.TextAligned(right-220, top+30, 220, label, pdf.AlignRight)
+
+	y := top
+	p.SetFont(r.bold, 13)
+	p.Text(pdfMargin, y, PartyName(d.SellerType, d.SellerCompanyName, d.SellerFirstName, d.SellerLastName))
+	y += 16
+	p.SetFont(r.regular, pdfSmallSize)
+	for _, line := range r.regular.WrapText(d.SellerAddress, pdfSmallSize, 280) {
+		p.Text(pdfMargin, y, line)
+		y += 11
+	}
+	if d.SellerTaxID != "" {
+		p.Text(pdfMargin, y, "เลขประจำตัวผู้เสียภาษี / Tax ID: "+d.SellerTaxID+branchLabel(d.SellerBranchNo))
+		y += 11
+	}
+
+	// document number box
+	boxY := top + 40
+	if y > boxY {
+		boxY = y
+	}
+	rows := [][2]string{
+		{"เลขที่ / No.", d.DocumentNo},
+		{"วันที่ / Date", ThaiDate(d.IssueDate)},
+	}
+	if domain.IsAdjustmentNote(d.DocumentType) && d.ReferenceID != nil {
+		rows = append(rows, [2]string{"อ้างอิง / Ref.", "#" + strconv.FormatUint(uint64(*d.ReferenceID), 10)})
+	}
+	if r.replaces != nil {
+		rows = append(rows, [2]string{"แทนฉบับเลขที่ / Replaces", r.replaces.DocumentNo})
+	}
+	p.SetFont(r.regular, pdfBodySize)
+	for i, row := range rows {
+		ry := boxY + float64(i+1)*pdfLineHeight
+		p.Text(right-220, ry, row[0])
+		p.TextAligned(right-120, ry, 120, row[1], pdf.AlignRight)
+	}
+	r.y = boxY + float64(len(rows))*pdfLineHeight + 14
+	if y+6 > r.y {
+		r.y = y + 6
+	}
+	p.SetStrokeColor(r.tpl.Accent)
+	p.SetLineWidth(1)
+	p.Line(pdfMargin, r.y, right, r.y)
+	p.SetStrokeColor(pdf.Black)
+	r.y += 8
+}
+
+// branchLabel names the head office or branch printed after a tax ID.
+func branchLabel(branch string) string {
+	switch branch {
+	case "":
+		return ""
+	case "00000":
+		return " (สำนักงานใหญ่)"
+	default:
+		return " (สาขาที่ " + branch + ")"
+	}
+}
+
+// parties draws the buyer block under the header of the first page.
+func (r *pdfRenderer) parties() {
+	p := r.page
+	d := r.doc
+	p.SetFont(r.bold, pdfBodySize)
+	r.y += pdfLineHeight
+	p.Text(pdfMargin, r.y, "ลูกค้า / Customer")
+	p.SetFont(r.regular, pdfBodySize)
+	r.y += pdfLineHeight
+	p.Text(pdfMargin, r.y, PartyName(d.BuyerType, d.BuyerCompanyName, d.BuyerFirstName, d.BuyerLastName))
+	for _, line := range r.regular.WrapText(d.BuyerAddress, pdfBodySize, r.contentWidth()) {
+		r.y += pdfLineHeight
+		p.Text(pdfMargin, r.y, line)
+	}
+	if d.BuyerTaxID != "" {
+		r.y += pdfLineHeight
+		p.Text(pdfMargin, r.y, "เลขประจำตัวผู้เสียภาษี / Tax ID: "+d.BuyerTaxID+branchLabel(d.BuyerBranchNo))
+	}
+	r.y += 12
+}
+
+func (r *pdfRenderer) columnWidths() []float64 {
+	widths := make([]float64, len(itemColumns))
+	fixed := 0.0
+	for i, c := range itemColumns {
+		widths[i] = c.width
+		fixed += c.width
+	}
+	widths[1] = r.contentWidth() - fixed
+	return widths
+}
+
+func (r *pdfRenderer) tableHeader() {
+	p := r.page
+	widths := r.columnWidths()
+	h := 2*pdfLineHeight + 4
+	if r.tpl.FilledTable {
+		p.SetFillColor(r.tpl.Accent)
+		p.Rect(pdfMargin, r.y, r.contentWidth(), h, true)
+		p.SetFillColor(pdf.Black)
+	} else {
+		p.Rect(pdfMargin, r.y, r.contentWidth(), h, false)
+	}
+	p.SetTextColor(r.tpl.HeaderText)
+	x := pdfMargin
+	for i, c := range itemColumns {
+		p.SetFont(r.bold, pdfBodySize)
+		p.TextAligned(x+4, r.y+pdfLineHeight, widths[i]-8, c.th, c.align)
+		p.SetFont(r.regular, pdfSmallSize)
+		p.TextAligned(x+4, r.y+2*pdfLineHeight, widths[i]-8, c.en, c.align)
+		x += widths[i]
+	}
+	p.SetTextColor(pdf.Black)
+	r.y += h
+}
+
+func (r *pdfRenderer) itemTable() {
+	widths := r.columnWidths()
+	r.tableHeader()
+	for i, it := range r.doc.Items {
+		desc := it.ProductName
+		if it.Sku != "" {
+			desc += " (" + it.Sku + ")"
+		}
+		lines := r.regular.WrapText(desc, pdfBodySize, widths[1]-8)
+		h := float64(len(lines))*pdfLineHeight + 6
+		if r.y+h > pdfFooterTop {
+			r.newPage()
+			r.tableHeader()
+		}
+		p := r.page
+		p.SetFont(r.regular, pdfBodySize)
+		cells := []string{
+			strconv.Itoa(i + 1),
+			"",
+			strconv.Itoa(it.Qty),
+			it.UnitPrice.Format(),
+			it.Discount.Format(),
+			it.LineTotal.Format(),
+		}
+		x := pdfMargin
+		base := r.y + pdfLineHeight
+		for c, text := range cells {
+			if c == 1 {
+				for j, line := range lines {
+					p.Text(x+4, base+float64(j)*pdfLineHeight, line)
+				}
+			} else {
+				p.TextAligned(x+4, base, widths[c]-8, text, itemColumns[c].align)
+			}
+			x += widths[c]
+		}
+		r.y += h
+		p.SetStrokeColor(pdf.Color{R: 200, G: 200, B: 200})
+		p.SetLineWidth(0.5)
+		p.Line(pdfMargin, r.y, pdfMargin+r.contentWidth(), r.y)
+		p.SetStrokeColor(pdf.Black)
+	}
+	r.y += 6
+}
+
+// summary draws the totals, VAT breakdown, the grand total in Thai words
+// and, for credit and debit notes, the adjustment details.
+func (r *pdfRenderer) summary() {
+	d := r.doc
+	rows := r.summaryRows()
+	height := float64(len(rows)+1)*pdfLineHeight + 20
+	if domain.IsAdjustmentNote(d.DocumentType) {
+		height += 5 * pdfLineHeight
+	}
+	if d.Remarks != "" {
+		height += 3 * pdfLineHeight
+	}
+	if r.voidNote() != "" {
+		height += 2 * pdfLineHeight
+	}
+	if r.y+height > pdfFooterTop {
+		r.newPage()
+	}
+
+	p := r.page
+	right := r.out.Width() - pdfMargin
+	labelX := right - 250
+	top := r.y
+	p.SetFont(r.regular, pdfBodySize)
+	for _, row := range rows {
+		r.y += pdfLineHeight
+		p.Text(labelX, r.y, row[0])
+		p.TextAligned(right-90, r.y, 90, row[1], pdf.AlignRight)
+	}
+	r.y += 6
+	p.SetStrokeColor(r.tpl.Accent)
+	p.Line(labelX, r.y, right, r.y)
+	p.SetStrokeColor(pdf.Black)
+	r.y += pdfLineHeight + 2
+	p.SetFont(r.bold, 11)
+	p.SetTextColor(r.tpl.Accent)
+	p.Text(labelX, r.y, "จำนวนเงินรวมทั้งสิ้น / Grand Total")
+	p.TextAligned(right-90, r.y, 90, d.GrandTotal.Format(), pdf.AlignRight)
+	p.SetTextColor(pdf.Black)
+	if !d.WhtAmount.IsZero() {
+		p.SetFont(r.regular, pdfBodySize)
+		for _, row := range [][2]string{
+			{"หักภาษี ณ ที่จ่าย / Withholding tax", d.WhtAmount.Format()},
+			{"ยอดชำระสุทธิ / Net payable", d.GrandTotal.Sub(d.WhtAmount).Format()},
+		} {
+			r.y += pdfLineHeight
+			p.Text(labelX, r.y, row[0])
+			p.TextAligned(right-90, r.y, 90, row[1], pdf.AlignRight)
+		}
+	}
+
+	// baht text sits to the left of the figures
+	boxW := labelX - pdfMargin - 15
+	p.SetFont(r.regular, pdfSmallSize)
+	p.Text(pdfMargin, top+pdfLineHeight, "จำนวนเงิน (ตัวอักษร) / Amount in words")
+	p.SetFont(r.bold, pdfBodySize)
+	ty := top + 2*pdfLineHeight + 2
+	for _, line := range r.bold.WrapText("("+d.GrandTotal.ThaiText()+")", pdfBodySize, boxW-10) {
+		p.Text(pdfMargin+5, ty, line)
+		ty += pdfLineHeight
+	}
+	p.Rect(pdfMargin, top+3, boxW, ty-top, false)
+	r.y += 10
+
+	if domain.IsAdjustmentNote(d.DocumentType) {
+		r.adjustment()
+	}
+	if note := r.voidNote(); note != "" {
+		p.SetFont(r.bold, pdfBodySize)
+		for _, line := range r.bold.WrapText(note, pdfBodySize, r.contentWidth()) {
+			r.y += pdfLineHeight
+			p.Text(pdfMargin, r.y, line)
+		}
+	}
+	if d.Remarks != "" {
+		p.SetFont(r.bold, pdfBodySize)
+		r.y += pdfLineHeight
+		p.Text(pdfMargin, r.y, "หมายเหตุ / Remarks")
+		p.SetFont(r.regular, pdfBodySize)
+		for _, line := range r.regular.WrapText(d.Remarks, pdfBodySize, r.contentWidth()) {
+			if r.y+pdfLineHeight > pdfFooterTop {
+				r.newPage()
+				p = r.page
+				p.SetFont(r.regular, pdfBodySize)
+			}
+			r.y += pdfLineHeight
+			p.Text(pdfMargin, r.y, line)
+		}
+	}
+}
+
+func (r *pdfRenderer) summaryRows() [][2]string {
+	d := r.doc
+	rows := [][2]string{{"รวมเป็นเงิน / Subtotal", d.Subtotal.Format()}}
+	if !d.DiscountAmount.IsZero() {
+		rows = append(rows,
+			[2]string{"ส่วนลด / Discount", d.DiscountAmount.Format()},
+			[2]string{"ยอดหลังหักส่วนลด / After Discount", d.Subtotal.Sub(d.DiscountAmount).Format()},
+		)
+	}
+
+	// recompute the VAT groups from the stored items for the breakdown
+	items := make([]domain.InvoiceItem, len(d.Items))
+	copy(items, d.Items)
+	vatRate := DefaultVatRate
+	if totals, err := CalculateTotals(d, items); err == nil {
+		if !totals.ExemptAmount.IsZero() {
+			rows = append(rows, [2]string{"มูลค่าที่ได้รับยกเว้นภาษี / Exempt", totals.ExemptAmount.Format()})
+		}
+		if !totals.ZeroRatedAmount.IsZero() {
+			rows = append(rows, [2]string{"มูลค่าที่เสียภาษีอัตราร้อยละ 0 / Zero-rated", totals.ZeroRatedAmount.Format()})
+		}
+		rows = append(rows, [2]string{"มูลค่าที่คำนวณภาษี / Vatable", totals.VatableAmount.Format()})
+		for _, it := range items {
+			if it.VatType == VatTypeInclude || it.VatType == VatTypeExclude {
+				vatRate = it.VatRate
+				break
+			}
+		}
+	}
+	rows = append(rows, [2]string{
+		fmt.Sprintf("ภาษีมูลค่าเพิ่ม %s%% / VAT", strconv.FormatFloat(vatRate, 'f', -1, 64)),
+		d.VatAmount.Format(),
+	})
+	return rows
+}
+
+func (r *pdfRenderer) adjustment() {
+	d := r.doc
+	p := r.page
+	rows := [][2]string{
+		{"มูลค่าสินค้าหรือบริการตามใบกำกับภาษีเดิม / Original value", d.OriginalAmount.Format()},
+		{"มูลค่าสินค้าหรือบริการที่ถูกต้อง / Correct value", d.CorrectedAmount.Format()},
+		{"ผลต่าง / Difference", d.DifferenceAmount.Format()},
+	}
+	p.SetFont(r.regular, pdfBodySize)
+	right := r.out.Width() - pdfMargin
+	for _, row := range rows {
+		r.y += pdfLineHeight
+		p.Text(pdfMargin, r.y, row[0])
+		p.TextAligned(right-90, r.y, 90, row[1], pdf.AlignRight)
+	}
+	r.y += pdfLineHeight
+	p.Text(pdfMargin, r.y, "เหตุผล / Reason: "+d.AdjustmentReason)
+	r.y += 6
+}
+
+// voidNote states why a voided document was voided, or which voided
+// document a replacement was issued for and why.
+func (r *pdfRenderer) voidNote() string {
+	switch {
+	case r.doc.Status == domain.StatusVoid && r.doc.VoidReason != "":
+		return "ยกเลิกเอกสาร / Voided: " + r.doc.VoidReason
+	case r.replaces != nil:
+		return "ออกแทนฉบับเดิมเลขที่ " + r.replaces.DocumentNo + " ซึ่งยกเลิกแล้ว / Replaces voided " +
+			r.replaces.DocumentNo + ": " + r.replaces.VoidReason
+	}
+	return ""
+}
+
+// promptPay draws the PromptPay code for the outstanding amount with its
+// label, keeping the two together on one page.
+func (r *pdfRenderer) promptPay() {
+	if r.qr == nil {
+		return
+	}
+	if r.y+pdfQRSize+20 > pdfFooterTop {
+		r.newPage()
+	}
+	p := r.page
+	top := r.y + 10
+	module := pdfQRSize / float64(r.qr.Size)
+	p.SetFillColor(pdf.Black)
+	for y, row := range r.qr.Modules {
+		// adjacent dark modules are drawn as one rectangle
+		for x := 0; x < len(row); x++ {
+			if !row[x] {
+				continue
+			}
+			start := x
+			for x+1 < len(row) && row[x+1] {
+				x++
+			}
+			p.Rect(pdfMargin+float64(start)*module, top+float64(y)*module,
+				float64(x-start+1)*module, module, true)
+		}
+	}
+
+	textX := pdfMargin + pdfQRSize + 15
+	p.SetFont(r.bold, pdfBodySize)
+	p.Text(textX, top+pdfLineHeight, "ชำระเงินผ่านพร้อมเพย์ / Pay with PromptPay")
+	p.SetFont(r.regular, pdfBodySize)
+	p.Text(textX, top+2*pdfLineHeight+2, "ยอดคงค้าง / Amount due: "+r.payment.Amount.Format()+" บาท")
+	p.SetFont(r.regular, pdfSmallSize)
+	p.Text(textX, top+3*pdfLineHeight+2, "สแกนด้วยแอปธนาคาร / Scan with any Thai banking app")
+	r.y = top + pdfQRSize + 10
+}
+
+// signatures draws the receiver and authorised signature boxes at the
+// bottom of the last page.
+func (r *pdfRenderer) signatures() {
+	top := pdfFooterTop - 60
+	if r.y+10 > top {
+		r.newPage()
+	}
+	p := r.page
+	w := (r.contentWidth() - 30) / 2
+	labels := []string{"ผู้รับเอกสาร / Received by", "ผู้มีอำนาจลงนาม / Authorized Signature"}
+	p.SetFont(r.regular, pdfSmallSize)
+	for i, label := range labels {
+		x := pdfMargin + float64(i)*(w+30)
+		p.Rect(x, top, w, 60, false)
+		p.Line(x+20, top+35, x+w-20, top+35)
+		p.TextAligned(x, top+48, w, label, pdf.AlignCenter)
+		p.TextAligned(x, top+58, w, "วันที่ / Date ____/____/______", pdf.AlignCenter)
+	}
+}
+
+// finishPages numbers the pages once the total is known.
+func (r *pdfRenderer) finishPages() {
+	for i, p := range r.pages {
+		p.SetFont(r.regular, pdfSmallSize)
+		p.TextAligned(pdfMargin, r.out.Height()-25, r.contentWidth(),
+			fmt.Sprintf("หน้า %d / %d", i+1, len(r.pages)), pdf.AlignRight)
+	}
+}
+
+// PartyName returns the printable name of a buyer or seller snapshot.
+func PartyName(partyType, company, first, last string) string {
+	if partyType == PartyTypeCompany || (company != "" && first == "" && last == "") {
+		return company
+	}
+	return strings.TrimSpace(first + " " + last)
+}
+
+// ThaiDate formats t as dd/mm/yyyy in the Buddhist era.
+func ThaiDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("%02d/%02d/%d", t.Day(), int(t.Month()), t.Year()+543)
+}
@@ -0,0 +1,53 @@
+package usecase
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+
+	"invoice_project/internal/invoice/domain"
+	"invoice_project/pkg/money"
+	"invoice_project/pkg/promptpay"
+)
+
+func TestRenderDocumentPDF(t *testing.T) {
+	font, err := os.ReadFile("/usr/share/fonts/truetype/dejavu/DejaVuSans.ttf")
+	if err != nil {
+		t.Skip("no truetype font available")
+	}
+	doc := &domain.InvoiceDocument{
+		DocumentType:      domain.DocumentTypeTaxInvoice,
+		DocumentNo:        "TIV-2026-000001",
+		IssueDate:         time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		Status:            domain.StatusIssued,
+		SellerType:        "company",
+		SellerCompanyName: "Example Co., Ltd.",
+		BuyerType:         "person",
+		BuyerFirstName:    "Somchai",
+		Subtotal:          money.MustParse("100"),
+		VatAmount:         money.MustParse("7"),
+		GrandTotal:        money.MustParse("107"),
+	}
+	for i := 0; i < 60; i++ {
+		doc.Items = append(doc.Items, domain.InvoiceItem{
+			ProductName: "Item", Qty: 1, UnitPrice: money.MustParse("1"),
+			VatType: VatTypeExclude, LineTotal: money.MustParse("1"),
+		})
+	}
+	payload, err := promptpay.Payload(promptpay.TypeMobile, "0899999999", doc.GrandTotal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payment := &domain.PaymentQR{Amount: doc.GrandTotal, Payload: payload}
+	b, err := renderDocumentPDF(doc, nil, payment, pdfTemplates["modern"], PDFFonts{Regular: font}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(b, []byte("%PDF-")) {
+		t.Fatal("output is not a pdf")
+	}
+	if n := bytes.Count(b, []byte("/Type /Page ")); n < 2 {
+		t.Fatalf("expected the item table to span several pages, got %d", n)
+	}
+}
@@ -0,0 +1,114 @@
+package usecase
+
+import (
+	"context"
+
+	"invoice_project/internal/invoice/domain"
+	"invoice_project/internal/invoice/repository"
+	"invoice_project/pkg/apperror"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type DocumentPDFUsecase interface {
+	RenderPDF(ctx context.Context, id uint, copy bool) ([]byte, *domain.InvoiceDocument, error)
+	GetStoreTemplate(ctx context.Context, storeID string) (*domain.StoreDocumentTemplate, error)
+	SetStoreTemplate(ctx context.Context, storeID, template string) (*domain.StoreDocumentTemplate, error)
+}
+
+type documentPDFUC struct {
+	docs      repository.InvoiceDocumentRepository
+	templates repository.DocumentTemplateRepository
+	promptPay PromptPayUsecase
+	fonts     PDFFonts
+}
+
+func NewDocumentPDFUsecase(docs repository.InvoiceDocumentRepository, templates repository.DocumentTemplateRepository, promptPay PromptPayUsecase, fonts PDFFonts) DocumentPDFUsecase {
+	return &documentPDFUC{docs: docs, templates: templates, promptPay: promptPay, fonts: fonts}
+}
+
+// RenderPDF renders a document with the template selected by its store.
+// Unpaid documents of stores with a PromptPay ID carry a payment code.
+// Rendering is unavailable when no Thai font is bundled or configured.
+func (u *documentPDFUC) RenderPDF(ctx context.Context, id uint, copy bool) ([]byte, *domain.InvoiceDocument, error) {
+	if id == 0 {
+		return nil, nil, apperror.New(fiber.StatusBadRequest)
+	}
+	if len(u.fonts.Regular) == 0 {
+		return nil, nil, apperror.New(fiber.StatusServiceUnavailable)
+	}
+	doc, err := u.docs.GetDocument(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if doc == nil {
+		return nil, nil, apperror.New(fiber.StatusNotFound)
+	}
+
+	name := DefaultPDFTemplate
+	if doc.StoreID != nil {
+		t, err := u.templates.GetStoreTemplate(ctx, *doc.StoreID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if t != nil {
+			name = t.Template
+		}
+	}
+	tpl, ok := pdfTemplates[name]
+	if !ok {
+		tpl = pdfTemplates[DefaultPDFTemplate]
+	}
+
+	var replaces *domain.InvoiceDocument
+	if doc.ReferenceID != nil && domain.IsReplaceable(doc.DocumentType) {
+		ref, err := u.docs.GetDocument(ctx, *doc.ReferenceID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if domain.IsReplacement(doc, ref) {
+			replaces = ref
+		}
+	}
+
+	payment, err := u.promptPay.DocumentQR(ctx, doc)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	b, err := renderDocumentPDF(doc, replaces, payment, tpl, u.fonts, copy)
+	if err != nil {
+		return nil, nil, err
+	}
+	return b, doc, nil
+}
+
+// GetStoreTemplate returns the template a store prints with, falling back
+// to DefaultPDFTemplate.
+func (u *documentPDFUC) GetStoreTemplate(ctx context.Context, storeID string) (*domain.StoreDocumentTemplate, error) {
+	if storeID == "" {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	t, err := u.templates.GetStoreTemplate(ctx, storeID)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		t = &domain.StoreDocumentTemplate{StoreID: storeID, Template: DefaultPDFTemplate}
+	}
+	return t, nil
+}
+
+func (u *documentPDFUC) SetStoreTemplate(ctx context.Context, storeID, template string) (*domain.StoreDocumentTemplate, error) {
+	if storeID == "" {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	if _, ok := pdfTemplates[template]; !ok {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	t := &domain.StoreDocumentTemplate{StoreID: storeID, Template: template}
+	if err := u.templates.SaveStoreTemplate(ctx, t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
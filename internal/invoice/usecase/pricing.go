@@ -0,0 +1,280 @@
+package usecase
+
+import (
+	"math"
+
+	"invoice_project/internal/invoice/domain"
+	"invoice_project/pkg/apperror"
+	"invoice_project/pkg/money"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// PricingMode controls what happens when the totals sent by the client do
+// not match the totals computed by the server.
+type PricingMode int
+
+const (
+	// PricingStrict rejects documents whose client totals are wrong.
+	PricingStrict PricingMode = iota
+	// PricingLenient silently replaces client totals with computed ones.
+	PricingLenient
+)
+
+// Document level discount types stored in InvoiceDocument.DiscountType.
+// For DiscountTypePercent the DiscountValue holds the percentage with two
+// decimals, e.g. 12.50 for 12.5%.
+const (
+	DiscountTypeNone    = 0
+	DiscountTypePercent = 1
+	DiscountTypeAmount  = 2
+)
+
+// VAT types stored in InvoiceItem.VatType. Zero-rated lines, such as
+// exports, are taxable at 0% and reported apart from exempt ones.
+const (
+	VatTypeInclude   = "include"
+	VatTypeExclude   = "exclude"
+	VatTypeZeroRated = "zero_rated"
+	VatTypeExempt    = "exempt"
+)
+
+// DefaultVatRate is the standard Thai VAT rate in percent.
+const DefaultVatRate = 7.0
+
+// WhtRates are the withholding tax rates in percent a buyer may deduct
+// from a payment for services, e.g. 1% for transport, 2% for advertising,
+// 3% for services and 5% for rent.
+var WhtRates = []float64{1, 1.5, 2, 3, 5}
+
+// validWhtRate reports whether r is zero, for no withholding, or one of
+// WhtRates.
+func validWhtRate(r float64) bool {
+	if r == 0 {
+		return true
+	}
+	for _, w := range WhtRates {
+		if r == w {
+			return true
+		}
+	}
+	return false
+}
+
+// ParsePricingMode converts a config value to a PricingMode. Anything other
+// than "lenient" is treated as strict.
+func ParsePricingMode(s string) PricingMode {
+	if s == "lenient" {
+		return PricingLenient
+	}
+	return PricingStrict
+}
+
+// DocumentTotals holds the computed amounts of a document.
+type DocumentTotals struct {
+	Subtotal        money.Amount
+	DiscountAmount  money.Amount
+	VatableAmount   money.Amount
+	ZeroRatedAmount money.Amount
+	ExemptAmount    money.Amount
+	VatAmount       money.Amount
+	GrandTotal      money.Amount
+	WhtAmount       money.Amount
+	LineTotals      []money.Amount
+}
+
+// vatGroup accumulates lines that share the same VAT treatment so VAT is
+// computed once per group and rounded once, as on a Thai tax invoice.
+type vatGroup struct {
+	vatType string
+	rateBP  int64
+	net     money.Amount
+}
+
+// whtGroup accumulates lines with the same VAT treatment and withholding
+// tax rate, so the document discount can be spread over them and WHT
+// computed on their pre-VAT amount.
+type whtGroup struct {
+	vatType string
+	rateBP  int64
+	whtBP   int64
+	net     money.Amount
+}
+
+// CalculateTotals recomputes every line total and the document totals from
+// quantities, unit prices, discounts, VAT and withholding tax settings.
+// Every line needs a VAT type. Lines without a withholding tax rate of
+// their own use the document rate.
+func CalculateTotals(doc *domain.InvoiceDocument, items []domain.InvoiceItem) (*DocumentTotals, error) {
+	t := &DocumentTotals{LineTotals: make([]money.Amount, len(items))}
+	var groups []*vatGroup
+	var whtGroups []*whtGroup
+	if !validWhtRate(doc.WhtRate) {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	for i := range items {
+		it := &items[i]
+		if it.Qty <= 0 || it.UnitPrice.IsNegative() || it.Discount.IsNegative() {
+			return nil, apperror.New(fiber.StatusBadRequest)
+		}
+		switch it.VatType {
+		case VatTypeInclude, VatTypeExclude:
+			if it.VatRate == 0 {
+				it.VatRate = DefaultVatRate
+			}
+		case VatTypeZeroRated, VatTypeExempt:
+			it.VatRate = 0
+		default:
+			return nil, apperror.New(fiber.StatusBadRequest)
+		}
+		if it.VatRate < 0 || it.VatRate > 100 {
+			return nil, apperror.New(fiber.StatusBadRequest)
+		}
+		whtRate := doc.WhtRate
+		if it.WhtRate != nil {
+			whtRate = *it.WhtRate
+		}
+		if !validWhtRate(whtRate) {
+			return nil, apperror.New(fiber.StatusBadRequest)
+		}
+
+		gross := it.UnitPrice.Mul(int64(it.Qty))
+		if it.Discount > gross {
+			return nil, apperror.New(fiber.StatusBadRequest)
+		}
+		net := gross.Sub(it.Discount)
+		t.LineTotals[i] = net
+		t.Subtotal += net
+
+		rateBP := int64(math.Round(it.VatRate * 100))
+		var g *vatGroup
+		for _, cand := range groups {
+			if cand.vatType == it.VatType && cand.rateBP == rateBP {
+				g = cand
+				break
+			}
+		}
+		if g == nil {
+			g = &vatGroup{vatType: it.VatType, rateBP: rateBP}
+			groups = append(groups, g)
+		}
+		g.net += net
+
+		whtBP := int64(math.Round(whtRate * 100))
+		var w *whtGroup
+		for _, cand := range whtGroups {
+			if cand.vatType == it.VatType && cand.rateBP == rateBP && cand.whtBP == whtBP {
+				w = cand
+				break
+			}
+		}
+		if w == nil {
+			w = &whtGroup{vatType: it.VatType, rateBP: rateBP, whtBP: whtBP}
+			whtGroups = append(whtGroups, w)
+		}
+		w.net += net
+	}
+
+	switch doc.DiscountType {
+	case DiscountTypeNone:
+		t.DiscountAmount = 0
+	case DiscountTypePercent:
+		if doc.DiscountValue.IsNegative() || doc.DiscountValue > money.FromBaht(100) {
+			return nil, apperror.New(fiber.StatusBadRequest)
+		}
+		t.DiscountAmount = t.Subtotal.Percent(doc.DiscountValue)
+	case DiscountTypeAmount:
+		t.DiscountAmount = doc.DiscountValue
+	default:
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	if t.DiscountAmount.IsNegative() || t.DiscountAmount > t.Subtotal {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+
+	// spread the document discount over the VAT groups in proportion to
+	// their share of the subtotal
+	weights := make([]money.Amount, len(groups))
+	for i, g := range groups {
+		weights[i] = g.net
+	}
+	shares := t.DiscountAmount.Allocate(weights)
+	for i, g := range groups {
+		amount := g.net.Sub(shares[i])
+
+		switch g.vatType {
+		case VatTypeExclude:
+			vat := amount.MulRate(g.rateBP, 10000)
+			t.VatableAmount += amount
+			t.VatAmount += vat
+			t.GrandTotal += amount + vat
+		case VatTypeInclude:
+			vat := amount.MulRate(g.rateBP, 10000+g.rateBP)
+			t.VatableAmount += amount - vat
+			t.VatAmount += vat
+			t.GrandTotal += amount
+		case VatTypeZeroRated:
+			t.ZeroRatedAmount += amount
+			t.GrandTotal += amount
+		default:
+			t.ExemptAmount += amount
+			t.GrandTotal += amount
+		}
+	}
+
+	weights = make([]money.Amount, len(whtGroups))
+	for i, w := range whtGroups {
+		weights[i] = w.net
+	}
+	shares = t.DiscountAmount.Allocate(weights)
+	for i, w := range whtGroups {
+		if w.whtBP == 0 {
+			continue
+		}
+		base := w.net.Sub(shares[i])
+		if w.vatType == VatTypeInclude {
+			base = base.Sub(base.MulRate(w.rateBP, 10000+w.rateBP))
+		}
+		t.WhtAmount += base.MulRate(w.whtBP, 10000)
+	}
+	return t, nil
+}
+
+// applyTotals compares the client totals with the computed ones. In strict
+// mode any mismatch is rejected; in lenient mode the computed values win.
+// Totals the client left at zero are always filled in, and the baht
+// totals follow the computed ones.
+func applyTotals(mode PricingMode, doc *domain.InvoiceDocument, items []domain.InvoiceItem, t *DocumentTotals) error {
+	if mode == PricingStrict {
+		for i := range items {
+			if !items[i].LineTotal.IsZero() && items[i].LineTotal != t.LineTotals[i] {
+				return apperror.New(fiber.StatusUnprocessableEntity)
+			}
+		}
+		for _, pair := range [][2]money.Amount{
+			{doc.Subtotal, t.Subtotal},
+			{doc.DiscountAmount, t.DiscountAmount},
+			{doc.VatAmount, t.VatAmount},
+			{doc.GrandTotal, t.GrandTotal},
+			{doc.WhtAmount, t.WhtAmount},
+		} {
+			if !pair[0].IsZero() && pair[0] != pair[1] {
+				return apperror.New(fiber.StatusUnprocessableEntity)
+			}
+		}
+	}
+
+	for i := range items {
+		items[i].LineTotal = t.LineTotals[i]
+	}
+	doc.Subtotal = t.Subtotal
+	doc.DiscountAmount = t.DiscountAmount
+	doc.VatAmount = t.VatAmount
+	doc.GrandTotal = t.GrandTotal
+	doc.VatableAmount = t.VatableAmount
+	doc.ZeroRatedAmount = t.ZeroRatedAmount
+	doc.ExemptAmount = t.ExemptAmount
+	doc.WhtAmount = t.WhtAmount
+	convertTotals(doc)
+	return nil
+}
@@ -0,0 +1,145 @@
+package usecase
+
+import (
+	"testing"
+
+	"invoice_project/internal/invoice/domain"
+	"invoice_project/pkg/money"
+)
+
+func TestCalculateTotals_ExcludeVat(t *testing.T) {
+	doc := &domain.InvoiceDocument{DiscountType: DiscountTypePercent, DiscountValue: money.FromBaht(10)}
+	items := []domain.InvoiceItem{
+		{Qty: 3, UnitPrice: money.FromBaht(100), Discount: 0, VatType: VatTypeExclude},
+		{Qty: 1, UnitPrice: money.FromBaht(50), Discount: money.FromBaht(5), VatType: VatTypeExclude},
+	}
+	totals, err := CalculateTotals(doc, items)
+	if err != nil {
+		t.Fatalf("CalculateTotals returned error: %v", err)
+	}
+	// subtotal 345.00, discount 34.50, vat 7% of 310.50 = 21.735 -> 21.74
+	if totals.Subtotal != 34500 || totals.DiscountAmount != 3450 {
+		t.Errorf("unexpected subtotal/discount: %+v", totals)
+	}
+	if totals.VatAmount != 2174 || totals.GrandTotal != 33224 {
+		t.Errorf("unexpected vat/grand total: %+v", totals)
+	}
+	if items[0].VatRate != DefaultVatRate {
+		t.Errorf("expected default vat rate, got %v", items[0].VatRate)
+	}
+}
+
+func TestCalculateTotals_IncludeAndExempt(t *testing.T) {
+	doc := &domain.InvoiceDocument{}
+	items := []domain.InvoiceItem{
+		{Qty: 1, UnitPrice: money.FromBaht(107), VatType: VatTypeInclude, VatRate: 7},
+		{Qty: 2, UnitPrice: money.FromBaht(10), VatType: VatTypeExempt, VatRate: 7},
+	}
+	totals, err := CalculateTotals(doc, items)
+	if err != nil {
+		t.Fatalf("CalculateTotals returned error: %v", err)
+	}
+	if totals.VatAmount != 700 || totals.VatableAmount != 10000 || totals.ExemptAmount != 2000 {
+		t.Errorf("unexpected vat breakdown: %+v", totals)
+	}
+	if totals.GrandTotal != 12700 {
+		t.Errorf("expected grand total 12700, got %d", totals.GrandTotal)
+	}
+	if items[1].VatRate != 0 {
+		t.Errorf("exempt item should have zero vat rate")
+	}
+}
+
+func TestCalculateTotals_InvalidInput(t *testing.T) {
+	cases := []struct {
+		name  string
+		doc   domain.InvoiceDocument
+		items []domain.InvoiceItem
+	}{
+		{"zero qty", domain.InvoiceDocument{}, []domain.InvoiceItem{{Qty: 0, UnitPrice: money.FromBaht(1), VatType: VatTypeExempt}}},
+		{"discount above price", domain.InvoiceDocument{}, []domain.InvoiceItem{{Qty: 1, UnitPrice: money.FromBaht(1), Discount: money.FromBaht(2), VatType: VatTypeExempt}}},
+		{"missing vat type", domain.InvoiceDocument{}, []domain.InvoiceItem{{Qty: 1, UnitPrice: money.FromBaht(1)}}},
+		{"unknown vat type", domain.InvoiceDocument{}, []domain.InvoiceItem{{Qty: 1, UnitPrice: money.FromBaht(1), VatType: "other"}}},
+		{"percent above 100", domain.InvoiceDocument{DiscountType: DiscountTypePercent, DiscountValue: money.FromBaht(101)}, []domain.InvoiceItem{{Qty: 1, UnitPrice: money.FromBaht(1), VatType: VatTypeExempt}}},
+		{"discount above subtotal", domain.InvoiceDocument{DiscountType: DiscountTypeAmount, DiscountValue: money.FromBaht(5)}, []domain.InvoiceItem{{Qty: 1, UnitPrice: money.FromBaht(1), VatType: VatTypeExempt}}},
+		{"negative wht rate", domain.InvoiceDocument{}, []domain.InvoiceItem{{Qty: 1, UnitPrice: money.FromBaht(1), VatType: VatTypeExempt, WhtRate: whtRate(-3)}}},
+		{"wht rate above 5", domain.InvoiceDocument{}, []domain.InvoiceItem{{Qty: 1, UnitPrice: money.FromBaht(1), VatType: VatTypeExempt, WhtRate: whtRate(30)}}},
+		{"unknown wht rate", domain.InvoiceDocument{}, []domain.InvoiceItem{{Qty: 1, UnitPrice: money.FromBaht(1), VatType: VatTypeExempt, WhtRate: whtRate(4)}}},
+		{"unknown document wht rate", domain.InvoiceDocument{WhtRate: 10}, []domain.InvoiceItem{{Qty: 1, UnitPrice: money.FromBaht(1), VatType: VatTypeExempt}}},
+	}
+	for _, tc := range cases {
+		if _, err := CalculateTotals(&tc.doc, tc.items); err == nil {
+			t.Errorf("%s: expected error", tc.name)
+		}
+	}
+}
+
+func TestCalculateTotals_WithholdingTax(t *testing.T) {
+	doc := &domain.InvoiceDocument{WhtRate: 3, DiscountType: DiscountTypeAmount, DiscountValue: money.FromBaht(100)}
+	items := []domain.InvoiceItem{
+		{Qty: 1, UnitPrice: money.FromBaht(1000), VatType: VatTypeExclude},
+		{Qty: 1, UnitPrice: money.FromBaht(1070), VatType: VatTypeInclude, WhtRate: whtRate(1)},
+		{Qty: 1, UnitPrice: money.FromBaht(200), VatType: VatTypeExempt, WhtRate: whtRate(5)},
+	}
+	totals, err := CalculateTotals(doc, items)
+	if err != nil {
+		t.Fatalf("CalculateTotals returned error: %v", err)
+	}
+	// discount shares 44.05 / 47.14 / 8.81 of 2270.00
+	// 3% of 955.95 = 28.68, 1% of (1022.86 - 66.92 VAT) = 9.56, 5% of 191.19 = 9.56
+	if totals.WhtAmount != money.MustParse("47.80") {
+		t.Errorf("wht amount = %s", totals.WhtAmount)
+	}
+
+	// a line with a zero rate of its own is not withheld from
+	items[0].WhtRate = whtRate(0)
+	totals, err = CalculateTotals(doc, items)
+	if err != nil {
+		t.Fatalf("CalculateTotals returned error: %v", err)
+	}
+	if totals.WhtAmount != money.MustParse("19.12") {
+		t.Errorf("wht amount without the first line = %s", totals.WhtAmount)
+	}
+}
+
+func TestCalculateTotals_ZeroRated(t *testing.T) {
+	doc := &domain.InvoiceDocument{}
+	items := []domain.InvoiceItem{
+		{Qty: 1, UnitPrice: money.FromBaht(100), VatType: VatTypeExclude},
+		{Qty: 1, UnitPrice: money.FromBaht(500), VatType: VatTypeZeroRated, VatRate: 7},
+		{Qty: 1, UnitPrice: money.FromBaht(20), VatType: VatTypeExempt},
+	}
+	totals, err := CalculateTotals(doc, items)
+	if err != nil {
+		t.Fatalf("CalculateTotals returned error: %v", err)
+	}
+	if totals.VatableAmount != money.FromBaht(100) || totals.ZeroRatedAmount != money.FromBaht(500) || totals.ExemptAmount != money.FromBaht(20) {
+		t.Errorf("unexpected vat breakdown: %+v", totals)
+	}
+	if totals.VatAmount != money.FromBaht(7) || totals.GrandTotal != money.FromBaht(627) {
+		t.Errorf("unexpected vat/grand total: %+v", totals)
+	}
+	if items[1].VatRate != 0 {
+		t.Errorf("zero-rated item should have zero vat rate")
+	}
+}
+
+func whtRate(r float64) *float64 { return &r }
+
+func TestApplyTotals_StrictRejectsMismatch(t *testing.T) {
+	doc := &domain.InvoiceDocument{GrandTotal: money.FromBaht(999)}
+	items := []domain.InvoiceItem{{Qty: 1, UnitPrice: money.FromBaht(100), VatType: VatTypeExclude}}
+	totals, err := CalculateTotals(doc, items)
+	if err != nil {
+		t.Fatalf("CalculateTotals returned error: %v", err)
+	}
+	if err := applyTotals(PricingStrict, doc, items, totals); err == nil {
+		t.Errorf("strict mode should reject mismatching grand total")
+	}
+	if err := applyTotals(PricingLenient, doc, items, totals); err != nil {
+		t.Fatalf("lenient mode returned error: %v", err)
+	}
+	if doc.GrandTotal != money.FromBaht(107) || doc.VatAmount != money.FromBaht(7) || items[0].LineTotal != money.FromBaht(100) {
+		t.Errorf("lenient mode did not replace totals: %+v", doc)
+	}
+}
@@ -0,0 +1,161 @@
+package usecase
+
+import (
+	"context"
+
+	"invoice_project/internal/invoice/domain"
+	"invoice_project/internal/invoice/repository"
+	"invoice_project/pkg/apperror"
+	"invoice_project/pkg/promptpay"
+	"invoice_project/pkg/qr"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// PromptPayPNGScale is the size in pixels of one module of a PromptPay
+// QR code image.
+const PromptPayPNGScale = 8
+
+type PromptPayUsecase interface {
+	GetStorePromptPay(ctx context.Context, storeID string) (*domain.StorePromptPay, error)
+	SetStorePromptPay(ctx context.Context, p *domain.StorePromptPay) (*domain.StorePromptPay, error)
+	PaymentQR(ctx context.Context, documentID uint) (*domain.PaymentQR, error)
+	PaymentPNG(ctx context.Context, documentID uint) ([]byte, error)
+	DocumentQR(ctx context.Context, doc *domain.InvoiceDocument) (*domain.PaymentQR, error)
+}
+
+type promptPayUC struct {
+	repo     repository.PromptPayRepository
+	docs     repository.InvoiceDocumentRepository
+	payments repository.PaymentRepository
+}
+
+func NewPromptPayUsecase(repo repository.PromptPayRepository, docs repository.InvoiceDocumentRepository, payments repository.PaymentRepository) PromptPayUsecase {
+	return &promptPayUC{repo: repo, docs: docs, payments: payments}
+}
+
+func (u *promptPayUC) GetStorePromptPay(ctx context.Context, storeID string) (*domain.StorePromptPay, error) {
+	if storeID == "" {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	p, err := u.repo.GetStorePromptPay(ctx, storeID)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, apperror.New(fiber.StatusNotFound)
+	}
+	return p, nil
+}
+
+// SetStorePromptPay registers the PromptPay ID a store is paid to,
+// replacing any earlier one. The ID is stored as digits only.
+func (u *promptPayUC) SetStorePromptPay(ctx context.Context, p *domain.StorePromptPay) (*domain.StorePromptPay, error) {
+	if _, err := uuid.Parse(p.StoreID); err != nil {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	id, err := promptpay.Normalize(p.IDType, p.PromptPayID)
+	if err != nil {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	p.PromptPayID = id
+	if err := u.repo.SaveStorePromptPay(ctx, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// PaymentQR returns the PromptPay code paying what is outstanding on a
+// document. Documents that cannot be paid, are fully paid or are not in
+// baht are a conflict; stores without a PromptPay ID have no code.
+func (u *promptPayUC) PaymentQR(ctx context.Context, documentID uint) (*domain.PaymentQR, error) {
+	if documentID == 0 {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	doc, err := u.docs.GetDocument(ctx, documentID)
+	if err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		return nil, apperror.New(fiber.StatusNotFound)
+	}
+	if !promptPayable(doc) {
+		return nil, apperror.New(fiber.StatusConflict)
+	}
+	if doc.StoreID == nil {
+		return nil, apperror.New(fiber.StatusNotFound)
+	}
+	p, err := u.repo.GetStorePromptPay(ctx, *doc.StoreID)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, apperror.New(fiber.StatusNotFound)
+	}
+	code, err := u.paymentQR(ctx, doc, p)
+	if err != nil {
+		return nil, err
+	}
+	if code == nil {
+		return nil, apperror.New(fiber.StatusConflict)
+	}
+	return code, nil
+}
+
+// DocumentQR returns the code printed on doc, or nil when doc is not
+// awaiting payment in baht or its store has no PromptPay ID.
+func (u *promptPayUC) DocumentQR(ctx context.Context, doc *domain.InvoiceDocument) (*domain.PaymentQR, error) {
+	if doc.StoreID == nil || !promptPayable(doc) {
+		return nil, nil
+	}
+	p, err := u.repo.GetStorePromptPay(ctx, *doc.StoreID)
+	if err != nil || p == nil {
+		return nil, err
+	}
+	return u.paymentQR(ctx, doc, p)
+}
+
+// promptPayable reports whether doc can be paid by PromptPay, which only
+// transfers baht.
+func promptPayable(doc *domain.InvoiceDocument) bool {
+	return domain.IsPayable(doc.DocumentType, doc.Status) && doc.Currency == domain.CurrencyTHB
+}
+
+// paymentQR builds the code for the outstanding amount of doc, nil when
+// nothing is outstanding.
+func (u *promptPayUC) paymentQR(ctx context.Context, doc *domain.InvoiceDocument, p *domain.StorePromptPay) (*domain.PaymentQR, error) {
+	b, err := u.payments.GetBalance(ctx, doc.ID)
+	if err != nil {
+		return nil, err
+	}
+	if b == nil {
+		return nil, apperror.New(fiber.StatusNotFound)
+	}
+	amount := b.Outstanding()
+	if amount <= 0 {
+		return nil, nil
+	}
+	payload, err := promptpay.Payload(p.IDType, p.PromptPayID, amount)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.PaymentQR{DocumentID: doc.ID, Amount: amount, Payload: payload}, nil
+}
+
+// PaymentPNG renders the code of PaymentQR as a PNG image.
+func (u *promptPayUC) PaymentPNG(ctx context.Context, documentID uint) ([]byte, error) {
+	code, err := u.PaymentQR(ctx, documentID)
+	if err != nil {
+		return nil, err
+	}
+	return paymentQRPNG(code)
+}
+
+func paymentQRPNG(code *domain.PaymentQR) ([]byte, error) {
+	c, err := qr.Encode([]byte(code.Payload), qr.M)
+	if err != nil {
+		return nil, err
+	}
+	return c.PNG(PromptPayPNGScale)
+}
@@ -0,0 +1,134 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"invoice_project/internal/invoice/domain"
+	"invoice_project/internal/invoice/repository"
+	"invoice_project/pkg/money"
+)
+
+type promptPayRepo struct {
+	repository.PromptPayRepository
+	p *domain.StorePromptPay
+}
+
+func (r *promptPayRepo) GetStorePromptPay(ctx context.Context, storeID string) (*domain.StorePromptPay, error) {
+	if r.p == nil || r.p.StoreID != storeID {
+		return nil, nil
+	}
+	p := *r.p
+	return &p, nil
+}
+
+func (r *promptPayRepo) SaveStorePromptPay(ctx context.Context, p *domain.StorePromptPay) error {
+	c := *p
+	r.p = &c
+	return nil
+}
+
+// balanceRepo reports the same balance for every document.
+type balanceRepo struct {
+	repository.PaymentRepository
+	balance domain.DocumentBalance
+}
+
+func (r *balanceRepo) GetBalance(ctx context.Context, documentID uint) (*domain.DocumentBalance, error) {
+	b := r.balance
+	b.DocumentID = documentID
+	return &b, nil
+}
+
+const promptPayStore = "8a0b7c3e-51f6-4c1e-9d7a-2f4e6b8c0d12"
+
+func newPromptPayUC(docs repository.InvoiceDocumentRepository) (*promptPayUC, *promptPayRepo, *balanceRepo) {
+	repo := &promptPayRepo{}
+	payments := &balanceRepo{balance: domain.DocumentBalance{
+		Total: money.MustParse("1070"),
+		Paid:  money.MustParse("70"),
+	}}
+	return &promptPayUC{repo: repo, docs: docs, payments: payments}, repo, payments
+}
+
+func TestPromptPay_PaymentQR(t *testing.T) {
+	ctx := context.Background()
+	store := promptPayStore
+	docs := &sendRepo{doc: domain.InvoiceDocument{
+		ID:           1,
+		StoreID:      &store,
+		DocumentType: domain.DocumentTypeInvoice,
+		Status:       domain.StatusPartiallyPaid,
+		Currency:     domain.CurrencyTHB,
+	}}
+	uc, _, payments := newPromptPayUC(docs)
+
+	if _, err := uc.PaymentQR(ctx, 1); statusCode(err) != 404 {
+		t.Errorf("no promptpay id: got %v", err)
+	}
+	if code, err := uc.DocumentQR(ctx, &docs.doc); err != nil || code != nil {
+		t.Errorf("no promptpay id: got %v, %v", code, err)
+	}
+
+	p, err := uc.SetStorePromptPay(ctx, &domain.StorePromptPay{StoreID: store, IDType: "mobile", PromptPayID: "089-999-9999"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.PromptPayID != "0899999999" {
+		t.Errorf("id = %s", p.PromptPayID)
+	}
+
+	code, err := uc.PaymentQR(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code.Amount != money.MustParse("1000") || !strings.Contains(code.Payload, "010212") || !strings.Contains(code.Payload, "54071000.00") {
+		t.Errorf("code = %+v", code)
+	}
+	png, err := uc.PaymentPNG(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(png, []byte("\x89PNG")) {
+		t.Error("not a png")
+	}
+
+	docs.doc.Currency = "USD"
+	if _, err := uc.PaymentQR(ctx, 1); statusCode(err) != 409 {
+		t.Errorf("usd: got %v", err)
+	}
+	if code, err := uc.DocumentQR(ctx, &docs.doc); err != nil || code != nil {
+		t.Errorf("usd: got %v, %v", code, err)
+	}
+	docs.doc.Currency = domain.CurrencyTHB
+
+	payments.balance.Paid = payments.balance.Total
+	if _, err := uc.PaymentQR(ctx, 1); statusCode(err) != 409 {
+		t.Errorf("fully paid: got %v", err)
+	}
+	docs.doc.Status = domain.StatusDraft
+	if _, err := uc.PaymentQR(ctx, 1); statusCode(err) != 409 {
+		t.Errorf("draft: got %v", err)
+	}
+	if _, err := uc.PaymentQR(ctx, 2); statusCode(err) != 404 {
+		t.Errorf("missing document: got %v", err)
+	}
+}
+
+func TestPromptPay_SetRejected(t *testing.T) {
+	uc, repo, _ := newPromptPayUC(&sendRepo{})
+	for _, p := range []domain.StorePromptPay{
+		{StoreID: "", IDType: "mobile", PromptPayID: "0899999999"},
+		{StoreID: promptPayStore, IDType: "email", PromptPayID: "0899999999"},
+		{StoreID: promptPayStore, IDType: "national_id", PromptPayID: "1234567890123"},
+	} {
+		if _, err := uc.SetStorePromptPay(context.Background(), &p); statusCode(err) != 400 {
+			t.Errorf("%+v: got %v", p, err)
+		}
+	}
+	if repo.p != nil {
+		t.Error("invalid id saved")
+	}
+}
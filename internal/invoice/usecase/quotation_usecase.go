@@ -0,0 +1,170 @@
+package usecase
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"invoice_project/internal/invoice/domain"
+	"invoice_project/internal/invoice/repository"
+	"invoice_project/pkg/apperror"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SystemActor is recorded as ChangedBy for changes made by background jobs.
+const SystemActor = "system"
+
+type QuotationUsecase interface {
+	ConvertQuotation(ctx context.Context, id uint, documentType, status, changedBy string) (*domain.InvoiceDocument, error)
+	ExpireQuotations(ctx context.Context) (int, error)
+}
+
+type quotationUC struct {
+	repo  repository.InvoiceDocumentRepository
+	rates ExchangeRateUsecase
+	now   func() time.Time
+}
+
+func NewQuotationUsecase(repo repository.InvoiceDocumentRepository, rates ExchangeRateUsecase) QuotationUsecase {
+	return &quotationUC{repo: repo, rates: rates, now: time.Now}
+}
+
+// ConvertQuotation creates an invoice document of documentType (an
+// invoice by default) from an open or accepted quotation, copying its
+// parties, currency and items. The new document starts as a draft unless
+// status is "issued", in which case its exchange rate is frozen.
+func (u *quotationUC) ConvertQuotation(ctx context.Context, id uint, documentType, status, changedBy string) (*domain.InvoiceDocument, error) {
+	if id == 0 {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	if documentType == "" {
+		documentType = domain.DocumentTypeInvoice
+	}
+	if status == "" {
+		status = domain.StatusDraft
+	}
+	if !domain.IsConvertible(documentType) || (status != domain.StatusDraft && status != domain.StatusIssued) {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+
+	doc := &domain.InvoiceDocument{}
+	today := startOfDay(u.now())
+	check := func(q *domain.InvoiceDocument) ([]domain.InvoiceItem, error) {
+		if q.DocumentType != domain.DocumentTypeQuotation {
+			return nil, apperror.New(fiber.StatusNotFound)
+		}
+		if !domain.IsQuotationOpen(q.Status) && q.Status != domain.StatusAccepted {
+			return nil, apperror.New(fiber.StatusConflict)
+		}
+		if domain.IsQuotationOpen(q.Status) && quotationExpired(q, today) {
+			return nil, apperror.New(fiber.StatusConflict)
+		}
+		items, err := convertQuotation(q, doc, documentType, status, today)
+		if err != nil {
+			return nil, err
+		}
+		if status == domain.StatusIssued {
+			if err := freezeRate(ctx, u.rates, doc); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	}
+	if err := u.repo.ConvertQuotation(ctx, id, doc, changedBy, check); err != nil {
+		return nil, err
+	}
+	return u.repo.GetDocument(ctx, doc.ID)
+}
+
+// convertQuotation fills doc in from quotation q and returns the copied
+// items with their totals recomputed.
+func convertQuotation(q, doc *domain.InvoiceDocument, documentType, status string, issueDate time.Time) ([]domain.InvoiceItem, error) {
+	*doc = domain.InvoiceDocument{
+		DocumentType:      documentType,
+		StoreID:           q.StoreID,
+		CustomerID:        q.CustomerID,
+		IssueDate:         issueDate,
+		Status:            status,
+		BuyerType:         q.BuyerType,
+		BuyerFirstName:    q.BuyerFirstName,
+		BuyerLastName:     q.BuyerLastName,
+		BuyerCompanyName:  q.BuyerCompanyName,
+		BuyerTaxID:        q.BuyerTaxID,
+		BuyerBranchNo:     q.BuyerBranchNo,
+		BuyerAddress:      q.BuyerAddress,
+		SellerType:        q.SellerType,
+		SellerFirstName:   q.SellerFirstName,
+		SellerLastName:    q.SellerLastName,
+		SellerCompanyName: q.SellerCompanyName,
+		SellerTaxID:       q.SellerTaxID,
+		SellerBranchNo:    q.SellerBranchNo,
+		SellerAddress:     q.SellerAddress,
+		DiscountType:      q.DiscountType,
+		DiscountValue:     q.DiscountValue,
+		WhtRate:           q.WhtRate,
+		Remarks:           q.Remarks,
+		Currency:          q.Currency,
+		ExchangeRate:      q.ExchangeRate,
+		RateDate:          q.RateDate,
+	}
+	items := make([]domain.InvoiceItem, len(q.Items))
+	for i, it := range q.Items {
+		it.ID = 0
+		it.DocumentID = 0
+		items[i] = it
+	}
+	totals, err := CalculateTotals(doc, items)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyTotals(PricingLenient, doc, items, totals); err != nil {
+		return nil, err
+	}
+	if status == domain.StatusIssued {
+		if err := readyToIssue(doc, items); err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+// ExpireQuotations expires every open quotation whose validity ended
+// before today.
+func (u *quotationUC) ExpireQuotations(ctx context.Context) (int, error) {
+	return u.repo.ExpireQuotations(ctx, startOfDay(u.now()), SystemActor)
+}
+
+// RunQuotationExpiry expires quotations now and then every interval until
+// ctx is cancelled.
+func RunQuotationExpiry(ctx context.Context, uc QuotationUsecase, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if n, err := uc.ExpireQuotations(ctx); err != nil {
+			log.Printf("quotation expiry failed: %v", err)
+		} else if n > 0 {
+			log.Printf("expired %d quotations", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// quotationExpired reports whether q's validity ended before today. A
+// quotation is valid through its ValidUntil date.
+func quotationExpired(q *domain.InvoiceDocument, today time.Time) bool {
+	if q.ValidUntil == nil {
+		return false
+	}
+	y, m, d := q.ValidUntil.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, today.Location()).Before(today)
+}
+
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,68 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"invoice_project/internal/invoice/domain"
+	"invoice_project/pkg/money"
+)
+
+func TestConvertQuotation_CopiesPartiesAndItems(t *testing.T) {
+	store := "6f1c2a8e-3b7d-4c55-9a0e-2d4f5b6c7d8e"
+	q := &domain.InvoiceDocument{
+		ID:               5,
+		DocumentType:     domain.DocumentTypeQuotation,
+		DocumentNo:       "QT-2026-000001",
+		StoreID:          &store,
+		Status:           domain.StatusAccepted,
+		BuyerType:        "company",
+		BuyerCompanyName: "บริษัท ลูกค้า จำกัด",
+		SellerType:       "company",
+		DiscountType:     DiscountTypeAmount,
+		DiscountValue:    money.FromBaht(10),
+		Items: []domain.InvoiceItem{
+			{ID: 11, DocumentID: 5, ProductName: "A", Qty: 2, UnitPrice: money.FromBaht(100), VatType: VatTypeExclude, VatRate: 7},
+		},
+	}
+	doc := &domain.InvoiceDocument{}
+	issue := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	items, err := convertQuotation(q, doc, domain.DocumentTypeTaxInvoice, domain.StatusDraft, issue)
+	if err != nil {
+		t.Fatalf("convertQuotation returned error: %v", err)
+	}
+	if doc.DocumentType != domain.DocumentTypeTaxInvoice || doc.StoreID != q.StoreID || !doc.IssueDate.Equal(issue) {
+		t.Errorf("unexpected document: %+v", doc)
+	}
+	if doc.BuyerCompanyName != q.BuyerCompanyName || doc.DocumentNo != "" || doc.ValidUntil != nil {
+		t.Errorf("parties not copied correctly: %+v", doc)
+	}
+	if len(items) != 1 || items[0].ID != 0 || items[0].DocumentID != 0 || items[0].LineTotal != money.FromBaht(200) {
+		t.Errorf("unexpected items: %+v", items)
+	}
+	// 200 - 10 discount + 7% VAT
+	if doc.GrandTotal != money.MustParse("203.30") {
+		t.Errorf("grand total = %s", doc.GrandTotal)
+	}
+	if q.Items[0].ID != 11 {
+		t.Error("quotation items were modified")
+	}
+}
+
+func TestQuotationExpired(t *testing.T) {
+	bkk := time.FixedZone("ICT", 7*3600)
+	today := startOfDay(time.Date(2026, 10, 18, 9, 0, 0, 0, bkk))
+	date := func(d int) *time.Time {
+		v := time.Date(2026, 10, d, 0, 0, 0, 0, time.UTC) // as loaded from a date column
+		return &v
+	}
+	if quotationExpired(&domain.InvoiceDocument{ValidUntil: date(18)}, today) {
+		t.Error("quotation is valid through its last day")
+	}
+	if !quotationExpired(&domain.InvoiceDocument{ValidUntil: date(17)}, today) {
+		t.Error("quotation past its validity should be expired")
+	}
+	if quotationExpired(&domain.InvoiceDocument{}, today) {
+		t.Error("quotation without a validity date never expires")
+	}
+}
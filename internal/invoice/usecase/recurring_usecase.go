@@ -0,0 +1,377 @@
+package usecase
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"invoice_project/internal/invoice/domain"
+	"invoice_project/internal/invoice/repository"
+	"invoice_project/pkg/apperror"
+	"invoice_project/pkg/cron"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type RecurringUsecase interface {
+	CreateSchedule(ctx context.Context, s *domain.RecurringSchedule, changedBy string) (*domain.RecurringSchedule, error)
+	GetSchedule(ctx context.Context, id uint) (*domain.RecurringSchedule, error)
+	ListSchedules(ctx context.Context, storeID string) ([]domain.RecurringSchedule, error)
+	PauseSchedule(ctx context.Context, id uint) (*domain.RecurringSchedule, error)
+	ResumeSchedule(ctx context.Context, id uint) (*domain.RecurringSchedule, error)
+	SetEndDate(ctx context.Context, id uint, endDate *time.Time) (*domain.RecurringSchedule, error)
+	RunDue(ctx context.Context) (int, error)
+}
+
+type recurringUC struct {
+	repo  repository.RecurringRepository
+	docs  repository.InvoiceDocumentRepository
+	docUC InvoiceDocumentUsecase
+	now   func() time.Time
+}
+
+// NewRecurringUsecase creates the usecase for recurring schedules.
+// Documents are generated through docUC, so they are validated, priced and
+// numbered like any other document.
+func NewRecurringUsecase(repo repository.RecurringRepository, docs repository.InvoiceDocumentRepository, docUC InvoiceDocumentUsecase) RecurringUsecase {
+	return &recurringUC{repo: repo, docs: docs, docUC: docUC, now: time.Now}
+}
+
+func (u *recurringUC) CreateSchedule(ctx context.Context, s *domain.RecurringSchedule, changedBy string) (*domain.RecurringSchedule, error) {
+	if s == nil || s.TemplateDocumentID == 0 {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	if _, err := uuid.Parse(s.StoreID); err != nil {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	switch s.IssueStatus {
+	case "":
+		s.IssueStatus = domain.StatusDraft
+	case domain.StatusDraft, domain.StatusIssued:
+	default:
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	if s.StartDate.IsZero() {
+		s.StartDate = u.now()
+	}
+	if s.EndDate != nil && s.EndDate.Before(s.StartDate) {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+
+	tpl, err := u.docs.GetDocument(ctx, s.TemplateDocumentID)
+	if err != nil {
+		return nil, err
+	}
+	if tpl == nil || tpl.StoreID == nil || *tpl.StoreID != s.StoreID {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	if !domain.IsRecurrable(tpl.DocumentType) || len(tpl.Items) == 0 {
+		return nil, apperror.New(fiber.StatusUnprocessableEntity)
+	}
+
+	s.ID = 0
+	s.Status = domain.ScheduleActive
+	s.Period = 0
+	s.LastRunAt = nil
+	s.Runs = nil
+	s.CreatedBy = changedBy
+	first, err := dueDate(s, 0, scheduleStart(s).Add(-time.Minute))
+	if err != nil {
+		return nil, err
+	}
+	s.NextRunAt = &first
+	endIfPastEndDate(s)
+	if err := u.repo.CreateSchedule(ctx, s); err != nil {
+		return nil, err
+	}
+	return u.repo.GetSchedule(ctx, s.ID)
+}
+
+// dueDate returns the due date of period in the server's time zone.
+// Calendar frequencies count whole months from the start date, clamped to
+// the end of shorter months; cron schedules take the first match after the
+// previous due date.
+func dueDate(s *domain.RecurringSchedule, period int, prev time.Time) (time.Time, error) {
+	if months := domain.FrequencyMonths(s.Frequency); months > 0 {
+		s.CronExpr = ""
+		return addMonths(scheduleStart(s), period*months), nil
+	}
+	if s.Frequency != domain.FrequencyCron {
+		return time.Time{}, apperror.New(fiber.StatusBadRequest)
+	}
+	expr, err := cron.Parse(s.CronExpr)
+	if err != nil {
+		return time.Time{}, apperror.New(fiber.StatusBadRequest)
+	}
+	next := expr.Next(prev.In(time.Local))
+	if next.IsZero() {
+		return time.Time{}, apperror.New(fiber.StatusBadRequest)
+	}
+	return next, nil
+}
+
+// scheduleStart returns the start of the schedule's first day in the
+// server's time zone.
+func scheduleStart(s *domain.RecurringSchedule) time.Time {
+	y, m, d := s.StartDate.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+}
+
+// addMonths adds n months to t, keeping the day of month where the target
+// month has it and using its last day otherwise.
+func addMonths(t time.Time, n int) time.Time {
+	y, m, d := t.Date()
+	first := time.Date(y, m+time.Month(n), 1, 0, 0, 0, 0, t.Location())
+	last := first.AddDate(0, 1, -1).Day()
+	if d > last {
+		d = last
+	}
+	return time.Date(first.Year(), first.Month(), d, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+}
+
+// endIfPastEndDate ends s once its next due date falls after its end date.
+func endIfPastEndDate(s *domain.RecurringSchedule) {
+	if s.EndDate == nil || s.NextRunAt == nil {
+		return
+	}
+	y, m, d := s.EndDate.Date()
+	if !s.NextRunAt.Before(time.Date(y, m, d+1, 0, 0, 0, 0, s.NextRunAt.Location())) {
+		s.Status = domain.ScheduleEnded
+		s.NextRunAt = nil
+	}
+}
+
+// advance moves s to its next period.
+func advance(s *domain.RecurringSchedule) error {
+	next, err := dueDate(s, s.Period+1, *s.NextRunAt)
+	if err != nil {
+		return err
+	}
+	s.Period++
+	s.NextRunAt = &next
+	endIfPastEndDate(s)
+	return nil
+}
+
+func (u *recurringUC) GetSchedule(ctx context.Context, id uint) (*domain.RecurringSchedule, error) {
+	if id == 0 {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	s, err := u.repo.GetSchedule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return nil, apperror.New(fiber.StatusNotFound)
+	}
+	return s, nil
+}
+
+func (u *recurringUC) ListSchedules(ctx context.Context, storeID string) ([]domain.RecurringSchedule, error) {
+	if _, err := uuid.Parse(storeID); err != nil {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	return u.repo.ListSchedules(ctx, storeID)
+}
+
+func (u *recurringUC) PauseSchedule(ctx context.Context, id uint) (*domain.RecurringSchedule, error) {
+	return u.update(ctx, id, func(s *domain.RecurringSchedule) error {
+		if s.Status != domain.ScheduleActive {
+			return apperror.New(fiber.StatusConflict)
+		}
+		s.Status = domain.SchedulePaused
+		return nil
+	})
+}
+
+// ResumeSchedule reactivates a paused schedule. Periods that fell due
+// while it was paused are skipped, not billed.
+func (u *recurringUC) ResumeSchedule(ctx context.Context, id uint) (*domain.RecurringSchedule, error) {
+	now := u.now()
+	return u.update(ctx, id, func(s *domain.RecurringSchedule) error {
+		if s.Status != domain.SchedulePaused {
+			return apperror.New(fiber.StatusConflict)
+		}
+		s.Status = domain.ScheduleActive
+		return skipMissed(s, now)
+	})
+}
+
+// skipMissed advances s past every period due before today.
+func skipMissed(s *domain.RecurringSchedule, now time.Time) error {
+	today := startOfDay(now)
+	for s.Status == domain.ScheduleActive && s.NextRunAt != nil && s.NextRunAt.Before(today) {
+		if err := advance(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetEndDate changes or clears the last date a schedule bills. An ended
+// schedule whose new end date leaves periods to bill becomes active again.
+func (u *recurringUC) SetEndDate(ctx context.Context, id uint, endDate *time.Time) (*domain.RecurringSchedule, error) {
+	now := u.now()
+	return u.update(ctx, id, func(s *domain.RecurringSchedule) error {
+		if endDate != nil && endDate.Before(s.StartDate) {
+			return apperror.New(fiber.StatusBadRequest)
+		}
+		s.EndDate = endDate
+		if s.Status == domain.ScheduleEnded {
+			prev := scheduleStart(s).Add(-time.Minute)
+			if s.LastRunAt != nil {
+				prev = *s.LastRunAt
+			}
+			next, err := dueDate(s, s.Period, prev)
+			if err != nil {
+				return err
+			}
+			s.Status = domain.ScheduleActive
+			s.NextRunAt = &next
+			if err := skipMissed(s, now); err != nil {
+				return err
+			}
+		}
+		endIfPastEndDate(s)
+		return nil
+	})
+}
+
+func (u *recurringUC) update(ctx context.Context, id uint, update repository.ScheduleUpdate) (*domain.RecurringSchedule, error) {
+	if id == 0 {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	if err := u.repo.UpdateSchedule(ctx, id, update); err != nil {
+		return nil, err
+	}
+	return u.repo.GetSchedule(ctx, id)
+}
+
+// RunDue generates the documents of every schedule that is due, including
+// periods missed while the server was down, and returns how many were
+// generated. A schedule whose document cannot be generated is paused and
+// the error recorded in its history.
+func (u *recurringUC) RunDue(ctx context.Context) (int, error) {
+	generated := 0
+	for {
+		found, err := u.repo.RunNext(ctx, u.now(), func(ctx context.Context, s *domain.RecurringSchedule) (*domain.RecurringRun, error) {
+			run, err := u.generate(ctx, s)
+			if err != nil {
+				return nil, err
+			}
+			if run.DocumentID != nil {
+				generated++
+			}
+			return run, nil
+		})
+		if err != nil || !found {
+			return generated, err
+		}
+	}
+}
+
+func (u *recurringUC) generate(ctx context.Context, s *domain.RecurringSchedule) (*domain.RecurringRun, error) {
+	due := *s.NextRunAt
+	run := &domain.RecurringRun{ScheduledFor: due}
+
+	doc, items, err := u.documentFor(ctx, s, due)
+	if err == nil {
+		err = u.docUC.CreateDocument(ctx, doc, items, SystemActor)
+	}
+	if err != nil {
+		log.Printf("recurring schedule %d: %v", s.ID, err)
+		run.Error = err.Error()
+		s.Status = domain.SchedulePaused
+		return run, nil
+	}
+
+	run.DocumentID = &doc.ID
+	s.LastRunAt = &due
+	if err := advance(s); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+func (u *recurringUC) documentFor(ctx context.Context, s *domain.RecurringSchedule, due time.Time) (*domain.InvoiceDocument, []domain.InvoiceItem, error) {
+	tpl, err := u.docs.GetDocument(ctx, s.TemplateDocumentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if tpl == nil {
+		return nil, nil, apperror.New(fiber.StatusNotFound)
+	}
+	doc, items := documentFromTemplate(tpl, s, due)
+	return doc, items, nil
+}
+
+// documentFromTemplate copies the parties, currency, discount and items of
+// the template into a new document issued on the due date. A rate agreed
+// on a draft template is kept; the rate frozen on an issued template only
+// applied on its own issue date, so the document gets the rate of the due
+// date when it is issued.
+func documentFromTemplate(tpl *domain.InvoiceDocument, s *domain.RecurringSchedule, due time.Time) (*domain.InvoiceDocument, []domain.InvoiceItem) {
+	storeID := s.StoreID
+	customerID := tpl.CustomerID
+	if s.CustomerID != nil {
+		customerID = s.CustomerID
+	}
+	doc := &domain.InvoiceDocument{
+		DocumentType:      tpl.DocumentType,
+		StoreID:           &storeID,
+		CustomerID:        customerID,
+		IssueDate:         startOfDay(due),
+		Status:            s.IssueStatus,
+		BuyerType:         tpl.BuyerType,
+		BuyerFirstName:    tpl.BuyerFirstName,
+		BuyerLastName:     tpl.BuyerLastName,
+		BuyerCompanyName:  tpl.BuyerCompanyName,
+		BuyerTaxID:        tpl.BuyerTaxID,
+		BuyerBranchNo:     tpl.BuyerBranchNo,
+		BuyerAddress:      tpl.BuyerAddress,
+		SellerType:        tpl.SellerType,
+		SellerFirstName:   tpl.SellerFirstName,
+		SellerLastName:    tpl.SellerLastName,
+		SellerCompanyName: tpl.SellerCompanyName,
+		SellerTaxID:       tpl.SellerTaxID,
+		SellerBranchNo:    tpl.SellerBranchNo,
+		SellerAddress:     tpl.SellerAddress,
+		DiscountType:      tpl.DiscountType,
+		DiscountValue:     tpl.DiscountValue,
+		WhtRate:           tpl.WhtRate,
+		Remarks:           tpl.Remarks,
+		Currency:          tpl.Currency,
+	}
+	if tpl.Status == domain.StatusDraft && tpl.ExchangeRate > 0 {
+		doc.ExchangeRate = tpl.ExchangeRate
+		doc.RateDate = tpl.RateDate
+	}
+	items := make([]domain.InvoiceItem, len(tpl.Items))
+	for i, it := range tpl.Items {
+		it.ID = 0
+		it.DocumentID = 0
+		it.LineTotal = 0
+		items[i] = it
+	}
+	return doc, items
+}
+
+// RunRecurringSchedules generates due documents now and then every
+// interval until ctx is cancelled. Every replica may run it.
+func RunRecurringSchedules(ctx context.Context, uc RecurringUsecase, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if n, err := uc.RunDue(ctx); err != nil {
+			log.Printf("recurring schedules failed: %v", err)
+		} else if n > 0 {
+			log.Printf("generated %d recurring documents", n)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"invoice_project/internal/invoice/domain"
+	"invoice_project/pkg/money"
+)
+
+func TestAdvance_MonthlyClampsWithoutDrift(t *testing.T) {
+	s := &domain.RecurringSchedule{
+		Frequency: domain.FrequencyMonthly,
+		StartDate: time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC), // as loaded from a date column
+		Status:    domain.ScheduleActive,
+	}
+	first, err := dueDate(s, 0, scheduleStart(s).Add(-time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.NextRunAt = &first
+	want := []string{"2026-02-28", "2026-03-31", "2026-04-30"}
+	for _, w := range want {
+		if err := advance(s); err != nil {
+			t.Fatal(err)
+		}
+		if got := s.NextRunAt.Format("2006-01-02"); got != w {
+			t.Errorf("period %d due %s, want %s", s.Period, got, w)
+		}
+	}
+}
+
+func TestAdvance_EndsAfterEndDate(t *testing.T) {
+	end := time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC)
+	s := &domain.RecurringSchedule{
+		Frequency: domain.FrequencyQuarterly,
+		StartDate: time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:   &end,
+		Status:    domain.ScheduleActive,
+	}
+	next := scheduleStart(s)
+	s.NextRunAt = &next
+	if err := advance(s); err != nil || s.Status != domain.ScheduleActive {
+		t.Fatalf("expected October to be billed: %v %+v", err, s)
+	}
+	if err := advance(s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Status != domain.ScheduleEnded || s.NextRunAt != nil {
+		t.Errorf("expected schedule to end, got %+v", s)
+	}
+}
+
+func TestDueDate_Cron(t *testing.T) {
+	s := &domain.RecurringSchedule{Frequency: domain.FrequencyCron, CronExpr: "0 9 15 * *"}
+	prev := time.Date(2026, 10, 15, 9, 0, 0, 0, time.Local)
+	next, err := dueDate(s, 1, prev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !next.Equal(time.Date(2026, 11, 15, 9, 0, 0, 0, time.Local)) {
+		t.Errorf("next = %v", next)
+	}
+	s.CronExpr = "every day"
+	if _, err := dueDate(s, 1, prev); err == nil {
+		t.Error("expected invalid cron expression to be rejected")
+	}
+}
+
+func TestSkipMissed(t *testing.T) {
+	s := &domain.RecurringSchedule{
+		Frequency: domain.FrequencyMonthly,
+		StartDate: time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC),
+		Status:    domain.ScheduleActive,
+	}
+	next := scheduleStart(s)
+	s.NextRunAt = &next
+	if err := skipMissed(s, time.Date(2026, 4, 10, 12, 0, 0, 0, time.Local)); err != nil {
+		t.Fatal(err)
+	}
+	// April 10 is due today and is still billed
+	if s.Period != 3 || s.NextRunAt.Format("2006-01-02") != "2026-04-10" {
+		t.Errorf("unexpected schedule after skipping: period %d next %v", s.Period, s.NextRunAt)
+	}
+}
+
+func TestDocumentFromTemplate(t *testing.T) {
+	store := "6f1c2a8e-3b7d-4c55-9a0e-2d4f5b6c7d8e"
+	tplCustomer, customer := uint(1), uint(2)
+	tpl := &domain.InvoiceDocument{
+		ID:               9,
+		DocumentType:     domain.DocumentTypeTaxInvoice,
+		DocumentNo:       "TAX-2026-000009",
+		StoreID:          &store,
+		CustomerID:       &tplCustomer,
+		Status:           domain.StatusPaid,
+		BuyerCompanyName: "บริษัท ลูกค้า จำกัด",
+		WhtRate:          3,
+		Items: []domain.InvoiceItem{
+			{ID: 4, DocumentID: 9, ProductName: "Subscription", Qty: 1, UnitPrice: money.FromBaht(990), LineTotal: money.FromBaht(990)},
+		},
+	}
+	s := &domain.RecurringSchedule{StoreID: store, CustomerID: &customer, IssueStatus: domain.StatusIssued}
+	due := time.Date(2026, 11, 1, 0, 0, 0, 0, time.Local)
+	doc, items := documentFromTemplate(tpl, s, due)
+	if doc.DocumentType != tpl.DocumentType || doc.Status != domain.StatusIssued || doc.DocumentNo != "" {
+		t.Errorf("unexpected document: %+v", doc)
+	}
+	if *doc.CustomerID != customer || !doc.IssueDate.Equal(due) || doc.WhtRate != 3 {
+		t.Errorf("unexpected customer, issue date or wht: %+v", doc)
+	}
+	if len(items) != 1 || items[0].ID != 0 || items[0].DocumentID != 0 || tpl.Items[0].ID != 4 {
+		t.Errorf("items not copied: %+v", items)
+	}
+}
+
+func TestDocumentFromTemplate_ForeignCurrency(t *testing.T) {
+	store := "6f1c2a8e-3b7d-4c55-9a0e-2d4f5b6c7d8e"
+	rateDate := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	tpl := &domain.InvoiceDocument{
+		ID:           42,
+		DocumentType: domain.DocumentTypeInvoice,
+		StoreID:      &store,
+		Status:       domain.StatusDraft,
+		Currency:     "USD",
+		ExchangeRate: money.MustParseRate("33.5"),
+		RateDate:     &rateDate,
+		Items: []domain.InvoiceItem{
+			{ID: 1, DocumentID: 42, ProductName: "Hosting", Qty: 1, UnitPrice: money.FromBaht(120), VatType: VatTypeExempt},
+		},
+	}
+	s := &domain.RecurringSchedule{StoreID: store, IssueStatus: domain.StatusIssued}
+	due := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+
+	doc, items := documentFromTemplate(tpl, s, due)
+	if doc.Currency != "USD" || doc.ExchangeRate != tpl.ExchangeRate || doc.RateDate != tpl.RateDate {
+		t.Errorf("agreed rate not copied: %s %s %v", doc.Currency, doc.ExchangeRate, doc.RateDate)
+	}
+	if len(items) != 1 || items[0].UnitPrice != money.FromBaht(120) {
+		t.Fatalf("unexpected items: %+v", items)
+	}
+	if err := freezeRate(context.Background(), nil, doc); err != nil {
+		t.Fatalf("freezeRate returned error: %v", err)
+	}
+
+	// the rate frozen on an issued template is not reused
+	tpl.Status = domain.StatusIssued
+	doc, _ = documentFromTemplate(tpl, s, due)
+	if doc.Currency != "USD" || doc.ExchangeRate != 0 || doc.RateDate != nil {
+		t.Errorf("frozen rate copied: %s %s %v", doc.Currency, doc.ExchangeRate, doc.RateDate)
+	}
+	if err := freezeRate(context.Background(), nil, doc); statusCode(err) != 422 {
+		t.Errorf("expected 422 without a rate, got %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"invoice_project/internal/invoice/domain"
+	"invoice_project/internal/invoice/repository"
+	"invoice_project/pkg/apperror"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type DocumentSequenceUsecase interface {
+	ListSequences(ctx context.Context, storeID string) ([]domain.DocumentSequence, error)
+	ConfigureSequence(ctx context.Context, in SequenceConfigInput) (*domain.DocumentSequence, error)
+}
+
+// SequenceConfigInput holds the configurable settings of a store sequence.
+// StartNumber may only be set before any number has been issued.
+type SequenceConfigInput struct {
+	StoreID      string
+	DocumentType string
+	FiscalYear   int
+	Prefix       *string
+	Format       *string
+	Padding      *int
+	StartNumber  *int
+}
+
+type sequenceUC struct {
+	repo repository.DocumentSequenceRepository
+}
+
+func NewDocumentSequenceUsecase(repo repository.DocumentSequenceRepository) DocumentSequenceUsecase {
+	return &sequenceUC{repo: repo}
+}
+
+func (u *sequenceUC) ListSequences(ctx context.Context, storeID string) ([]domain.DocumentSequence, error) {
+	if _, err := uuid.Parse(storeID); err != nil {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	return u.repo.ListSequences(ctx, storeID)
+}
+
+func (u *sequenceUC) ConfigureSequence(ctx context.Context, in SequenceConfigInput) (*domain.DocumentSequence, error) {
+	if _, err := uuid.Parse(in.StoreID); err != nil || in.DocumentType == "" {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	if in.FiscalYear == 0 {
+		in.FiscalYear = domain.FiscalYearOf(time.Now())
+	}
+
+	if in.Prefix != nil {
+		prefix := strings.TrimSpace(*in.Prefix)
+		in.Prefix = &prefix
+	}
+	if in.Format != nil && !strings.Contains(*in.Format, "{SEQ}") {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	if in.Padding != nil && (*in.Padding < 1 || *in.Padding > 12) {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	if in.StartNumber != nil && *in.StartNumber < 1 {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+
+	seq, err := u.repo.ConfigureSequence(ctx, in.StoreID, in.DocumentType, in.FiscalYear, func(seq *domain.DocumentSequence) error {
+		if in.Prefix != nil {
+			seq.Prefix = *in.Prefix
+		}
+		if in.Format != nil {
+			seq.Format = *in.Format
+		}
+		if in.Padding != nil {
+			seq.Padding = *in.Padding
+		}
+		if in.StartNumber != nil {
+			// moving the counter after issuing would create gaps or duplicates
+			if seq.LastNumber != 0 {
+				return apperror.New(fiber.StatusConflict)
+			}
+			seq.LastNumber = *in.StartNumber - 1
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return seq, nil
+}
@@ -0,0 +1,126 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"invoice_project/internal/invoice/domain"
+	"invoice_project/internal/invoice/repository"
+)
+
+type fakeSequenceRepo struct {
+	repository.DocumentSequenceRepository
+	seqs map[string]*domain.DocumentSequence
+}
+
+func (r *fakeSequenceRepo) ConfigureSequence(ctx context.Context, storeID, documentType string, fiscalYear int, update repository.SequenceUpdate) (*domain.DocumentSequence, error) {
+	key := storeID + "/" + documentType
+	stored, ok := r.seqs[key]
+	if !ok {
+		stored = &domain.DocumentSequence{
+			StoreID:      storeID,
+			DocumentType: documentType,
+			FiscalYear:   fiscalYear,
+			Prefix:       domain.DefaultSequencePrefix(documentType),
+			Format:       domain.DefaultSequenceFormat,
+			Padding:      domain.DefaultSequencePadding,
+		}
+	}
+	seq := *stored
+	if err := update(&seq); err != nil {
+		return nil, err
+	}
+	r.seqs[key] = &seq
+	return &seq, nil
+}
+
+func TestFormatNumber(t *testing.T) {
+	issue := time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		seq  domain.DocumentSequence
+		want string
+	}{
+		{"defaults", domain.DocumentSequence{Prefix: "INV", FiscalYear: 2026}, "INV-2026-000042"},
+		{"buddhist era and month", domain.DocumentSequence{Prefix: "TIV", Format: "{PREFIX}{BE}/{MM}-{SEQ}", Padding: 4, FiscalYear: 2026}, "TIV2569/03-0042"},
+		{"short year", domain.DocumentSequence{Prefix: "RC", Format: "{PREFIX}{YY}{SEQ}", Padding: 3, FiscalYear: 2026}, "RC26042"},
+		{"number wider than padding", domain.DocumentSequence{Prefix: "CN", Format: "{SEQ}", Padding: 1, FiscalYear: 2026}, "42"},
+	}
+	for _, tt := range tests {
+		if got := tt.seq.FormatNumber(42, issue); got != tt.want {
+			t.Errorf("%s: FormatNumber = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFiscalYearOf(t *testing.T) {
+	if got := domain.FiscalYearOf(time.Date(2026, 12, 31, 23, 0, 0, 0, time.UTC)); got != 2026 {
+		t.Errorf("FiscalYearOf(31 Dec 2026) = %d", got)
+	}
+	if got := domain.FiscalYearOf(time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)); got != 2027 {
+		t.Errorf("FiscalYearOf(1 Jan 2027) = %d", got)
+	}
+	if got := domain.FiscalYearOf(time.Time{}); got != time.Now().Year() {
+		t.Errorf("FiscalYearOf(zero) = %d, want this year", got)
+	}
+}
+
+func TestConfigureSequence(t *testing.T) {
+	store := "6f1c2a8e-3b7d-4c55-9a0e-2d4f5b6c7d8e"
+	repo := &fakeSequenceRepo{seqs: map[string]*domain.DocumentSequence{}}
+	uc := NewDocumentSequenceUsecase(repo)
+	ctx := context.Background()
+	str := func(s string) *string { return &s }
+	num := func(n int) *int { return &n }
+
+	seq, err := uc.ConfigureSequence(ctx, SequenceConfigInput{
+		StoreID:      store,
+		DocumentType: domain.DocumentTypeInvoice,
+		FiscalYear:   2026,
+		Prefix:       str(" IV "),
+		Padding:      num(4),
+		StartNumber:  num(100),
+	})
+	if err != nil {
+		t.Fatalf("ConfigureSequence returned error: %v", err)
+	}
+	if seq.Prefix != "IV" || seq.Padding != 4 || seq.LastNumber != 99 || seq.Format != domain.DefaultSequenceFormat {
+		t.Errorf("unexpected sequence: %+v", seq)
+	}
+	if got := seq.FormatNumber(seq.LastNumber+1, time.Now()); got != "IV-2026-0100" {
+		t.Errorf("next number = %q", got)
+	}
+
+	bad := []SequenceConfigInput{
+		{StoreID: "not-a-uuid", DocumentType: domain.DocumentTypeInvoice},
+		{StoreID: store},
+		{StoreID: store, DocumentType: domain.DocumentTypeInvoice, Format: str("{PREFIX}-{YYYY}")},
+		{StoreID: store, DocumentType: domain.DocumentTypeInvoice, Padding: num(0)},
+		{StoreID: store, DocumentType: domain.DocumentTypeInvoice, Padding: num(13)},
+		{StoreID: store, DocumentType: domain.DocumentTypeInvoice, StartNumber: num(0)},
+	}
+	for _, in := range bad {
+		if _, err := uc.ConfigureSequence(ctx, in); statusCode(err) != 400 {
+			t.Errorf("%+v: expected 400, got %v", in, err)
+		}
+	}
+
+	// once numbers are issued the counter cannot move, but the format can
+	repo.seqs[store+"/"+domain.DocumentTypeInvoice].LastNumber = 120
+	_, err = uc.ConfigureSequence(ctx, SequenceConfigInput{
+		StoreID: store, DocumentType: domain.DocumentTypeInvoice, FiscalYear: 2026, StartNumber: num(1),
+	})
+	if statusCode(err) != 409 {
+		t.Errorf("expected 409 moving an issued counter, got %v", err)
+	}
+	seq, err = uc.ConfigureSequence(ctx, SequenceConfigInput{
+		StoreID: store, DocumentType: domain.DocumentTypeInvoice, FiscalYear: 2026, Format: str("{PREFIX}{BE}-{SEQ}"),
+	})
+	if err != nil {
+		t.Fatalf("ConfigureSequence returned error: %v", err)
+	}
+	if seq.LastNumber != 120 || seq.FormatNumber(121, time.Now()) != "IV2569-0121" {
+		t.Errorf("unexpected sequence: %+v", seq)
+	}
+}
@@ -0,0 +1,243 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"invoice_project/internal/invoice/domain"
+	"invoice_project/internal/invoice/repository"
+	"invoice_project/pkg/apperror"
+	"invoice_project/pkg/middleware"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// SharePath is where the public routes serve shared documents, at
+// SharePath/<token>.
+const SharePath = "/public/documents"
+
+type DocumentShareUsecase interface {
+	CreateShare(ctx context.Context, documentID uint, expiresAt *time.Time, createdBy string) (*domain.ShareLink, error)
+	ListShares(ctx context.Context, documentID uint) ([]domain.DocumentShare, error)
+	RevokeShare(ctx context.Context, documentID uint, shareID, revokedBy string) error
+	OpenShare(ctx context.Context, token string) (*domain.SharedDocument, error)
+	SharedPDF(ctx context.Context, token string) ([]byte, *domain.InvoiceDocument, error)
+	SharedPromptPayPNG(ctx context.Context, token string) ([]byte, error)
+}
+
+type documentShareUC struct {
+	shares    repository.DocumentShareRepository
+	docs      repository.InvoiceDocumentRepository
+	pdf       DocumentPDFUsecase
+	promptPay PromptPayUsecase
+	secret    string
+	baseURL   string
+}
+
+// NewDocumentShareUsecase creates the usecase for public document links.
+// Tokens are signed with secret, which must differ from the access token
+// key; sharing returns 503 while it is empty. Links are built on baseURL,
+// the public address of the API, or left relative when it is empty.
+func NewDocumentShareUsecase(shares repository.DocumentShareRepository, docs repository.InvoiceDocumentRepository, pdf DocumentPDFUsecase, promptPay PromptPayUsecase, secret, baseURL string) DocumentShareUsecase {
+	return &documentShareUC{shares: shares, docs: docs, pdf: pdf, promptPay: promptPay, secret: secret, baseURL: strings.TrimRight(baseURL, "/")}
+}
+
+// CreateShare creates a read-only link to a document valid until
+// expiresAt, DefaultShareDays from now when nil and at most MaxShareDays.
+// Drafts and voided or cancelled documents cannot be shared.
+func (u *documentShareUC) CreateShare(ctx context.Context, documentID uint, expiresAt *time.Time, createdBy string) (*domain.ShareLink, error) {
+	if documentID == 0 {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	if u.secret == "" {
+		return nil, apperror.New(fiber.StatusServiceUnavailable)
+	}
+	now := time.Now()
+	expires := now.AddDate(0, 0, domain.DefaultShareDays)
+	if expiresAt != nil {
+		expires = *expiresAt
+		if !expires.After(now) || expires.After(now.AddDate(0, 0, domain.MaxShareDays)) {
+			return nil, apperror.New(fiber.StatusBadRequest)
+		}
+	}
+	doc, err := u.docs.GetDocument(ctx, documentID)
+	if err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		return nil, apperror.New(fiber.StatusNotFound)
+	}
+	if !domain.IsSendable(doc.Status) {
+		return nil, apperror.New(fiber.StatusConflict)
+	}
+
+	id := uuid.New()
+	token, err := middleware.GenerateShareToken(u.secret, id, expires)
+	if err != nil {
+		return nil, err
+	}
+	s := domain.DocumentShare{
+		ID:         id.String(),
+		DocumentID: doc.ID,
+		ExpiresAt:  expires,
+		CreatedBy:  createdBy,
+	}
+	if err := u.shares.CreateShare(ctx, &s); err != nil {
+		return nil, err
+	}
+	return &domain.ShareLink{DocumentShare: s, Token: token, URL: u.baseURL + SharePath + "/" + token}, nil
+}
+
+// ListShares returns the links of a document with their view counts.
+func (u *documentShareUC) ListShares(ctx context.Context, documentID uint) ([]domain.DocumentShare, error) {
+	if documentID == 0 {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	return u.shares.ListShares(ctx, documentID)
+}
+
+// RevokeShare ends a link of a document before it expires.
+func (u *documentShareUC) RevokeShare(ctx context.Context, documentID uint, shareID, revokedBy string) error {
+	if documentID == 0 {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	if _, err := uuid.Parse(shareID); err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	return u.shares.RevokeShare(ctx, documentID, shareID, revokedBy, time.Now())
+}
+
+// OpenShare returns what the buyer may see of the document a share token
+// grants access to, with its PromptPay code while unpaid, and counts the
+// view. Invalid, expired and revoked tokens, and links to documents voided
+// or cancelled since, are all reported as not found.
+func (u *documentShareUC) OpenShare(ctx context.Context, token string) (*domain.SharedDocument, error) {
+	doc, err := u.view(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	payment, err := u.promptPay.DocumentQR(ctx, doc)
+	if err != nil {
+		return nil, err
+	}
+	return sharedDocument(doc, payment), nil
+}
+
+// view opens a share token and counts the view.
+func (u *documentShareUC) view(ctx context.Context, token string) (*domain.InvoiceDocument, error) {
+	s, doc, err := u.open(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.shares.RecordView(ctx, s.ID, time.Now()); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// open resolves a share token to its link and document.
+func (u *documentShareUC) open(ctx context.Context, token string) (*domain.DocumentShare, *domain.InvoiceDocument, error) {
+	if u.secret == "" {
+		return nil, nil, apperror.New(fiber.StatusNotFound)
+	}
+	id, err := middleware.ParseShareToken(u.secret, token)
+	if err != nil {
+		return nil, nil, apperror.New(fiber.StatusNotFound)
+	}
+	s, err := u.shares.GetShare(ctx, id.String())
+	if err != nil {
+		return nil, nil, err
+	}
+	if s == nil || !s.IsActive(time.Now()) {
+		return nil, nil, apperror.New(fiber.StatusNotFound)
+	}
+	doc, err := u.docs.GetDocument(ctx, s.DocumentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if doc == nil || !domain.IsSendable(doc.Status) {
+		return nil, nil, apperror.New(fiber.StatusNotFound)
+	}
+	return s, doc, nil
+}
+
+// sharedDocument copies the public details of doc.
+func sharedDocument(doc *domain.InvoiceDocument, qr *domain.PaymentQR) *domain.SharedDocument {
+	d := &domain.SharedDocument{
+		DocumentType: doc.DocumentType,
+		DocumentNo:   doc.DocumentNo,
+		IssueDate:    doc.IssueDate,
+		ValidUntil:   doc.ValidUntil,
+		Status:       doc.Status,
+		Seller: domain.SharedParty{
+			Name:     PartyName(doc.SellerType, doc.SellerCompanyName, doc.SellerFirstName, doc.SellerLastName),
+			TaxID:    doc.SellerTaxID,
+			BranchNo: doc.SellerBranchNo,
+			Address:  doc.SellerAddress,
+		},
+		Buyer: domain.SharedParty{
+			Name:     PartyName(doc.BuyerType, doc.BuyerCompanyName, doc.BuyerFirstName, doc.BuyerLastName),
+			TaxID:    doc.BuyerTaxID,
+			BranchNo: doc.BuyerBranchNo,
+			Address:  doc.BuyerAddress,
+		},
+		Currency:         doc.Currency,
+		Subtotal:         doc.Subtotal,
+		DiscountAmount:   doc.DiscountAmount,
+		VatAmount:        doc.VatAmount,
+		GrandTotal:       doc.GrandTotal,
+		WhtAmount:        doc.WhtAmount,
+		AdjustmentReason: doc.AdjustmentReason,
+		Remarks:          doc.Remarks,
+		Items:            make([]domain.SharedItem, len(doc.Items)),
+	}
+	for i, it := range doc.Items {
+		d.Items[i] = domain.SharedItem{
+			ProductName: it.ProductName,
+			Sku:         it.Sku,
+			Qty:         it.Qty,
+			UnitPrice:   it.UnitPrice,
+			Discount:    it.Discount,
+			VatType:     it.VatType,
+			VatRate:     it.VatRate,
+			LineTotal:   it.LineTotal,
+		}
+	}
+	if qr != nil {
+		d.Payment = &domain.SharedPayment{Amount: qr.Amount, Payload: qr.Payload}
+	}
+	return d
+}
+
+// SharedPDF renders the document a share token grants access to.
+func (u *documentShareUC) SharedPDF(ctx context.Context, token string) ([]byte, *domain.InvoiceDocument, error) {
+	doc, err := u.view(ctx, token)
+	if err != nil {
+		return nil, nil, err
+	}
+	b, _, err := u.pdf.RenderPDF(ctx, doc.ID, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	return b, doc, nil
+}
+
+// SharedPromptPayPNG returns the PromptPay code of a shared document as a
+// PNG image. It is not counted as a view, the share page embeds it. A
+// document without a code is not found.
+func (u *documentShareUC) SharedPromptPayPNG(ctx context.Context, token string) ([]byte, error) {
+	_, doc, err := u.open(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	code, err := u.promptPay.DocumentQR(ctx, doc)
+	if err != nil {
+		return nil, err
+	}
+	if code == nil {
+		return nil, apperror.New(fiber.StatusNotFound)
+	}
+	return paymentQRPNG(code)
+}
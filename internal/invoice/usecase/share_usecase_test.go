@@ -0,0 +1,159 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"invoice_project/internal/invoice/domain"
+	"invoice_project/internal/invoice/repository"
+	"invoice_project/pkg/middleware"
+
+	"github.com/google/uuid"
+)
+
+// shareRepo keeps share links in memory.
+type shareRepo struct {
+	repository.DocumentShareRepository
+	shares map[string]*domain.DocumentShare
+}
+
+func (r *shareRepo) CreateShare(ctx context.Context, s *domain.DocumentShare) error {
+	c := *s
+	r.shares[s.ID] = &c
+	return nil
+}
+
+func (r *shareRepo) GetShare(ctx context.Context, id string) (*domain.DocumentShare, error) {
+	s, ok := r.shares[id]
+	if !ok {
+		return nil, nil
+	}
+	c := *s
+	return &c, nil
+}
+
+func (r *shareRepo) RecordView(ctx context.Context, id string, at time.Time) error {
+	s := r.shares[id]
+	s.ViewCount++
+	s.LastViewedAt = &at
+	if s.FirstViewedAt == nil {
+		s.FirstViewedAt = &at
+	}
+	return nil
+}
+
+func newShareUC() (*documentShareUC, *shareRepo, *sendRepo) {
+	docs := &sendRepo{doc: domain.InvoiceDocument{
+		ID:           1,
+		DocumentType: domain.DocumentTypeInvoice,
+		DocumentNo:   "INV-2026-000001",
+		Status:       domain.StatusIssued,
+		Currency:     domain.CurrencyTHB,
+		Timelines:    []domain.DocumentTimeline{{EventType: domain.EventCreated, ChangedBy: "u"}},
+	}}
+	shares := &shareRepo{shares: map[string]*domain.DocumentShare{}}
+	promptPay, _, _ := newPromptPayUC(docs)
+	return &documentShareUC{shares: shares, docs: docs, promptPay: promptPay, secret: "secret", baseURL: "https://api.example.com"}, shares, docs
+}
+
+func TestShare_CreateAndOpen(t *testing.T) {
+	uc, shares, _ := newShareUC()
+	ctx := context.Background()
+
+	link, err := uc.CreateShare(ctx, 1, nil, "u")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(link.URL, "https://api.example.com/public/documents/") || !strings.HasSuffix(link.URL, link.Token) {
+		t.Errorf("url = %s", link.URL)
+	}
+	if days := time.Until(link.ExpiresAt).Hours() / 24; days < domain.DefaultShareDays-1 || days > domain.DefaultShareDays {
+		t.Errorf("expires in %.1f days", days)
+	}
+
+	for i := 0; i < 2; i++ {
+		doc, err := uc.OpenShare(ctx, link.Token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if doc.DocumentNo != "INV-2026-000001" || doc.Status != domain.StatusIssued {
+			t.Errorf("doc = %+v", doc)
+		}
+	}
+	s := shares.shares[link.ID]
+	if s.ViewCount != 2 || s.FirstViewedAt == nil || s.LastViewedAt.Before(*s.FirstViewedAt) {
+		t.Errorf("views not tracked: %+v", s)
+	}
+}
+
+func TestShare_Rejected(t *testing.T) {
+	ctx := context.Background()
+
+	uc, _, docs := newShareUC()
+	docs.doc.Status = domain.StatusDraft
+	if _, err := uc.CreateShare(ctx, 1, nil, "u"); statusCode(err) != 409 {
+		t.Errorf("draft: got %v", err)
+	}
+
+	uc, shares, docs := newShareUC()
+	past := time.Now().Add(-time.Hour)
+	tooLate := time.Now().AddDate(0, 0, domain.MaxShareDays+1)
+	for _, at := range []time.Time{past, tooLate} {
+		if _, err := uc.CreateShare(ctx, 1, &at, "u"); statusCode(err) != 400 {
+			t.Errorf("expires %v: got %v", at, err)
+		}
+	}
+
+	link, err := uc.CreateShare(ctx, 1, nil, "u")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	shares.shares[link.ID].RevokedAt = &now
+	if _, err := uc.OpenShare(ctx, link.Token); statusCode(err) != 404 {
+		t.Errorf("revoked: got %v", err)
+	}
+
+	// the stored expiry wins over a longer token
+	link, _ = uc.CreateShare(ctx, 1, nil, "u")
+	shares.shares[link.ID].ExpiresAt = now.Add(-time.Minute)
+	if _, err := uc.OpenShare(ctx, link.Token); statusCode(err) != 404 {
+		t.Errorf("expired: got %v", err)
+	}
+
+	// a document voided or cancelled after sharing is no longer served
+	link, _ = uc.CreateShare(ctx, 1, nil, "u")
+	for _, status := range []string{domain.StatusVoid, domain.StatusCancelled} {
+		docs.doc.Status = status
+		if _, err := uc.OpenShare(ctx, link.Token); statusCode(err) != 404 {
+			t.Errorf("%s: got %v", status, err)
+		}
+	}
+	docs.doc.Status = domain.StatusIssued
+
+	forged, _ := middleware.GenerateShareToken("other", uuid.New(), now.Add(time.Hour))
+	for _, token := range []string{"", "garbage", forged} {
+		if _, err := uc.OpenShare(ctx, token); statusCode(err) != 404 {
+			t.Errorf("token %q: got %v", token, err)
+		}
+	}
+}
+
+func TestShare_NoSecret(t *testing.T) {
+	ctx := context.Background()
+	uc, _, _ := newShareUC()
+	link, err := uc.CreateShare(ctx, 1, nil, "u")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	uc.secret = ""
+	if _, err := uc.CreateShare(ctx, 1, nil, "u"); statusCode(err) != 503 {
+		t.Errorf("create: got %v", err)
+	}
+	if _, err := uc.OpenShare(ctx, link.Token); statusCode(err) != 404 {
+		t.Errorf("open: got %v", err)
+	}
+}
@@ -0,0 +1,345 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"strings"
+	"unicode"
+
+	"invoice_project/internal/invoice/domain"
+	"invoice_project/internal/invoice/repository"
+	"invoice_project/pkg/apperror"
+	"invoice_project/pkg/bankstatement"
+	"invoice_project/pkg/money"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type BankStatementUsecase interface {
+	ImportStatement(ctx context.Context, in ImportStatementInput, importedBy string) (*domain.BankStatement, error)
+	GetStatement(ctx context.Context, id uint) (*domain.BankStatement, error)
+	ListStatements(ctx context.Context, storeID string) ([]domain.BankStatement, error)
+	MatchStatement(ctx context.Context, id uint, toleranceDays int) (*domain.BankStatement, error)
+	GetLine(ctx context.Context, id uint) (*domain.BankStatementLine, error)
+	ListLines(ctx context.Context, storeID, status string) ([]domain.BankStatementLine, error)
+	ConfirmLine(ctx context.Context, id uint, in ConfirmLineInput, reviewedBy string) (*domain.BankStatementLine, error)
+	IgnoreLine(ctx context.Context, id uint, reviewedBy string) (*domain.BankStatementLine, error)
+}
+
+// ImportStatementInput is an uploaded statement. Bank is one of
+// bankstatement.Banks or bankstatement.Generic, which reads the file with
+// Mapping. ToleranceDays defaults to domain.DefaultMatchToleranceDays.
+type ImportStatementInput struct {
+	StoreID       string
+	Bank          string
+	FileName      string
+	Data          []byte
+	Mapping       *bankstatement.Mapping
+	ToleranceDays int
+}
+
+// ConfirmLineInput settles a statement line. DocumentID puts the whole
+// line on one document; Allocations spread it over several, with the tax
+// each buyer withheld from the transfer in WhtAmount.
+type ConfirmLineInput struct {
+	DocumentID   uint
+	Allocations  []domain.PaymentAllocation
+	IssueReceipt bool
+}
+
+const (
+	// maxMatchToleranceDays bounds the tolerance a caller may ask for.
+	maxMatchToleranceDays = 90
+	// maxLineMatches is how many documents are proposed per line.
+	maxLineMatches = 5
+)
+
+type bankStatementUC struct {
+	repo     repository.BankStatementRepository
+	payments PaymentUsecase
+}
+
+func NewBankStatementUsecase(repo repository.BankStatementRepository, payments PaymentUsecase) BankStatementUsecase {
+	return &bankStatementUC{repo: repo, payments: payments}
+}
+
+// ImportStatement reads a statement and keeps the money it received as
+// lines, each with the open documents of the store it may pay. Payments
+// out of the account are counted in Skipped.
+func (u *bankStatementUC) ImportStatement(ctx context.Context, in ImportStatementInput, importedBy string) (*domain.BankStatement, error) {
+	if _, err := uuid.Parse(in.StoreID); err != nil {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	tolerance, err := matchTolerance(in.ToleranceDays)
+	if err != nil {
+		return nil, err
+	}
+	bank := strings.ToLower(in.Bank)
+	var mapping bankstatement.Mapping
+	if bank == bankstatement.Generic {
+		if in.Mapping == nil {
+			return nil, apperror.New(fiber.StatusBadRequest)
+		}
+		mapping = *in.Mapping
+	} else {
+		var ok bool
+		if mapping, ok = bankstatement.BankMapping(bank); !ok {
+			return nil, apperror.New(fiber.StatusBadRequest)
+		}
+	}
+	parsed, err := bankstatement.Parse(bytes.NewReader(in.Data), mapping)
+	if err != nil {
+		return nil, apperror.New(fiber.StatusUnprocessableEntity)
+	}
+
+	s := &domain.BankStatement{
+		StoreID:    in.StoreID,
+		Bank:       bank,
+		FileName:   in.FileName,
+		ImportedBy: importedBy,
+	}
+	for _, l := range parsed {
+		if l.Amount <= 0 {
+			s.Skipped++
+			continue
+		}
+		s.Lines = append(s.Lines, domain.BankStatementLine{
+			StoreID:         in.StoreID,
+			Row:             l.Row,
+			TransactionDate: l.Date,
+			Description:     l.Description,
+			Reference:       l.Reference,
+			Amount:          l.Amount,
+		})
+	}
+	docs, err := u.repo.ListOpenDocuments(ctx, in.StoreID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range s.Lines {
+		proposeMatches(&s.Lines[i], docs, tolerance)
+	}
+	if err := u.repo.CreateStatement(ctx, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func matchTolerance(days int) (int, error) {
+	if days < 0 || days > maxMatchToleranceDays {
+		return 0, apperror.New(fiber.StatusBadRequest)
+	}
+	if days == 0 {
+		days = domain.DefaultMatchToleranceDays
+	}
+	return days, nil
+}
+
+func (u *bankStatementUC) GetStatement(ctx context.Context, id uint) (*domain.BankStatement, error) {
+	if id == 0 {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	s, err := u.repo.GetStatement(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return nil, apperror.New(fiber.StatusNotFound)
+	}
+	return s, nil
+}
+
+func (u *bankStatementUC) ListStatements(ctx context.Context, storeID string) ([]domain.BankStatement, error) {
+	if storeID == "" {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	return u.repo.ListStatements(ctx, storeID)
+}
+
+// MatchStatement proposes documents again for the lines of a statement
+// still awaiting review, picking up documents issued or paid since the
+// import.
+func (u *bankStatementUC) MatchStatement(ctx context.Context, id uint, toleranceDays int) (*domain.BankStatement, error) {
+	tolerance, err := matchTolerance(toleranceDays)
+	if err != nil {
+		return nil, err
+	}
+	s, err := u.GetStatement(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	docs, err := u.repo.ListOpenDocuments(ctx, s.StoreID)
+	if err != nil {
+		return nil, err
+	}
+	var open []domain.BankStatementLine
+	for _, l := range s.Lines {
+		if l.Status == domain.StatementLineUnmatched || l.Status == domain.StatementLineProposed {
+			proposeMatches(&l, docs, tolerance)
+			open = append(open, l)
+		}
+	}
+	if err := u.repo.SaveMatches(ctx, open); err != nil {
+		return nil, err
+	}
+	return u.GetStatement(ctx, id)
+}
+
+func (u *bankStatementUC) GetLine(ctx context.Context, id uint) (*domain.BankStatementLine, error) {
+	if id == 0 {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	l, err := u.repo.GetLine(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if l == nil {
+		return nil, apperror.New(fiber.StatusNotFound)
+	}
+	return l, nil
+}
+
+// ListLines returns the lines of a store in a status, by default those
+// awaiting review.
+func (u *bankStatementUC) ListLines(ctx context.Context, storeID, status string) ([]domain.BankStatementLine, error) {
+	if storeID == "" {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	statuses := []string{domain.StatementLineUnmatched, domain.StatementLineProposed}
+	switch status {
+	case "":
+	case domain.StatementLineUnmatched, domain.StatementLineProposed, domain.StatementLineMatched, domain.StatementLineIgnored:
+		statuses = []string{status}
+	default:
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	return u.repo.ListLines(ctx, storeID, statuses)
+}
+
+// ConfirmLine records a transfer payment for a line awaiting review and
+// marks it matched. The payment is dated on the transaction and its
+// amount is the line plus any tax withheld, so the allocations must add
+// up to that.
+func (u *bankStatementUC) ConfirmLine(ctx context.Context, id uint, in ConfirmLineInput, reviewedBy string) (*domain.BankStatementLine, error) {
+	if id == 0 || (in.DocumentID == 0 && len(in.Allocations) == 0) {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	err := u.repo.ConfirmLine(ctx, id, reviewedBy, func(ctx context.Context, l *domain.BankStatementLine) (uint, error) {
+		allocations := in.Allocations
+		if len(allocations) == 0 {
+			allocations = []domain.PaymentAllocation{{DocumentID: in.DocumentID, Amount: l.Amount}}
+		}
+		var withheld money.Amount
+		for _, a := range allocations {
+			withheld = withheld.Add(a.WhtAmount)
+		}
+		p := &domain.Payment{
+			StoreID:     l.StoreID,
+			Method:      domain.PaymentMethodTransfer,
+			Amount:      l.Amount.Add(withheld),
+			PaymentDate: l.TransactionDate,
+			Reference:   lineReference(l),
+			Allocations: allocations,
+		}
+		if _, err := u.payments.RecordPayment(ctx, p, in.IssueReceipt, reviewedBy); err != nil {
+			return 0, err
+		}
+		return p.ID, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return u.GetLine(ctx, id)
+}
+
+// IgnoreLine sets aside a line that pays no document.
+func (u *bankStatementUC) IgnoreLine(ctx context.Context, id uint, reviewedBy string) (*domain.BankStatementLine, error) {
+	if id == 0 {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	if err := u.repo.IgnoreLine(ctx, id, reviewedBy); err != nil {
+		return nil, err
+	}
+	return u.GetLine(ctx, id)
+}
+
+// lineReference is the payment reference of a line, cut to the size of
+// Payment.Reference.
+func lineReference(l *domain.BankStatementLine) string {
+	ref := strings.TrimSpace(l.Reference + " " + l.Description)
+	if r := []rune(ref); len(r) > 255 {
+		ref = string(r[:255])
+	}
+	return ref
+}
+
+// proposeMatches sets the documents a credit line may pay, best first,
+// and the line's status. A document is a candidate when the line pays
+// exactly what is outstanding on it or quotes its number, and the line
+// neither exceeds its balance nor precedes its issue by more than
+// toleranceDays. A transfer within toleranceDays of the issue date ranks
+// higher, as do closer dates among equal scores.
+func proposeMatches(l *domain.BankStatementLine, docs []domain.OpenDocument, toleranceDays int) {
+	text := referenceKey(l.Description + " " + l.Reference)
+	type candidate struct {
+		match domain.BankStatementMatch
+		days  int
+	}
+	var candidates []candidate
+	for _, d := range docs {
+		days := int(l.TransactionDate.Sub(d.IssueDate).Hours() / 24)
+		if days < -toleranceDays || l.Amount > d.Outstanding {
+			continue
+		}
+		m := domain.BankStatementMatch{DocumentID: d.ID, DocumentNo: d.DocumentNo, Amount: l.Amount}
+		if l.Amount == d.Outstanding {
+			m.Score += 50
+			m.Reasons = append(m.Reasons, domain.MatchReasonAmount)
+		}
+		if no := referenceKey(d.DocumentNo); len(no) >= 4 && strings.Contains(text, no) {
+			m.Score += 40
+			m.Reasons = append(m.Reasons, domain.MatchReasonReference)
+		}
+		if m.Score == 0 {
+			continue
+		}
+		if days <= toleranceDays {
+			m.Score += 10
+			m.Reasons = append(m.Reasons, domain.MatchReasonDate)
+		}
+		if days < 0 {
+			days = -days
+		}
+		candidates = append(candidates, candidate{m, days})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].match.Score != candidates[j].match.Score {
+			return candidates[i].match.Score > candidates[j].match.Score
+		}
+		return candidates[i].days < candidates[j].days
+	})
+	if len(candidates) > maxLineMatches {
+		candidates = candidates[:maxLineMatches]
+	}
+	l.Matches = nil
+	for _, c := range candidates {
+		l.Matches = append(l.Matches, c.match)
+	}
+	l.Status = domain.StatementLineUnmatched
+	if len(l.Matches) > 0 {
+		l.Status = domain.StatementLineProposed
+	}
+}
+
+// referenceKey reduces a document number or bank memo to its upper case
+// letters and digits, as banks drop or change the separators.
+func referenceKey(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return unicode.ToUpper(r)
+		}
+		return -1
+	}, s)
+}
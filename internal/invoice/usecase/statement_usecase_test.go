@@ -0,0 +1,219 @@
+package usecase
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"invoice_project/internal/invoice/domain"
+	"invoice_project/internal/invoice/repository"
+	"invoice_project/pkg/money"
+)
+
+// statementRepo keeps one imported statement and the open documents of
+// its store.
+type statementRepo struct {
+	repository.BankStatementRepository
+	docs      []domain.OpenDocument
+	statement *domain.BankStatement
+	line      domain.BankStatementLine
+}
+
+func (r *statementRepo) ListOpenDocuments(ctx context.Context, storeID string) ([]domain.OpenDocument, error) {
+	return r.docs, nil
+}
+
+func (r *statementRepo) CreateStatement(ctx context.Context, s *domain.BankStatement) error {
+	s.ID = 1
+	r.statement = s
+	return nil
+}
+
+func (r *statementRepo) GetLine(ctx context.Context, id uint) (*domain.BankStatementLine, error) {
+	l := r.line
+	return &l, nil
+}
+
+func (r *statementRepo) ConfirmLine(ctx context.Context, id uint, reviewedBy string, confirm repository.StatementConfirm) error {
+	paymentID, err := confirm(ctx, &r.line)
+	if err != nil {
+		return err
+	}
+	r.line.Status = domain.StatementLineMatched
+	r.line.PaymentID = &paymentID
+	return nil
+}
+
+// recordingPayments validates payments like the payment usecase and keeps
+// the last one.
+type recordingPayments struct {
+	PaymentUsecase
+	payment *domain.Payment
+}
+
+func (p *recordingPayments) RecordPayment(ctx context.Context, payment *domain.Payment, issueReceipt bool, changedBy string) (*domain.Payment, error) {
+	if err := validatePayment(payment); err != nil {
+		return nil, err
+	}
+	payment.ID = 7
+	p.payment = payment
+	return payment, nil
+}
+
+const statementStore = "6f1c2d4e-8a7b-4c3d-9e2f-1a2b3c4d5e6f"
+
+func statementDate(day int) time.Time {
+	return time.Date(2026, 3, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestProposeMatches(t *testing.T) {
+	docs := []domain.OpenDocument{
+		{ID: 1, DocumentNo: "INV-2026-000001", IssueDate: statementDate(1), Outstanding: money.MustParse("1070")},
+		{ID: 2, DocumentNo: "INV-2026-000002", IssueDate: statementDate(10), Outstanding: money.MustParse("1070")},
+		{ID: 3, DocumentNo: "INV-2026-000003", IssueDate: statementDate(2), Outstanding: money.MustParse("5000")},
+		{ID: 4, DocumentNo: "INV-2026-000004", IssueDate: statementDate(25), Outstanding: money.MustParse("1070")},
+	}
+	cases := []struct {
+		name   string
+		line   domain.BankStatementLine
+		ids    []uint
+		status string
+	}{
+		{
+			name:   "amount, closest date first",
+			line:   domain.BankStatementLine{TransactionDate: statementDate(11), Amount: money.MustParse("1070")},
+			ids:    []uint{2, 1},
+			status: domain.StatementLineProposed,
+		},
+		{
+			name:   "reference beats amount",
+			line:   domain.BankStatementLine{TransactionDate: statementDate(11), Amount: money.MustParse("1070"), Description: "inv2026000001 payment"},
+			ids:    []uint{1, 2},
+			status: domain.StatementLineProposed,
+		},
+		{
+			name:   "partial payment by reference",
+			line:   domain.BankStatementLine{TransactionDate: statementDate(3), Amount: money.MustParse("2000"), Reference: "INV 2026 000003"},
+			ids:    []uint{3},
+			status: domain.StatementLineProposed,
+		},
+		{
+			name:   "more than outstanding",
+			line:   domain.BankStatementLine{TransactionDate: statementDate(3), Amount: money.MustParse("6000"), Reference: "INV-2026-000003"},
+			status: domain.StatementLineUnmatched,
+		},
+		{
+			name:   "no amount or reference",
+			line:   domain.BankStatementLine{TransactionDate: statementDate(11), Amount: money.MustParse("99")},
+			status: domain.StatementLineUnmatched,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			proposeMatches(&tc.line, docs, 7)
+			var ids []uint
+			for _, m := range tc.line.Matches {
+				ids = append(ids, m.DocumentID)
+				if m.Amount != tc.line.Amount {
+					t.Errorf("match %d amount = %s", m.DocumentID, m.Amount)
+				}
+			}
+			if !reflect.DeepEqual(ids, tc.ids) || tc.line.Status != tc.status {
+				t.Errorf("got %v %s, want %v %s", ids, tc.line.Status, tc.ids, tc.status)
+			}
+		})
+	}
+
+	// document 4 is issued more than the tolerance after the transfer
+	l := domain.BankStatementLine{TransactionDate: statementDate(11), Amount: money.MustParse("1070"), Reference: "INV-2026-000004"}
+	proposeMatches(&l, docs, 7)
+	for _, m := range l.Matches {
+		if m.DocumentID == 4 {
+			t.Error("document issued after the tolerance was proposed")
+		}
+	}
+}
+
+func TestImportStatement(t *testing.T) {
+	repo := &statementRepo{docs: []domain.OpenDocument{
+		{ID: 1, DocumentNo: "INV-2026-000001", IssueDate: statementDate(1), Outstanding: money.MustParse("1070")},
+	}}
+	uc := NewBankStatementUsecase(repo, &recordingPayments{})
+	csv := "Date,Description,Withdrawal,Deposit,Details\n" +
+		"02/03/2026,Transfer,,\"1,070.00\",INV-2026-000001\n" +
+		"03/03/2026,Fee,25.00,,\n" +
+		"04/03/2026,Transfer,,300.00,unknown\n"
+	s, err := uc.ImportStatement(context.Background(), ImportStatementInput{
+		StoreID: statementStore,
+		Bank:    "KBANK",
+		Data:    []byte(csv),
+	}, "u")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Bank != "kbank" || s.Skipped != 1 || len(s.Lines) != 2 {
+		t.Fatalf("statement = %+v", s)
+	}
+	if l := s.Lines[0]; l.Status != domain.StatementLineProposed || len(l.Matches) != 1 || l.Matches[0].Score != 100 {
+		t.Errorf("line 0 = %+v", l)
+	}
+	if l := s.Lines[1]; l.Status != domain.StatementLineUnmatched || l.StoreID != statementStore {
+		t.Errorf("line 1 = %+v", l)
+	}
+
+	ctx := context.Background()
+	for _, in := range []ImportStatementInput{
+		{StoreID: "x", Bank: "kbank", Data: []byte(csv)},
+		{StoreID: statementStore, Bank: "nobank", Data: []byte(csv)},
+		{StoreID: statementStore, Bank: "generic", Data: []byte(csv)},
+		{StoreID: statementStore, Bank: "kbank", Data: []byte(csv), ToleranceDays: 365},
+	} {
+		if _, err := uc.ImportStatement(ctx, in, "u"); statusCode(err) != 400 {
+			t.Errorf("%s/%s: got %v", in.StoreID, in.Bank, err)
+		}
+	}
+	if _, err := uc.ImportStatement(ctx, ImportStatementInput{StoreID: statementStore, Bank: "scb", Data: []byte(csv)}, "u"); statusCode(err) != 422 {
+		t.Errorf("wrong bank format: got %v", err)
+	}
+}
+
+func TestConfirmLine(t *testing.T) {
+	repo := &statementRepo{line: domain.BankStatementLine{
+		ID:              3,
+		StoreID:         statementStore,
+		TransactionDate: statementDate(2),
+		Description:     "Transfer",
+		Reference:       "INV-2026-000001",
+		Amount:          money.MustParse("1040"),
+		Status:          domain.StatementLineProposed,
+	}}
+	payments := &recordingPayments{}
+	uc := NewBankStatementUsecase(repo, payments)
+	ctx := context.Background()
+
+	if _, err := uc.ConfirmLine(ctx, 3, ConfirmLineInput{}, "u"); statusCode(err) != 400 {
+		t.Errorf("nothing to settle: got %v", err)
+	}
+	if _, err := uc.ConfirmLine(ctx, 3, ConfirmLineInput{Allocations: []domain.PaymentAllocation{
+		{DocumentID: 1, Amount: money.MustParse("1000")},
+	}}, "u"); statusCode(err) != 422 {
+		t.Errorf("allocations short of the line: got %v", err)
+	}
+
+	// the buyer withheld 30.00 from a 1,070.00 invoice
+	l, err := uc.ConfirmLine(ctx, 3, ConfirmLineInput{Allocations: []domain.PaymentAllocation{
+		{DocumentID: 1, Amount: money.MustParse("1070"), WhtAmount: money.MustParse("30")},
+	}}, "u")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := payments.payment
+	if p.Method != domain.PaymentMethodTransfer || p.Amount != money.MustParse("1070") || p.NetAmount != l.Amount ||
+		!p.PaymentDate.Equal(l.TransactionDate) || p.Reference != "INV-2026-000001 Transfer" {
+		t.Errorf("payment = %+v", p)
+	}
+	if l.Status != domain.StatementLineMatched || l.PaymentID == nil || *l.PaymentID != 7 {
+		t.Errorf("line = %+v", l)
+	}
+}
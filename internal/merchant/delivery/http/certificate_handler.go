@@ -0,0 +1,66 @@
+package http
+
+import (
+	"io"
+
+	"invoice_project/internal/merchant/usecase"
+	"invoice_project/pkg/apperror"
+	"invoice_project/pkg/middleware"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type CertificateHandler struct {
+	uc     usecase.CertificateUsecase
+	access middleware.StoreAuthorizer
+}
+
+func NewCertificateHandler(uc usecase.CertificateUsecase, access middleware.StoreAuthorizer) *CertificateHandler {
+	return &CertificateHandler{uc: uc, access: access}
+}
+
+// Upload stores a merchant's .p12 signing certificate sent as the
+// multipart field "file" together with "merchant_id".
+func (h *CertificateHandler) Upload(c *fiber.Ctx) error {
+	merchantID, err := uuid.Parse(c.FormValue("merchant_id"))
+	if err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	fh, err := c.FormFile("file")
+	if err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	f, err := fh.Open()
+	if err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	cert, err := h.uc.UploadCertificate(merchantID, data)
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(cert)
+}
+
+func (h *CertificateHandler) Get(c *fiber.Ctx) error {
+	merchantID, err := uuid.Parse(c.Query("merchant_id"))
+	if err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	cert, err := h.uc.GetCertificate(merchantID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(cert)
+}
+
+func (h *CertificateHandler) RegisterRoutes(app *fiber.App) {
+	api := app.Group("/merchants/certificate", middleware.RequireRoles("user", "admin"))
+	api.Post("/", middleware.RequireMerchantAccess(h.access, middleware.FromForm("merchant_id")), h.Upload)
+	api.Get("/", middleware.RequireMerchantAccess(h.access, middleware.FromQuery("merchant_id")), h.Get) // ?merchant_id=<uuid>
+}
@@ -11,11 +11,12 @@ import (
 )
 
 type MerchantHandler struct {
-	uc usecase.MerchantUsecase
+	uc     usecase.MerchantUsecase
+	access middleware.StoreAuthorizer
 }
 
-func NewMerchantHandler(uc usecase.MerchantUsecase) *MerchantHandler {
-	return &MerchantHandler{uc: uc}
+func NewMerchantHandler(uc usecase.MerchantUsecase, access middleware.StoreAuthorizer) *MerchantHandler {
+	return &MerchantHandler{uc: uc, access: access}
 }
 
 func (h *MerchantHandler) CreateMerchant(c *fiber.Ctx) error {
@@ -200,10 +201,19 @@ func (h *MerchantHandler) RegisterRoutes(app *fiber.App) {
 	api := app.Group("/merchants", middleware.RequireRoles("user", "admin"))
 	api.Post("/register", h.RegisterMerchant)
 	api.Post("/", h.CreateMerchant)
-	api.Post("/stores", h.CreateStore)
-	api.Get("/stores", h.ListStores)
-	api.Post("/person", h.AddPerson)
-	api.Post("/company", h.AddCompany)
-	api.Post("/contacts", h.AddContact)
-	api.Get("/contacts", h.ListContacts)
+	byQuery := middleware.RequireMerchantAccess(h.access, middleware.FromQuery("merchant_id"))
+	api.Post("/stores", middleware.RequireMerchantAccess(h.access, middleware.FromBody(func(r *CreateStoreRequest) string {
+		return r.MerchantID
+	})), h.CreateStore)
+	api.Get("/stores", byQuery, h.ListStores)
+	api.Post("/person", middleware.RequireMerchantAccess(h.access, middleware.FromBody(func(r *AddPersonRequest) string {
+		return r.MerchantID
+	})), h.AddPerson)
+	api.Post("/company", middleware.RequireMerchantAccess(h.access, middleware.FromBody(func(r *AddCompanyRequest) string {
+		return r.MerchantID
+	})), h.AddCompany)
+	api.Post("/contacts", middleware.RequireMerchantAccess(h.access, middleware.FromBody(func(r *AddContactRequest) string {
+		return r.MerchantID
+	})), h.AddContact)
+	api.Get("/contacts", byQuery, h.ListContacts)
 }
@@ -0,0 +1,24 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MerchantCertificate is the signing certificate (.p12) of a merchant,
+// stored encrypted. The passphrase unlocking it is kept in the signing
+// secrets file, never in the database.
+type MerchantCertificate struct {
+	ID           uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	MerchantID   uuid.UUID `gorm:"type:uuid;not null;uniqueIndex" json:"merchant_id"`
+	EncryptedP12 []byte    `gorm:"type:bytea;not null" json:"-"`
+	Subject      string    `gorm:"size:500" json:"subject"`
+	Issuer       string    `gorm:"size:500" json:"issuer"`
+	SerialNumber string    `gorm:"size:100" json:"serial_number"`
+	Fingerprint  string    `gorm:"size:64" json:"fingerprint"`
+	NotBefore    time.Time `json:"not_before"`
+	NotAfter     time.Time `json:"not_after"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
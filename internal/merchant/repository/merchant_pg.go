@@ -22,6 +22,10 @@ type MerchantRepository interface {
 	ListContacts(merchantID uuid.UUID) ([]domain.MerchantContact, error)
 	GetPerson(merchantID uuid.UUID) (*domain.PersonMerchant, error)
 	GetCompany(merchantID uuid.UUID) (*domain.CompanyMerchant, error)
+	GetStore(id uuid.UUID) (*domain.Store, error)
+	StoreBelongsToUser(storeID, userID uuid.UUID) (bool, error)
+	SaveCertificate(cert *domain.MerchantCertificate) error
+	GetCertificate(merchantID uuid.UUID) (*domain.MerchantCertificate, error)
 }
 
 type merchantPG struct{ db *gorm.DB }
@@ -157,3 +161,59 @@ func (r *merchantPG) GetCompany(merchantID uuid.UUID) (*domain.CompanyMerchant,
 	}
 	return &c, nil
 }
+
+func (r *merchantPG) GetStore(id uuid.UUID) (*domain.Store, error) {
+	var s domain.Store
+	err := r.db.Preload("Address").First(&s, "id = ?", id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &s, nil
+}
+
+// StoreBelongsToUser reports whether the store is owned by one of the
+// user's merchants.
+func (r *merchantPG) StoreBelongsToUser(storeID, userID uuid.UUID) (bool, error) {
+	var n int64
+	err := r.db.Model(&domain.Store{}).
+		Joins("JOIN merchants ON merchants.id = stores.merchant_id").
+		Where("stores.id = ? AND merchants.user_id = ?", storeID, userID).
+		Count(&n).Error
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// SaveCertificate stores cert, replacing the merchant's previous one.
+func (r *merchantPG) SaveCertificate(cert *domain.MerchantCertificate) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var existing domain.MerchantCertificate
+		err := tx.Where("merchant_id = ?", cert.MerchantID).First(&existing).Error
+		switch {
+		case err == nil:
+			cert.ID = existing.ID
+			cert.CreatedAt = existing.CreatedAt
+			return tx.Save(cert).Error
+		case err == gorm.ErrRecordNotFound:
+			return tx.Create(cert).Error
+		default:
+			return err
+		}
+	})
+}
+
+func (r *merchantPG) GetCertificate(merchantID uuid.UUID) (*domain.MerchantCertificate, error) {
+	var c domain.MerchantCertificate
+	err := r.db.Where("merchant_id = ?", merchantID).First(&c).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &c, nil
+}
@@ -0,0 +1,132 @@
+package usecase
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"invoice_project/internal/merchant/domain"
+	"invoice_project/internal/merchant/repository"
+	"invoice_project/pkg/apperror"
+	"invoice_project/pkg/secret"
+	"invoice_project/pkg/xades"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// maxCertificateSize bounds uploaded .p12 files.
+const maxCertificateSize = 64 << 10
+
+type CertificateUsecase interface {
+	UploadCertificate(merchantID uuid.UUID, p12 []byte) (*domain.MerchantCertificate, error)
+	GetCertificate(merchantID uuid.UUID) (*domain.MerchantCertificate, error)
+	// SignerForStore unlocks the certificate of the merchant owning the
+	// store. It returns nil when the merchant has not uploaded one.
+	SignerForStore(storeID string) (*xades.Signer, error)
+}
+
+type certificateUC struct {
+	repo        repository.MerchantRepository
+	box         *secret.Box
+	passphrases map[string]string
+	now         func() time.Time
+}
+
+// NewCertificateUsecase returns the certificate usecase. Without a box
+// (no encryption key configured) certificates cannot be uploaded or used.
+func NewCertificateUsecase(repo repository.MerchantRepository, box *secret.Box, passphrases map[string]string) CertificateUsecase {
+	return &certificateUC{repo: repo, box: box, passphrases: passphrases, now: time.Now}
+}
+
+// UploadCertificate checks that p12 unlocks with the merchant's configured
+// passphrase and holds a currently valid certificate, then stores it
+// encrypted.
+func (u *certificateUC) UploadCertificate(merchantID uuid.UUID, p12 []byte) (*domain.MerchantCertificate, error) {
+	if u.box == nil {
+		return nil, apperror.New(fiber.StatusServiceUnavailable)
+	}
+	if len(p12) == 0 || len(p12) > maxCertificateSize {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	m, err := u.repo.GetMerchant(merchantID)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return nil, apperror.New(fiber.StatusNotFound)
+	}
+	passphrase, ok := u.passphrases[merchantID.String()]
+	if !ok {
+		return nil, apperror.New(fiber.StatusUnprocessableEntity)
+	}
+	signer, err := xades.LoadPKCS12(p12, passphrase)
+	if err != nil {
+		return nil, apperror.New(fiber.StatusUnprocessableEntity)
+	}
+	crt := signer.Certificate
+	if now := u.now(); now.Before(crt.NotBefore) || now.After(crt.NotAfter) {
+		return nil, apperror.New(fiber.StatusUnprocessableEntity)
+	}
+
+	sealed, err := u.box.Seal(p12)
+	if err != nil {
+		return nil, err
+	}
+	fingerprint := sha256.Sum256(crt.Raw)
+	cert := &domain.MerchantCertificate{
+		MerchantID:   merchantID,
+		EncryptedP12: sealed,
+		Subject:      crt.Subject.String(),
+		Issuer:       crt.Issuer.String(),
+		SerialNumber: crt.SerialNumber.String(),
+		Fingerprint:  hex.EncodeToString(fingerprint[:]),
+		NotBefore:    crt.NotBefore,
+		NotAfter:     crt.NotAfter,
+	}
+	if err := u.repo.SaveCertificate(cert); err != nil {
+		return nil, err
+	}
+	return cert, nil
+}
+
+func (u *certificateUC) GetCertificate(merchantID uuid.UUID) (*domain.MerchantCertificate, error) {
+	cert, err := u.repo.GetCertificate(merchantID)
+	if err != nil {
+		return nil, err
+	}
+	if cert == nil {
+		return nil, apperror.New(fiber.StatusNotFound)
+	}
+	return cert, nil
+}
+
+func (u *certificateUC) SignerForStore(storeID string) (*xades.Signer, error) {
+	id, err := uuid.Parse(storeID)
+	if err != nil {
+		return nil, nil
+	}
+	store, err := u.repo.GetStore(id)
+	if err != nil || store == nil {
+		return nil, err
+	}
+	cert, err := u.repo.GetCertificate(store.MerchantID)
+	if err != nil || cert == nil {
+		return nil, err
+	}
+	// a stored certificate that can no longer be unlocked must not
+	// silently produce unsigned documents
+	passphrase, ok := u.passphrases[store.MerchantID.String()]
+	if u.box == nil || !ok {
+		return nil, apperror.New(fiber.StatusServiceUnavailable)
+	}
+	p12, err := u.box.Open(cert.EncryptedP12)
+	if err != nil {
+		return nil, apperror.New(fiber.StatusServiceUnavailable)
+	}
+	signer, err := xades.LoadPKCS12(p12, passphrase)
+	if err != nil {
+		return nil, apperror.New(fiber.StatusServiceUnavailable)
+	}
+	return signer, nil
+}
@@ -0,0 +1,33 @@
+package usecase
+
+import (
+	"invoice_project/internal/merchant/repository"
+
+	"github.com/google/uuid"
+)
+
+// StoreAccess decides which stores and merchants a user may act on: a user
+// owns the merchants created under their account and every store of those
+// merchants.
+type StoreAccess interface {
+	CanAccessStore(userID, storeID uuid.UUID) (bool, error)
+	CanAccessMerchant(userID, merchantID uuid.UUID) (bool, error)
+}
+
+type storeAccess struct{ repo repository.MerchantRepository }
+
+func NewStoreAccess(repo repository.MerchantRepository) StoreAccess {
+	return &storeAccess{repo: repo}
+}
+
+func (a *storeAccess) CanAccessStore(userID, storeID uuid.UUID) (bool, error) {
+	return a.repo.StoreBelongsToUser(storeID, userID)
+}
+
+func (a *storeAccess) CanAccessMerchant(userID, merchantID uuid.UUID) (bool, error) {
+	m, err := a.repo.GetMerchant(merchantID)
+	if err != nil {
+		return false, err
+	}
+	return m != nil && m.UserID == userID, nil
+}
@@ -11,11 +11,12 @@ import (
 )
 
 type ProductHandler struct {
-	uc usecase.ProductUseCase
+	uc     usecase.ProductUseCase
+	access middleware.StoreAuthorizer
 }
 
-func NewProductHandler(uc usecase.ProductUseCase) *ProductHandler {
-	return &ProductHandler{uc: uc}
+func NewProductHandler(uc usecase.ProductUseCase, access middleware.StoreAuthorizer) *ProductHandler {
+	return &ProductHandler{uc: uc, access: access}
 }
 
 func (h *ProductHandler) CreateProduct(c *fiber.Ctx) error {
@@ -106,13 +107,47 @@ func (h *ProductHandler) DeleteProduct(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{"message": "Product deleted successfully"})
 }
 
+// productStore resolves the store of an existing product.
+func (h *ProductHandler) productStore(c *fiber.Ctx, id uint) (string, error) {
+	product, _, err := h.uc.GetProduct(c.Context(), id)
+	if err != nil || product == nil {
+		return "", apperror.New(fiber.StatusNotFound)
+	}
+	return product.StoreID.String(), nil
+}
+
+// paramStore resolves the store of the product in the :id param.
+func (h *ProductHandler) paramStore(c *fiber.Ctx) (string, error) {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return "", apperror.New(fiber.StatusBadRequest)
+	}
+	return h.productStore(c, uint(id))
+}
+
+// updatedStore resolves the current store of the product being updated.
+func (h *ProductHandler) updatedStore(c *fiber.Ctx) (string, error) {
+	var req CreateProductRequest
+	if err := c.BodyParser(&req); err != nil {
+		return "", apperror.New(fiber.StatusBadRequest)
+	}
+	if req.Product.ID == 0 {
+		return "", nil
+	}
+	return h.productStore(c, req.Product.ID)
+}
+
 // ------------------------ Routes -------------------------
 
 func (h *ProductHandler) RegisterRoutes(app *fiber.App) {
 	api := app.Group("/products", middleware.RequireRoles("user", "admin"))
-	api.Post("/", h.CreateProduct)
-	api.Get("/", h.ListProducts)      // ?store_id=<uuid>
-	api.Get("/:id", h.GetProduct)
-	api.Put("/", h.UpdateProduct)     // Body: Product + Images
-	api.Delete("/:id", h.DeleteProduct)
+	bodyStore := middleware.FromBody(func(r *CreateProductRequest) string {
+		return r.Product.StoreID.String()
+	})
+	byProduct := middleware.RequireStoreAccess(h.access, h.paramStore)
+	api.Post("/", middleware.RequireStoreAccess(h.access, bodyStore), h.CreateProduct)
+	api.Get("/", middleware.RequireStoreAccess(h.access, middleware.FromQuery("store_id")), h.ListProducts)      // ?store_id=<uuid>
+	api.Get("/:id", byProduct, h.GetProduct)
+	api.Put("/", middleware.RequireStoreAccess(h.access, h.updatedStore, bodyStore), h.UpdateProduct)     // Body: Product + Images
+	api.Delete("/:id", byProduct, h.DeleteProduct)
 }
\ No newline at end of file
@@ -3,6 +3,8 @@ package domain
 import (
 	"time"
 
+	"invoice_project/pkg/money"
+
 	"github.com/google/uuid"
 )
 
@@ -11,7 +13,7 @@ type Product struct {
 	StoreID   uuid.UUID `gorm:"type:uuid;not null" json:"store_id"`
 	Sku       string    `gorm:"type:text;uniqueIndex" json:"sku"`
 	Name      string    `gorm:"type:text" json:"name"`
-	Price     float64   `gorm:"not null" json:"price"`      // float64 ถ้ามีทศนิยม
+	Price     money.Amount `gorm:"type:numeric(14,2);not null" json:"price"`
 	VatType   string    `gorm:"not null;type:text" json:"vat_type"`
 	VatRate   int       `gorm:"not null" json:"vat_rate"`   
 	CreatedAt time.Time `json:"created_at"`
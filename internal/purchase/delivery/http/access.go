@@ -0,0 +1,51 @@
+package http
+
+import (
+	"strconv"
+
+	"invoice_project/internal/purchase/usecase"
+	"invoice_project/pkg/apperror"
+	"invoice_project/pkg/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// paramID parses the numeric :id route parameter.
+func paramID(c *fiber.Ctx) (uint, error) {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 64)
+	if err != nil {
+		return 0, apperror.New(fiber.StatusBadRequest)
+	}
+	return uint(id), nil
+}
+
+// supplierStore resolves the store of the supplier in the :id param.
+func supplierStore(suppliers usecase.SupplierUsecase) middleware.IDResolver {
+	return func(c *fiber.Ctx) (string, error) {
+		id, err := paramID(c)
+		if err != nil {
+			return "", err
+		}
+		s, err := suppliers.GetSupplier(c.Context(), id)
+		if err != nil {
+			return "", err
+		}
+		return s.StoreID, nil
+	}
+}
+
+// purchaseStore resolves the store of the purchase document in the :id
+// param.
+func purchaseStore(purchases usecase.PurchaseUsecase) middleware.IDResolver {
+	return func(c *fiber.Ctx) (string, error) {
+		id, err := paramID(c)
+		if err != nil {
+			return "", err
+		}
+		doc, err := purchases.GetPurchase(c.Context(), id)
+		if err != nil {
+			return "", err
+		}
+		return doc.StoreID, nil
+	}
+}
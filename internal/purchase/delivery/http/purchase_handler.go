@@ -0,0 +1,95 @@
+package http
+
+import (
+	"invoice_project/internal/purchase/domain"
+	"invoice_project/internal/purchase/usecase"
+	"invoice_project/pkg/apperror"
+	"invoice_project/pkg/middleware"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type PurchaseHandler struct {
+	uc     usecase.PurchaseUsecase
+	access middleware.StoreAuthorizer
+}
+
+func NewPurchaseHandler(uc usecase.PurchaseUsecase, access middleware.StoreAuthorizer) *PurchaseHandler {
+	return &PurchaseHandler{uc: uc, access: access}
+}
+
+func (h *PurchaseHandler) Record(c *fiber.Ctx) error {
+	var doc domain.PurchaseDocument
+	if err := c.BodyParser(&doc); err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	userID := c.Locals("user_id").(uuid.UUID)
+	created, err := h.uc.RecordPurchase(c.Context(), &doc, userID.String())
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(created)
+}
+
+// List returns a store's purchase documents, e.g.
+// ?store_id=<uuid>&tax_month=2026-10&supplier_id=3&status=recorded.
+func (h *PurchaseHandler) List(c *fiber.Ctx) error {
+	f := domain.PurchaseFilter{
+		StoreID:  c.Query("store_id"),
+		TaxMonth: c.Query("tax_month"),
+		Status:   c.Query("status"),
+	}
+	if c.Query("supplier_id") != "" {
+		id := c.QueryInt("supplier_id")
+		if id <= 0 {
+			return apperror.New(fiber.StatusBadRequest)
+		}
+		f.SupplierID = uint(id)
+	}
+	docs, err := h.uc.ListPurchases(c.Context(), f)
+	if err != nil {
+		return err
+	}
+	return c.JSON(docs)
+}
+
+func (h *PurchaseHandler) Get(c *fiber.Ctx) error {
+	id, err := paramID(c)
+	if err != nil {
+		return err
+	}
+	doc, err := h.uc.GetPurchase(c.Context(), id)
+	if err != nil {
+		return err
+	}
+	return c.JSON(doc)
+}
+
+func (h *PurchaseHandler) Void(c *fiber.Ctx) error {
+	id, err := paramID(c)
+	if err != nil {
+		return err
+	}
+	var req VoidPurchaseRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	doc, err := h.uc.VoidPurchase(c.Context(), id, req.Reason)
+	if err != nil {
+		return err
+	}
+	return c.JSON(doc)
+}
+
+func (h *PurchaseHandler) RegisterRoutes(app *fiber.App) {
+	api := app.Group("/purchase-documents", middleware.RequireRoles("user", "admin"))
+	byBody := middleware.RequireStoreAccess(h.access, middleware.FromBody(func(doc *domain.PurchaseDocument) string {
+		return doc.StoreID
+	}))
+	byPurchase := middleware.RequireStoreAccess(h.access, purchaseStore(h.uc))
+	api.Post("/", byBody, h.Record)
+	api.Get("/", middleware.RequireStoreAccess(h.access, middleware.FromQuery("store_id")), h.List)
+	api.Get("/:id", byPurchase, h.Get)
+	api.Post("/:id/void", byPurchase, h.Void)
+}
@@ -0,0 +1,6 @@
+package http
+
+// VoidPurchaseRequest voids a purchase document recorded by mistake.
+type VoidPurchaseRequest struct {
+	Reason string `json:"reason"`
+}
@@ -0,0 +1,82 @@
+package http
+
+import (
+	"invoice_project/internal/purchase/domain"
+	"invoice_project/internal/purchase/usecase"
+	"invoice_project/pkg/apperror"
+	"invoice_project/pkg/middleware"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type SupplierHandler struct {
+	uc     usecase.SupplierUsecase
+	access middleware.StoreAuthorizer
+}
+
+func NewSupplierHandler(uc usecase.SupplierUsecase, access middleware.StoreAuthorizer) *SupplierHandler {
+	return &SupplierHandler{uc: uc, access: access}
+}
+
+func (h *SupplierHandler) Create(c *fiber.Ctx) error {
+	var s domain.Supplier
+	if err := c.BodyParser(&s); err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	userID := c.Locals("user_id").(uuid.UUID)
+	created, err := h.uc.CreateSupplier(c.Context(), &s, userID.String())
+	if err != nil {
+		return err
+	}
+	return c.Status(fiber.StatusCreated).JSON(created)
+}
+
+func (h *SupplierHandler) List(c *fiber.Ctx) error {
+	suppliers, err := h.uc.ListSuppliers(c.Context(), c.Query("store_id"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(suppliers)
+}
+
+func (h *SupplierHandler) Get(c *fiber.Ctx) error {
+	id, err := paramID(c)
+	if err != nil {
+		return err
+	}
+	s, err := h.uc.GetSupplier(c.Context(), id)
+	if err != nil {
+		return err
+	}
+	return c.JSON(s)
+}
+
+func (h *SupplierHandler) Update(c *fiber.Ctx) error {
+	id, err := paramID(c)
+	if err != nil {
+		return err
+	}
+	var s domain.Supplier
+	if err := c.BodyParser(&s); err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	s.ID = id
+	updated, err := h.uc.UpdateSupplier(c.Context(), &s)
+	if err != nil {
+		return err
+	}
+	return c.JSON(updated)
+}
+
+func (h *SupplierHandler) RegisterRoutes(app *fiber.App) {
+	api := app.Group("/suppliers", middleware.RequireRoles("user", "admin"))
+	byBody := middleware.RequireStoreAccess(h.access, middleware.FromBody(func(s *domain.Supplier) string {
+		return s.StoreID
+	}))
+	bySupplier := middleware.RequireStoreAccess(h.access, supplierStore(h.uc))
+	api.Post("/", byBody, h.Create)
+	api.Get("/", middleware.RequireStoreAccess(h.access, middleware.FromQuery("store_id")), h.List) // ?store_id=<uuid>
+	api.Get("/:id", bySupplier, h.Get)
+	api.Put("/:id", bySupplier, h.Update)
+}
@@ -0,0 +1,118 @@
+package domain
+
+import (
+	"time"
+
+	"invoice_project/pkg/money"
+)
+
+// Purchase document types stored in PurchaseDocument.DocumentType.
+const (
+	PurchaseTypeTaxInvoice = "tax_invoice"
+	PurchaseTypeReceipt    = "receipt"
+	PurchaseTypeCreditNote = "credit_note"
+	PurchaseTypeDebitNote  = "debit_note"
+)
+
+// Purchase document statuses.
+const (
+	PurchaseStatusRecorded = "recorded"
+	PurchaseStatusVoid     = "void"
+)
+
+// Reasons input tax cannot be claimed (ภาษีซื้อต้องห้าม, section 82/5 of
+// the Revenue Code), stored in PurchaseDocument.NonClaimableReason.
+const (
+	NonClaimableNoTaxInvoice  = "no_tax_invoice"
+	NonClaimableIncomplete    = "incomplete_tax_invoice"
+	NonClaimableEntertainment = "entertainment"
+	NonClaimablePassengerCar  = "passenger_car"
+	NonClaimableNotBusiness   = "not_business"
+	NonClaimableOther         = "other"
+)
+
+// ClaimMonths is how many months after the month of issue input tax may
+// still be claimed (section 82/3).
+const ClaimMonths = 6
+
+// IsValidPurchaseType reports whether t is a known purchase document type.
+func IsValidPurchaseType(t string) bool {
+	switch t {
+	case PurchaseTypeTaxInvoice, PurchaseTypeReceipt, PurchaseTypeCreditNote, PurchaseTypeDebitNote:
+		return true
+	default:
+		return false
+	}
+}
+
+// CarriesInputTax reports whether a document of type t is a tax document
+// whose VAT may be claimed: a tax invoice or a credit or debit note.
+func CarriesInputTax(t string) bool {
+	return t != PurchaseTypeReceipt
+}
+
+// IsValidNonClaimableReason reports whether r is a known reason.
+func IsValidNonClaimableReason(r string) bool {
+	switch r {
+	case NonClaimableNoTaxInvoice, NonClaimableIncomplete, NonClaimableEntertainment,
+		NonClaimablePassengerCar, NonClaimableNotBusiness, NonClaimableOther:
+		return true
+	default:
+		return false
+	}
+}
+
+// PurchaseDocument is a tax invoice, receipt, credit note or debit note
+// received from a supplier. DocumentNo is the supplier's number. The
+// input tax of a claimable document counts in TaxMonth (YYYY-MM), which
+// defaults to the month of issue; credit notes reduce it.
+type PurchaseDocument struct {
+	ID                 uint         `gorm:"primaryKey;autoIncrement" json:"id"`
+	StoreID            string       `gorm:"type:uuid;not null;index:idx_purchase_documents_store_month,priority:1" json:"store_id"`
+	SupplierID         uint         `gorm:"not null;index" json:"supplier_id"`
+	DocumentType       string       `gorm:"size:50;not null" json:"document_type"`
+	DocumentNo         string       `gorm:"size:100;not null" json:"document_no"`
+	IssueDate          time.Time    `gorm:"type:date;not null" json:"issue_date"`
+	TaxMonth           string       `gorm:"size:7;not null;index:idx_purchase_documents_store_month,priority:2" json:"tax_month"`
+	Status             string       `gorm:"size:20;not null" json:"status"`
+	SupplierName       string       `gorm:"size:255" json:"supplier_name"`
+	SupplierTaxID      string       `gorm:"size:100" json:"supplier_tax_id"`
+	SupplierBranchNo   string       `gorm:"size:10" json:"supplier_branch_no"`
+	Subtotal           money.Amount `gorm:"type:numeric(14,2)" json:"subtotal"`
+	VatableAmount      money.Amount `gorm:"type:numeric(14,2)" json:"vatable_amount"`
+	ZeroRatedAmount    money.Amount `gorm:"type:numeric(14,2);not null;default:0" json:"zero_rated_amount"`
+	ExemptAmount       money.Amount `gorm:"type:numeric(14,2)" json:"exempt_amount"`
+	VatAmount          money.Amount `gorm:"type:numeric(14,2)" json:"vat_amount"`
+	GrandTotal         money.Amount `gorm:"type:numeric(14,2)" json:"grand_total"`
+	Claimable          bool         `gorm:"not null;default:true" json:"claimable"`
+	NonClaimableReason string       `gorm:"size:50" json:"non_claimable_reason,omitempty"`
+	Remarks            string       `gorm:"type:text" json:"remarks"`
+	VoidReason         string       `gorm:"type:text" json:"void_reason,omitempty"`
+	CreatedBy          string       `gorm:"size:100" json:"created_by"`
+	CreatedAt          time.Time    `gorm:"autoCreateTime" json:"created_at"`
+
+	Items []PurchaseItem `gorm:"foreignKey:DocumentID" json:"items,omitempty"`
+}
+
+// PurchaseItem is a line of a purchase document. VatType and VatRate
+// follow InvoiceItem: VatType is required and is include, exclude,
+// zero_rated or exempt, with the rate in percent.
+type PurchaseItem struct {
+	ID          uint         `gorm:"primaryKey;autoIncrement" json:"id"`
+	DocumentID  uint         `gorm:"not null;index" json:"document_id"`
+	Description string       `gorm:"size:255" json:"description"`
+	Qty         int          `json:"qty"`
+	UnitPrice   money.Amount `gorm:"type:numeric(14,2)" json:"unit_price"`
+	Discount    money.Amount `gorm:"type:numeric(14,2)" json:"discount"`
+	VatType     string       `gorm:"size:50" json:"vat_type"`
+	VatRate     float64      `gorm:"type:numeric(5,2)" json:"vat_rate"`
+	LineTotal   money.Amount `gorm:"type:numeric(14,2)" json:"line_total"`
+}
+
+// PurchaseFilter narrows ListPurchases. Empty fields do not filter.
+type PurchaseFilter struct {
+	StoreID    string
+	TaxMonth   string
+	SupplierID uint
+	Status     string
+}
@@ -0,0 +1,26 @@
+package domain
+
+import "time"
+
+// Supplier types stored in Supplier.SupplierType.
+const (
+	SupplierTypePerson  = "person"
+	SupplierTypeCompany = "company"
+)
+
+// Supplier is a business a store buys from. Its name, tax ID and branch
+// are copied onto each purchase document recorded against it.
+type Supplier struct {
+	ID           uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	StoreID      string    `gorm:"type:uuid;not null;index" json:"store_id"`
+	SupplierType string    `gorm:"size:20;not null" json:"supplier_type"`
+	Name         string    `gorm:"size:255;not null" json:"name"`
+	TaxID        string    `gorm:"size:100" json:"tax_id"`
+	BranchNo     string    `gorm:"size:10" json:"branch_no"`
+	Address      string    `gorm:"type:text" json:"address"`
+	Phone        string    `gorm:"size:50" json:"phone"`
+	Email        string    `gorm:"size:255" json:"email"`
+	CreatedBy    string    `gorm:"size:100" json:"created_by"`
+	CreatedAt    time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
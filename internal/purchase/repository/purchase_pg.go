@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"invoice_project/internal/purchase/domain"
+	"invoice_project/pkg/apperror"
+)
+
+type PurchaseRepository interface {
+	CreatePurchase(ctx context.Context, doc *domain.PurchaseDocument) error
+	GetPurchase(ctx context.Context, id uint) (*domain.PurchaseDocument, error)
+	ListPurchases(ctx context.Context, f domain.PurchaseFilter) ([]domain.PurchaseDocument, error)
+	VoidPurchase(ctx context.Context, id uint, reason string) error
+}
+
+type purchasePG struct {
+	db *gorm.DB
+}
+
+func NewPurchaseRepository(db *gorm.DB) PurchaseRepository {
+	return &purchasePG{db: db}
+}
+
+// CreatePurchase saves doc with its items. A document already recorded
+// for the store with the same supplier, type and number is rejected with
+// 409 so its input tax cannot be claimed twice.
+func (r *purchasePG) CreatePurchase(ctx context.Context, doc *domain.PurchaseDocument) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// serialise recordings for the supplier so two requests cannot
+		// both pass the duplicate check
+		var supplier domain.Supplier
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&supplier, doc.SupplierID).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return apperror.New(fiber.StatusBadRequest)
+			}
+			return err
+		}
+		var count int64
+		err = tx.Model(&domain.PurchaseDocument{}).
+			Where("store_id = ? AND supplier_tax_id = ? AND supplier_branch_no = ? AND document_type = ? AND document_no = ? AND status <> ?",
+				doc.StoreID, doc.SupplierTaxID, doc.SupplierBranchNo, doc.DocumentType, doc.DocumentNo, domain.PurchaseStatusVoid).
+			Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return apperror.New(fiber.StatusConflict)
+		}
+		return tx.Create(doc).Error
+	})
+}
+
+func (r *purchasePG) GetPurchase(ctx context.Context, id uint) (*domain.PurchaseDocument, error) {
+	var doc domain.PurchaseDocument
+	if err := r.db.WithContext(ctx).Preload("Items").First(&doc, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// ListPurchases returns the documents matching f, without their items, in
+// the order they were issued.
+func (r *purchasePG) ListPurchases(ctx context.Context, f domain.PurchaseFilter) ([]domain.PurchaseDocument, error) {
+	q := r.db.WithContext(ctx).Where("store_id = ?", f.StoreID)
+	if f.TaxMonth != "" {
+		q = q.Where("tax_month = ?", f.TaxMonth)
+	}
+	if f.SupplierID != 0 {
+		q = q.Where("supplier_id = ?", f.SupplierID)
+	}
+	if f.Status != "" {
+		q = q.Where("status = ?", f.Status)
+	}
+	var docs []domain.PurchaseDocument
+	if err := q.Order("issue_date, id").Find(&docs).Error; err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+// VoidPurchase marks a recorded document void so it drops out of the
+// input tax report.
+func (r *purchasePG) VoidPurchase(ctx context.Context, id uint, reason string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var doc domain.PurchaseDocument
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&doc, id).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return apperror.New(fiber.StatusNotFound)
+			}
+			return err
+		}
+		if doc.Status == domain.PurchaseStatusVoid {
+			return apperror.New(fiber.StatusConflict)
+		}
+		return tx.Model(&domain.PurchaseDocument{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"status":      domain.PurchaseStatusVoid,
+			"void_reason": reason,
+		}).Error
+	})
+}
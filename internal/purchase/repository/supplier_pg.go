@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"invoice_project/internal/purchase/domain"
+)
+
+type SupplierRepository interface {
+	CreateSupplier(ctx context.Context, s *domain.Supplier) error
+	GetSupplier(ctx context.Context, id uint) (*domain.Supplier, error)
+	ListSuppliers(ctx context.Context, storeID string) ([]domain.Supplier, error)
+	UpdateSupplier(ctx context.Context, s *domain.Supplier) error
+}
+
+type supplierPG struct {
+	db *gorm.DB
+}
+
+func NewSupplierRepository(db *gorm.DB) SupplierRepository {
+	return &supplierPG{db: db}
+}
+
+func (r *supplierPG) CreateSupplier(ctx context.Context, s *domain.Supplier) error {
+	return r.db.WithContext(ctx).Create(s).Error
+}
+
+func (r *supplierPG) GetSupplier(ctx context.Context, id uint) (*domain.Supplier, error) {
+	var s domain.Supplier
+	if err := r.db.WithContext(ctx).First(&s, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *supplierPG) ListSuppliers(ctx context.Context, storeID string) ([]domain.Supplier, error) {
+	var suppliers []domain.Supplier
+	err := r.db.WithContext(ctx).Where("store_id = ?", storeID).Order("name, id").Find(&suppliers).Error
+	if err != nil {
+		return nil, err
+	}
+	return suppliers, nil
+}
+
+// UpdateSupplier saves the editable fields of s. Purchase documents keep
+// the details they were recorded with.
+func (r *supplierPG) UpdateSupplier(ctx context.Context, s *domain.Supplier) error {
+	return r.db.WithContext(ctx).Model(&domain.Supplier{}).Where("id = ?", s.ID).Updates(map[string]interface{}{
+		"supplier_type": s.SupplierType,
+		"name":          s.Name,
+		"tax_id":        s.TaxID,
+		"branch_no":     s.BranchNo,
+		"address":       s.Address,
+		"phone":         s.Phone,
+		"email":         s.Email,
+	}).Error
+}
@@ -0,0 +1,204 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	invoiceDomain "invoice_project/internal/invoice/domain"
+	invoiceUC "invoice_project/internal/invoice/usecase"
+	"invoice_project/internal/purchase/domain"
+	"invoice_project/internal/purchase/repository"
+	"invoice_project/pkg/apperror"
+	"invoice_project/pkg/money"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type PurchaseUsecase interface {
+	RecordPurchase(ctx context.Context, doc *domain.PurchaseDocument, createdBy string) (*domain.PurchaseDocument, error)
+	GetPurchase(ctx context.Context, id uint) (*domain.PurchaseDocument, error)
+	ListPurchases(ctx context.Context, f domain.PurchaseFilter) ([]domain.PurchaseDocument, error)
+	VoidPurchase(ctx context.Context, id uint, reason string) (*domain.PurchaseDocument, error)
+}
+
+type purchaseUC struct {
+	purchases repository.PurchaseRepository
+	suppliers repository.SupplierRepository
+}
+
+func NewPurchaseUsecase(purchases repository.PurchaseRepository, suppliers repository.SupplierRepository) PurchaseUsecase {
+	return &purchaseUC{purchases: purchases, suppliers: suppliers}
+}
+
+// RecordPurchase saves a document received from one of the store's
+// suppliers. Totals are computed from the items the same way as on sales
+// documents; totals sent by the client must match them. The input tax is
+// claimable unless a NonClaimableReason is given, and never on a plain
+// receipt.
+func (u *purchaseUC) RecordPurchase(ctx context.Context, doc *domain.PurchaseDocument, createdBy string) (*domain.PurchaseDocument, error) {
+	if doc == nil || !domain.IsValidPurchaseType(doc.DocumentType) || doc.IssueDate.IsZero() || len(doc.Items) == 0 {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	doc.DocumentNo = strings.TrimSpace(doc.DocumentNo)
+	if doc.DocumentNo == "" {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	if _, err := uuid.Parse(doc.StoreID); err != nil {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	supplier, err := u.suppliers.GetSupplier(ctx, doc.SupplierID)
+	if err != nil {
+		return nil, err
+	}
+	if supplier == nil || supplier.StoreID != doc.StoreID {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	doc.SupplierName = supplier.Name
+	doc.SupplierTaxID = supplier.TaxID
+	doc.SupplierBranchNo = supplier.BranchNo
+
+	if err := applyPurchaseTotals(doc); err != nil {
+		return nil, err
+	}
+	if err := setTaxMonth(doc); err != nil {
+		return nil, err
+	}
+	if err := setClaimable(doc); err != nil {
+		return nil, err
+	}
+
+	doc.ID = 0
+	doc.Status = domain.PurchaseStatusRecorded
+	doc.VoidReason = ""
+	doc.CreatedBy = createdBy
+	if err := u.purchases.CreatePurchase(ctx, doc); err != nil {
+		return nil, err
+	}
+	return u.purchases.GetPurchase(ctx, doc.ID)
+}
+
+// applyPurchaseTotals fills in the line totals and document totals of doc.
+// Every line must state its VAT type: a line without one is not taken as
+// exempt, which would drop its input tax from the report.
+func applyPurchaseTotals(doc *domain.PurchaseDocument) error {
+	items := make([]invoiceDomain.InvoiceItem, len(doc.Items))
+	for i, it := range doc.Items {
+		switch it.VatType {
+		case invoiceUC.VatTypeInclude, invoiceUC.VatTypeExclude, invoiceUC.VatTypeZeroRated, invoiceUC.VatTypeExempt:
+		default:
+			return apperror.New(fiber.StatusBadRequest)
+		}
+		items[i] = invoiceDomain.InvoiceItem{
+			ProductName: it.Description,
+			Qty:         it.Qty,
+			UnitPrice:   it.UnitPrice,
+			Discount:    it.Discount,
+			VatType:     it.VatType,
+			VatRate:     it.VatRate,
+		}
+	}
+	t, err := invoiceUC.CalculateTotals(&invoiceDomain.InvoiceDocument{}, items)
+	if err != nil {
+		return err
+	}
+	for _, pair := range [][2]money.Amount{
+		{doc.Subtotal, t.Subtotal},
+		{doc.VatAmount, t.VatAmount},
+		{doc.GrandTotal, t.GrandTotal},
+	} {
+		if !pair[0].IsZero() && pair[0] != pair[1] {
+			return apperror.New(fiber.StatusUnprocessableEntity)
+		}
+	}
+	for i := range doc.Items {
+		it := &doc.Items[i]
+		it.ID = 0
+		it.DocumentID = 0
+		it.VatType = items[i].VatType
+		it.VatRate = items[i].VatRate
+		it.LineTotal = t.LineTotals[i]
+	}
+	doc.Subtotal = t.Subtotal
+	doc.VatableAmount = t.VatableAmount
+	doc.ZeroRatedAmount = t.ZeroRatedAmount
+	doc.ExemptAmount = t.ExemptAmount
+	doc.VatAmount = t.VatAmount
+	doc.GrandTotal = t.GrandTotal
+	return nil
+}
+
+// setTaxMonth defaults the tax month to the month of issue. Input tax may
+// be claimed in that month or one of the ClaimMonths after it.
+func setTaxMonth(doc *domain.PurchaseDocument) error {
+	issued := time.Date(doc.IssueDate.Year(), doc.IssueDate.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if doc.TaxMonth == "" {
+		doc.TaxMonth = issued.Format("2006-01")
+		return nil
+	}
+	month, err := time.Parse("2006-01", doc.TaxMonth)
+	if err != nil {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	if month.Before(issued) || month.After(issued.AddDate(0, domain.ClaimMonths, 0)) {
+		return apperror.New(fiber.StatusUnprocessableEntity)
+	}
+	return nil
+}
+
+// setClaimable decides whether the input tax of doc may be claimed. A
+// claimable document needs the supplier's tax ID, as a tax invoice
+// without it is incomplete.
+func setClaimable(doc *domain.PurchaseDocument) error {
+	if !domain.CarriesInputTax(doc.DocumentType) && doc.NonClaimableReason == "" {
+		doc.NonClaimableReason = domain.NonClaimableNoTaxInvoice
+	}
+	if doc.NonClaimableReason != "" && !domain.IsValidNonClaimableReason(doc.NonClaimableReason) {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	doc.Claimable = doc.NonClaimableReason == ""
+	if doc.Claimable && doc.SupplierTaxID == "" {
+		return apperror.New(fiber.StatusUnprocessableEntity)
+	}
+	return nil
+}
+
+func (u *purchaseUC) GetPurchase(ctx context.Context, id uint) (*domain.PurchaseDocument, error) {
+	if id == 0 {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	doc, err := u.purchases.GetPurchase(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		return nil, apperror.New(fiber.StatusNotFound)
+	}
+	return doc, nil
+}
+
+func (u *purchaseUC) ListPurchases(ctx context.Context, f domain.PurchaseFilter) ([]domain.PurchaseDocument, error) {
+	if _, err := uuid.Parse(f.StoreID); err != nil {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	if f.TaxMonth != "" {
+		if _, err := time.Parse("2006-01", f.TaxMonth); err != nil {
+			return nil, apperror.New(fiber.StatusBadRequest)
+		}
+	}
+	return u.purchases.ListPurchases(ctx, f)
+}
+
+// VoidPurchase voids a document recorded by mistake. Its number can then
+// be recorded again.
+func (u *purchaseUC) VoidPurchase(ctx context.Context, id uint, reason string) (*domain.PurchaseDocument, error) {
+	reason = strings.TrimSpace(reason)
+	if id == 0 || reason == "" {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	if err := u.purchases.VoidPurchase(ctx, id, reason); err != nil {
+		return nil, err
+	}
+	return u.GetPurchase(ctx, id)
+}
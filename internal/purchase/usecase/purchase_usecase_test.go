@@ -0,0 +1,188 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"invoice_project/internal/purchase/domain"
+	"invoice_project/internal/purchase/repository"
+	"invoice_project/pkg/apperror"
+	"invoice_project/pkg/money"
+)
+
+const testStore = "6f1c2d4e-8a7b-4c3d-9e2f-1a2b3c4d5e6f"
+
+type fakeSuppliers struct {
+	repository.SupplierRepository
+	suppliers map[uint]*domain.Supplier
+}
+
+func (r *fakeSuppliers) GetSupplier(ctx context.Context, id uint) (*domain.Supplier, error) {
+	return r.suppliers[id], nil
+}
+
+type fakePurchases struct {
+	repository.PurchaseRepository
+	saved *domain.PurchaseDocument
+}
+
+func (r *fakePurchases) CreatePurchase(ctx context.Context, doc *domain.PurchaseDocument) error {
+	doc.ID = 1
+	r.saved = doc
+	return nil
+}
+
+func (r *fakePurchases) GetPurchase(ctx context.Context, id uint) (*domain.PurchaseDocument, error) {
+	return r.saved, nil
+}
+
+func newTestPurchaseUC() (*purchaseUC, *fakePurchases) {
+	purchases := &fakePurchases{}
+	suppliers := &fakeSuppliers{suppliers: map[uint]*domain.Supplier{
+		1: {ID: 1, StoreID: testStore, SupplierType: domain.SupplierTypeCompany, Name: "ผู้ขาย จำกัด", TaxID: "0105550000001", BranchNo: "00000"},
+		2: {ID: 2, StoreID: testStore, SupplierType: domain.SupplierTypePerson, Name: "ร้านค้า"},
+		3: {ID: 3, StoreID: "2b7e1c4d-1111-4c3d-9e2f-1a2b3c4d5e6f", SupplierType: domain.SupplierTypePerson, Name: "Other"},
+	}}
+	return &purchaseUC{purchases: purchases, suppliers: suppliers}, purchases
+}
+
+func newPurchase(supplierID uint, documentType string) *domain.PurchaseDocument {
+	return &domain.PurchaseDocument{
+		StoreID:      testStore,
+		SupplierID:   supplierID,
+		DocumentType: documentType,
+		DocumentNo:   " IV-001 ",
+		IssueDate:    time.Date(2026, 9, 28, 0, 0, 0, 0, time.UTC),
+		Items: []domain.PurchaseItem{
+			{Description: "กระดาษ", Qty: 2, UnitPrice: money.FromBaht(400), VatType: "exclude"},
+			{Description: "ผักสด", Qty: 1, UnitPrice: money.FromBaht(50), VatType: "exempt"},
+		},
+	}
+}
+
+func statusCode(err error) int {
+	if e, ok := err.(*apperror.StatusError); ok {
+		return e.Code
+	}
+	return 0
+}
+
+func TestRecordPurchase(t *testing.T) {
+	u, repo := newTestPurchaseUC()
+	doc, err := u.RecordPurchase(context.Background(), newPurchase(1, domain.PurchaseTypeTaxInvoice), "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc != repo.saved || doc.Status != domain.PurchaseStatusRecorded || doc.DocumentNo != "IV-001" {
+		t.Fatalf("saved %+v", repo.saved)
+	}
+	if doc.SupplierTaxID != "0105550000001" || doc.SupplierBranchNo != "00000" {
+		t.Errorf("supplier not copied: %+v", doc)
+	}
+	if doc.VatAmount != money.FromBaht(56) || doc.GrandTotal != money.FromBaht(906) || doc.ExemptAmount != money.FromBaht(50) {
+		t.Errorf("totals vat %s grand %s exempt %s", doc.VatAmount, doc.GrandTotal, doc.ExemptAmount)
+	}
+	if doc.Items[0].LineTotal != money.FromBaht(800) || doc.Items[0].VatRate != 7 {
+		t.Errorf("item = %+v", doc.Items[0])
+	}
+	if !doc.Claimable || doc.TaxMonth != "2026-09" {
+		t.Errorf("claimable %v tax month %s", doc.Claimable, doc.TaxMonth)
+	}
+
+	d := newPurchase(1, domain.PurchaseTypeTaxInvoice)
+	d.DocumentNo = "IV-002"
+	d.Items[1].VatType = "zero_rated"
+	doc, err = u.RecordPurchase(context.Background(), d, "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.VatableAmount != money.FromBaht(800) || doc.ZeroRatedAmount != money.FromBaht(50) || !doc.ExemptAmount.IsZero() || doc.VatAmount != money.FromBaht(56) {
+		t.Errorf("zero-rated: vatable %s zero-rated %s exempt %s vat %s", doc.VatableAmount, doc.ZeroRatedAmount, doc.ExemptAmount, doc.VatAmount)
+	}
+}
+
+func TestRecordPurchaseClaimable(t *testing.T) {
+	u, _ := newTestPurchaseUC()
+	ctx := context.Background()
+
+	doc, err := u.RecordPurchase(ctx, newPurchase(2, domain.PurchaseTypeReceipt), "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Claimable || doc.NonClaimableReason != domain.NonClaimableNoTaxInvoice {
+		t.Errorf("receipt claimable %v reason %q", doc.Claimable, doc.NonClaimableReason)
+	}
+
+	in := newPurchase(1, domain.PurchaseTypeTaxInvoice)
+	in.NonClaimableReason = domain.NonClaimableEntertainment
+	if doc, err = u.RecordPurchase(ctx, in, "user"); err != nil || doc.Claimable {
+		t.Errorf("entertainment: claimable %v err %v", doc.Claimable, err)
+	}
+
+	// a tax invoice without the supplier's tax ID is incomplete
+	if _, err = u.RecordPurchase(ctx, newPurchase(2, domain.PurchaseTypeTaxInvoice), "user"); statusCode(err) != 422 {
+		t.Errorf("supplier without tax ID: %v", err)
+	}
+
+	in = newPurchase(1, domain.PurchaseTypeTaxInvoice)
+	in.NonClaimableReason = "tired"
+	if _, err = u.RecordPurchase(ctx, in, "user"); statusCode(err) != 400 {
+		t.Errorf("unknown reason: %v", err)
+	}
+}
+
+func TestRecordPurchaseRejects(t *testing.T) {
+	u, _ := newTestPurchaseUC()
+	ctx := context.Background()
+	for name, tc := range map[string]struct {
+		edit func(d *domain.PurchaseDocument)
+		want int
+	}{
+		"other store's supplier": {func(d *domain.PurchaseDocument) { d.SupplierID = 3 }, 400},
+		"unknown type":           {func(d *domain.PurchaseDocument) { d.DocumentType = "quotation" }, 400},
+		"no number":              {func(d *domain.PurchaseDocument) { d.DocumentNo = " " }, 400},
+		"wrong vat":              {func(d *domain.PurchaseDocument) { d.VatAmount = money.FromBaht(60) }, 422},
+		"no vat type":            {func(d *domain.PurchaseDocument) { d.Items[0].VatType = "" }, 400},
+		"unknown vat type":       {func(d *domain.PurchaseDocument) { d.Items[1].VatType = "none" }, 400},
+		"tax month before issue": {func(d *domain.PurchaseDocument) { d.TaxMonth = "2026-08" }, 422},
+		"tax month too late":     {func(d *domain.PurchaseDocument) { d.TaxMonth = "2027-04" }, 422},
+		"bad tax month":          {func(d *domain.PurchaseDocument) { d.TaxMonth = "09/2026" }, 400},
+	} {
+		d := newPurchase(1, domain.PurchaseTypeTaxInvoice)
+		tc.edit(d)
+		if _, err := u.RecordPurchase(ctx, d, "user"); statusCode(err) != tc.want {
+			t.Errorf("%s: err %v, want %d", name, err, tc.want)
+		}
+	}
+
+	d := newPurchase(1, domain.PurchaseTypeTaxInvoice)
+	d.TaxMonth = "2027-03"
+	if _, err := u.RecordPurchase(ctx, d, "user"); err != nil {
+		t.Errorf("last month of the claim window: %v", err)
+	}
+}
+
+func TestNormalizeSupplier(t *testing.T) {
+	s := &domain.Supplier{SupplierType: domain.SupplierTypeCompany, Name: " ผู้ขาย ", TaxID: "0105550000001", BranchNo: "3"}
+	if err := normalizeSupplier(s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Name != "ผู้ขาย" || s.BranchNo != "00003" {
+		t.Errorf("normalized = %+v", s)
+	}
+	s = &domain.Supplier{SupplierType: domain.SupplierTypeCompany, Name: "A"}
+	if err := normalizeSupplier(s); err != nil || s.BranchNo != "00000" {
+		t.Errorf("default branch %q err %v", s.BranchNo, err)
+	}
+	for _, bad := range []domain.Supplier{
+		{SupplierType: domain.SupplierTypeCompany, Name: "A", TaxID: "12345"},
+		{SupplierType: domain.SupplierTypeCompany, Name: "A", BranchNo: "HQ"},
+		{SupplierType: "partnership", Name: "A"},
+		{SupplierType: domain.SupplierTypePerson},
+	} {
+		if err := normalizeSupplier(&bad); err == nil {
+			t.Errorf("accepted %+v", bad)
+		}
+	}
+}
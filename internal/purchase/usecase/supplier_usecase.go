@@ -0,0 +1,131 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+
+	invoiceUC "invoice_project/internal/invoice/usecase"
+	"invoice_project/internal/purchase/domain"
+	"invoice_project/internal/purchase/repository"
+	"invoice_project/pkg/apperror"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type SupplierUsecase interface {
+	CreateSupplier(ctx context.Context, s *domain.Supplier, createdBy string) (*domain.Supplier, error)
+	GetSupplier(ctx context.Context, id uint) (*domain.Supplier, error)
+	ListSuppliers(ctx context.Context, storeID string) ([]domain.Supplier, error)
+	UpdateSupplier(ctx context.Context, s *domain.Supplier) (*domain.Supplier, error)
+}
+
+type supplierUC struct {
+	repo repository.SupplierRepository
+}
+
+func NewSupplierUsecase(repo repository.SupplierRepository) SupplierUsecase {
+	return &supplierUC{repo: repo}
+}
+
+func (u *supplierUC) CreateSupplier(ctx context.Context, s *domain.Supplier, createdBy string) (*domain.Supplier, error) {
+	if s == nil {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	if _, err := uuid.Parse(s.StoreID); err != nil {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	if err := normalizeSupplier(s); err != nil {
+		return nil, err
+	}
+	s.ID = 0
+	s.CreatedBy = createdBy
+	if err := u.repo.CreateSupplier(ctx, s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (u *supplierUC) GetSupplier(ctx context.Context, id uint) (*domain.Supplier, error) {
+	if id == 0 {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	s, err := u.repo.GetSupplier(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return nil, apperror.New(fiber.StatusNotFound)
+	}
+	return s, nil
+}
+
+func (u *supplierUC) ListSuppliers(ctx context.Context, storeID string) ([]domain.Supplier, error) {
+	if _, err := uuid.Parse(storeID); err != nil {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	return u.repo.ListSuppliers(ctx, storeID)
+}
+
+// UpdateSupplier replaces the details of an existing supplier. Its store
+// cannot be changed.
+func (u *supplierUC) UpdateSupplier(ctx context.Context, s *domain.Supplier) (*domain.Supplier, error) {
+	if s == nil {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	current, err := u.GetSupplier(ctx, s.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := normalizeSupplier(s); err != nil {
+		return nil, err
+	}
+	s.StoreID = current.StoreID
+	if err := u.repo.UpdateSupplier(ctx, s); err != nil {
+		return nil, err
+	}
+	return u.repo.GetSupplier(ctx, s.ID)
+}
+
+// normalizeSupplier checks the supplier's type, name and tax ID. A
+// company's branch number is padded to five digits and defaults to the
+// head office.
+func normalizeSupplier(s *domain.Supplier) error {
+	s.Name = strings.TrimSpace(s.Name)
+	s.TaxID = strings.TrimSpace(s.TaxID)
+	if s.Name == "" || (s.TaxID != "" && !isTaxID(s.TaxID)) {
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	switch s.SupplierType {
+	case domain.SupplierTypeCompany:
+		s.BranchNo = invoiceUC.PadBranchNo(s.BranchNo)
+		if s.BranchNo == "" {
+			s.BranchNo = "00000"
+		}
+		if !isDigits(s.BranchNo, 5) {
+			return apperror.New(fiber.StatusBadRequest)
+		}
+	case domain.SupplierTypePerson:
+		s.BranchNo = ""
+	default:
+		return apperror.New(fiber.StatusBadRequest)
+	}
+	return nil
+}
+
+// isTaxID reports whether s is a 13 digit Thai tax identification number.
+func isTaxID(s string) bool {
+	return isDigits(s, 13)
+}
+
+func isDigits(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
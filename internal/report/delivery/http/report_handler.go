@@ -0,0 +1,133 @@
+package http
+
+import (
+	"invoice_project/internal/report/domain"
+	"invoice_project/internal/report/usecase"
+	"invoice_project/pkg/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ReportHandler struct {
+	vatUC       usecase.VatReportUsecase
+	analyticsUC usecase.AnalyticsUsecase
+	access      middleware.StoreAuthorizer
+}
+
+func NewReportHandler(vatUC usecase.VatReportUsecase, analyticsUC usecase.AnalyticsUsecase, access middleware.StoreAuthorizer) *ReportHandler {
+	return &ReportHandler{vatUC: vatUC, analyticsUC: analyticsUC, access: access}
+}
+
+// exportTypes are the content types of the export formats.
+var exportTypes = map[string]string{
+	usecase.FormatCSV:  "text/csv; charset=utf-8",
+	usecase.FormatXLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	usecase.FormatPDF:  "application/pdf",
+}
+
+// OutputVAT returns the output tax report of a store for a month as JSON,
+// or as a download with ?format=csv, xlsx or pdf.
+func (h *ReportHandler) OutputVAT(c *fiber.Ctx) error {
+	r, err := h.vatUC.OutputTax(c.Context(), c.Query("store_id"), c.Query("month"))
+	if err != nil {
+		return err
+	}
+	format := c.Query("format")
+	if format == "" || format == "json" {
+		return c.JSON(r)
+	}
+	b, err := h.vatUC.ExportOutputTax(r, format)
+	if err != nil {
+		return err
+	}
+	return download(c, "output-vat", r.TaxReportHeader, format, b)
+}
+
+// InputVAT returns the input tax report of a store for a month as JSON,
+// or as a download with ?format=csv, xlsx or pdf.
+func (h *ReportHandler) InputVAT(c *fiber.Ctx) error {
+	r, err := h.vatUC.InputTax(c.Context(), c.Query("store_id"), c.Query("month"))
+	if err != nil {
+		return err
+	}
+	format := c.Query("format")
+	if format == "" || format == "json" {
+		return c.JSON(r)
+	}
+	b, err := h.vatUC.ExportInputTax(r, format)
+	if err != nil {
+		return err
+	}
+	return download(c, "input-vat", r.TaxReportHeader, format, b)
+}
+
+// VatReturn returns the ภ.พ.30 figures of a store for a month, including
+// the net VAT payable or paid in excess.
+func (h *ReportHandler) VatReturn(c *fiber.Ctx) error {
+	r, err := h.vatUC.VatReturn(c.Context(), c.Query("store_id"), c.Query("month"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(r)
+}
+
+// Revenue returns a store's sales by period, e.g.
+// ?store_id=<uuid>&interval=week&from=2026-07-01&to=2026-09-30.
+func (h *ReportHandler) Revenue(c *fiber.Ctx) error {
+	r, err := h.analyticsUC.Revenue(c.Context(), c.Query("store_id"), c.Query("interval"), c.Query("from"), c.Query("to"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(r)
+}
+
+// TopCustomers ranks a store's customers by revenue, e.g.
+// ?store_id=<uuid>&from=2026-01-01&to=2026-12-31&limit=5.
+func (h *ReportHandler) TopCustomers(c *fiber.Ctx) error {
+	customers, err := h.analyticsUC.TopCustomers(c.Context(), c.Query("store_id"), c.Query("from"), c.Query("to"), c.QueryInt("limit"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(customers)
+}
+
+// TopProducts ranks a store's products by revenue, with the same query
+// as TopCustomers.
+func (h *ReportHandler) TopProducts(c *fiber.Ctx) error {
+	products, err := h.analyticsUC.TopProducts(c.Context(), c.Query("store_id"), c.Query("from"), c.Query("to"), c.QueryInt("limit"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(products)
+}
+
+// Receivables returns a store's outstanding receivables by age, e.g.
+// ?store_id=<uuid>&as_of=2026-10-31.
+func (h *ReportHandler) Receivables(c *fiber.Ctx) error {
+	r, err := h.analyticsUC.Receivables(c.Context(), c.Query("store_id"), c.Query("as_of"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(r)
+}
+
+// download sends an exported report as an attachment named after the
+// report, month and branch.
+func download(c *fiber.Ctx, report string, h domain.TaxReportHeader, format string, b []byte) error {
+	name := report + "-" + h.Month + "-" + h.BranchNo + "." + format
+	c.Set(fiber.HeaderContentType, exportTypes[format])
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="`+name+`"`)
+	return c.Send(b)
+}
+
+func (h *ReportHandler) RegisterRoutes(app *fiber.App) {
+	api := app.Group("/reports", middleware.RequireRoles("user", "admin"))
+	byStore := middleware.RequireStoreAccess(h.access, middleware.FromQuery("store_id"))
+	api.Get("/output-vat", byStore, h.OutputVAT) // ?store_id=<uuid>&month=YYYY-MM&format=csv|xlsx|pdf
+	api.Get("/input-vat", byStore, h.InputVAT)
+	api.Get("/vat-return", byStore, h.VatReturn)
+	api.Get("/revenue", byStore, h.Revenue)
+	api.Get("/top-customers", byStore, h.TopCustomers)
+	api.Get("/top-products", byStore, h.TopProducts)
+	api.Get("/receivables", byStore, h.Receivables)
+}
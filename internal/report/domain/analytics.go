@@ -0,0 +1,104 @@
+package domain
+
+import (
+	"time"
+
+	"invoice_project/pkg/money"
+)
+
+// Intervals a revenue series can be grouped by. Weeks start on Monday.
+const (
+	IntervalDay   = "day"
+	IntervalWeek  = "week"
+	IntervalMonth = "month"
+)
+
+// RevenueReport is the sales of a store between From and To (both
+// inclusive) grouped by Interval. Every period in the range has a point,
+// with zeros when nothing was sold. Amounts are in baht; Currencies
+// breaks the range down by the currency documents were issued in.
+type RevenueReport struct {
+	StoreID    string            `json:"store_id"`
+	Interval   string            `json:"interval"`
+	From       time.Time         `json:"from"`
+	To         time.Time         `json:"to"`
+	Points     []RevenuePoint    `json:"points"`
+	Summary    SalesSummary      `json:"summary"`
+	Currencies []CurrencyRevenue `json:"currencies"`
+}
+
+// RevenuePoint is the sales of one period. Documents counts the sales
+// documents issued; Revenue is their value before VAT and Total includes
+// VAT, both in baht at the rate of each document. Credit notes reduce and
+// debit notes raise both amounts.
+type RevenuePoint struct {
+	Period    time.Time    `json:"period"`
+	Documents int          `json:"documents"`
+	Revenue   money.Amount `json:"revenue"`
+	VatAmount money.Amount `json:"vat_amount"`
+	Total     money.Amount `json:"total"`
+}
+
+// CurrencyRevenue is the sales in one currency: Revenue and Total in the
+// currency itself, ThbRevenue and ThbTotal converted to baht.
+type CurrencyRevenue struct {
+	Currency   string       `json:"currency"`
+	Documents  int          `json:"documents"`
+	Revenue    money.Amount `json:"revenue"`
+	Total      money.Amount `json:"total"`
+	ThbRevenue money.Amount `json:"thb_revenue"`
+	ThbTotal   money.Amount `json:"thb_total"`
+}
+
+// SalesSummary totals a RevenueReport. AverageValue is the average revenue
+// per sales document.
+type SalesSummary struct {
+	Documents    int          `json:"documents"`
+	Revenue      money.Amount `json:"revenue"`
+	Total        money.Amount `json:"total"`
+	AverageValue money.Amount `json:"average_value"`
+}
+
+// TopCustomer is a customer ranked by revenue before VAT, in baht.
+type TopCustomer struct {
+	CustomerID uint         `json:"customer_id"`
+	Name       string       `json:"name"`
+	TaxID      string       `json:"tax_id"`
+	Documents  int          `json:"documents"`
+	Revenue    money.Amount `json:"revenue"`
+}
+
+// TopProduct is a product ranked by revenue in baht before VAT and
+// document discounts. Qty is net of the quantities on credit notes.
+type TopProduct struct {
+	ProductID uint         `json:"product_id"`
+	Name      string       `json:"name"`
+	Sku       string       `json:"sku"`
+	Qty       int64        `json:"qty"`
+	Revenue   money.Amount `json:"revenue"`
+}
+
+// ReceivablesReport is what customers owe a store on AsOf: the
+// outstanding balance of its unpaid sales documents in baht, aged by the
+// days since they were issued.
+type ReceivablesReport struct {
+	StoreID     string        `json:"store_id"`
+	AsOf        time.Time     `json:"as_of"`
+	Documents   int           `json:"documents"`
+	Outstanding money.Amount  `json:"outstanding"`
+	Buckets     []AgingBucket `json:"buckets"`
+}
+
+// AgingBucket holds the documents aged between MinDays and MaxDays (both
+// inclusive); the last bucket has no MaxDays.
+type AgingBucket struct {
+	Label       string       `json:"label"`
+	MinDays     int          `json:"min_days"`
+	MaxDays     *int         `json:"max_days"`
+	Documents   int          `json:"documents"`
+	Outstanding money.Amount `json:"outstanding"`
+}
+
+// AgingLimits are the upper bounds in days of every aging bucket but the
+// last: 0–30, 31–60, 61–90 and over 90.
+var AgingLimits = []int{30, 60, 90}
@@ -0,0 +1,114 @@
+package domain
+
+import (
+	"time"
+
+	"invoice_project/pkg/money"
+)
+
+// HeadOffice is the branch number of a head office (สำนักงานใหญ่).
+const HeadOffice = "00000"
+
+// TaxReportHeader identifies a monthly VAT report: the operator
+// (ผู้ประกอบการ) and the store, registered for VAT as a branch, it is made
+// for, and the tax month as YYYY-MM.
+type TaxReportHeader struct {
+	StoreID       string `json:"store_id"`
+	Month         string `json:"month"`
+	OperatorName  string `json:"operator_name"`
+	OperatorTaxID string `json:"operator_tax_id"`
+	StoreName     string `json:"store_name"`
+	BranchNo      string `json:"branch_no"`
+}
+
+// OutputTaxReport is the output tax report (รายงานภาษีขาย) of one store for
+// one tax month. It lists the tax invoices, credit notes and debit notes
+// issued in the month and totals the sales lines of the VAT return
+// (ภ.พ.30).
+type OutputTaxReport struct {
+	TaxReportHeader
+	Entries []OutputTaxEntry `json:"entries"`
+	Totals  OutputTaxTotals  `json:"totals"`
+}
+
+// OutputTaxEntry is one document in an OutputTaxReport. Value is the
+// value of the goods or services before VAT; both amounts are in baht and
+// negative for credit notes. Documents in another currency also carry
+// their amounts in Currency and the ExchangeRate they were converted at.
+// Note names the document a credit or debit note adjusts and the amount
+// in a foreign currency.
+type OutputTaxEntry struct {
+	No                int          `json:"no"`
+	DocumentID        uint         `json:"document_id"`
+	DocumentType      string       `json:"document_type"`
+	IssueDate         time.Time    `json:"issue_date"`
+	DocumentNo        string       `json:"document_no"`
+	BuyerName         string       `json:"buyer_name"`
+	BuyerTaxID        string       `json:"buyer_tax_id"`
+	BuyerBranch       string       `json:"buyer_branch"`
+	Value             money.Amount `json:"value"`
+	VatAmount         money.Amount `json:"vat_amount"`
+	Currency          string       `json:"currency"`
+	ExchangeRate      money.Rate   `json:"exchange_rate"`
+	CurrencyValue     money.Amount `json:"currency_value"`
+	CurrencyVatAmount money.Amount `json:"currency_vat_amount"`
+	Note              string       `json:"note,omitempty"`
+}
+
+// OutputTaxTotals are the sales lines 1 to 5 of ภ.พ.30: total sales, the
+// zero-rated and exempt sales deducted from them, the taxable sales and
+// the output tax.
+type OutputTaxTotals struct {
+	Sales          money.Amount `json:"sales"`
+	ZeroRatedSales money.Amount `json:"zero_rated_sales"`
+	ExemptSales    money.Amount `json:"exempt_sales"`
+	TaxableSales   money.Amount `json:"taxable_sales"`
+	OutputTax      money.Amount `json:"output_tax"`
+}
+
+// InputTaxReport is the input tax report (รายงานภาษีซื้อ) of one store for
+// one tax month. Entries are the purchase documents whose input tax is
+// claimed in the month; NonClaimable lists the documents of the month
+// whose VAT cannot be claimed and is left out of the totals.
+type InputTaxReport struct {
+	TaxReportHeader
+	Entries      []InputTaxEntry `json:"entries"`
+	NonClaimable []InputTaxEntry `json:"non_claimable"`
+	Totals       InputTaxTotals  `json:"totals"`
+}
+
+// InputTaxEntry is one purchase document in an InputTaxReport. Both
+// amounts are negative for credit notes.
+type InputTaxEntry struct {
+	No             int          `json:"no"`
+	PurchaseID     uint         `json:"purchase_id"`
+	DocumentType   string       `json:"document_type"`
+	IssueDate      time.Time    `json:"issue_date"`
+	DocumentNo     string       `json:"document_no"`
+	SupplierName   string       `json:"supplier_name"`
+	SupplierTaxID  string       `json:"supplier_tax_id"`
+	SupplierBranch string       `json:"supplier_branch"`
+	Value          money.Amount `json:"value"`
+	VatAmount      money.Amount `json:"vat_amount"`
+	Note           string       `json:"note,omitempty"`
+}
+
+// InputTaxTotals are the purchase lines 6 and 7 of ภ.พ.30, and the VAT
+// paid on purchases that cannot be claimed.
+type InputTaxTotals struct {
+	Purchases       money.Amount `json:"purchases"`
+	InputTax        money.Amount `json:"input_tax"`
+	NonClaimableTax money.Amount `json:"non_claimable_tax"`
+}
+
+// VatReturn is the VAT return (ภ.พ.30) of one store for one tax month:
+// output tax less input tax gives the tax payable (line 8) or, when the
+// input tax is larger, the excess to be refunded or carried forward
+// (line 9).
+type VatReturn struct {
+	TaxReportHeader
+	Sales      OutputTaxTotals `json:"sales"`
+	Purchases  InputTaxTotals  `json:"purchases"`
+	TaxPayable money.Amount    `json:"tax_payable"`
+	ExcessTax  money.Amount    `json:"excess_tax"`
+}
@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	invoiceDomain "invoice_project/internal/invoice/domain"
+	"invoice_project/internal/report/domain"
+	"invoice_project/pkg/money"
+)
+
+// AgingRow is the number and outstanding balance of the open documents
+// in one aging bucket, indexed like AgingLimits with the open-ended
+// bucket last.
+type AgingRow struct {
+	Bucket      int
+	Documents   int
+	Outstanding money.Amount
+}
+
+type AnalyticsRepository interface {
+	Revenue(ctx context.Context, storeID, interval string, from, to time.Time) ([]domain.RevenuePoint, error)
+	RevenueByCurrency(ctx context.Context, storeID string, from, to time.Time) ([]domain.CurrencyRevenue, error)
+	TopCustomers(ctx context.Context, storeID string, from, to time.Time, limit int) ([]domain.TopCustomer, error)
+	TopProducts(ctx context.Context, storeID string, from, to time.Time, limit int) ([]domain.TopProduct, error)
+	Aging(ctx context.Context, storeID string, asOf time.Time, limits []int) ([]AgingRow, error)
+}
+
+type analyticsPG struct {
+	db *gorm.DB
+}
+
+// NewAnalyticsRepository creates the analytics repository. Every query is
+// a single aggregate over invoice_documents, filtered on the store and
+// issue date index.
+func NewAnalyticsRepository(db *gorm.DB) AnalyticsRepository {
+	return &analyticsPG{db: db}
+}
+
+// revenueTypes are the documents counted in sales figures: the sales
+// documents and the notes adjusting them.
+func revenueTypes() []string {
+	return append(invoiceDomain.SalesTypes(), invoiceDomain.DocumentTypeCreditNote, invoiceDomain.DocumentTypeDebitNote)
+}
+
+// signed negates an amount column on credit notes; the first argument of
+// the query must be the credit note type.
+func signed(column string) string {
+	return "CASE WHEN document_type = ? THEN -(" + column + ") ELSE " + column + " END"
+}
+
+// Revenue sums the sales of a store issued between from and to
+// (exclusive) by period, in baht.
+func (r *analyticsPG) Revenue(ctx context.Context, storeID, interval string, from, to time.Time) ([]domain.RevenuePoint, error) {
+	cn := invoiceDomain.DocumentTypeCreditNote
+	var points []domain.RevenuePoint
+	err := r.db.WithContext(ctx).Raw(`
+SELECT date_trunc(?, issue_date::timestamp)::date AS period,
+	COUNT(*) FILTER (WHERE document_type IN ?) AS documents,
+	COALESCE(SUM(`+signed("thb_grand_total - thb_vat_amount")+`), 0) AS revenue,
+	COALESCE(SUM(`+signed("thb_vat_amount")+`), 0) AS vat_amount,
+	COALESCE(SUM(`+signed("thb_grand_total")+`), 0) AS total
+FROM invoice_documents
+WHERE store_id = ? AND document_type IN ? AND status IN ? AND issue_date >= ? AND issue_date < ?
+GROUP BY 1
+ORDER BY 1`,
+		interval, invoiceDomain.SalesTypes(), cn, cn, cn,
+		storeID, revenueTypes(), invoiceDomain.IssuedStatuses(), from.Format("2006-01-02"), to.Format("2006-01-02"),
+	).Scan(&points).Error
+	if err != nil {
+		return nil, err
+	}
+	return points, nil
+}
+
+// RevenueByCurrency sums the sales of a store issued between from and to
+// (exclusive) by the currency they were issued in, both in that currency
+// and in baht.
+func (r *analyticsPG) RevenueByCurrency(ctx context.Context, storeID string, from, to time.Time) ([]domain.CurrencyRevenue, error) {
+	cn := invoiceDomain.DocumentTypeCreditNote
+	var rows []domain.CurrencyRevenue
+	err := r.db.WithContext(ctx).Raw(`
+SELECT currency,
+	COUNT(*) FILTER (WHERE document_type IN ?) AS documents,
+	COALESCE(SUM(`+signed("grand_total - vat_amount")+`), 0) AS revenue,
+	COALESCE(SUM(`+signed("grand_total")+`), 0) AS total,
+	COALESCE(SUM(`+signed("thb_grand_total - thb_vat_amount")+`), 0) AS thb_revenue,
+	COALESCE(SUM(`+signed("thb_grand_total")+`), 0) AS thb_total
+FROM invoice_documents
+WHERE store_id = ? AND document_type IN ? AND status IN ? AND issue_date >= ? AND issue_date < ?
+GROUP BY currency
+ORDER BY thb_revenue DESC, currency`,
+		invoiceDomain.SalesTypes(), cn, cn, cn, cn,
+		storeID, revenueTypes(), invoiceDomain.IssuedStatuses(), from.Format("2006-01-02"), to.Format("2006-01-02"),
+	).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// TopCustomers ranks the customers of a store by revenue between from and
+// to (exclusive). Names are taken from each customer's latest document.
+func (r *analyticsPG) TopCustomers(ctx context.Context, storeID string, from, to time.Time, limit int) ([]domain.TopCustomer, error) {
+	cn := invoiceDomain.DocumentTypeCreditNote
+	var customers []domain.TopCustomer
+	err := r.db.WithContext(ctx).Raw(`
+SELECT customer_id,
+	(array_agg(CASE WHEN buyer_company_name <> '' THEN buyer_company_name
+		ELSE trim(buyer_first_name || ' ' || buyer_last_name) END ORDER BY issue_date DESC, id DESC))[1] AS name,
+	(array_agg(buyer_tax_id ORDER BY issue_date DESC, id DESC))[1] AS tax_id,
+	COUNT(*) FILTER (WHERE document_type IN ?) AS documents,
+	SUM(`+signed("thb_grand_total - thb_vat_amount")+`) AS revenue
+FROM invoice_documents
+WHERE store_id = ? AND customer_id IS NOT NULL AND document_type IN ? AND status IN ?
+	AND issue_date >= ? AND issue_date < ?
+GROUP BY customer_id
+ORDER BY revenue DESC, customer_id
+LIMIT ?`,
+		invoiceDomain.SalesTypes(), cn,
+		storeID, revenueTypes(), invoiceDomain.IssuedStatuses(), from.Format("2006-01-02"), to.Format("2006-01-02"), limit,
+	).Scan(&customers).Error
+	if err != nil {
+		return nil, err
+	}
+	return customers, nil
+}
+
+// TopProducts ranks the products of a store by the revenue of their
+// lines between from and to (exclusive), converted to baht at the rate of
+// their document. Lines priced with VAT included are counted without it.
+func (r *analyticsPG) TopProducts(ctx context.Context, storeID string, from, to time.Time, limit int) ([]domain.TopProduct, error) {
+	cn := invoiceDomain.DocumentTypeCreditNote
+	var products []domain.TopProduct
+	err := r.db.WithContext(ctx).Raw(`
+SELECT i.product_id,
+	(array_agg(i.product_name ORDER BY d.issue_date DESC, i.id DESC))[1] AS name,
+	(array_agg(i.sku ORDER BY d.issue_date DESC, i.id DESC))[1] AS sku,
+	SUM(CASE WHEN d.document_type = ? THEN -i.qty ELSE i.qty END) AS qty,
+	SUM(CASE WHEN d.document_type = ? THEN -1 ELSE 1 END *
+		ROUND(CASE WHEN i.vat_type = 'include' THEN ROUND(i.line_total * 100 / (100 + i.vat_rate), 2) ELSE i.line_total END
+			* d.exchange_rate, 2)) AS revenue
+FROM invoice_items i
+JOIN invoice_documents d ON d.id = i.document_id
+WHERE d.store_id = ? AND i.product_id IS NOT NULL AND d.document_type IN ? AND d.status IN ?
+	AND d.issue_date >= ? AND d.issue_date < ?
+GROUP BY i.product_id
+ORDER BY revenue DESC, i.product_id
+LIMIT ?`,
+		cn, cn,
+		storeID, revenueTypes(), invoiceDomain.IssuedStatuses(), from.Format("2006-01-02"), to.Format("2006-01-02"), limit,
+	).Scan(&products).Error
+	if err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// Aging groups the open sales documents of a store issued up to asOf by
+// the days since issue. The outstanding balance of a document is its
+// total adjusted by its notes, less the payments allocated to it, as in
+// DocumentBalance, converted to baht at the rate of the document.
+func (r *analyticsPG) Aging(ctx context.Context, storeID string, asOf time.Time, limits []int) ([]AgingRow, error) {
+	day := asOf.Format("2006-01-02")
+	var bucket strings.Builder
+	args := []interface{}{
+		day,
+		invoiceDomain.DocumentTypeDebitNote, invoiceDomain.IssuedStatuses(),
+		invoiceDomain.DocumentTypeCreditNote, invoiceDomain.IssuedStatuses(),
+		storeID, invoiceDomain.SalesTypes(), invoiceDomain.OpenStatuses(), day,
+	}
+	bucket.WriteString("CASE")
+	for i, limit := range limits {
+		bucket.WriteString(" WHEN age <= ? THEN " + strconv.Itoa(i))
+		args = append(args, limit)
+	}
+	bucket.WriteString(" ELSE " + strconv.Itoa(len(limits)) + " END")
+
+	var rows []AgingRow
+	err := r.db.WithContext(ctx).Raw(`
+WITH open AS (
+	SELECT ?::date - d.issue_date AS age,
+		ROUND((d.grand_total
+		+ COALESCE((SELECT SUM(n.grand_total) FROM invoice_documents n
+			WHERE n.reference_id = d.id AND n.document_type = ? AND n.status IN ?), 0)
+		- COALESCE((SELECT SUM(n.grand_total) FROM invoice_documents n
+			WHERE n.reference_id = d.id AND n.document_type = ? AND n.status IN ?), 0)
+		- COALESCE((SELECT SUM(a.amount) FROM payment_allocations a WHERE a.document_id = d.id), 0))
+		* d.exchange_rate, 2) AS outstanding
+	FROM invoice_documents d
+	WHERE d.store_id = ? AND d.document_type IN ? AND d.status IN ? AND d.issue_date <= ?
+)
+SELECT `+bucket.String()+` AS bucket, COUNT(*) AS documents, SUM(outstanding) AS outstanding
+FROM open
+WHERE outstanding > 0
+GROUP BY 1
+ORDER BY 1`, args...).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
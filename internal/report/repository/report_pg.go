@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	invoiceDomain "invoice_project/internal/invoice/domain"
+	purchaseDomain "invoice_project/internal/purchase/domain"
+)
+
+type ReportRepository interface {
+	ListTaxDocuments(ctx context.Context, storeID string, from, to time.Time) ([]invoiceDomain.InvoiceDocument, error)
+	DocumentNumbers(ctx context.Context, ids []uint) (map[uint]string, error)
+	ListPurchases(ctx context.Context, storeID, month string) ([]purchaseDomain.PurchaseDocument, error)
+}
+
+type reportPG struct {
+	db *gorm.DB
+}
+
+func NewReportRepository(db *gorm.DB) ReportRepository {
+	return &reportPG{db: db}
+}
+
+// ListTaxDocuments returns the tax invoices, credit notes and debit notes
+// of a store issued between from and to (exclusive) that are still in
+// force, in the order they were issued.
+func (r *reportPG) ListTaxDocuments(ctx context.Context, storeID string, from, to time.Time) ([]invoiceDomain.InvoiceDocument, error) {
+	var docs []invoiceDomain.InvoiceDocument
+	err := r.db.WithContext(ctx).
+		Where("store_id = ? AND document_type IN ? AND status IN ?",
+			storeID, invoiceDomain.TaxInvoiceTypes(), invoiceDomain.IssuedStatuses()).
+		Where("issue_date >= ? AND issue_date < ?", from.Format("2006-01-02"), to.Format("2006-01-02")).
+		Order("issue_date, document_no, id").
+		Find(&docs).Error
+	if err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+// DocumentNumbers returns the document numbers of the given documents.
+func (r *reportPG) DocumentNumbers(ctx context.Context, ids []uint) (map[uint]string, error) {
+	numbers := make(map[uint]string, len(ids))
+	if len(ids) == 0 {
+		return numbers, nil
+	}
+	var docs []invoiceDomain.InvoiceDocument
+	if err := r.db.WithContext(ctx).Select("id, document_no").Where("id IN ?", ids).Find(&docs).Error; err != nil {
+		return nil, err
+	}
+	for _, d := range docs {
+		numbers[d.ID] = d.DocumentNo
+	}
+	return numbers, nil
+}
+
+// ListPurchases returns the purchase documents of a store recorded for a
+// tax month and not voided, in the order they were issued.
+func (r *reportPG) ListPurchases(ctx context.Context, storeID, month string) ([]purchaseDomain.PurchaseDocument, error) {
+	var docs []purchaseDomain.PurchaseDocument
+	err := r.db.WithContext(ctx).
+		Where("store_id = ? AND tax_month = ? AND status = ?", storeID, month, purchaseDomain.PurchaseStatusRecorded).
+		Order("issue_date, document_no, id").
+		Find(&docs).Error
+	if err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
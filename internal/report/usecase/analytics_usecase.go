@@ -0,0 +1,250 @@
+package usecase
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"invoice_project/internal/report/domain"
+	"invoice_project/internal/report/repository"
+	"invoice_project/pkg/apperror"
+	"invoice_project/pkg/money"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// Limits of the analytics queries.
+const (
+	DefaultTopLimit  = 10
+	MaxTopLimit      = 100
+	MaxRevenuePoints = 1000
+)
+
+type AnalyticsUsecase interface {
+	Revenue(ctx context.Context, storeID, interval, from, to string) (*domain.RevenueReport, error)
+	TopCustomers(ctx context.Context, storeID, from, to string, limit int) ([]domain.TopCustomer, error)
+	TopProducts(ctx context.Context, storeID, from, to string, limit int) ([]domain.TopProduct, error)
+	Receivables(ctx context.Context, storeID, asOf string) (*domain.ReceivablesReport, error)
+}
+
+type analyticsUC struct {
+	repo repository.AnalyticsRepository
+	now  func() time.Time
+}
+
+func NewAnalyticsUsecase(repo repository.AnalyticsRepository) AnalyticsUsecase {
+	return &analyticsUC{repo: repo, now: time.Now}
+}
+
+// Revenue returns the sales of a store in baht by day, week or month
+// between from and to, given as YYYY-MM-DD, with the sales of the range by
+// currency. The range defaults to the twelve months up to today, the
+// interval to month.
+func (u *analyticsUC) Revenue(ctx context.Context, storeID, interval, from, to string) (*domain.RevenueReport, error) {
+	if _, err := uuid.Parse(storeID); err != nil {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	if interval == "" {
+		interval = domain.IntervalMonth
+	}
+	if interval != domain.IntervalDay && interval != domain.IntervalWeek && interval != domain.IntervalMonth {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	start, end, err := u.dateRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+	if countPeriods(interval, start, end) > MaxRevenuePoints {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	rows, err := u.repo.Revenue(ctx, storeID, interval, start, end.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+	currencies, err := u.repo.RevenueByCurrency(ctx, storeID, start, end.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+	r := revenueReport(storeID, interval, start, end, rows)
+	r.Currencies = currencies
+	if r.Currencies == nil {
+		r.Currencies = []domain.CurrencyRevenue{}
+	}
+	return r, nil
+}
+
+func (u *analyticsUC) TopCustomers(ctx context.Context, storeID, from, to string, limit int) ([]domain.TopCustomer, error) {
+	start, end, limit, err := u.topQuery(storeID, from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+	customers, err := u.repo.TopCustomers(ctx, storeID, start, end.AddDate(0, 0, 1), limit)
+	if err != nil {
+		return nil, err
+	}
+	if customers == nil {
+		customers = []domain.TopCustomer{}
+	}
+	return customers, nil
+}
+
+func (u *analyticsUC) TopProducts(ctx context.Context, storeID, from, to string, limit int) ([]domain.TopProduct, error) {
+	start, end, limit, err := u.topQuery(storeID, from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+	products, err := u.repo.TopProducts(ctx, storeID, start, end.AddDate(0, 0, 1), limit)
+	if err != nil {
+		return nil, err
+	}
+	if products == nil {
+		products = []domain.TopProduct{}
+	}
+	return products, nil
+}
+
+// topQuery validates the parameters of a ranking. The limit defaults to
+// DefaultTopLimit.
+func (u *analyticsUC) topQuery(storeID, from, to string, limit int) (time.Time, time.Time, int, error) {
+	if _, err := uuid.Parse(storeID); err != nil {
+		return time.Time{}, time.Time{}, 0, apperror.New(fiber.StatusBadRequest)
+	}
+	if limit == 0 {
+		limit = DefaultTopLimit
+	}
+	if limit < 0 || limit > MaxTopLimit {
+		return time.Time{}, time.Time{}, 0, apperror.New(fiber.StatusBadRequest)
+	}
+	start, end, err := u.dateRange(from, to)
+	return start, end, limit, err
+}
+
+// Receivables returns the outstanding balance of a store's unpaid sales
+// documents on asOf (YYYY-MM-DD, default today), aged by issue date.
+func (u *analyticsUC) Receivables(ctx context.Context, storeID, asOf string) (*domain.ReceivablesReport, error) {
+	if _, err := uuid.Parse(storeID); err != nil {
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+	day := today(u.now())
+	if asOf != "" {
+		var err error
+		if day, err = time.ParseInLocation("2006-01-02", asOf, time.Local); err != nil {
+			return nil, apperror.New(fiber.StatusBadRequest)
+		}
+	}
+	rows, err := u.repo.Aging(ctx, storeID, day, domain.AgingLimits)
+	if err != nil {
+		return nil, err
+	}
+	return receivablesReport(storeID, day, rows), nil
+}
+
+// dateRange parses an inclusive range of YYYY-MM-DD dates. to defaults to
+// today and from to the first day of the month eleven months before to.
+func (u *analyticsUC) dateRange(from, to string) (time.Time, time.Time, error) {
+	end := today(u.now())
+	var err error
+	if to != "" {
+		if end, err = time.ParseInLocation("2006-01-02", to, time.Local); err != nil {
+			return time.Time{}, time.Time{}, apperror.New(fiber.StatusBadRequest)
+		}
+	}
+	start := time.Date(end.Year(), end.Month()-11, 1, 0, 0, 0, 0, time.Local)
+	if from != "" {
+		if start, err = time.ParseInLocation("2006-01-02", from, time.Local); err != nil {
+			return time.Time{}, time.Time{}, apperror.New(fiber.StatusBadRequest)
+		}
+	}
+	if start.After(end) {
+		return time.Time{}, time.Time{}, apperror.New(fiber.StatusBadRequest)
+	}
+	return start, end, nil
+}
+
+func today(now time.Time) time.Time {
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// periodStart returns the first day of the period containing t.
+func periodStart(interval string, t time.Time) time.Time {
+	switch interval {
+	case domain.IntervalWeek:
+		return time.Date(t.Year(), t.Month(), t.Day()-(int(t.Weekday())+6)%7, 0, 0, 0, 0, t.Location())
+	case domain.IntervalMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	}
+}
+
+func nextPeriod(interval string, t time.Time) time.Time {
+	switch interval {
+	case domain.IntervalWeek:
+		return t.AddDate(0, 0, 7)
+	case domain.IntervalMonth:
+		return t.AddDate(0, 1, 0)
+	default:
+		return t.AddDate(0, 0, 1)
+	}
+}
+
+func countPeriods(interval string, from, to time.Time) int {
+	n := 0
+	for p := periodStart(interval, from); !p.After(to) && n <= MaxRevenuePoints; p = nextPeriod(interval, p) {
+		n++
+	}
+	return n
+}
+
+// revenueReport puts the periods with sales among the periods of the
+// range and totals them.
+func revenueReport(storeID, interval string, from, to time.Time, rows []domain.RevenuePoint) *domain.RevenueReport {
+	byPeriod := make(map[string]domain.RevenuePoint, len(rows))
+	for _, row := range rows {
+		byPeriod[row.Period.Format("2006-01-02")] = row
+	}
+	r := &domain.RevenueReport{StoreID: storeID, Interval: interval, From: from, To: to, Points: []domain.RevenuePoint{}}
+	s := &r.Summary
+	for p := periodStart(interval, from); !p.After(to); p = nextPeriod(interval, p) {
+		point := byPeriod[p.Format("2006-01-02")]
+		point.Period = p
+		r.Points = append(r.Points, point)
+
+		s.Documents += point.Documents
+		s.Revenue = s.Revenue.Add(point.Revenue)
+		s.Total = s.Total.Add(point.Total)
+	}
+	if s.Documents > 0 {
+		s.AverageValue = money.FromSatang(money.RoundDiv(s.Revenue.Satang(), int64(s.Documents)))
+	}
+	return r
+}
+
+// receivablesReport lays the aging rows out in every bucket of
+// AgingLimits, empty ones included.
+func receivablesReport(storeID string, asOf time.Time, rows []repository.AgingRow) *domain.ReceivablesReport {
+	r := &domain.ReceivablesReport{StoreID: storeID, AsOf: asOf}
+	low := 0
+	for i := 0; i <= len(domain.AgingLimits); i++ {
+		b := domain.AgingBucket{MinDays: low}
+		if i < len(domain.AgingLimits) {
+			high := domain.AgingLimits[i]
+			b.MaxDays = &high
+			b.Label = strconv.Itoa(low) + "-" + strconv.Itoa(high)
+			low = high + 1
+		} else {
+			b.Label = strconv.Itoa(low-1) + "+"
+		}
+		for _, row := range rows {
+			if row.Bucket == i {
+				b.Documents = row.Documents
+				b.Outstanding = row.Outstanding
+			}
+		}
+		r.Documents += b.Documents
+		r.Outstanding = r.Outstanding.Add(b.Outstanding)
+		r.Buckets = append(r.Buckets, b)
+	}
+	return r
+}
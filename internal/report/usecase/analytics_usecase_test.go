@@ -0,0 +1,138 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"invoice_project/internal/report/domain"
+	"invoice_project/internal/report/repository"
+	"invoice_project/pkg/apperror"
+	"invoice_project/pkg/money"
+)
+
+const analyticsStore = "6f1c2d4e-8a7b-4c3d-9e2f-1a2b3c4d5e6f"
+
+type fakeAnalytics struct {
+	repository.AnalyticsRepository
+	points     []domain.RevenuePoint
+	currencies []domain.CurrencyRevenue
+	from, to   time.Time
+	limit      int
+}
+
+func (r *fakeAnalytics) Revenue(ctx context.Context, storeID, interval string, from, to time.Time) ([]domain.RevenuePoint, error) {
+	r.from, r.to = from, to
+	return r.points, nil
+}
+
+func (r *fakeAnalytics) RevenueByCurrency(ctx context.Context, storeID string, from, to time.Time) ([]domain.CurrencyRevenue, error) {
+	return r.currencies, nil
+}
+
+func (r *fakeAnalytics) TopProducts(ctx context.Context, storeID string, from, to time.Time, limit int) ([]domain.TopProduct, error) {
+	r.limit = limit
+	return nil, nil
+}
+
+func statusCode(err error) int {
+	if e, ok := err.(*apperror.StatusError); ok {
+		return e.Code
+	}
+	return 0
+}
+
+func day(s string) time.Time {
+	t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestRevenueFillsPeriods(t *testing.T) {
+	repo := &fakeAnalytics{points: []domain.RevenuePoint{
+		// the database returns dates in UTC
+		{Period: time.Date(2026, 9, 7, 0, 0, 0, 0, time.UTC), Documents: 2, Revenue: money.FromBaht(300), Total: money.FromBaht(321)},
+		{Period: time.Date(2026, 9, 21, 0, 0, 0, 0, time.UTC), Documents: 0, Revenue: money.FromBaht(-50), Total: money.MustParse("-53.50")},
+	}}
+	u := &analyticsUC{repo: repo, now: time.Now}
+	r, err := u.Revenue(context.Background(), analyticsStore, domain.IntervalWeek, "2026-09-09", "2026-09-30")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !repo.from.Equal(day("2026-09-09")) || !repo.to.Equal(day("2026-10-01")) {
+		t.Errorf("queried %s to %s", repo.from, repo.to)
+	}
+	// weeks start on Monday: 7, 14, 21 and 28 September
+	if len(r.Points) != 4 || !r.Points[0].Period.Equal(day("2026-09-07")) || !r.Points[3].Period.Equal(day("2026-09-28")) {
+		t.Fatalf("points = %+v", r.Points)
+	}
+	if r.Points[1].Documents != 0 || r.Points[2].Revenue != money.FromBaht(-50) {
+		t.Errorf("points = %+v", r.Points)
+	}
+	if r.Summary.Documents != 2 || r.Summary.Revenue != money.FromBaht(250) || r.Summary.AverageValue != money.FromBaht(125) {
+		t.Errorf("summary = %+v", r.Summary)
+	}
+}
+
+func TestRevenueRejects(t *testing.T) {
+	u := &analyticsUC{repo: &fakeAnalytics{}, now: time.Now}
+	ctx := context.Background()
+	for name, args := range map[string][4]string{
+		"store":         {"x", "", "", ""},
+		"interval":      {analyticsStore, "year", "", ""},
+		"date":          {analyticsStore, "", "2026-13-01", ""},
+		"reversed":      {analyticsStore, "", "2026-10-01", "2026-09-01"},
+		"too many days": {analyticsStore, domain.IntervalDay, "2020-01-01", "2026-01-01"},
+	} {
+		if _, err := u.Revenue(ctx, args[0], args[1], args[2], args[3]); statusCode(err) != 400 {
+			t.Errorf("%s: err %v", name, err)
+		}
+	}
+}
+
+func TestDateRangeDefaults(t *testing.T) {
+	u := &analyticsUC{now: func() time.Time { return time.Date(2026, 10, 18, 15, 4, 0, 0, time.Local) }}
+	from, to, err := u.dateRange("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !from.Equal(day("2025-11-01")) || !to.Equal(day("2026-10-18")) {
+		t.Errorf("range %s to %s", from, to)
+	}
+}
+
+func TestTopLimit(t *testing.T) {
+	repo := &fakeAnalytics{}
+	u := &analyticsUC{repo: repo, now: time.Now}
+	products, err := u.TopProducts(context.Background(), analyticsStore, "", "", 0)
+	if err != nil || repo.limit != DefaultTopLimit || products == nil {
+		t.Errorf("limit %d products %v err %v", repo.limit, products, err)
+	}
+	if _, err := u.TopProducts(context.Background(), analyticsStore, "", "", MaxTopLimit+1); statusCode(err) != 400 {
+		t.Errorf("limit over max: %v", err)
+	}
+}
+
+func TestReceivablesReport(t *testing.T) {
+	r := receivablesReport(analyticsStore, day("2026-10-18"), []repository.AgingRow{
+		{Bucket: 0, Documents: 3, Outstanding: money.FromBaht(1000)},
+		{Bucket: 3, Documents: 1, Outstanding: money.FromBaht(250)},
+	})
+	if len(r.Buckets) != 4 || r.Documents != 4 || r.Outstanding != money.FromBaht(1250) {
+		t.Fatalf("report = %+v", r)
+	}
+	labels := []string{"0-30", "31-60", "61-90", "90+"}
+	for i, b := range r.Buckets {
+		if b.Label != labels[i] {
+			t.Errorf("bucket %d label %q, want %q", i, b.Label, labels[i])
+		}
+	}
+	if r.Buckets[1].MinDays != 31 || *r.Buckets[1].MaxDays != 60 || r.Buckets[3].MaxDays != nil || r.Buckets[3].MinDays != 91 {
+		t.Errorf("bucket bounds = %+v", r.Buckets)
+	}
+	if r.Buckets[3].Outstanding != money.FromBaht(250) || r.Buckets[2].Documents != 0 {
+		t.Errorf("buckets = %+v", r.Buckets)
+	}
+}
@@ -0,0 +1,431 @@
+package usecase
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	invoiceUC "invoice_project/internal/invoice/usecase"
+	"invoice_project/internal/report/domain"
+	"invoice_project/pkg/apperror"
+	"invoice_project/pkg/money"
+	"invoice_project/pkg/pdf"
+	"invoice_project/pkg/xlsx"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Export formats of a report.
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+	FormatPDF  = "pdf"
+)
+
+// taxTable is a VAT report laid out for export: a heading block, the
+// table in the Revenue Department's column order with a total row, and
+// the ภ.พ.30 lines the report feeds.
+type taxTable struct {
+	title   string
+	sheet   string
+	heading [][2]string
+	columns []string
+	rows    [][]string
+	total   [2]string
+	summary []pp30Line
+}
+
+type pp30Line struct {
+	label  string
+	amount money.Amount
+}
+
+// Column indexes in taxTable.columns: the party name and remarks wrap in
+// the PDF, the value and VAT are amounts.
+const (
+	nameColumn  = 3
+	valueColumn = 6
+	vatColumn   = 7
+	noteColumn  = 8
+)
+
+var thaiMonths = []string{"มกราคม", "กุมภาพันธ์", "มีนาคม", "เมษายน", "พฤษภาคม", "มิถุนายน",
+	"กรกฎาคม", "สิงหาคม", "กันยายน", "ตุลาคม", "พฤศจิกายน", "ธันวาคม"}
+
+// ExportOutputTax renders an output tax report as CSV, XLSX or PDF.
+func (u *vatReportUC) ExportOutputTax(r *domain.OutputTaxReport, format string) ([]byte, error) {
+	t := &taxTable{
+		title:   "รายงานภาษีขาย",
+		sheet:   "ภาษีขาย " + r.Month,
+		heading: reportHeading(r.TaxReportHeader),
+		columns: []string{
+			"ลำดับที่",
+			"วัน เดือน ปี",
+			"เลขที่ใบกำกับภาษี",
+			"ชื่อผู้ซื้อสินค้า/ผู้รับบริการ",
+			"เลขประจำตัวผู้เสียภาษีอากรของผู้ซื้อ",
+			"สถานประกอบการ",
+			"มูลค่าสินค้าหรือบริการ",
+			"จำนวนเงินภาษีมูลค่าเพิ่ม",
+			"หมายเหตุ",
+		},
+		total: [2]string{r.Totals.Sales.String(), r.Totals.OutputTax.String()},
+		summary: []pp30Line{
+			{"1. ยอดขายในเดือนนี้", r.Totals.Sales},
+			{"2. หัก ยอดขายที่เสียภาษีในอัตราร้อยละ 0", r.Totals.ZeroRatedSales},
+			{"3. หัก ยอดขายที่ได้รับยกเว้น", r.Totals.ExemptSales},
+			{"4. ยอดขายที่ต้องเสียภาษี", r.Totals.TaxableSales},
+			{"5. ภาษีขายเดือนนี้", r.Totals.OutputTax},
+		},
+	}
+	for _, e := range r.Entries {
+		t.rows = append(t.rows, []string{
+			strconv.Itoa(e.No),
+			invoiceUC.ThaiDate(e.IssueDate),
+			e.DocumentNo,
+			e.BuyerName,
+			e.BuyerTaxID,
+			branchLabel(e.BuyerBranch),
+			e.Value.String(),
+			e.VatAmount.String(),
+			e.Note,
+		})
+	}
+	return u.export(t, format)
+}
+
+// ExportInputTax renders an input tax report as CSV, XLSX or PDF. Only
+// the claimable documents are listed.
+func (u *vatReportUC) ExportInputTax(r *domain.InputTaxReport, format string) ([]byte, error) {
+	t := &taxTable{
+		title:   "รายงานภาษีซื้อ",
+		sheet:   "ภาษีซื้อ " + r.Month,
+		heading: reportHeading(r.TaxReportHeader),
+		columns: []string{
+			"ลำดับที่",
+			"วัน เดือน ปี",
+			"เลขที่ใบกำกับภาษี",
+			"ชื่อผู้ขายสินค้า/ผู้ให้บริการ",
+			"เลขประจำตัวผู้เสียภาษีอากรของผู้ขาย",
+			"สถานประกอบการ",
+			"มูลค่าสินค้าหรือบริการ",
+			"จำนวนเงินภาษีมูลค่าเพิ่ม",
+			"หมายเหตุ",
+		},
+		total: [2]string{r.Totals.Purchases.String(), r.Totals.InputTax.String()},
+		summary: []pp30Line{
+			{"6. ยอดซื้อที่มีสิทธินำภาษีซื้อมาหักในการคำนวณภาษีเดือนนี้", r.Totals.Purchases},
+			{"7. ภาษีซื้อเดือนนี้", r.Totals.InputTax},
+		},
+	}
+	for _, e := range r.Entries {
+		t.rows = append(t.rows, []string{
+			strconv.Itoa(e.No),
+			invoiceUC.ThaiDate(e.IssueDate),
+			e.DocumentNo,
+			e.SupplierName,
+			e.SupplierTaxID,
+			branchLabel(e.SupplierBranch),
+			e.Value.String(),
+			e.VatAmount.String(),
+			e.Note,
+		})
+	}
+	return u.export(t, format)
+}
+
+func (u *vatReportUC) export(t *taxTable, format string) ([]byte, error) {
+	switch format {
+	case FormatCSV:
+		return t.csv()
+	case FormatXLSX:
+		return t.xlsx()
+	case FormatPDF:
+		if len(u.fonts.Regular) == 0 {
+			return nil, apperror.New(fiber.StatusServiceUnavailable)
+		}
+		return u.pdf(t)
+	default:
+		return nil, apperror.New(fiber.StatusBadRequest)
+	}
+}
+
+// reportHeading is the block above the table: the tax month and the
+// operator and branch the report is made for.
+func reportHeading(h domain.TaxReportHeader) [][2]string {
+	return [][2]string{
+		{"เดือนภาษี", thaiMonth(h.Month)},
+		{"ชื่อผู้ประกอบการ", h.OperatorName},
+		{"เลขประจำตัวผู้เสียภาษีอากร", h.OperatorTaxID},
+		{"ชื่อสถานประกอบการ", h.StoreName},
+		{"สถานประกอบการ", branchLabel(h.BranchNo)},
+	}
+}
+
+// totalRow returns the total row of the table.
+func (t *taxTable) totalRow() []string {
+	row := make([]string, len(t.columns))
+	row[nameColumn] = "รวม"
+	row[valueColumn], row[vatColumn] = t.total[0], t.total[1]
+	return row
+}
+
+func (t *taxTable) csv() ([]byte, error) {
+	var buf bytes.Buffer
+	// the byte order mark makes Excel read the file as UTF-8
+	buf.WriteString("\ufeff")
+	w := csv.NewWriter(&buf)
+	rows := [][]string{{t.title}}
+	for _, h := range t.heading {
+		rows = append(rows, h[:])
+	}
+	rows = append(rows, nil, t.columns)
+	rows = append(rows, t.rows...)
+	rows = append(rows, t.totalRow(), nil)
+	for _, l := range t.summary {
+		rows = append(rows, []string{l.label, l.amount.String()})
+	}
+	if err := w.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (t *taxTable) xlsx() ([]byte, error) {
+	book := xlsx.New()
+	s := book.AddSheet(t.sheet)
+	s.SetWidths(8, 12, 18, 40, 20, 16, 18, 18, 30)
+	s.AddRow(xlsx.Bold(t.title))
+	for _, h := range t.heading {
+		s.AddRow(xlsx.Bold(h[0]), xlsx.Text(h[1]))
+	}
+	s.AddRow()
+
+	header := make([]xlsx.Cell, len(t.columns))
+	for i, c := range t.columns {
+		header[i] = xlsx.Bold(c)
+	}
+	s.AddRow(header...)
+	for _, row := range t.rows {
+		cells := make([]xlsx.Cell, len(row))
+		for i, v := range row {
+			cells[i] = xlsx.Text(v)
+		}
+		if no, err := strconv.Atoi(row[0]); err == nil {
+			cells[0] = xlsx.Int(no)
+		}
+		cells[valueColumn] = xlsx.Amount(row[valueColumn])
+		cells[vatColumn] = xlsx.Amount(row[vatColumn])
+		s.AddRow(cells...)
+	}
+	total := make([]xlsx.Cell, vatColumn+1)
+	total[nameColumn] = xlsx.Bold("รวม")
+	total[valueColumn] = xlsx.Cell{Number: t.total[0], Style: xlsx.StyleBoldAmount}
+	total[vatColumn] = xlsx.Cell{Number: t.total[1], Style: xlsx.StyleBoldAmount}
+	s.AddRow(total...)
+
+	s.AddRow()
+	s.AddRow(xlsx.Bold("ภ.พ.30"))
+	for _, l := range t.summary {
+		row := make([]xlsx.Cell, valueColumn+1)
+		row[0] = xlsx.Text(l.label)
+		row[valueColumn] = xlsx.Amount(l.amount.String())
+		s.AddRow(row...)
+	}
+	return book.Bytes()
+}
+
+// Layout of the PDF report on a landscape A4 page.
+const (
+	reportMargin     = 30.0
+	reportLineHeight = 12.0
+	reportFontSize   = 8.5
+	reportFooterTop  = 540.0
+)
+
+// reportColumnWidths are the widths of the table columns; the name column
+// takes the remaining space.
+var reportColumnWidths = []float64{32, 52, 90, 0, 88, 72, 80, 75, 110}
+
+type reportPDF struct {
+	t       *taxTable
+	out     *pdf.Document
+	regular *pdf.Font
+	bold    *pdf.Font
+	page    *pdf.Page
+	pages   []*pdf.Page
+	widths  []float64
+	y       float64
+}
+
+func (u *vatReportUC) pdf(t *taxTable) ([]byte, error) {
+	out := pdf.NewLandscape()
+	regular, err := out.AddFont(u.fonts.Regular)
+	if err != nil {
+		return nil, err
+	}
+	bold := regular
+	if len(u.fonts.Bold) > 0 {
+		if bold, err = out.AddFont(u.fonts.Bold); err != nil {
+			return nil, err
+		}
+	}
+	out.SetTitle(t.title + " " + t.heading[0][1])
+
+	p := &reportPDF{t: t, out: out, regular: regular, bold: bold}
+	p.widths = append([]float64(nil), reportColumnWidths...)
+	rest := out.Width() - 2*reportMargin
+	for _, w := range p.widths {
+		rest -= w
+	}
+	p.widths[nameColumn] = rest
+
+	p.newPage()
+	for _, row := range t.rows {
+		p.row(row, p.regular)
+	}
+	p.row(t.totalRow(), p.bold)
+	p.pp30()
+	for i, page := range p.pages {
+		page.SetFont(p.regular, reportFontSize)
+		page.TextAligned(reportMargin, out.Height()-20, out.Width()-2*reportMargin,
+			fmt.Sprintf("หน้า %d / %d", i+1, len(p.pages)), pdf.AlignRight)
+	}
+	return out.Bytes()
+}
+
+// newPage starts a page with the report heading and the table header.
+func (p *reportPDF) newPage() {
+	p.page = p.out.AddPage()
+	p.pages = append(p.pages, p.page)
+	page := p.page
+
+	p.y = reportMargin + 14
+	page.SetFont(p.bold, 14)
+	page.TextAligned(reportMargin, p.y, p.out.Width()-2*reportMargin, p.t.title, pdf.AlignCenter)
+	p.y += 8
+	page.SetFont(p.regular, pdfBodySize)
+	for i, h := range p.t.heading {
+		if i%2 == 0 {
+			p.y += reportLineHeight + 2
+		}
+		x := reportMargin + float64(i%2)*(p.out.Width()/2-reportMargin)
+		page.Text(x, p.y, h[0]+": "+h[1])
+	}
+	p.y += 10
+
+	// the column titles may wrap over two lines
+	x := reportMargin
+	page.SetFont(p.bold, reportFontSize)
+	top := p.y
+	height := 2*reportLineHeight + 6
+	for i, title := range p.t.columns {
+		page.Rect(x, top, p.widths[i], height, false)
+		for j, line := range p.bold.WrapText(title, reportFontSize, p.widths[i]-6) {
+			if j == 2 {
+				break
+			}
+			page.TextAligned(x+3, top+reportLineHeight+float64(j)*reportLineHeight-1, p.widths[i]-6, line, pdf.AlignCenter)
+		}
+		x += p.widths[i]
+	}
+	p.y = top + height
+}
+
+// row draws one table row, wrapping the buyer name and remarks.
+func (p *reportPDF) row(cells []string, font *pdf.Font) {
+	lines := make([][]string, len(cells))
+	n := 1
+	for i, c := range cells {
+		lines[i] = []string{c}
+		if i == nameColumn || i == noteColumn {
+			lines[i] = font.WrapText(c, reportFontSize, p.widths[i]-6)
+		}
+		if len(lines[i]) > n {
+			n = len(lines[i])
+		}
+	}
+	height := float64(n)*reportLineHeight + 4
+	if p.y+height > reportFooterTop {
+		p.newPage()
+	}
+	page := p.page
+	page.SetFont(font, reportFontSize)
+	x := reportMargin
+	for i := range cells {
+		page.Rect(x, p.y, p.widths[i], height, false)
+		align := pdf.AlignLeft
+		switch i {
+		case 0, 1:
+			align = pdf.AlignCenter
+		case valueColumn, vatColumn:
+			align = pdf.AlignRight
+		}
+		for j, line := range lines[i] {
+			if i == valueColumn || i == vatColumn {
+				line = formatAmount(line)
+			}
+			page.TextAligned(x+3, p.y+float64(j+1)*reportLineHeight-1, p.widths[i]-6, line, align)
+		}
+		x += p.widths[i]
+	}
+	p.y += height
+}
+
+// pp30 draws the ภ.พ.30 totals under the table.
+func (p *reportPDF) pp30() {
+	lines := p.t.summary
+	if p.y+float64(len(lines)+2)*reportLineHeight > reportFooterTop {
+		p.newPage()
+	}
+	page := p.page
+	p.y += 2 * reportLineHeight
+	page.SetFont(p.bold, pdfBodySize)
+	page.Text(reportMargin, p.y, "ยอดสำหรับ ภ.พ.30")
+	page.SetFont(p.regular, pdfBodySize)
+	for _, l := range lines {
+		p.y += reportLineHeight + 2
+		page.Text(reportMargin+10, p.y, l.label)
+		page.TextAligned(reportMargin+340, p.y, 100, l.amount.Format(), pdf.AlignRight)
+	}
+}
+
+const pdfBodySize = 10.0
+
+// formatAmount adds thousands separators to an amount printed as its
+// plain decimal form.
+func formatAmount(s string) string {
+	if s == "" {
+		return s
+	}
+	a, err := money.Parse(s)
+	if err != nil {
+		return s
+	}
+	return a.Format()
+}
+
+// thaiMonth formats a YYYY-MM month as its Thai name and Buddhist era
+// year, e.g. ตุลาคม 2569.
+func thaiMonth(month string) string {
+	t, err := time.Parse("2006-01", month)
+	if err != nil {
+		return month
+	}
+	return thaiMonths[t.Month()-1] + " " + strconv.Itoa(t.Year()+543)
+}
+
+// branchLabel names a head office or branch as printed on tax reports.
+func branchLabel(branch string) string {
+	switch strings.TrimSpace(branch) {
+	case "":
+		return ""
+	case domain.HeadOffice:
+		return "สำนักงานใหญ่"
+	default:
+		return "สาขาที่ " + branch
+	}
+}
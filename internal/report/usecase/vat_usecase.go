@@ -0,0 +1,271 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	invoiceDomain "invoice_project/internal/invoice/domain"
+	invoiceRepo "invoice_project/internal/invoice/repository"
+	invoiceUC "invoice_project/internal/invoice/usecase"
+	purchaseDomain "invoice_project/internal/purchase/domain"
+	"invoice_project/internal/report/domain"
+	"invoice_project/internal/report/repository"
+	"invoice_project/pkg/apperror"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type VatReportUsecase interface {
+	OutputTax(ctx context.Context, storeID, month string) (*domain.OutputTaxReport, error)
+	InputTax(ctx context.Context, storeID, month string) (*domain.InputTaxReport, error)
+	VatReturn(ctx context.Context, storeID, month string) (*domain.VatReturn, error)
+	ExportOutputTax(r *domain.OutputTaxReport, format string) ([]byte, error)
+	ExportInputTax(r *domain.InputTaxReport, format string) ([]byte, error)
+}
+
+type vatReportUC struct {
+	repo    repository.ReportRepository
+	sellers invoiceRepo.PartyRepository
+	fonts   invoiceUC.PDFFonts
+}
+
+// NewVatReportUsecase creates the VAT report usecase. The operator printed
+// on a report is loaded from the store and its merchant through sellers;
+// fonts are needed for PDF exports.
+func NewVatReportUsecase(repo repository.ReportRepository, sellers invoiceRepo.PartyRepository, fonts invoiceUC.PDFFonts) VatReportUsecase {
+	return &vatReportUC{repo: repo, sellers: sellers, fonts: fonts}
+}
+
+// OutputTax builds the output tax report of a store for month, given as
+// YYYY-MM. Documents count in the month of their issue date.
+func (u *vatReportUC) OutputTax(ctx context.Context, storeID, month string) (*domain.OutputTaxReport, error) {
+	header, from, err := u.header(ctx, storeID, month)
+	if err != nil {
+		return nil, err
+	}
+	docs, err := u.repo.ListTaxDocuments(ctx, storeID, from, from.AddDate(0, 1, 0))
+	if err != nil {
+		return nil, err
+	}
+	var refs []uint
+	for _, d := range docs {
+		if invoiceDomain.IsAdjustmentNote(d.DocumentType) && d.ReferenceID != nil {
+			refs = append(refs, *d.ReferenceID)
+		}
+	}
+	numbers, err := u.repo.DocumentNumbers(ctx, refs)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &domain.OutputTaxReport{TaxReportHeader: *header, Entries: []domain.OutputTaxEntry{}}
+	addOutputTaxEntries(report, docs, numbers)
+	return report, nil
+}
+
+// InputTax builds the input tax report of a store for month, given as
+// YYYY-MM. Purchase documents count in the tax month they were recorded
+// for.
+func (u *vatReportUC) InputTax(ctx context.Context, storeID, month string) (*domain.InputTaxReport, error) {
+	header, _, err := u.header(ctx, storeID, month)
+	if err != nil {
+		return nil, err
+	}
+	docs, err := u.repo.ListPurchases(ctx, storeID, header.Month)
+	if err != nil {
+		return nil, err
+	}
+	report := &domain.InputTaxReport{
+		TaxReportHeader: *header,
+		Entries:         []domain.InputTaxEntry{},
+		NonClaimable:    []domain.InputTaxEntry{},
+	}
+	addInputTaxEntries(report, docs)
+	return report, nil
+}
+
+// VatReturn computes the VAT return of a store for month from its output
+// and input tax reports.
+func (u *vatReportUC) VatReturn(ctx context.Context, storeID, month string) (*domain.VatReturn, error) {
+	out, err := u.OutputTax(ctx, storeID, month)
+	if err != nil {
+		return nil, err
+	}
+	in, err := u.InputTax(ctx, storeID, month)
+	if err != nil {
+		return nil, err
+	}
+	return vatReturn(out, in), nil
+}
+
+// vatReturn nets the output tax of a month against its input tax.
+func vatReturn(out *domain.OutputTaxReport, in *domain.InputTaxReport) *domain.VatReturn {
+	r := &domain.VatReturn{
+		TaxReportHeader: out.TaxReportHeader,
+		Sales:           out.Totals,
+		Purchases:       in.Totals,
+	}
+	net := out.Totals.OutputTax.Sub(in.Totals.InputTax)
+	if net.IsNegative() {
+		r.ExcessTax = net.Neg()
+	} else {
+		r.TaxPayable = net
+	}
+	return r
+}
+
+// header loads the operator and branch of a store for a report on month,
+// given as YYYY-MM, and returns the first day of the month.
+func (u *vatReportUC) header(ctx context.Context, storeID, month string) (*domain.TaxReportHeader, time.Time, error) {
+	if _, err := uuid.Parse(storeID); err != nil {
+		return nil, time.Time{}, apperror.New(fiber.StatusBadRequest)
+	}
+	from, _, err := parseMonth(month)
+	if err != nil {
+		return nil, time.Time{}, apperror.New(fiber.StatusBadRequest)
+	}
+
+	// the operator is the store as it is now, not the snapshots on the
+	// documents
+	seller, err := u.sellers.GetSeller(ctx, storeID)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if seller == nil {
+		return nil, time.Time{}, apperror.New(fiber.StatusNotFound)
+	}
+	h := &domain.TaxReportHeader{
+		StoreID:   storeID,
+		Month:     from.Format("2006-01"),
+		StoreName: seller.Store.StoreName,
+		BranchNo:  invoiceUC.PadBranchNo(seller.Store.BranchNo),
+	}
+	switch {
+	case seller.Company != nil && seller.Merchant.MerchantType.Name != invoiceUC.PartyTypePerson:
+		h.OperatorName = seller.Company.CompanyName
+		h.OperatorTaxID = seller.Company.VatNo
+	case seller.Person != nil:
+		h.OperatorName = strings.TrimSpace(seller.Person.FirstName + " " + seller.Person.LastName)
+		if seller.Person.VatNo != nil {
+			h.OperatorTaxID = *seller.Person.VatNo
+		}
+	}
+	if h.BranchNo == "" {
+		h.BranchNo = domain.HeadOffice
+	}
+	return h, from, nil
+}
+
+// addOutputTaxEntries lists docs on the report and adds them to its
+// totals. Amounts are reported in baht at the rate frozen on each
+// document when it was issued, from the totals saved with it. Credit
+// notes reduce the sales and the output tax.
+func addOutputTaxEntries(r *domain.OutputTaxReport, docs []invoiceDomain.InvoiceDocument, numbers map[uint]string) {
+	t := &r.Totals
+	for i := range docs {
+		d := &docs[i]
+		value, vat := d.ThbGrandTotal.Sub(d.ThbVatAmount), d.ThbVatAmount
+		zeroRated := d.ExchangeRate.Convert(d.ZeroRatedAmount)
+		exempt := d.ExchangeRate.Convert(d.ExemptAmount)
+		currencyValue, currencyVat := d.GrandTotal.Sub(d.VatAmount), d.VatAmount
+		if d.DocumentType == invoiceDomain.DocumentTypeCreditNote {
+			value, vat, zeroRated, exempt = value.Neg(), vat.Neg(), zeroRated.Neg(), exempt.Neg()
+			currencyValue, currencyVat = currencyValue.Neg(), currencyVat.Neg()
+		}
+
+		e := domain.OutputTaxEntry{
+			No:                len(r.Entries) + 1,
+			DocumentID:        d.ID,
+			DocumentType:      d.DocumentType,
+			IssueDate:         d.IssueDate,
+			DocumentNo:        d.DocumentNo,
+			BuyerName:         invoiceUC.PartyName(d.BuyerType, d.BuyerCompanyName, d.BuyerFirstName, d.BuyerLastName),
+			BuyerTaxID:        d.BuyerTaxID,
+			BuyerBranch:       d.BuyerBranchNo,
+			Value:             value,
+			VatAmount:         vat,
+			Currency:          d.Currency,
+			ExchangeRate:      d.ExchangeRate,
+			CurrencyValue:     currencyValue,
+			CurrencyVatAmount: currencyVat,
+		}
+		if d.ReferenceID != nil && invoiceDomain.IsAdjustmentNote(d.DocumentType) {
+			title, _ := invoiceDomain.DocumentTitle(d.DocumentType)
+			e.Note = title + " อ้างถึง " + numbers[*d.ReferenceID]
+		}
+		if d.Currency != invoiceDomain.CurrencyTHB {
+			e.Note = strings.TrimSpace(e.Note + " " + d.Currency + " " + currencyValue.Format() + " อัตรา " + d.ExchangeRate.String())
+		}
+		r.Entries = append(r.Entries, e)
+
+		t.Sales = t.Sales.Add(value)
+		t.ZeroRatedSales = t.ZeroRatedSales.Add(zeroRated)
+		t.ExemptSales = t.ExemptSales.Add(exempt)
+		t.OutputTax = t.OutputTax.Add(vat)
+	}
+	t.TaxableSales = t.Sales.Sub(t.ZeroRatedSales).Sub(t.ExemptSales)
+}
+
+// purchaseNotes describe the purchase documents other than tax invoices
+// and the reasons input tax is not claimed in the report's remarks.
+var purchaseNotes = map[string]string{
+	purchaseDomain.PurchaseTypeReceipt:       "ใบเสร็จรับเงิน",
+	purchaseDomain.PurchaseTypeCreditNote:    "ใบลดหนี้",
+	purchaseDomain.PurchaseTypeDebitNote:     "ใบเพิ่มหนี้",
+	purchaseDomain.NonClaimableNoTaxInvoice:  "ไม่มีใบกำกับภาษี",
+	purchaseDomain.NonClaimableIncomplete:    "ใบกำกับภาษีไม่สมบูรณ์",
+	purchaseDomain.NonClaimableEntertainment: "ค่ารับรอง",
+	purchaseDomain.NonClaimablePassengerCar:  "รถยนต์นั่ง",
+	purchaseDomain.NonClaimableNotBusiness:   "ไม่เกี่ยวกับการประกอบกิจการ",
+	purchaseDomain.NonClaimableOther:         "ภาษีซื้อต้องห้าม",
+}
+
+// addInputTaxEntries lists docs on the report. Claimable documents are
+// added to the purchases and input tax, the others to the non-claimable
+// tax only. Credit notes reduce the amounts.
+func addInputTaxEntries(r *domain.InputTaxReport, docs []purchaseDomain.PurchaseDocument) {
+	t := &r.Totals
+	for _, d := range docs {
+		value, vat := d.GrandTotal.Sub(d.VatAmount), d.VatAmount
+		if d.DocumentType == purchaseDomain.PurchaseTypeCreditNote {
+			value, vat = value.Neg(), vat.Neg()
+		}
+		e := domain.InputTaxEntry{
+			PurchaseID:     d.ID,
+			DocumentType:   d.DocumentType,
+			IssueDate:      d.IssueDate,
+			DocumentNo:     d.DocumentNo,
+			SupplierName:   d.SupplierName,
+			SupplierTaxID:  d.SupplierTaxID,
+			SupplierBranch: d.SupplierBranchNo,
+			Value:          value,
+			VatAmount:      vat,
+			Note:           purchaseNotes[d.DocumentType],
+		}
+		if !d.Claimable {
+			if reason := purchaseNotes[d.NonClaimableReason]; reason != "" && reason != e.Note {
+				e.Note = strings.TrimSpace(e.Note + " " + reason)
+			}
+			e.No = len(r.NonClaimable) + 1
+			r.NonClaimable = append(r.NonClaimable, e)
+			t.NonClaimableTax = t.NonClaimableTax.Add(vat)
+			continue
+		}
+		e.No = len(r.Entries) + 1
+		r.Entries = append(r.Entries, e)
+		t.Purchases = t.Purchases.Add(value)
+		t.InputTax = t.InputTax.Add(vat)
+	}
+}
+
+// parseMonth returns the first day of month, given as YYYY-MM, and of the
+// month after.
+func parseMonth(month string) (from, to time.Time, err error) {
+	from, err = time.ParseInLocation("2006-01", month, time.Local)
+	if err != nil {
+		return from, to, err
+	}
+	return from, from.AddDate(0, 1, 0), nil
+}
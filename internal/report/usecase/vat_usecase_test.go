@@ -0,0 +1,285 @@
+package usecase
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	invoiceDomain "invoice_project/internal/invoice/domain"
+	invoiceUC "invoice_project/internal/invoice/usecase"
+	purchaseDomain "invoice_project/internal/purchase/domain"
+	"invoice_project/internal/report/domain"
+	"invoice_project/pkg/money"
+)
+
+var testHeader = domain.TaxReportHeader{
+	Month:         "2026-10",
+	OperatorName:  "Example Co., Ltd.",
+	OperatorTaxID: "0105559999999",
+	StoreName:     "สาขาลาดพร้าว",
+	BranchNo:      "00001",
+}
+
+func outputTaxFixture(t *testing.T) *domain.OutputTaxReport {
+	t.Helper()
+	ref := uint(1)
+	docs := []invoiceDomain.InvoiceDocument{
+		{
+			ID:               1,
+			DocumentType:     invoiceDomain.DocumentTypeTaxInvoice,
+			DocumentNo:       "TAX-2026-000001",
+			IssueDate:        time.Date(2026, 10, 3, 0, 0, 0, 0, time.UTC),
+			BuyerType:        invoiceUC.PartyTypeCompany,
+			BuyerCompanyName: "บริษัท ตัวอย่าง จำกัด",
+			BuyerTaxID:       "0105551234567",
+			BuyerBranchNo:    "00000",
+			VatAmount:        money.FromBaht(70),
+			GrandTotal:       money.FromBaht(1270),
+			Currency:         invoiceDomain.CurrencyTHB,
+			ExchangeRate:     money.RateScale,
+			ThbVatAmount:     money.FromBaht(70),
+			ThbGrandTotal:    money.FromBaht(1270),
+			VatableAmount:    money.FromBaht(1000),
+			ExemptAmount:     money.FromBaht(200),
+			// saved before the pricing rules of today; the report must
+			// not validate it again
+			Items: []invoiceDomain.InvoiceItem{
+				{ProductName: "A", Qty: 0, UnitPrice: money.FromBaht(1000), VatType: "vat7"},
+			},
+		},
+		{
+			ID:             2,
+			DocumentType:   invoiceDomain.DocumentTypeCreditNote,
+			DocumentNo:     "CN-2026-000001",
+			IssueDate:      time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC),
+			ReferenceID:    &ref,
+			BuyerType:      invoiceUC.PartyTypePerson,
+			BuyerFirstName: "สมชาย",
+			BuyerLastName:  "ใจดี",
+			VatAmount:      money.FromBaht(7),
+			GrandTotal:     money.FromBaht(107),
+			Currency:       invoiceDomain.CurrencyTHB,
+			ExchangeRate:   money.RateScale,
+			ThbVatAmount:   money.FromBaht(7),
+			ThbGrandTotal:  money.FromBaht(107),
+			VatableAmount:  money.FromBaht(100),
+		},
+		{
+			ID:               3,
+			DocumentType:     invoiceDomain.DocumentTypeTaxInvoice,
+			DocumentNo:       "TAX-2026-000002",
+			IssueDate:        time.Date(2026, 10, 25, 0, 0, 0, 0, time.UTC),
+			BuyerType:        invoiceUC.PartyTypeCompany,
+			BuyerCompanyName: "Export Co., Ltd.",
+			GrandTotal:       money.FromBaht(500),
+			Currency:         invoiceDomain.CurrencyTHB,
+			ExchangeRate:     money.RateScale,
+			ThbGrandTotal:    money.FromBaht(500),
+			ZeroRatedAmount:  money.FromBaht(500),
+		},
+	}
+	r := &domain.OutputTaxReport{TaxReportHeader: testHeader}
+	addOutputTaxEntries(r, docs, map[uint]string{1: "TAX-2026-000001"})
+	return r
+}
+
+func TestAddOutputTaxEntries(t *testing.T) {
+	r := outputTaxFixture(t)
+	if len(r.Entries) != 3 {
+		t.Fatalf("entries = %d, want 3", len(r.Entries))
+	}
+	cn := r.Entries[1]
+	if cn.No != 2 || cn.Value != money.FromBaht(-100) || cn.VatAmount != money.FromBaht(-7) {
+		t.Errorf("credit note entry = %+v", cn)
+	}
+	if cn.BuyerName != "สมชาย ใจดี" || !strings.Contains(cn.Note, "TAX-2026-000001") {
+		t.Errorf("credit note buyer %q note %q", cn.BuyerName, cn.Note)
+	}
+	want := domain.OutputTaxTotals{
+		Sales:          money.FromBaht(1600),
+		ZeroRatedSales: money.FromBaht(500),
+		ExemptSales:    money.FromBaht(200),
+		TaxableSales:   money.FromBaht(900),
+		OutputTax:      money.FromBaht(63),
+	}
+	if r.Totals != want {
+		t.Errorf("totals = %+v, want %+v", r.Totals, want)
+	}
+}
+
+func TestAddOutputTaxEntries_ForeignCurrency(t *testing.T) {
+	rate := money.MustParseRate("33.5")
+	doc := invoiceDomain.InvoiceDocument{
+		ID:               3,
+		DocumentType:     invoiceDomain.DocumentTypeTaxInvoice,
+		DocumentNo:       "TAX-2026-000002",
+		BuyerType:        invoiceUC.PartyTypeCompany,
+		BuyerCompanyName: "Example Inc.",
+		Currency:         "USD",
+		ExchangeRate:     rate,
+		Items: []invoiceDomain.InvoiceItem{
+			{ProductName: "A", Qty: 1, UnitPrice: money.FromBaht(100), VatType: invoiceUC.VatTypeExclude, VatRate: 7},
+			{ProductName: "B", Qty: 1, UnitPrice: money.FromBaht(20), VatType: invoiceUC.VatTypeExempt},
+		},
+	}
+	totals, err := invoiceUC.CalculateTotals(&doc, doc.Items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc.VatAmount, doc.GrandTotal = totals.VatAmount, totals.GrandTotal
+	doc.VatableAmount, doc.ExemptAmount = totals.VatableAmount, totals.ExemptAmount
+	doc.ThbVatAmount, doc.ThbGrandTotal = rate.Convert(doc.VatAmount), rate.Convert(doc.GrandTotal)
+
+	r := &domain.OutputTaxReport{TaxReportHeader: testHeader}
+	addOutputTaxEntries(r, []invoiceDomain.InvoiceDocument{doc}, nil)
+	e := r.Entries[0]
+	if e.Value != money.MustParse("4020") || e.VatAmount != money.MustParse("234.50") {
+		t.Errorf("baht amounts = %s, %s", e.Value, e.VatAmount)
+	}
+	if e.Currency != "USD" || e.CurrencyValue != money.FromBaht(120) || e.CurrencyVatAmount != money.FromBaht(7) {
+		t.Errorf("entry = %+v", e)
+	}
+	if e.Note != "USD 120.00 อัตรา 33.5" {
+		t.Errorf("note = %q", e.Note)
+	}
+	want := domain.OutputTaxTotals{
+		Sales:        money.MustParse("4020"),
+		ExemptSales:  money.MustParse("670"),
+		TaxableSales: money.MustParse("3350"),
+		OutputTax:    money.MustParse("234.50"),
+	}
+	if r.Totals != want {
+		t.Errorf("totals = %+v, want %+v", r.Totals, want)
+	}
+}
+
+func inputTaxFixture() *domain.InputTaxReport {
+	date := time.Date(2026, 10, 5, 0, 0, 0, 0, time.UTC)
+	docs := []purchaseDomain.PurchaseDocument{
+		{ID: 1, DocumentType: purchaseDomain.PurchaseTypeTaxInvoice, DocumentNo: "IV-001", IssueDate: date, SupplierName: "ผู้ขาย จำกัด", SupplierTaxID: "0105550000001", SupplierBranchNo: "00000",
+			VatAmount: money.FromBaht(56), GrandTotal: money.FromBaht(856), Claimable: true},
+		{ID: 2, DocumentType: purchaseDomain.PurchaseTypeCreditNote, DocumentNo: "CN-001", IssueDate: date, SupplierName: "ผู้ขาย จำกัด", SupplierTaxID: "0105550000001", SupplierBranchNo: "00000",
+			VatAmount: money.FromBaht(7), GrandTotal: money.FromBaht(107), Claimable: true},
+		{ID: 3, DocumentType: purchaseDomain.PurchaseTypeTaxInvoice, DocumentNo: "R-77", IssueDate: date, SupplierName: "ร้านอาหาร",
+			VatAmount: money.FromBaht(14), GrandTotal: money.FromBaht(214), NonClaimableReason: purchaseDomain.NonClaimableEntertainment},
+	}
+	r := &domain.InputTaxReport{TaxReportHeader: testHeader}
+	addInputTaxEntries(r, docs)
+	return r
+}
+
+func TestAddInputTaxEntries(t *testing.T) {
+	r := inputTaxFixture()
+	if len(r.Entries) != 2 || len(r.NonClaimable) != 1 {
+		t.Fatalf("entries %d non-claimable %d", len(r.Entries), len(r.NonClaimable))
+	}
+	if e := r.Entries[1]; e.No != 2 || e.Value != money.FromBaht(-100) || e.Note != "ใบลดหนี้" {
+		t.Errorf("credit note entry = %+v", e)
+	}
+	if e := r.NonClaimable[0]; e.No != 1 || e.Note != "ค่ารับรอง" {
+		t.Errorf("non-claimable entry = %+v", e)
+	}
+	want := domain.InputTaxTotals{
+		Purchases:       money.FromBaht(700),
+		InputTax:        money.FromBaht(49),
+		NonClaimableTax: money.FromBaht(14),
+	}
+	if r.Totals != want {
+		t.Errorf("totals = %+v, want %+v", r.Totals, want)
+	}
+}
+
+func TestVatReturn(t *testing.T) {
+	out := outputTaxFixture(t)
+	in := inputTaxFixture()
+	r := vatReturn(out, in)
+	if r.TaxPayable != money.FromBaht(14) || !r.ExcessTax.IsZero() {
+		t.Errorf("payable %s excess %s, want 14.00 and 0", r.TaxPayable, r.ExcessTax)
+	}
+
+	in.Totals.InputTax = money.FromBaht(100)
+	r = vatReturn(out, in)
+	if !r.TaxPayable.IsZero() || r.ExcessTax != money.FromBaht(37) {
+		t.Errorf("payable %s excess %s, want 0 and 37.00", r.TaxPayable, r.ExcessTax)
+	}
+}
+
+func TestParseMonth(t *testing.T) {
+	from, to, err := parseMonth("2026-12")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from.Format("2006-01-02") != "2026-12-01" || to.Format("2006-01-02") != "2027-01-01" {
+		t.Errorf("parseMonth = %s .. %s", from, to)
+	}
+	for _, bad := range []string{"", "2026-13", "2026/10", "10-2026"} {
+		if _, _, err := parseMonth(bad); err == nil {
+			t.Errorf("parseMonth(%q) accepted", bad)
+		}
+	}
+}
+
+func TestExportOutputTax(t *testing.T) {
+	r := outputTaxFixture(t)
+	u := &vatReportUC{}
+
+	b, err := u.ExportOutputTax(r, FormatCSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	csv := string(b)
+	if !strings.HasPrefix(csv, "\ufeff") {
+		t.Error("csv has no byte order mark")
+	}
+	for _, want := range []string{
+		"เดือนภาษี,ตุลาคม 2569",
+		"สถานประกอบการ,สาขาที่ 00001",
+		"1,03/10/2569,TAX-2026-000001,บริษัท ตัวอย่าง จำกัด,0105551234567,สำนักงานใหญ่,1200.00,70.00,",
+		"2,20/10/2569,CN-2026-000001,สมชาย ใจดี,,,-100.00,-7.00,",
+		"4. ยอดขายที่ต้องเสียภาษี,900.00",
+	} {
+		if !strings.Contains(csv, want) {
+			t.Errorf("csv missing %q", want)
+		}
+	}
+
+	b, err = u.ExportInputTax(inputTaxFixture(), FormatCSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"รายงานภาษีซื้อ",
+		"2,05/10/2569,CN-001,ผู้ขาย จำกัด,0105550000001,สำนักงานใหญ่,-100.00,-7.00,ใบลดหนี้",
+		"7. ภาษีซื้อเดือนนี้,49.00",
+	} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("input tax csv missing %q", want)
+		}
+	}
+	if strings.Contains(string(b), "R-77") {
+		t.Error("non-claimable document exported")
+	}
+
+	if b, err = u.ExportOutputTax(r, FormatXLSX); err != nil || len(b) == 0 {
+		t.Fatalf("xlsx: %v", err)
+	}
+	if _, err = u.ExportOutputTax(r, "doc"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+	if _, err = u.ExportOutputTax(r, FormatPDF); err == nil {
+		t.Error("expected an error for a pdf without fonts")
+	}
+
+	font, err := os.ReadFile("/usr/share/fonts/truetype/dejavu/DejaVuSans.ttf")
+	if err != nil {
+		t.Skip("no truetype font available")
+	}
+	u.fonts = invoiceUC.PDFFonts{Regular: font}
+	if b, err = u.ExportOutputTax(r, FormatPDF); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(b), "%PDF-") {
+		t.Error("not a pdf")
+	}
+}
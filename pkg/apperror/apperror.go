@@ -6,8 +6,12 @@ import "github.com/gofiber/fiber/v2"
 var statusText = map[int]string{
 	fiber.StatusBadRequest:          "BAD_REQUEST",
 	fiber.StatusUnauthorized:        "UNAUTHORIZED",
+	fiber.StatusForbidden:           "FORBIDDEN",
 	fiber.StatusNotFound:            "NOT_FOUND",
+	fiber.StatusConflict:            "CONFLICT",
+	fiber.StatusUnprocessableEntity: "UNPROCESSABLE_ENTITY",
 	fiber.StatusInternalServerError: "INTERNAL_SERVER_ERROR",
+	fiber.StatusServiceUnavailable:  "SERVICE_UNAVAILABLE",
 }
 
 // StatusMessage returns the application error message for the given status code.
@@ -0,0 +1,78 @@
+package bankstatement
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"invoice_project/pkg/money"
+)
+
+func TestParse_Bank(t *testing.T) {
+	csv := "\xef\xbb\xbfAccount No.,123-4-56789-0\n" +
+		"\n" +
+		"Date,Time,Description,Withdrawal,Deposit,Outstanding Balance,Details\n" +
+		"01/03/2026,09:15,Opening balance,,,\"10,000.00\",\n" +
+		"02/03/2026,10:01,Transfer Deposit,,\"1,070.00\",\"11,070.00\",INV-2026-000001 SOMCHAI\n" +
+		"03/03/2026,11:20,Transfer Withdrawal,250.50,,\"10,819.50\",Rent\n" +
+		",,Total,250.50,\"1,070.00\",,\n"
+	m, ok := BankMapping("KBank")
+	if !ok {
+		t.Fatal("kbank mapping missing")
+	}
+	lines, err := Parse(strings.NewReader(csv), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Line{
+		{Row: 5, Date: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), Description: "Transfer Deposit", Reference: "INV-2026-000001 SOMCHAI", Amount: money.MustParse("1070")},
+		{Row: 6, Date: time.Date(2026, 3, 3, 0, 0, 0, 0, time.UTC), Description: "Transfer Withdrawal", Reference: "Rent", Amount: money.MustParse("-250.50")},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines: %+v", len(lines), lines)
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("line %d = %+v, want %+v", i, lines[i], want[i])
+		}
+	}
+}
+
+func TestParse_GenericThai(t *testing.T) {
+	// TIS-620 encoded, Buddhist era dates, signed amounts
+	csv := "\xc7\xd1\xb9\xb7\xd5\xe8,\xca\xd8\xb7\xb8\xd4,\xc3\xd2\xc2\xa1\xd2\xc3\n" +
+		"29-02-2567,(500.00),\xa4\xe8\xd2\xe0\xaa\xe8\xd2\n" +
+		"01-03-2567,\"2,000\",\xc3\xd1\xba\xe2\xcd\xb9\n"
+	m := Mapping{Date: "วันที่", Amount: "สุทธิ", Description: "รายการ", DateFormat: "02-01-2006"}
+	lines, err := Parse(strings.NewReader(csv), m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 2 {
+		t.Fatalf("got %+v", lines)
+	}
+	if !lines[0].Date.Equal(time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)) || lines[0].Amount != money.MustParse("-500") || lines[0].Description != "ค่าเช่า" {
+		t.Errorf("line 0 = %+v", lines[0])
+	}
+	if lines[1].Amount != money.MustParse("2000") || lines[1].Description != "รับโอน" {
+		t.Errorf("line 1 = %+v", lines[1])
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	if _, err := Parse(strings.NewReader("a,b\n1,2\n"), Mapping{Date: "Date", Credit: "Credit"}); !errors.Is(err, ErrNoHeader) {
+		t.Errorf("no header: got %v", err)
+	}
+	if _, err := Parse(strings.NewReader(""), Mapping{Date: "Date"}); !errors.Is(err, ErrInvalidMapping) {
+		t.Errorf("no amount column: got %v", err)
+	}
+	_, err := Parse(strings.NewReader("Date,Amount\n2026-03-01,10\n"), Mapping{Date: "Date", Amount: "Amount"})
+	if err == nil || !strings.Contains(err.Error(), "row 2") {
+		t.Errorf("bad date: got %v", err)
+	}
+	_, err = Parse(strings.NewReader("Date,Amount\n01/03/2026,ten\n"), Mapping{Date: "Date", Amount: "Amount"})
+	if err == nil || !strings.Contains(err.Error(), "row 2") {
+		t.Errorf("bad amount: got %v", err)
+	}
+}
@@ -0,0 +1,218 @@
+// Package botrate reads the daily foreign exchange rates published by the
+// Bank of Thailand, as downloaded in CSV from its statistics site or
+// exported from its API.
+package botrate
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"invoice_project/pkg/money"
+)
+
+// ErrNoHeader is returned when no row of the file holds a date, currency
+// and rate column.
+var ErrNoHeader = errors.New("botrate: header row not found")
+
+// ErrUnknownColumn is returned for a rate column that is not one of the
+// Column* names.
+var ErrUnknownColumn = errors.New("botrate: unknown rate column")
+
+// Rate columns the Bank of Thailand publishes for each currency, in baht.
+const (
+	ColumnBuyingSight    = "buying_sight"
+	ColumnBuyingTransfer = "buying_transfer"
+	ColumnSelling        = "selling"
+	ColumnMidRate        = "mid_rate"
+)
+
+// DefaultColumn is the buying transfer rate, the rate commonly used to
+// convert export sales.
+const DefaultColumn = ColumnBuyingTransfer
+
+// Rate is the baht value of one unit of Currency on Date. Rates quoted
+// per 100 or more units, like the yen, are divided down to one unit.
+type Rate struct {
+	Row      int
+	Date     time.Time
+	Currency string
+	Rate     money.Rate
+}
+
+// headers maps the normalized header names used by the CSV downloads and
+// the API exports to their column.
+var headers = map[string]string{
+	"period":             "date",
+	"date":               "date",
+	"currencyid":         "currency",
+	"currency":           "currency",
+	"currencynameeng":    "name",
+	"currencyname":       "name",
+	"buyingsight":        ColumnBuyingSight,
+	"buyingtransfer":     ColumnBuyingTransfer,
+	"buyingtransferrate": ColumnBuyingTransfer,
+	"selling":            ColumnSelling,
+	"sellingrate":        ColumnSelling,
+	"midrate":            ColumnMidRate,
+	"averagerate":        ColumnMidRate,
+}
+
+// Parse reads the rates of column from a CSV file, DefaultColumn when
+// column is empty. Rows before the header are skipped, as are rows
+// without a published rate for the column.
+func Parse(r io.Reader, column string) ([]Rate, error) {
+	if column == "" {
+		column = DefaultColumn
+	}
+	switch column {
+	case ColumnBuyingSight, ColumnBuyingTransfer, ColumnSelling, ColumnMidRate:
+	default:
+		return nil, ErrUnknownColumn
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	cr := csv.NewReader(bytes.NewReader(data))
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	var cols map[string]int
+	var rates []Rate
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("botrate: %w", err)
+		}
+		row, _ := cr.FieldPos(0)
+		if cols == nil {
+			cols = header(rec, column)
+			continue
+		}
+		dateCell, currency, value := cell(rec, cols["date"]), cell(rec, cols["currency"]), cell(rec, cols[column])
+		if dateCell == "" || currency == "" || !published(value) {
+			continue
+		}
+		date, err := parseDate(dateCell)
+		if err != nil {
+			return nil, fmt.Errorf("botrate: row %d: invalid date %q", row, dateCell)
+		}
+		rate, err := money.ParseRate(value)
+		if err != nil || rate <= 0 {
+			return nil, fmt.Errorf("botrate: row %d: invalid rate %q", row, value)
+		}
+		code, units := currencyOf(currency, cell(rec, cols["name"]))
+		if code == "" {
+			return nil, fmt.Errorf("botrate: row %d: invalid currency %q", row, currency)
+		}
+		rates = append(rates, Rate{Row: row, Date: date, Currency: code, Rate: rate.Per(units)})
+	}
+	if cols == nil {
+		return nil, ErrNoHeader
+	}
+	return rates, nil
+}
+
+// header returns the index of the date, currency, name and rate columns
+// of rec, or nil when rec is not the header row.
+func header(rec []string, column string) map[string]int {
+	cols := map[string]int{"name": -1}
+	for i, h := range rec {
+		if key, ok := headers[normalizeHeader(h)]; ok {
+			if _, seen := cols[key]; !seen || key == "name" {
+				cols[key] = i
+			}
+		}
+	}
+	for _, key := range []string{"date", "currency", column} {
+		if _, ok := cols[key]; !ok {
+			return nil
+		}
+	}
+	return cols
+}
+
+func normalizeHeader(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if r >= 'a' && r <= 'z' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func cell(rec []string, i int) string {
+	if i < 0 || i >= len(rec) {
+		return ""
+	}
+	return strings.TrimSpace(rec[i])
+}
+
+// published reports whether a rate cell holds a rate; the Bank of
+// Thailand leaves it empty or prints a dash or n/a when none was quoted.
+func published(s string) bool {
+	switch strings.ToLower(s) {
+	case "", "-", "n/a", "na":
+		return false
+	}
+	return true
+}
+
+var (
+	currencyCode = regexp.MustCompile(`\b[A-Z]{3}\b`)
+	quoteUnits   = regexp.MustCompile(`\(\s*([\d,]+)`)
+)
+
+// currencyOf returns the ISO code in the currency cell and the number of
+// units the rate is quoted for, read from the currency or its name, e.g.
+// "JAPAN : YEN (100 JPY)". The code is the last one in the cell, which
+// may start with the country.
+func currencyOf(currency, name string) (string, int64) {
+	code := ""
+	if codes := currencyCode.FindAllString(strings.ToUpper(currency), -1); len(codes) > 0 {
+		code = codes[len(codes)-1]
+	}
+	units := int64(1)
+	for _, s := range []string{currency, name} {
+		if m := quoteUnits.FindStringSubmatch(s); m != nil {
+			if n, err := strconv.ParseInt(strings.ReplaceAll(m[1], ",", ""), 10, 64); err == nil && n > 0 {
+				units = n
+				break
+			}
+		}
+	}
+	return code, units
+}
+
+// dateLayouts are the date formats of the downloads: ISO dates from the
+// API and day first dates, in either era, from the website.
+var dateLayouts = []string{"2006-01-02", "02/01/2006", "2/1/2006", "02 Jan 2006", "2 Jan 2006"}
+
+var buddhistYear = regexp.MustCompile(`\b2[4-9]\d\d\b`)
+
+func parseDate(s string) (time.Time, error) {
+	s = buddhistYear.ReplaceAllStringFunc(s, func(y string) string {
+		n, _ := strconv.Atoi(y)
+		return strconv.Itoa(n - 543)
+	})
+	var err error
+	for _, layout := range dateLayouts {
+		var t time.Time
+		if t, err = time.Parse(layout, s); err == nil {
+			return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), nil
+		}
+	}
+	return time.Time{}, err
+}
@@ -0,0 +1,69 @@
+package botrate
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"invoice_project/pkg/money"
+)
+
+func TestParse_API(t *testing.T) {
+	csv := "\xef\xbb\xbfperiod,currency_id,currency_name_th,currency_name_eng,buying_sight,buying_transfer,selling,mid_rate\n" +
+		"2026-10-16,USD,ดอลลาร์สหรัฐ,USA : DOLLAR (USD),33.1000000,33.2012345,33.5500000,33.3700000\n" +
+		"2026-10-16,JPY,เยน,JAPAN : YEN (100 JPY),22.1000000,22.3456780,22.9000000,22.6000000\n" +
+		"2026-10-16,MMK,จ๊าด,MYANMAR : KYAT (MMK),,,,\n"
+	rates, err := Parse(strings.NewReader(csv), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	day := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+	want := []Rate{
+		{Row: 2, Date: day, Currency: "USD", Rate: money.MustParseRate("33.201235")},
+		{Row: 3, Date: day, Currency: "JPY", Rate: money.MustParseRate("0.223457")},
+	}
+	if len(rates) != len(want) {
+		t.Fatalf("got %+v", rates)
+	}
+	for i := range want {
+		if rates[i] != want[i] {
+			t.Errorf("rate %d = %+v, want %+v", i, rates[i], want[i])
+		}
+	}
+}
+
+func TestParse_Website(t *testing.T) {
+	csv := "Rates of Exchange of Commercial Banks in Bangkok Metropolis\n" +
+		"\n" +
+		"Date,Currency,Buying Sight,Buying Transfer,Selling,Mid Rate\n" +
+		"15/10/2569,EURO ZONE : EURO (EUR),38.10,38.20,39.00,38.60\n" +
+		"15/10/2569,UK : POUND STERLING (GBP),n/a,-,44.50,\n"
+	rates, err := Parse(strings.NewReader(csv), ColumnSelling)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rates) != 2 {
+		t.Fatalf("got %+v", rates)
+	}
+	if rates[0].Currency != "EUR" || rates[0].Rate != money.MustParseRate("39") ||
+		!rates[0].Date.Equal(time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("rate 0 = %+v", rates[0])
+	}
+	if rates[1].Currency != "GBP" || rates[1].Row != 5 {
+		t.Errorf("rate 1 = %+v", rates[1])
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	if _, err := Parse(strings.NewReader("a,b\n1,2\n"), ""); !errors.Is(err, ErrNoHeader) {
+		t.Errorf("err = %v, want ErrNoHeader", err)
+	}
+	if _, err := Parse(strings.NewReader(""), "spot"); !errors.Is(err, ErrUnknownColumn) {
+		t.Errorf("err = %v, want ErrUnknownColumn", err)
+	}
+	csv := "period,currency_id,buying_transfer\n2026-13-01,USD,33\n"
+	if _, err := Parse(strings.NewReader(csv), ""); err == nil || !strings.Contains(err.Error(), "row 2") {
+		t.Errorf("err = %v, want invalid date on row 2", err)
+	}
+}
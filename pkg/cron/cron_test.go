@@ -0,0 +1,57 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	bkk := time.FixedZone("ICT", 7*3600)
+	at := func(y int, m time.Month, d, h, min int) time.Time {
+		return time.Date(y, m, d, h, min, 0, 0, bkk)
+	}
+	cases := []struct {
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{"0 9 1 * *", at(2026, 10, 18, 12, 0), at(2026, 11, 1, 9, 0)},
+		{"0 9 1 * *", at(2026, 11, 1, 8, 59), at(2026, 11, 1, 9, 0)},
+		{"0 9 1 * *", at(2026, 11, 1, 9, 0), at(2026, 12, 1, 9, 0)},
+		{"*/15 * * * *", at(2026, 10, 18, 12, 7), at(2026, 10, 18, 12, 15)},
+		{"0 0 31 * *", at(2026, 11, 1, 0, 0), at(2026, 12, 31, 0, 0)},
+		{"0 8 * * 1-5", at(2026, 10, 17, 9, 0), at(2026, 10, 19, 8, 0)}, // Saturday -> Monday
+		{"0 0 1 1,7 *", at(2026, 2, 1, 0, 0), at(2026, 7, 1, 0, 0)},
+		{"0 0 29 2 *", at(2026, 3, 1, 0, 0), at(2028, 2, 29, 0, 0)},
+		{"30 6 * * 7", at(2026, 10, 18, 7, 0), at(2026, 10, 25, 6, 30)},
+	}
+	for _, tc := range cases {
+		s, err := Parse(tc.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tc.expr, err)
+		}
+		if got := s.Next(tc.from); !got.Equal(tc.want) {
+			t.Errorf("%q after %v = %v, want %v", tc.expr, tc.from, got, tc.want)
+		}
+	}
+}
+
+func TestNext_DayOfMonthOrWeek(t *testing.T) {
+	// both day fields restricted: the 15th or any Monday
+	s, err := Parse("0 0 15 * 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := time.Date(2026, 10, 10, 0, 0, 0, 0, time.UTC)
+	if got := s.Next(from); got.Day() != 12 {
+		t.Errorf("expected Monday the 12th, got %v", got)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q): expected error", expr)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+// Package etax builds Thai e-Tax Invoice & e-Receipt XML messages in the
+// structure of the ETDA CrossIndustryInvoice standard (ขมธอ. 3-2560) and
+// checks them against the bundled schemas. Those schemas cover only the
+// elements this package writes and are not the official ETDA XSDs, so
+// passing them does not prove a message conforms to the standard. Moving
+// to the official XSDs is still to be done; see schema/README.md.
+package etax
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"time"
+
+	"invoice_project/pkg/money"
+)
+
+// Document type codes defined by ETDA.
+const (
+	TypeReceipt            = "T01" // ใบเสร็จรับเงิน
+	TypeInvoiceTaxInvoice  = "T02" // ใบแจ้งหนี้/ใบกำกับภาษี
+	TypeReceiptTaxInvoice  = "T03" // ใบเสร็จรับเงิน/ใบกำกับภาษี
+	TypeDeliveryTaxInvoice = "T04" // ใบส่งของ/ใบกำกับภาษี
+	TypeDebitNote          = "80"  // ใบเพิ่มหนี้
+	TypeCreditNote         = "81"  // ใบลดหนี้
+	TypeTaxInvoice         = "388" // ใบกำกับภาษี
+)
+
+// Tax registration schemes of a party.
+const (
+	TaxSchemeTaxID      = "TXID" // 13 digit tax ID followed by a 5 digit branch
+	TaxSchemeNationalID = "NIDN"
+	TaxSchemePassport   = "CCPT"
+	TaxSchemeOther      = "OTHR"
+)
+
+// Tax type codes of a VAT group.
+const (
+	TaxTypeVAT    = "VAT"
+	TaxTypeExempt = "FRE"
+)
+
+// Purpose codes for notes and replacement tax invoices whose reason is not
+// one of the listed ones.
+const (
+	PurposeCreditNoteOther  = "CDNG99"
+	PurposeDebitNoteOther   = "DBNG99"
+	PurposeReplacementOther = "TIVC99"
+)
+
+const (
+	namespacePrefix  = "urn:etda:uncefact:data:standard:"
+	guidelineID      = "ER3-2560"
+	guidelineAgency  = "ETDA"
+	guidelineVersion = "v2.0"
+	defaultCurrency  = "THB"
+	currencyListID   = "ISO 4217 3A"
+	defaultCountry   = "TH"
+	countrySchemeID  = "3166-1 alpha-2"
+	defaultUnitCode  = "EA"
+)
+
+// ErrUnknownTypeCode is returned for a document type code outside the
+// ETDA code list.
+var ErrUnknownTypeCode = errors.New("etax: unknown document type code")
+
+// families maps each type code to the message family whose root element
+// and namespaces it uses.
+var families = map[string]string{
+	TypeTaxInvoice:         "TaxInvoice",
+	TypeInvoiceTaxInvoice:  "TaxInvoice",
+	TypeReceiptTaxInvoice:  "TaxInvoice",
+	TypeDeliveryTaxInvoice: "TaxInvoice",
+	TypeReceipt:            "Receipt",
+	TypeDebitNote:          "DebitCreditNote",
+	TypeCreditNote:         "DebitCreditNote",
+}
+
+// Family returns the message family ("TaxInvoice", "Receipt" or
+// "DebitCreditNote") of a document type code.
+func Family(typeCode string) (string, error) {
+	f, ok := families[typeCode]
+	if !ok {
+		return "", ErrUnknownTypeCode
+	}
+	return f, nil
+}
+
+// Invoice is an e-Tax message. Marshal sets the root element and namespaces
+// from Document.TypeCode.
+type Invoice struct {
+	XMLName     xml.Name
+	NSRsm       string            `xml:"xmlns:rsm,attr"`
+	NSRam       string            `xml:"xmlns:ram,attr"`
+	Context     DocumentContext   `xml:"rsm:ExchangedDocumentContext"`
+	Document    ExchangedDocument `xml:"rsm:ExchangedDocument"`
+	Transaction Transaction       `xml:"rsm:SupplyChainTradeTransaction"`
+}
+
+type DocumentContext struct {
+	GuidelineID ID `xml:"ram:GuidelineSpecifiedDocumentContextParameter>ram:ID"`
+}
+
+// ID is an identifier with optional scheme attributes.
+type ID struct {
+	Value           string `xml:",chardata"`
+	SchemeID        string `xml:"schemeID,attr,omitempty"`
+	SchemeAgencyID  string `xml:"schemeAgencyID,attr,omitempty"`
+	SchemeVersionID string `xml:"schemeVersionID,attr,omitempty"`
+}
+
+type ExchangedDocument struct {
+	ID               ID       `xml:"ram:ID"`
+	Name             string   `xml:"ram:Name"`
+	TypeCode         string   `xml:"ram:TypeCode"`
+	IssueDateTime    DateTime `xml:"ram:IssueDateTime"`
+	Purpose          string   `xml:"ram:Purpose,omitempty"`
+	PurposeCode      string   `xml:"ram:PurposeCode,omitempty"`
+	CreationDateTime DateTime `xml:"ram:CreationDateTime"`
+	Notes            []Note   `xml:"ram:IncludedNote,omitempty"`
+}
+
+type Note struct {
+	Subject string `xml:"ram:Subject,omitempty"`
+	Content string `xml:"ram:Content,omitempty"`
+}
+
+// DateTime is rendered as an xs:dateTime without fractional seconds.
+type DateTime time.Time
+
+func (d DateTime) MarshalText() ([]byte, error) {
+	return []byte(time.Time(d).Format("2006-01-02T15:04:05")), nil
+}
+
+type Transaction struct {
+	Agreement  HeaderAgreement  `xml:"ram:ApplicableHeaderTradeAgreement"`
+	Delivery   struct{}         `xml:"ram:ApplicableHeaderTradeDelivery"`
+	Settlement HeaderSettlement `xml:"ram:ApplicableHeaderTradeSettlement"`
+	Lines      []LineItem       `xml:"ram:IncludedSupplyChainTradeLineItem"`
+}
+
+type HeaderAgreement struct {
+	Seller     Party                `xml:"ram:SellerTradeParty"`
+	Buyer      Party                `xml:"ram:BuyerTradeParty"`
+	References []ReferencedDocument `xml:"ram:AdditionalReferencedDocument,omitempty"`
+}
+
+// Party is a seller or buyer. TaxID carries the scheme (TXID, NIDN, CCPT
+// or OTHR) in SchemeID.
+type Party struct {
+	Name    string   `xml:"ram:Name"`
+	TaxID   *ID      `xml:"ram:SpecifiedTaxRegistration>ram:ID,omitempty"`
+	Address *Address `xml:"ram:PostalTradeAddress,omitempty"`
+}
+
+type Address struct {
+	Postcode  string `xml:"ram:PostcodeCode,omitempty"`
+	LineOne   string `xml:"ram:LineOne,omitempty"`
+	LineTwo   string `xml:"ram:LineTwo,omitempty"`
+	CountryID ID     `xml:"ram:CountryID"`
+}
+
+type ReferencedDocument struct {
+	IssuerAssignedID  string    `xml:"ram:IssuerAssignedID"`
+	IssueDateTime     *DateTime `xml:"ram:IssueDateTime,omitempty"`
+	ReferenceTypeCode string    `xml:"ram:ReferenceTypeCode"`
+}
+
+type HeaderSettlement struct {
+	Currency   Currency              `xml:"ram:InvoiceCurrencyCode"`
+	Taxes      []TradeTax            `xml:"ram:ApplicableTradeTax"`
+	Allowances []AllowanceCharge     `xml:"ram:SpecifiedTradeAllowanceCharge,omitempty"`
+	Summation  HeaderMonetarySummary `xml:"ram:SpecifiedTradeSettlementHeaderMonetarySummation"`
+}
+
+type Currency struct {
+	Code   string `xml:",chardata"`
+	ListID string `xml:"listID,attr,omitempty"`
+}
+
+// TradeTax is one VAT group: TypeCode is VAT or FRE (exempt).
+type TradeTax struct {
+	TypeCode         string        `xml:"ram:TypeCode"`
+	CalculatedRate   string        `xml:"ram:CalculatedRate,omitempty"`
+	BasisAmount      *money.Amount `xml:"ram:BasisAmount,omitempty"`
+	CalculatedAmount *money.Amount `xml:"ram:CalculatedAmount,omitempty"`
+}
+
+// AllowanceCharge is a discount (ChargeIndicator false) or a charge.
+type AllowanceCharge struct {
+	ChargeIndicator bool         `xml:"ram:ChargeIndicator"`
+	ActualAmount    money.Amount `xml:"ram:ActualAmount"`
+	Reason          string       `xml:"ram:Reason,omitempty"`
+}
+
+type HeaderMonetarySummary struct {
+	OriginalInformationAmount   *money.Amount `xml:"ram:OriginalInformationAmount,omitempty"`
+	LineTotalAmount             money.Amount  `xml:"ram:LineTotalAmount"`
+	DifferenceInformationAmount *money.Amount `xml:"ram:DifferenceInformationAmount,omitempty"`
+	AllowanceTotalAmount        money.Amount  `xml:"ram:AllowanceTotalAmount"`
+	TaxBasisTotalAmount         money.Amount  `xml:"ram:TaxBasisTotalAmount"`
+	TaxTotalAmount              money.Amount  `xml:"ram:TaxTotalAmount"`
+	GrandTotalAmount            money.Amount  `xml:"ram:GrandTotalAmount"`
+}
+
+type LineItem struct {
+	LineID       string            `xml:"ram:AssociatedDocumentLineDocument>ram:LineID"`
+	Product      Product           `xml:"ram:SpecifiedTradeProduct"`
+	GrossPrice   money.Amount      `xml:"ram:SpecifiedLineTradeAgreement>ram:GrossPriceProductTradePrice>ram:ChargeAmount"`
+	Quantity     Quantity          `xml:"ram:SpecifiedLineTradeDelivery>ram:BilledQuantity"`
+	Tax          []TradeTax        `xml:"ram:SpecifiedLineTradeSettlement>ram:ApplicableTradeTax,omitempty"`
+	Allowances   []AllowanceCharge `xml:"ram:SpecifiedLineTradeSettlement>ram:SpecifiedTradeAllowanceCharge,omitempty"`
+	NetLineTotal money.Amount      `xml:"ram:SpecifiedLineTradeSettlement>ram:SpecifiedTradeSettlementLineMonetarySummation>ram:NetLineTotalAmount"`
+}
+
+type Product struct {
+	ID   *ID    `xml:"ram:ID,omitempty"`
+	Name string `xml:"ram:Name"`
+}
+
+type Quantity struct {
+	Value    int    `xml:",chardata"`
+	UnitCode string `xml:"unitCode,attr,omitempty"`
+}
+
+// NewInvoice returns a message of the given type with the ETDA guideline,
+// currency and creation time filled in.
+func NewInvoice(typeCode string) (*Invoice, error) {
+	if _, err := Family(typeCode); err != nil {
+		return nil, err
+	}
+	inv := &Invoice{}
+	inv.Context.GuidelineID = ID{Value: guidelineID, SchemeAgencyID: guidelineAgency, SchemeVersionID: guidelineVersion}
+	inv.Document.TypeCode = typeCode
+	inv.Document.CreationDateTime = DateTime(time.Now())
+	inv.Transaction.Settlement.Currency = Currency{Code: defaultCurrency, ListID: currencyListID}
+	return inv, nil
+}
+
+// NewAddress returns a Thai postal address.
+func NewAddress(postcode, lineOne, lineTwo string) *Address {
+	return &Address{
+		Postcode:  postcode,
+		LineOne:   lineOne,
+		LineTwo:   lineTwo,
+		CountryID: ID{Value: defaultCountry, SchemeID: countrySchemeID},
+	}
+}
+
+// NewQuantity returns a billed quantity in pieces.
+func NewQuantity(n int) Quantity { return Quantity{Value: n, UnitCode: defaultUnitCode} }
+
+// Marshal renders inv as XML. The root element and namespaces follow the
+// family of inv.Document.TypeCode.
+func Marshal(inv *Invoice) ([]byte, error) {
+	family, err := Family(inv.Document.TypeCode)
+	if err != nil {
+		return nil, err
+	}
+	inv.XMLName = xml.Name{Local: "rsm:" + family + "_CrossIndustryInvoice"}
+	inv.NSRsm = namespacePrefix + family + "_CrossIndustryInvoice:2"
+	inv.NSRam = namespacePrefix + family + "_ReusableAggregateBusinessInformationEntity:2"
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(inv); err != nil {
+		return nil, fmt.Errorf("etax: %w", err)
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,93 @@
+package etax
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"invoice_project/pkg/money"
+	"invoice_project/pkg/xsd"
+)
+
+func sampleInvoice(t *testing.T, typeCode string) *Invoice {
+	t.Helper()
+	inv, err := NewInvoice(typeCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inv.Document.ID = ID{Value: "TIV-2026-000001"}
+	inv.Document.Name = "ใบกำกับภาษี"
+	inv.Document.IssueDateTime = DateTime(time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC))
+	inv.Transaction.Agreement.Seller = Party{
+		Name:    "บริษัท ตัวอย่าง จำกัด",
+		TaxID:   &ID{Value: "010555500000100000", SchemeID: TaxSchemeTaxID},
+		Address: NewAddress("10110", "1 ถนนสุขุมวิท กรุงเทพฯ", ""),
+	}
+	inv.Transaction.Agreement.Buyer = Party{
+		Name:  "สมชาย ใจดี",
+		TaxID: &ID{Value: "N/A", SchemeID: TaxSchemeOther},
+	}
+	vat := money.MustParse("7.00")
+	basis := money.MustParse("100.00")
+	inv.Transaction.Settlement.Taxes = []TradeTax{{TypeCode: TaxTypeVAT, CalculatedRate: "7.00", BasisAmount: &basis, CalculatedAmount: &vat}}
+	inv.Transaction.Settlement.Summation = HeaderMonetarySummary{
+		LineTotalAmount:     basis,
+		TaxBasisTotalAmount: basis,
+		TaxTotalAmount:      vat,
+		GrandTotalAmount:    money.MustParse("107.00"),
+	}
+	inv.Transaction.Lines = []LineItem{{
+		LineID:       "1",
+		Product:      Product{Name: "สินค้า"},
+		GrossPrice:   basis,
+		Quantity:     NewQuantity(1),
+		NetLineTotal: basis,
+	}}
+	return inv
+}
+
+func TestMarshalValidates(t *testing.T) {
+	for _, code := range []string{TypeTaxInvoice, TypeInvoiceTaxInvoice, TypeReceipt, TypeCreditNote} {
+		inv := sampleInvoice(t, code)
+		if code == TypeCreditNote {
+			inv.Document.Purpose = "สินค้าชำรุด"
+			inv.Document.PurposeCode = PurposeCreditNoteOther
+			inv.Transaction.Agreement.References = []ReferencedDocument{{IssuerAssignedID: "TIV-2026-000001", ReferenceTypeCode: TypeTaxInvoice}}
+		}
+		b, err := Marshal(inv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := Validate(code, b); err != nil {
+			t.Fatalf("%s: %v\n%s", code, err, b)
+		}
+		family, _ := Family(code)
+		if !strings.Contains(string(b), "<rsm:"+family+"_CrossIndustryInvoice") {
+			t.Fatalf("%s: unexpected root element\n%s", code, b)
+		}
+	}
+}
+
+func TestValidateRejects(t *testing.T) {
+	inv := sampleInvoice(t, TypeTaxInvoice)
+	inv.Transaction.Agreement.Seller.Name = ""
+	b, err := Marshal(inv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var verr *xsd.ValidationError
+	if err := Validate(TypeTaxInvoice, b); !errors.As(err, &verr) {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+
+	// a credit note code in a tax invoice message is not allowed
+	b = []byte(strings.Replace(string(b), "<ram:TypeCode>388<", "<ram:TypeCode>81<", 1))
+	if err := Validate(TypeTaxInvoice, b); !errors.As(err, &verr) {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+
+	if _, err := NewInvoice("999"); !errors.Is(err, ErrUnknownTypeCode) {
+		t.Fatalf("expected ErrUnknownTypeCode, got %v", err)
+	}
+}
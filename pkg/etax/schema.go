@@ -0,0 +1,46 @@
+package etax
+
+import (
+	"bytes"
+	"embed"
+	"sync"
+
+	"invoice_project/pkg/xsd"
+)
+
+//go:embed schema/*.xsd
+var schemaFS embed.FS
+
+var (
+	schemaOnce sync.Once
+	schemas    map[string]*xsd.Schema
+	schemaErr  error
+)
+
+func loadSchemas() {
+	schemas = make(map[string]*xsd.Schema)
+	for _, family := range []string{"TaxInvoice", "Receipt", "DebitCreditNote"} {
+		s, err := xsd.Load(schemaFS, "schema/"+family+"_CrossIndustryInvoice_2p0.xsd")
+		if err != nil {
+			schemaErr = err
+			return
+		}
+		schemas[family] = s
+	}
+}
+
+// Validate checks an e-Tax message produced by Marshal against the bundled
+// schema of its document family, catching missing or malformed elements.
+// It is not an ETDA conformance check. Problems are reported as
+// *xsd.ValidationError.
+func Validate(typeCode string, data []byte) error {
+	family, err := Family(typeCode)
+	if err != nil {
+		return err
+	}
+	schemaOnce.Do(loadSchemas)
+	if schemaErr != nil {
+		return schemaErr
+	}
+	return schemas[family].Validate(bytes.NewReader(data))
+}
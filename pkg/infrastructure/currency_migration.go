@@ -0,0 +1,23 @@
+package infrastructure
+
+import (
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// BackfillDocumentCurrency gives documents saved before they had a
+// currency their baht totals. They are all in baht, so they convert at 1.
+// It runs after AutoMigrate has added the columns and only touches rows
+// without a rate, so it is safe to run on every start.
+func BackfillDocumentCurrency(db *gorm.DB) {
+	res := db.Exec(`UPDATE invoice_documents
+		SET exchange_rate = 1, thb_grand_total = grand_total, thb_vat_amount = vat_amount
+		WHERE currency = 'THB' AND exchange_rate = 0`)
+	if res.Error != nil {
+		log.Fatalf("backfill document currency failed: %v", res.Error)
+	}
+	if res.RowsAffected > 0 {
+		log.Printf("converted %d documents to baht totals", res.RowsAffected)
+	}
+}
@@ -48,6 +48,27 @@ type AppConfig struct {
 		Password  string `yaml:"password"`
 		FromEmail string `yaml:"from_email"`
 	} `yaml:"smtp"`
+	Invoice struct {
+		// PricingMode is "strict" (reject wrong client totals) or
+		// "lenient" (replace them with the computed values).
+		PricingMode string `yaml:"pricing_mode"`
+		// PublicURL is the address buyers reach the API at, used to
+		// build share links; links are relative when it is empty.
+		PublicURL string `yaml:"public_url"`
+		// ShareSecret signs document share links. It is kept apart from
+		// the JWT secret so either can be rotated on its own.
+		ShareSecret string `yaml:"share_secret"`
+	} `yaml:"invoice"`
+	PDF struct {
+		// TrueType fonts with Thai glyphs overriding the bundled Sarabun
+		FontRegular string `yaml:"font_regular"`
+		FontBold    string `yaml:"font_bold"`
+	} `yaml:"pdf"`
+	Signing struct {
+		// SecretsFile is a YAML file with the certificate encryption key and
+		// the passphrases unlocking merchant certificates
+		SecretsFile string `yaml:"secrets_file"`
+	} `yaml:"signing"`
 	Server ServerConfig `yaml:"server"`
 }
 
@@ -126,12 +147,36 @@ func LoadConfig(path string) (*AppConfig, error) {
 	if env := os.Getenv("SMTP_FROM_EMAIL"); env != "" {
 		cfg.SMTP.FromEmail = env
 	}
+	if env := os.Getenv("INVOICE_PRICING_MODE"); env != "" {
+		cfg.Invoice.PricingMode = env
+	}
+	if env := os.Getenv("INVOICE_PUBLIC_URL"); env != "" {
+		cfg.Invoice.PublicURL = env
+	}
+	if env := os.Getenv("INVOICE_SHARE_SECRET"); env != "" {
+		cfg.Invoice.ShareSecret = env
+	}
+	if env := os.Getenv("PDF_FONT_REGULAR"); env != "" {
+		cfg.PDF.FontRegular = env
+	}
+	if env := os.Getenv("PDF_FONT_BOLD"); env != "" {
+		cfg.PDF.FontBold = env
+	}
+	if env := os.Getenv("SIGNING_SECRETS_FILE"); env != "" {
+		cfg.Signing.SecretsFile = env
+	}
 	// If JWTSecret points to a file, read its contents
 	if cfg.Auth.JWTSecret != "" {
 		if b, err := os.ReadFile(cfg.Auth.JWTSecret); err == nil {
 			cfg.Auth.JWTSecret = strings.TrimSpace(string(b))
 		}
 	}
+	// The share secret may point to a file the same way
+	if cfg.Invoice.ShareSecret != "" {
+		if b, err := os.ReadFile(cfg.Invoice.ShareSecret); err == nil {
+			cfg.Invoice.ShareSecret = strings.TrimSpace(string(b))
+		}
+	}
 	return &cfg, nil
 }
 
@@ -0,0 +1,63 @@
+package infrastructure
+
+import (
+	"fmt"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// moneyColumn identifies a legacy integer/float column that now stores a
+// money.Amount as numeric(14,2).
+type moneyColumn struct {
+	Table  string
+	Column string
+}
+
+var moneyColumns = []moneyColumn{
+	{"invoices", "amount"},
+	{"products", "price"},
+	{"invoice_documents", "subtotal"},
+	{"invoice_documents", "discount_value"},
+	{"invoice_documents", "discount_amount"},
+	{"invoice_documents", "vat_amount"},
+	{"invoice_documents", "grand_total"},
+	{"invoice_items", "unit_price"},
+	{"invoice_items", "discount"},
+	{"invoice_items", "line_total"},
+}
+
+// MigrateMoneyColumns converts existing money columns to numeric(14,2)
+// before AutoMigrate runs. Integer columns already hold whole baht and float
+// columns hold baht with decimals, so both are cast through numeric and
+// rounded to two places; no satang is lost. Columns that are already
+// numeric(14,2) or don't exist yet are left alone.
+func MigrateMoneyColumns(db *gorm.DB) {
+	for _, mc := range moneyColumns {
+		var info struct {
+			DataType         string
+			NumericPrecision *int
+			NumericScale     *int
+		}
+		err := db.Raw(`SELECT data_type, numeric_precision, numeric_scale
+			FROM information_schema.columns
+			WHERE table_schema = current_schema() AND table_name = ? AND column_name = ?`,
+			mc.Table, mc.Column).Scan(&info).Error
+		if err != nil {
+			log.Fatalf("inspect money column %s.%s failed: %v", mc.Table, mc.Column, err)
+		}
+		if info.DataType == "" {
+			continue
+		}
+		if info.DataType == "numeric" && info.NumericPrecision != nil && *info.NumericPrecision == 14 &&
+			info.NumericScale != nil && *info.NumericScale == 2 {
+			continue
+		}
+		stmt := fmt.Sprintf(`ALTER TABLE %q ALTER COLUMN %q TYPE numeric(14,2) USING round(%q::numeric, 2)`,
+			mc.Table, mc.Column, mc.Column)
+		if err := db.Exec(stmt).Error; err != nil {
+			log.Fatalf("migrate money column %s.%s failed: %v", mc.Table, mc.Column, err)
+		}
+		log.Printf("migrated money column %s.%s from %s to numeric(14,2)", mc.Table, mc.Column, info.DataType)
+	}
+}
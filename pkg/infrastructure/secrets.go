@@ -0,0 +1,36 @@
+package infrastructure
+
+import (
+	"os"
+
+	"gopkg.in/yaml.v2"
+)
+
+// SigningSecrets holds the key merchant certificates are encrypted with
+// and the passphrases unlocking them, keyed by merchant ID. They are kept
+// out of config.yaml and the database.
+type SigningSecrets struct {
+	// EncryptionKey is a base64 encoded 32 byte AES key
+	EncryptionKey string            `yaml:"encryption_key"`
+	Passphrases   map[string]string `yaml:"certificate_passphrases"`
+}
+
+// LoadSigningSecrets reads the signing secrets file. The encryption key
+// can be overridden with the SIGNING_ENCRYPTION_KEY environment variable.
+func LoadSigningSecrets(path string) (*SigningSecrets, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var s SigningSecrets
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	if env := os.Getenv("SIGNING_ENCRYPTION_KEY"); env != "" {
+		s.EncryptionKey = env
+	}
+	if s.Passphrases == nil {
+		s.Passphrases = map[string]string{}
+	}
+	return &s, nil
+}
@@ -0,0 +1,37 @@
+package infrastructure
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadSigningSecrets(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "secrets.yaml")
+	content := `encryption_key: "ZmlsZS1rZXk="
+certificate_passphrases:
+  "6f1c2a8e-3b7d-4c55-9a0e-2d4f5b6c7d8e": "p12-pass"`
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write secrets file: %v", err)
+	}
+
+	s, err := LoadSigningSecrets(file)
+	if err != nil {
+		t.Fatalf("LoadSigningSecrets returned error: %v", err)
+	}
+	if s.EncryptionKey != "ZmlsZS1rZXk=" {
+		t.Errorf("encryption key not loaded correctly: %s", s.EncryptionKey)
+	}
+	if s.Passphrases["6f1c2a8e-3b7d-4c55-9a0e-2d4f5b6c7d8e"] != "p12-pass" {
+		t.Errorf("passphrases not loaded correctly: %v", s.Passphrases)
+	}
+
+	t.Setenv("SIGNING_ENCRYPTION_KEY", "ZW52LWtleQ==")
+	s, err = LoadSigningSecrets(file)
+	if err != nil {
+		t.Fatalf("LoadSigningSecrets returned error: %v", err)
+	}
+	if s.EncryptionKey != "ZW52LWtleQ==" {
+		t.Errorf("encryption key env override failed: %s", s.EncryptionKey)
+	}
+}
@@ -0,0 +1,35 @@
+package infrastructure
+
+import (
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// BackfillDocumentVatBreakdown fills in the vatable, zero-rated and exempt
+// amounts of documents saved before they were stored. Lines that were not
+// include or exclude were exempt then; their share of the document
+// discount is taken in proportion to the subtotal, and the rest of the
+// pre-VAT total is vatable. It only touches rows without a breakdown, so
+// it is safe to run on every start.
+func BackfillDocumentVatBreakdown(db *gorm.DB) {
+	res := db.Exec(`UPDATE invoice_documents d
+		SET exempt_amount = e.amount,
+			vatable_amount = d.grand_total - d.vat_amount - e.amount
+		FROM (
+			SELECT d2.id, CASE WHEN d2.subtotal = 0 THEN 0 ELSE
+				ROUND(COALESCE(SUM(i.line_total) FILTER (WHERE COALESCE(i.vat_type, '') NOT IN ('include', 'exclude')), 0)
+					* (d2.subtotal - d2.discount_amount) / d2.subtotal, 2) END AS amount
+			FROM invoice_documents d2
+			LEFT JOIN invoice_items i ON i.document_id = d2.id
+			GROUP BY d2.id
+		) e
+		WHERE e.id = d.id AND d.grand_total <> 0
+			AND d.vatable_amount = 0 AND d.zero_rated_amount = 0 AND d.exempt_amount = 0`)
+	if res.Error != nil {
+		log.Fatalf("backfill document VAT breakdown failed: %v", res.Error)
+	}
+	if res.RowsAffected > 0 {
+		log.Printf("split %d documents by VAT treatment", res.RowsAffected)
+	}
+}
@@ -0,0 +1,48 @@
+package mailer
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/option"
+)
+
+// GmailMailer implements Mailer using the Gmail API.
+type GmailMailer struct {
+	srv  *gmail.Service
+	from string
+}
+
+// NewGmailMailer creates a GmailMailer from OAuth client credentials and a
+// token allowed to send mail.
+func NewGmailMailer(ctx context.Context, credentialsJSON, tokenJSON []byte, from string) (*GmailMailer, error) {
+	config, err := google.ConfigFromJSON(credentialsJSON, gmail.GmailSendScope)
+	if err != nil {
+		return nil, err
+	}
+	var token oauth2.Token
+	if err := json.Unmarshal(tokenJSON, &token); err != nil {
+		return nil, err
+	}
+	srv, err := gmail.NewService(ctx, option.WithTokenSource(config.TokenSource(ctx, &token)))
+	if err != nil {
+		return nil, err
+	}
+	return &GmailMailer{srv: srv, from: from}, nil
+}
+
+// Send delivers m through the authorised Gmail account. Gmail reads the
+// recipients, Bcc included, from the headers.
+func (g *GmailMailer) Send(ctx context.Context, m *Message) error {
+	msg, err := Build(g.from, m, true)
+	if err != nil {
+		return err
+	}
+	raw := &gmail.Message{Raw: base64.URLEncoding.EncodeToString(msg)}
+	_, err = g.srv.Users.Messages.Send("me", raw).Context(ctx).Do()
+	return err
+}
@@ -0,0 +1,176 @@
+// Package mailer sends HTML email with attachments through an SMTP server
+// or the Gmail API.
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// Mailer delivers messages.
+type Mailer interface {
+	Send(ctx context.Context, m *Message) error
+}
+
+// Message is an HTML email. Bcc recipients receive the message without
+// appearing in its headers.
+type Message struct {
+	To          []string
+	Cc          []string
+	Bcc         []string
+	Subject     string
+	HTML        string
+	Attachments []Attachment
+}
+
+// Attachment is a file attached to a Message.
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// Recipients returns the addresses the message is delivered to: To, Cc
+// and Bcc.
+func (m *Message) Recipients() []string {
+	all := make([]string, 0, len(m.To)+len(m.Cc)+len(m.Bcc))
+	all = append(all, m.To...)
+	all = append(all, m.Cc...)
+	return append(all, m.Bcc...)
+}
+
+// ParseAddress returns the bare address of an address that may carry a
+// display name, e.g. "Shop <shop@example.com>".
+func ParseAddress(address string) (string, error) {
+	a, err := mail.ParseAddress(address)
+	if err != nil {
+		return "", err
+	}
+	return a.Address, nil
+}
+
+// Build encodes m as a MIME message sent by from. With withBcc the Bcc
+// recipients are listed in a Bcc header, for transports such as the Gmail
+// API that take the recipients from the headers and strip it.
+func Build(from string, m *Message, withBcc bool) ([]byte, error) {
+	if len(m.Recipients()) == 0 {
+		return nil, errors.New("mailer: message has no recipients")
+	}
+	sender, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("mailer: invalid sender: %w", err)
+	}
+	if strings.ContainsAny(m.Subject, "\r\n") {
+		return nil, errors.New("mailer: subject contains a line break")
+	}
+
+	var buf bytes.Buffer
+	header := func(name, value string) {
+		buf.WriteString(name + ": " + value + "\r\n")
+	}
+	header("From", sender.String())
+	for _, h := range []struct {
+		name  string
+		addrs []string
+	}{{"To", m.To}, {"Cc", m.Cc}, {"Bcc", m.Bcc}} {
+		if len(h.addrs) == 0 || (h.name == "Bcc" && !withBcc) {
+			continue
+		}
+		list, err := addressList(h.addrs)
+		if err != nil {
+			return nil, err
+		}
+		header(h.name, list)
+	}
+	header("Subject", mime.BEncoding.Encode("UTF-8", m.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("Message-ID", messageID(sender.Address))
+	header("MIME-Version", "1.0")
+
+	if len(m.Attachments) == 0 {
+		header("Content-Type", "text/html; charset=UTF-8")
+		header("Content-Transfer-Encoding", "base64")
+		buf.WriteString("\r\n")
+		writeBase64(&buf, []byte(m.HTML))
+		return buf.Bytes(), nil
+	}
+
+	w := multipart.NewWriter(&buf)
+	header("Content-Type", mime.FormatMediaType("multipart/mixed", map[string]string{"boundary": w.Boundary()}))
+	buf.WriteString("\r\n")
+	part, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/html; charset=UTF-8"},
+		"Content-Transfer-Encoding": {"base64"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	writeBase64(part, []byte(m.HTML))
+	for _, a := range m.Attachments {
+		if a.Filename == "" || strings.ContainsAny(a.Filename, "\r\n") {
+			return nil, errors.New("mailer: invalid attachment name")
+		}
+		contentType := a.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		part, err := w.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {contentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": a.Filename})},
+		})
+		if err != nil {
+			return nil, err
+		}
+		writeBase64(part, a.Data)
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// addressList formats addresses for a To or Cc header.
+func addressList(addrs []string) (string, error) {
+	out := make([]string, len(addrs))
+	for i, s := range addrs {
+		a, err := mail.ParseAddress(s)
+		if err != nil {
+			return "", fmt.Errorf("mailer: invalid recipient %q: %w", s, err)
+		}
+		out[i] = a.String()
+	}
+	return strings.Join(out, ", "), nil
+}
+
+func messageID(sender string) string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	domain := "localhost"
+	if i := strings.LastIndex(sender, "@"); i >= 0 {
+		domain = sender[i+1:]
+	}
+	return "<" + hex.EncodeToString(b) + "@" + domain + ">"
+}
+
+// writeBase64 writes data base64 encoded in lines of 76 characters.
+func writeBase64(w io.Writer, data []byte) {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		w.Write([]byte(encoded[:76] + "\r\n"))
+		encoded = encoded[76:]
+	}
+	w.Write([]byte(encoded + "\r\n"))
+}
@@ -0,0 +1,148 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"invoice_project/pkg/mailer/mailertest"
+)
+
+func testMessage() *Message {
+	return &Message{
+		To:      []string{"Buyer <buyer@example.com>"},
+		Cc:      []string{"cc@example.com"},
+		Bcc:     []string{"audit@example.com"},
+		Subject: "ใบกำกับภาษี INV-0001",
+		HTML:    "<p>เรียน ลูกค้า</p>",
+		Attachments: []Attachment{
+			{Filename: "INV-0001.pdf", ContentType: "application/pdf", Data: []byte("%PDF-1.4 test")},
+		},
+	}
+}
+
+type part struct {
+	filename string
+	body     []byte
+}
+
+// parts returns the parts of a multipart message, base64 bodies decoded.
+func parts(t *testing.T, msg *mail.Message) []part {
+	t.Helper()
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/mixed" {
+		t.Fatalf("content type %q: %v", msg.Header.Get("Content-Type"), err)
+	}
+	var out []part
+	r := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		p, err := r.NextPart()
+		if err == io.EOF {
+			return out
+		}
+		if err != nil {
+			t.Fatalf("next part: %v", err)
+		}
+		body, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, p))
+		if err != nil {
+			t.Fatalf("decode part: %v", err)
+		}
+		out = append(out, part{filename: p.FileName(), body: body})
+	}
+}
+
+func TestBuild(t *testing.T) {
+	raw, err := Build("Shop <shop@example.com>", testMessage(), false)
+	if err != nil {
+		t.Fatalf("Build returned error: %v", err)
+	}
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("ReadMessage: %v", err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil || subject != "ใบกำกับภาษี INV-0001" {
+		t.Errorf("subject = %q, %v", subject, err)
+	}
+	if got := msg.Header.Get("To"); got != `"Buyer" <buyer@example.com>` {
+		t.Errorf("To = %q", got)
+	}
+	if msg.Header.Get("Bcc") != "" {
+		t.Errorf("Bcc header should be left out")
+	}
+
+	ps := parts(t, msg)
+	if len(ps) != 2 {
+		t.Fatalf("expected 2 parts, got %d", len(ps))
+	}
+	if string(ps[0].body) != "<p>เรียน ลูกค้า</p>" {
+		t.Errorf("html part = %q", ps[0].body)
+	}
+	if ps[1].filename != "INV-0001.pdf" || string(ps[1].body) != "%PDF-1.4 test" {
+		t.Errorf("unexpected attachment %q: %q", ps[1].filename, ps[1].body)
+	}
+}
+
+func TestBuildRejectsInvalidMessages(t *testing.T) {
+	cases := map[string]*Message{
+		"no recipients":  {Subject: "x"},
+		"bad address":    {To: []string{"not an address"}},
+		"header newline": {To: []string{"a@example.com"}, Subject: "x\r\nBcc: b@example.com"},
+	}
+	for name, m := range cases {
+		if _, err := Build("shop@example.com", m, false); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestSMTPMailerSend(t *testing.T) {
+	srv, err := mailertest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+
+	m := NewSMTPMailer(srv.Host, srv.Port, "user", "pass", "Shop <shop@example.com>")
+	if err := m.Send(context.Background(), testMessage()); err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+	mails := srv.Messages()
+	if len(mails) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(mails))
+	}
+	got := mails[0]
+	if got.From != "shop@example.com" || got.Username != "user" {
+		t.Errorf("unexpected envelope %+v", got)
+	}
+	want := []string{"buyer@example.com", "cc@example.com", "audit@example.com"}
+	if strings.Join(got.To, ",") != strings.Join(want, ",") {
+		t.Errorf("recipients = %v, want %v", got.To, want)
+	}
+	if bytes.Contains(got.Data, []byte("audit@example.com")) {
+		t.Errorf("Bcc recipient visible in the message")
+	}
+}
+
+func TestSMTPMailerRejectedRecipient(t *testing.T) {
+	srv, err := mailertest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	srv.Reject("buyer@example.com")
+
+	m := NewSMTPMailer(srv.Host, srv.Port, "", "", "shop@example.com")
+	if err := m.Send(context.Background(), testMessage()); err == nil {
+		t.Fatal("expected error for rejected recipient")
+	}
+	if len(srv.Messages()) != 0 {
+		t.Errorf("no message should be delivered")
+	}
+}
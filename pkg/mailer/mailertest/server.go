@@ -0,0 +1,197 @@
+// Package mailertest provides an in-process SMTP server for testing code
+// that sends mail.
+package mailertest
+
+import (
+	"bufio"
+	"encoding/base64"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Mail is a message received by a Server.
+type Mail struct {
+	From string
+	To   []string
+	Data []byte
+	// Username is the user authenticated with AUTH PLAIN, if any.
+	Username string
+}
+
+// Server is a minimal SMTP server listening on the loopback interface. It
+// accepts every message, except for recipients listed with Reject.
+type Server struct {
+	Host string
+	Port int
+
+	ln       net.Listener
+	wg       sync.WaitGroup
+	mu       sync.Mutex
+	mails    []Mail
+	rejected map[string]bool
+}
+
+// NewServer starts a Server on a free port of 127.0.0.1.
+func NewServer() (*Server, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	addr := ln.Addr().(*net.TCPAddr)
+	s := &Server{Host: addr.IP.String(), Port: addr.Port, ln: ln, rejected: map[string]bool{}}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// Addr returns the host:port the server listens on.
+func (s *Server) Addr() string {
+	return s.ln.Addr().String()
+}
+
+// Close stops the server and waits for open sessions to end.
+func (s *Server) Close() error {
+	err := s.ln.Close()
+	s.wg.Wait()
+	return err
+}
+
+// Reject makes the server refuse the given recipient addresses.
+func (s *Server) Reject(addresses ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, a := range addresses {
+		s.rejected[strings.ToLower(a)] = true
+	}
+}
+
+// Messages returns the messages received so far.
+func (s *Server) Messages() []Mail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Mail(nil), s.mails...)
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer conn.Close()
+			s.session(conn)
+		}()
+	}
+}
+
+// session speaks SMTP with one client until it quits or disconnects.
+func (s *Server) session(conn net.Conn) {
+	r := bufio.NewReader(conn)
+	reply := func(lines ...string) {
+		for _, l := range lines {
+			conn.Write([]byte(l + "\r\n"))
+		}
+	}
+	reply("220 mailertest ESMTP")
+
+	var cur Mail
+	var user string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			reply("250-mailertest", "250-8BITMIME", "250 AUTH PLAIN")
+		case "HELO":
+			reply("250 mailertest")
+		case "AUTH":
+			mech, resp, _ := strings.Cut(arg, " ")
+			creds, err := base64.StdEncoding.DecodeString(resp)
+			parts := strings.Split(string(creds), "\x00")
+			if !strings.EqualFold(mech, "PLAIN") || err != nil || len(parts) != 3 {
+				reply("535 5.7.8 authentication failed")
+				continue
+			}
+			user = parts[1]
+			reply("235 2.7.0 authentication successful")
+		case "MAIL":
+			cur = Mail{From: address(arg), Username: user}
+			reply("250 2.1.0 ok")
+		case "RCPT":
+			to := address(arg)
+			s.mu.Lock()
+			rejected := s.rejected[strings.ToLower(to)]
+			s.mu.Unlock()
+			if rejected {
+				reply("550 5.1.1 mailbox unavailable")
+				continue
+			}
+			cur.To = append(cur.To, to)
+			reply("250 2.1.5 ok")
+		case "DATA":
+			if len(cur.To) == 0 {
+				reply("503 5.5.1 no recipients")
+				continue
+			}
+			reply("354 end data with <CR><LF>.<CR><LF>")
+			data, err := readData(r)
+			if err != nil {
+				return
+			}
+			cur.Data = data
+			s.mu.Lock()
+			s.mails = append(s.mails, cur)
+			s.mu.Unlock()
+			cur = Mail{}
+			reply("250 2.0.0 queued as " + strconv.Itoa(len(s.Messages())))
+		case "RSET":
+			cur = Mail{}
+			reply("250 2.0.0 ok")
+		case "NOOP":
+			reply("250 2.0.0 ok")
+		case "QUIT":
+			reply("221 2.0.0 bye")
+			return
+		default:
+			reply("502 5.5.2 command not recognized")
+		}
+	}
+}
+
+// address extracts the address of a MAIL FROM or RCPT TO argument.
+func address(arg string) string {
+	if i := strings.Index(arg, "<"); i >= 0 {
+		if j := strings.Index(arg[i:], ">"); j >= 0 {
+			return arg[i+1 : i+j]
+		}
+	}
+	_, a, _ := strings.Cut(arg, ":")
+	return strings.TrimSpace(a)
+}
+
+// readData reads a message up to the terminating dot line, undoing dot
+// stuffing.
+func readData(r *bufio.Reader) ([]byte, error) {
+	var data []byte
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		if line == ".\r\n" || line == ".\n" {
+			return data, nil
+		}
+		line = strings.TrimPrefix(line, ".")
+		data = append(data, line...)
+	}
+}
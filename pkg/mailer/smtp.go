@@ -0,0 +1,55 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"net/smtp"
+)
+
+// SMTPMailer implements Mailer using a generic SMTP server.
+type SMTPMailer struct {
+	host     string
+	port     int
+	username string
+	password string
+	from     string
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewSMTPMailer creates a new SMTPMailer sending as from, which may carry
+// a display name. The server is used without authentication when username
+// is empty.
+func NewSMTPMailer(host string, port int, username, password, from string) *SMTPMailer {
+	return &SMTPMailer{
+		host:     host,
+		port:     port,
+		username: username,
+		password: password,
+		from:     from,
+		sendMail: smtp.SendMail,
+	}
+}
+
+// Send delivers m to all its recipients, Bcc included.
+func (s *SMTPMailer) Send(ctx context.Context, m *Message) error {
+	msg, err := Build(s.from, m, false)
+	if err != nil {
+		return err
+	}
+	sender, err := ParseAddress(s.from)
+	if err != nil {
+		return err
+	}
+	to := m.Recipients()
+	for i, r := range to {
+		if to[i], err = ParseAddress(r); err != nil {
+			return err
+		}
+	}
+	var auth smtp.Auth
+	if s.username != "" {
+		auth = smtp.PlainAuth("", s.username, s.password, s.host)
+	}
+	addr := fmt.Sprintf("%s:%d", s.host, s.port)
+	return s.sendMail(addr, auth, sender, to, msg)
+}
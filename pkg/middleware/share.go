@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+)
+
+// ErrInvalidShareToken is returned for share tokens that are malformed,
+// wrongly signed, expired or of another token type.
+var ErrInvalidShareToken = errors.New("invalid share token")
+
+// GenerateShareToken signs a token granting read access to the shared
+// resource id until expiresAt. Its token_type keeps it from being accepted
+// as an access token, and access tokens from being accepted as share
+// tokens.
+func GenerateShareToken(secret string, id uuid.UUID, expiresAt time.Time) (string, error) {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"sub":        id.String(),
+		"token_type": "share",
+		"exp":        expiresAt.Unix(),
+		"iat":        now.Unix(),
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(secret))
+}
+
+// ParseShareToken verifies a token made by GenerateShareToken and returns
+// the ID of the shared resource.
+func ParseShareToken(secret, tokenString string) (uuid.UUID, error) {
+	token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
+		if t.Method.Alg() != jwt.SigningMethodHS256.Alg() {
+			return nil, ErrInvalidShareToken
+		}
+		return []byte(secret), nil
+	})
+	if err != nil || !token.Valid {
+		return uuid.Nil, ErrInvalidShareToken
+	}
+	claims := token.Claims.(jwt.MapClaims)
+	if typ, ok := claims["token_type"].(string); !ok || typ != "share" {
+		return uuid.Nil, ErrInvalidShareToken
+	}
+	if _, ok := claims["exp"]; !ok {
+		return uuid.Nil, ErrInvalidShareToken
+	}
+	sub, _ := claims["sub"].(string)
+	id, err := uuid.Parse(sub)
+	if err != nil {
+		return uuid.Nil, ErrInvalidShareToken
+	}
+	return id, nil
+}
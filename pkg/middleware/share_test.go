@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+func TestShareToken(t *testing.T) {
+	id := uuid.New()
+	token, err := GenerateShareToken("secret", id, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ParseShareToken("secret", token)
+	if err != nil || got != id {
+		t.Fatalf("ParseShareToken = %v, %v", got, err)
+	}
+
+	if _, err := ParseShareToken("other", token); err != ErrInvalidShareToken {
+		t.Errorf("wrong secret: got %v", err)
+	}
+	expired, _ := GenerateShareToken("secret", id, time.Now().Add(-time.Minute))
+	if _, err := ParseShareToken("secret", expired); err != ErrInvalidShareToken {
+		t.Errorf("expired: got %v", err)
+	}
+	access, _ := GenerateJWTWithExpiry("secret", id, "user", time.Hour, "access")
+	if _, err := ParseShareToken("secret", access); err != ErrInvalidShareToken {
+		t.Errorf("access token accepted as share token")
+	}
+}
+
+func TestShareTokenIsNotAnAccessToken(t *testing.T) {
+	token, _ := GenerateShareToken("secret", uuid.New(), time.Now().Add(time.Hour))
+	app := fiber.New()
+	app.Use(JWTMiddleware("secret"))
+	app.Get("/", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusUnauthorized {
+		t.Errorf("status = %d, want 401", resp.StatusCode)
+	}
+}
@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type fakeAuthorizer struct {
+	stores    map[uuid.UUID]uuid.UUID // store ID -> owner
+	merchants map[uuid.UUID]uuid.UUID // merchant ID -> owner
+}
+
+func (f fakeAuthorizer) CanAccessStore(userID, storeID uuid.UUID) (bool, error) {
+	return f.stores[storeID] == userID, nil
+}
+
+func (f fakeAuthorizer) CanAccessMerchant(userID, merchantID uuid.UUID) (bool, error) {
+	return f.merchants[merchantID] == userID, nil
+}
+
+type storeBody struct {
+	Item struct {
+		StoreID string `json:"store_id"`
+	} `json:"item"`
+}
+
+func newStoreApp(userID uuid.UUID, role string, auth StoreAuthorizer) *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user_id", userID)
+		c.Locals("role", role)
+		return c.Next()
+	})
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) }
+	app.Get("/stores/:store_id", RequireStoreAccess(auth, FromParam("store_id")), ok)
+	app.Get("/items", RequireStoreAccess(auth, FromQuery("store_id")), ok)
+	app.Post("/items", RequireStoreAccess(auth, FromBody(func(b *storeBody) string { return b.Item.StoreID })), ok)
+	app.Get("/merchant", RequireMerchantAccess(auth, FromQuery("merchant_id")), ok)
+	return app
+}
+
+func TestRequireStoreAccess(t *testing.T) {
+	owner, other := uuid.New(), uuid.New()
+	store, merchant := uuid.New(), uuid.New()
+	auth := fakeAuthorizer{
+		stores:    map[uuid.UUID]uuid.UUID{store: owner},
+		merchants: map[uuid.UUID]uuid.UUID{merchant: owner},
+	}
+
+	cases := []struct {
+		name   string
+		user   uuid.UUID
+		role   string
+		method string
+		target string
+		body   string
+		want   int
+	}{
+		{"owner by param", owner, "user", "GET", "/stores/" + store.String(), "", 200},
+		{"other by param", other, "user", "GET", "/stores/" + store.String(), "", 403},
+		{"admin by param", other, "admin", "GET", "/stores/" + store.String(), "", 200},
+		{"owner by query", owner, "user", "GET", "/items?store_id=" + store.String(), "", 200},
+		{"other by query", other, "user", "GET", "/items?store_id=" + store.String(), "", 403},
+		{"no store named", other, "user", "GET", "/items", "", 200},
+		{"invalid store id", owner, "user", "GET", "/items?store_id=nope", "", 400},
+		{"owner by body", owner, "user", "POST", "/items", `{"item":{"store_id":"` + store.String() + `"}}`, 200},
+		{"other by body", other, "user", "POST", "/items", `{"item":{"store_id":"` + store.String() + `"}}`, 403},
+		{"malformed body", owner, "user", "POST", "/items", `{"item":`, 400},
+		{"owner merchant", owner, "user", "GET", "/merchant?merchant_id=" + merchant.String(), "", 200},
+		{"other merchant", other, "user", "GET", "/merchant?merchant_id=" + merchant.String(), "", 403},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			app := newStoreApp(tc.user, tc.role, auth)
+			req := httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body))
+			if tc.body != "" {
+				req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != tc.want {
+				t.Fatalf("status = %d, want %d", resp.StatusCode, tc.want)
+			}
+		})
+	}
+}
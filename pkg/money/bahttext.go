@@ -0,0 +1,76 @@
+package money
+
+import "strings"
+
+var thaiDigits = []string{"ศูนย์", "หนึ่ง", "สอง", "สาม", "สี่", "ห้า", "หก", "เจ็ด", "แปด", "เก้า"}
+
+var thaiPlaces = []string{"", "สิบ", "ร้อย", "พัน", "หมื่น", "แสน"}
+
+// ThaiText spells the amount in Thai as printed on tax invoices, e.g.
+// "หนึ่งร้อยบาทถ้วน" or "สิบเอ็ดบาทห้าสิบสตางค์". It follows the same
+// rules as the BAHTTEXT spreadsheet function.
+func (a Amount) ThaiText() string {
+	baht, satang := a.Baht()
+	var b strings.Builder
+	if a < 0 {
+		b.WriteString("ลบ")
+	}
+	if baht == 0 && satang == 0 {
+		b.WriteString("ศูนย์บาทถ้วน")
+		return b.String()
+	}
+	if baht > 0 {
+		b.WriteString(thaiNumber(baht))
+		b.WriteString("บาท")
+	}
+	if satang == 0 {
+		b.WriteString("ถ้วน")
+	} else {
+		b.WriteString(thaiNumber(satang))
+		b.WriteString("สตางค์")
+	}
+	return b.String()
+}
+
+// thaiNumber spells a positive integer, grouping by millions.
+func thaiNumber(n int64) string {
+	if n >= 1000000 {
+		high := thaiNumber(n / 1000000)
+		low := n % 1000000
+		if low == 0 {
+			return high + "ล้าน"
+		}
+		return high + "ล้าน" + thaiGroup(low, true)
+	}
+	return thaiGroup(n, false)
+}
+
+// thaiGroup spells a number below one million. A trailing one is read
+// "เอ็ด" when anything precedes it, including higher groups.
+func thaiGroup(n int64, hasHigher bool) string {
+	var b strings.Builder
+	digits := []int64{}
+	for v := n; v > 0; v /= 10 {
+		digits = append(digits, v%10)
+	}
+	for pos := len(digits) - 1; pos >= 0; pos-- {
+		d := digits[pos]
+		if d == 0 {
+			continue
+		}
+		switch {
+		case pos == 1 && d == 1:
+			b.WriteString("สิบ")
+			continue
+		case pos == 1 && d == 2:
+			b.WriteString("ยี่สิบ")
+			continue
+		case pos == 0 && d == 1 && (n > 1 || hasHigher):
+			b.WriteString("เอ็ด")
+			continue
+		}
+		b.WriteString(thaiDigits[d])
+		b.WriteString(thaiPlaces[pos])
+	}
+	return b.String()
+}
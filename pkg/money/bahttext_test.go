@@ -0,0 +1,27 @@
+package money
+
+import "testing"
+
+func TestThaiText(t *testing.T) {
+	cases := map[string]string{
+		"0":          "ศูนย์บาทถ้วน",
+		"1":          "หนึ่งบาทถ้วน",
+		"11":         "สิบเอ็ดบาทถ้วน",
+		"21":         "ยี่สิบเอ็ดบาทถ้วน",
+		"100":        "หนึ่งร้อยบาทถ้วน",
+		"101":        "หนึ่งร้อยเอ็ดบาทถ้วน",
+		"1250.50":    "หนึ่งพันสองร้อยห้าสิบบาทห้าสิบสตางค์",
+		"0.25":       "ยี่สิบห้าสตางค์",
+		"0.01":       "หนึ่งสตางค์",
+		"1000000":    "หนึ่งล้านบาทถ้วน",
+		"1000001":    "หนึ่งล้านเอ็ดบาทถ้วน",
+		"21000000":   "ยี่สิบเอ็ดล้านบาทถ้วน",
+		"3210987.65": "สามล้านสองแสนหนึ่งหมื่นเก้าร้อยแปดสิบเจ็ดบาทหกสิบห้าสตางค์",
+		"-5":         "ลบห้าบาทถ้วน",
+	}
+	for in, want := range cases {
+		if got := MustParse(in).ThaiText(); got != want {
+			t.Errorf("ThaiText(%s) = %s, want %s", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,316 @@
+// Package money provides a fixed-point amount type with satang (1/100 baht)
+// precision. Amounts are stored as integers so adding and comparing them
+// never loses a cent, and they are persisted as numeric(14,2) columns.
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Amount is a monetary value in satang.
+type Amount int64
+
+// Zero is the zero amount.
+const Zero Amount = 0
+
+// ErrInvalidAmount is returned when a value cannot be parsed as an amount.
+var ErrInvalidAmount = errors.New("money: invalid amount")
+
+// FromSatang returns the amount of s satang.
+func FromSatang(s int64) Amount { return Amount(s) }
+
+// FromBaht returns the amount of b whole baht.
+func FromBaht(b int64) Amount { return Amount(b * 100) }
+
+// FromFloat converts a float baht value, rounding half away from zero to
+// the nearest satang. It is meant for legacy float data only.
+func FromFloat(f float64) Amount { return Amount(math.Round(f * 100)) }
+
+// Parse reads a decimal baht string such as "1,234.50" or "-12.5". Digits
+// beyond the second decimal place are rounded half away from zero.
+func Parse(s string) (Amount, error) {
+	s = strings.TrimSpace(strings.ReplaceAll(s, ",", ""))
+	if s == "" {
+		return 0, ErrInvalidAmount
+	}
+	neg := false
+	switch s[0] {
+	case '-':
+		neg = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	if intPart == "" {
+		intPart = "0"
+	}
+	if !isDigits(intPart) || !isDigits(fracPart) {
+		return 0, ErrInvalidAmount
+	}
+	whole, err := strconv.ParseInt(intPart, 10, 64)
+	if err != nil {
+		return 0, ErrInvalidAmount
+	}
+	frac := fracPart + "000"
+	cents, _ := strconv.ParseInt(frac[:2], 10, 64)
+	v := whole*100 + cents
+	if frac[2] >= '5' {
+		v++
+	}
+	if neg {
+		v = -v
+	}
+	return Amount(v), nil
+}
+
+// MustParse is like Parse but panics on error. It is intended for
+// constants in tests.
+func MustParse(s string) Amount {
+	a, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Satang returns the amount in satang.
+func (a Amount) Satang() int64 { return int64(a) }
+
+// Float returns the amount in baht as a float. Use it for display only.
+func (a Amount) Float() float64 { return float64(a) / 100 }
+
+// Baht returns the whole baht part and the satang part of the amount.
+func (a Amount) Baht() (baht int64, satang int64) {
+	v := int64(a)
+	if v < 0 {
+		v = -v
+	}
+	return v / 100, v % 100
+}
+
+// IsZero reports whether the amount is zero.
+func (a Amount) IsZero() bool { return a == 0 }
+
+// IsNegative reports whether the amount is below zero.
+func (a Amount) IsNegative() bool { return a < 0 }
+
+// Add returns a + b.
+func (a Amount) Add(b Amount) Amount { return a + b }
+
+// Sub returns a - b.
+func (a Amount) Sub(b Amount) Amount { return a - b }
+
+// Neg returns -a.
+func (a Amount) Neg() Amount { return -a }
+
+// Abs returns the absolute value of a.
+func (a Amount) Abs() Amount {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
+// Mul returns a multiplied by n.
+func (a Amount) Mul(n int64) Amount { return a * Amount(n) }
+
+// MulRate returns a * num / den rounded half away from zero to the satang,
+// the rounding used on Thai tax invoices (0.005 rounds up to 0.01).
+func (a Amount) MulRate(num, den int64) Amount {
+	return Amount(RoundDiv(int64(a)*num, den))
+}
+
+// Percent returns p percent of a, where p is itself expressed as an amount
+// with two decimals (e.g. 7.00 for 7%).
+func (a Amount) Percent(p Amount) Amount { return a.MulRate(int64(p), 10000) }
+
+// Sum adds all amounts.
+func Sum(amounts ...Amount) Amount {
+	var total Amount
+	for _, a := range amounts {
+		total += a
+	}
+	return total
+}
+
+// Min returns the smaller of a and b.
+func Min(a, b Amount) Amount {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// Allocate splits a over the given weights in proportion, handing the
+// rounding remainder to the last non-zero weight so the parts always add
+// up to a.
+func (a Amount) Allocate(weights []Amount) []Amount {
+	parts := make([]Amount, len(weights))
+	var total Amount
+	last := -1
+	for i, w := range weights {
+		total += w
+		if w != 0 {
+			last = i
+		}
+	}
+	if total == 0 || last < 0 {
+		return parts
+	}
+	remaining := a
+	for i, w := range weights {
+		if i == last {
+			parts[i] = remaining
+			break
+		}
+		parts[i] = a.MulRate(int64(w), int64(total))
+		remaining -= parts[i]
+	}
+	return parts
+}
+
+// RoundDiv divides a by b rounding half away from zero.
+func RoundDiv(a, b int64) int64 {
+	if b == 0 {
+		return 0
+	}
+	neg := (a < 0) != (b < 0)
+	if a < 0 {
+		a = -a
+	}
+	if b < 0 {
+		b = -b
+	}
+	q := (a + b/2) / b
+	if neg {
+		return -q
+	}
+	return q
+}
+
+// String formats the amount as a plain decimal with two places, e.g.
+// "-1234.50".
+func (a Amount) String() string {
+	baht, satang := a.Baht()
+	sign := ""
+	if a < 0 {
+		sign = "-"
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, baht, satang)
+}
+
+// Format formats the amount with thousands separators, e.g. "1,234.50".
+func (a Amount) Format() string {
+	baht, satang := a.Baht()
+	digits := strconv.FormatInt(baht, 10)
+	var b strings.Builder
+	if a < 0 {
+		b.WriteByte('-')
+	}
+	for i, r := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(r)
+	}
+	fmt.Fprintf(&b, ".%02d", satang)
+	return b.String()
+}
+
+// MarshalJSON encodes the amount as a JSON number with two decimals.
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalJSON accepts a JSON number or a numeric string.
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	s := strings.TrimSpace(string(data))
+	if s == "null" {
+		return nil
+	}
+	s = strings.Trim(s, `"`)
+	if s == "" {
+		*a = 0
+		return nil
+	}
+	if strings.ContainsAny(s, "eE") {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return ErrInvalidAmount
+		}
+		*a = FromFloat(f)
+		return nil
+	}
+	v, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*a = v
+	return nil
+}
+
+// MarshalText renders the amount as a plain decimal, e.g. in XML.
+func (a Amount) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalText parses a plain decimal amount.
+func (a *Amount) UnmarshalText(text []byte) error {
+	v, err := Parse(strings.TrimSpace(string(text)))
+	if err != nil {
+		return err
+	}
+	*a = v
+	return nil
+}
+
+// Value implements driver.Valuer, storing the amount as a decimal string
+// so numeric columns keep the exact value.
+func (a Amount) Value() (driver.Value, error) {
+	return a.String(), nil
+}
+
+// Scan implements sql.Scanner for numeric, integer and float columns.
+func (a *Amount) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*a = 0
+		return nil
+	case int64:
+		*a = FromBaht(v)
+		return nil
+	case float64:
+		*a = FromFloat(v)
+		return nil
+	case []byte:
+		return a.scanString(string(v))
+	case string:
+		return a.scanString(v)
+	default:
+		return fmt.Errorf("money: cannot scan %T", src)
+	}
+}
+
+func (a *Amount) scanString(s string) error {
+	v, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*a = v
+	return nil
+}
@@ -0,0 +1,182 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	cases := map[string]Amount{
+		"0":        0,
+		"12":       1200,
+		"12.5":     1250,
+		"1,234.56": 123456,
+		"-0.01":    -1,
+		"10.005":   1001,
+		"-10.005":  -1001,
+		"0.004":    0,
+		".75":      75,
+		"+3.10":    310,
+	}
+	for in, want := range cases {
+		got, err := Parse(in)
+		if err != nil {
+			t.Errorf("Parse(%q) returned error: %v", in, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("Parse(%q) = %d, want %d", in, got, want)
+		}
+	}
+	for _, in := range []string{"", "abc", "1.2.3", "1e5", "--1"} {
+		if _, err := Parse(in); err == nil {
+			t.Errorf("Parse(%q) expected error", in)
+		}
+	}
+}
+
+func TestStringAndFormat(t *testing.T) {
+	a := FromSatang(-123456789)
+	if a.String() != "-1234567.89" {
+		t.Errorf("unexpected String: %s", a.String())
+	}
+	if a.Format() != "-1,234,567.89" {
+		t.Errorf("unexpected Format: %s", a.Format())
+	}
+	if FromSatang(5).String() != "0.05" {
+		t.Errorf("unexpected String for 5 satang: %s", FromSatang(5).String())
+	}
+}
+
+func TestMulRateRoundsHalfUp(t *testing.T) {
+	// 7% of 310.50 = 21.735 -> 21.74
+	if got := MustParse("310.50").Percent(MustParse("7")); got != 2174 {
+		t.Errorf("expected 2174, got %d", got)
+	}
+	// VAT included in 100.00 at 7% = 6.542 -> 6.54
+	if got := FromBaht(100).MulRate(700, 10700); got != 654 {
+		t.Errorf("expected 654, got %d", got)
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	parts := FromBaht(10).Allocate([]Amount{1, 1, 1})
+	if Sum(parts...) != FromBaht(10) {
+		t.Errorf("allocation does not add up: %v", parts)
+	}
+	if parts[0] != 333 || parts[2] != 334 {
+		t.Errorf("unexpected allocation: %v", parts)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var v struct {
+		A Amount `json:"a"`
+		B Amount `json:"b"`
+	}
+	if err := json.Unmarshal([]byte(`{"a": 12.34, "b": "56.7"}`), &v); err != nil {
+		t.Fatalf("Unmarshal returned error: %v", err)
+	}
+	if v.A != 1234 || v.B != 5670 {
+		t.Errorf("unexpected values: %+v", v)
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("Marshal returned error: %v", err)
+	}
+	if string(out) != `{"a":12.34,"b":56.70}` {
+		t.Errorf("unexpected json: %s", out)
+	}
+}
+
+func TestScanAndValue(t *testing.T) {
+	var a Amount
+	for src, want := range map[interface{}]Amount{
+		"1234.50":          123450,
+		int64(15):          1500,
+		float64(0.1 + 0.2): 30,
+	} {
+		if err := a.Scan(src); err != nil {
+			t.Fatalf("Scan(%v) returned error: %v", src, err)
+		}
+		if a != want {
+			t.Errorf("Scan(%v) = %d, want %d", src, a, want)
+		}
+	}
+	if err := a.Scan([]byte("-0.50")); err != nil || a != -50 {
+		t.Errorf("Scan([]byte) = %d, %v", a, err)
+	}
+	v, err := FromSatang(123450).Value()
+	if err != nil || v != "1234.50" {
+		t.Errorf("Value() = %v, %v", v, err)
+	}
+}
+
+func TestParseRate(t *testing.T) {
+	cases := map[string]Rate{
+		"1":          1000000,
+		"35.1234":    35123400,
+		"0.2345675":  234568,
+		"-0.0000005": -1,
+		"1,000.5":    1000500000,
+	}
+	for in, want := range cases {
+		got, err := ParseRate(in)
+		if err != nil {
+			t.Errorf("ParseRate(%q) returned error: %v", in, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("ParseRate(%q) = %d, want %d", in, got, want)
+		}
+	}
+	for _, in := range []string{"", "abc", "1.2.3", "--1"} {
+		if _, err := ParseRate(in); err == nil {
+			t.Errorf("ParseRate(%q) expected error", in)
+		}
+	}
+	if s := MustParseRate("35.120000").String(); s != "35.12" {
+		t.Errorf("String() = %q, want 35.12", s)
+	}
+	if s := MustParseRate("2").String(); s != "2" {
+		t.Errorf("String() = %q, want 2", s)
+	}
+}
+
+func TestRateConvert(t *testing.T) {
+	rate := MustParseRate("35.5678")
+	if got := rate.Convert(MustParse("100.00")); got != MustParse("3556.78") {
+		t.Errorf("Convert = %s, want 3556.78", got)
+	}
+	// 0.01 * 0.5 = 0.005 rounds away from zero
+	half := MustParseRate("0.5")
+	if got := half.Convert(FromSatang(1)); got != 1 {
+		t.Errorf("Convert = %d, want 1", got)
+	}
+	if got := half.Convert(FromSatang(-1)); got != -1 {
+		t.Errorf("Convert = %d, want -1", got)
+	}
+	if got := MustParseRate("23.4567").Per(100); got != MustParseRate("0.234567") {
+		t.Errorf("Per(100) = %s", got)
+	}
+}
+
+func TestRateJSONAndScan(t *testing.T) {
+	var v struct {
+		R Rate `json:"r"`
+	}
+	if err := json.Unmarshal([]byte(`{"r":"33.25"}`), &v); err != nil || v.R != 33250000 {
+		t.Fatalf("Unmarshal = %d, %v", v.R, err)
+	}
+	out, _ := json.Marshal(v)
+	if string(out) != `{"r":33.25}` {
+		t.Errorf("unexpected json: %s", out)
+	}
+	var r Rate
+	if err := r.Scan([]byte("0.234568")); err != nil || r != 234568 {
+		t.Errorf("Scan([]byte) = %d, %v", r, err)
+	}
+	if val, _ := r.Value(); val != "0.234568" {
+		t.Errorf("Value() = %v", val)
+	}
+}
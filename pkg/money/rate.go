@@ -0,0 +1,163 @@
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Rate is an exchange rate in baht per unit of another currency, held in
+// millionths so that rates are exact to the six decimal places the Bank
+// of Thailand publishes.
+type Rate int64
+
+// RateScale is the number of Rate units in one baht per unit.
+const RateScale = 1000000
+
+// ErrInvalidRate is returned when a string is not a decimal rate.
+var ErrInvalidRate = errors.New("money: invalid rate")
+
+// ParseRate reads a decimal rate such as "35.1234". Digits beyond the
+// sixth decimal place are rounded half away from zero.
+func ParseRate(s string) (Rate, error) {
+	s = strings.TrimSpace(strings.ReplaceAll(s, ",", ""))
+	if s == "" {
+		return 0, ErrInvalidRate
+	}
+	neg := false
+	switch s[0] {
+	case '-':
+		neg = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	if intPart == "" {
+		intPart = "0"
+	}
+	if !isDigits(intPart) || !isDigits(fracPart) {
+		return 0, ErrInvalidRate
+	}
+	whole, err := strconv.ParseInt(intPart, 10, 64)
+	if err != nil || whole > math.MaxInt64/RateScale-1 {
+		return 0, ErrInvalidRate
+	}
+	frac := fracPart + "0000000"
+	micro, _ := strconv.ParseInt(frac[:6], 10, 64)
+	v := whole*RateScale + micro
+	if frac[6] >= '5' {
+		v++
+	}
+	if neg {
+		v = -v
+	}
+	return Rate(v), nil
+}
+
+// MustParseRate is like ParseRate but panics on error. It is intended for
+// constants in tests.
+func MustParseRate(s string) Rate {
+	r, err := ParseRate(s)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// Convert returns a, an amount in the foreign currency, in baht rounded
+// half away from zero to the satang.
+func (r Rate) Convert(a Amount) Amount {
+	return a.MulRate(int64(r), RateScale)
+}
+
+// Per returns the rate of one unit when r is quoted per units, as the Bank
+// of Thailand quotes the yen per 100.
+func (r Rate) Per(units int64) Rate {
+	return Rate(RoundDiv(int64(r), units))
+}
+
+// String formats the rate as a plain decimal without trailing zeros, e.g.
+// "35.1234".
+func (r Rate) String() string {
+	sign := ""
+	v := int64(r)
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+	s := fmt.Sprintf("%s%d.%06d", sign, v/RateScale, v%RateScale)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// MarshalJSON encodes the rate as a JSON number.
+func (r Rate) MarshalJSON() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalJSON accepts a JSON number or a numeric string.
+func (r *Rate) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(strings.TrimSpace(string(data)), `"`)
+	if s == "null" {
+		return nil
+	}
+	if s == "" {
+		*r = 0
+		return nil
+	}
+	v, err := ParseRate(s)
+	if err != nil {
+		return err
+	}
+	*r = v
+	return nil
+}
+
+// MarshalText renders the rate as a plain decimal, e.g. in XML.
+func (r Rate) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalText parses a plain decimal rate.
+func (r *Rate) UnmarshalText(text []byte) error {
+	return r.scanString(string(text))
+}
+
+// Value implements driver.Valuer, storing the rate as a decimal string.
+func (r Rate) Value() (driver.Value, error) {
+	return r.String(), nil
+}
+
+// Scan implements sql.Scanner for numeric, integer and float columns.
+func (r *Rate) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*r = 0
+		return nil
+	case int64:
+		*r = Rate(v * RateScale)
+		return nil
+	case float64:
+		*r = Rate(math.Round(v * RateScale))
+		return nil
+	case []byte:
+		return r.scanString(string(v))
+	case string:
+		return r.scanString(v)
+	default:
+		return fmt.Errorf("money: cannot scan %T as rate", src)
+	}
+}
+
+func (r *Rate) scanString(s string) error {
+	v, err := ParseRate(s)
+	if err != nil {
+		return err
+	}
+	*r = v
+	return nil
+}
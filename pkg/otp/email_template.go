@@ -1,10 +1,23 @@
 package otp
 
-import "fmt"
+import (
+	"fmt"
 
-func buildOTPEmail(to, code string) string {
-	return fmt.Sprintf("To: %s\r\nSubject: OTP for E-mail Verification on ScaleTax by Sunscaleup\r\nMIME-Version: 1.0\r\nContent-Type: text/html; charset=\"UTF-8\"\r\n\r\n"+
-		`<!DOCTYPE html>
+	"invoice_project/pkg/mailer"
+)
+
+const otpSubject = "OTP for E-mail Verification on ScaleTax by Sunscaleup"
+
+// buildOTPEmail returns the message delivering code to to.
+func buildOTPEmail(to, code string) *mailer.Message {
+	return &mailer.Message{
+		To:      []string{to},
+		Subject: otpSubject,
+		HTML:    fmt.Sprintf(otpHTML, code),
+	}
+}
+
+const otpHTML = `<!DOCTYPE html>
 <html>
 <head>
   <meta charset="UTF-8">
@@ -70,5 +83,4 @@ func buildOTPEmail(to, code string) string {
     </div>
   </div>
 </body>
-</html>`, to, code)
-}
+</html>`
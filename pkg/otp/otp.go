@@ -3,15 +3,10 @@ package otp
 import (
 	"context"
 	"crypto/rand"
-	"encoding/base64"
-	"encoding/json"
 	"fmt"
 	"time"
 
-	"golang.org/x/oauth2"
-	"golang.org/x/oauth2/google"
-	"google.golang.org/api/gmail/v1"
-	"google.golang.org/api/option"
+	"invoice_project/pkg/mailer"
 )
 
 // Service defines OTP sending and verification behaviour.
@@ -22,9 +17,8 @@ type Service interface {
 
 // GmailOTPService implements the Service interface using Gmail API.
 type GmailOTPService struct {
-	srv       *gmail.Service
-	fromEmail string
-	otps      map[string]otpEntry
+	mailer mailer.Mailer
+	otps   map[string]otpEntry
 }
 
 type otpEntry struct {
@@ -32,20 +26,11 @@ type otpEntry struct {
 	ExpiresAt time.Time
 }
 
-func NewGmailOTPService(ctx context.Context, credentialsJSON, tokenJSON []byte, from string) (*GmailOTPService, error) {
-	config, err := google.ConfigFromJSON(credentialsJSON, gmail.GmailSendScope)
-	if err != nil {
-		return nil, err
-	}
-	var token oauth2.Token
-	if err := json.Unmarshal(tokenJSON, &token); err != nil {
-		return nil, err
-	}
-	srv, err := gmail.NewService(ctx, option.WithTokenSource(config.TokenSource(ctx, &token)))
-	if err != nil {
-		return nil, err
-	}
-	return &GmailOTPService{srv: srv, fromEmail: from, otps: make(map[string]otpEntry)}, nil
+// NewGmailOTPService creates a GmailOTPService sending codes through m,
+// usually a mailer.GmailMailer shared with the other mail the application
+// sends.
+func NewGmailOTPService(m mailer.Mailer) *GmailOTPService {
+	return &GmailOTPService{mailer: m, otps: make(map[string]otpEntry)}
 }
 
 func generateCode() (string, error) {
@@ -63,10 +48,7 @@ func (g *GmailOTPService) SendOTP(ctx context.Context, to, ref string) (string,
 	if err != nil {
 		return "", err
 	}
-	// msgStr := buildOTPEmail(to, code, ref)
-	msgStr := buildOTPEmail(to, code)
-	msg := &gmail.Message{Raw: base64.URLEncoding.EncodeToString([]byte(msgStr))}
-	if _, err := g.srv.Users.Messages.Send("me", msg).Do(); err != nil {
+	if err := g.mailer.Send(ctx, buildOTPEmail(to, code)); err != nil {
 		return "", err
 	}
 	g.otps[to] = otpEntry{Code: code, ExpiresAt: time.Now().Add(5 * time.Minute)}
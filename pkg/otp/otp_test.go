@@ -1,6 +1,8 @@
 package otp
 
 import (
+	"context"
+	"strings"
 	"testing"
 	"time"
 )
@@ -30,3 +32,21 @@ func TestVerifyOTP(t *testing.T) {
 		t.Errorf("expired OTP should fail")
 	}
 }
+
+func TestGmailSendOTP(t *testing.T) {
+	m := &recordingMailer{}
+	svc := NewGmailOTPService(m)
+	code, err := svc.SendOTP(context.Background(), "to@example.com", "ref123")
+	if err != nil {
+		t.Fatalf("SendOTP returned error: %v", err)
+	}
+	if len(m.sent) != 1 || m.sent[0].To[0] != "to@example.com" || m.sent[0].Subject != otpSubject {
+		t.Fatalf("unexpected messages: %+v", m.sent)
+	}
+	if !strings.Contains(m.sent[0].HTML, code) {
+		t.Errorf("code %s missing from the message", code)
+	}
+	if !svc.VerifyOTP("to@example.com", code) {
+		t.Errorf("sent code does not verify")
+	}
+}
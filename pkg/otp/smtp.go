@@ -2,32 +2,24 @@ package otp
 
 import (
 	"context"
-	"fmt"
-	"net/smtp"
 	"time"
+
+	"invoice_project/pkg/mailer"
 )
 
 // SMTPOTPService implements the Service interface using a generic SMTP server.
 type SMTPOTPService struct {
-	host      string
-	port      int
-	username  string
-	password  string
-	fromEmail string
-	otps      map[string]otpEntry
-	sendMail  func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+	mailer mailer.Mailer
+	otps   map[string]otpEntry
 }
 
-// NewSMTPOTPService creates a new SMTPOTPService instance.
-func NewSMTPOTPService(host string, port int, username, password, from string) *SMTPOTPService {
+// NewSMTPOTPService creates a new SMTPOTPService sending codes through m,
+// usually a mailer.SMTPMailer shared with the other mail the application
+// sends.
+func NewSMTPOTPService(m mailer.Mailer) *SMTPOTPService {
 	return &SMTPOTPService{
-		host:      host,
-		port:      port,
-		username:  username,
-		password:  password,
-		fromEmail: from,
-		otps:      make(map[string]otpEntry),
-		sendMail:  smtp.SendMail,
+		mailer: m,
+		otps:   make(map[string]otpEntry),
 	}
 }
 
@@ -37,11 +29,7 @@ func (s *SMTPOTPService) SendOTP(ctx context.Context, to, ref string) (string, e
 	if err != nil {
 		return "", err
 	}
-	addr := fmt.Sprintf("%s:%d", s.host, s.port)
-	auth := smtp.PlainAuth("", s.username, s.password, s.host)
-	// msg := []byte(buildOTPEmail(to, code, ref))
-	msg := []byte(buildOTPEmail(to, code))
-	if err := s.sendMail(addr, auth, s.fromEmail, []string{to}, msg); err != nil {
+	if err := s.mailer.Send(ctx, buildOTPEmail(to, code)); err != nil {
 		return "", err
 	}
 	s.otps[to] = otpEntry{Code: code, ExpiresAt: time.Now().Add(5 * time.Minute)}
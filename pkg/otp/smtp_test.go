@@ -2,18 +2,29 @@ package otp
 
 import (
 	"context"
-	"net/smtp"
 	"testing"
 	"time"
+
+	"invoice_project/pkg/mailer"
+	"invoice_project/pkg/mailer/mailertest"
 )
 
+type recordingMailer struct {
+	sent []*mailer.Message
+}
+
+func (m *recordingMailer) Send(ctx context.Context, msg *mailer.Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
 func TestSMTPSendOTP(t *testing.T) {
-	var sent bool
-	svc := NewSMTPOTPService("smtp.example.com", 587, "user", "pass", "from@example.com")
-	svc.sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
-		sent = true
-		return nil
+	srv, err := mailertest.NewServer()
+	if err != nil {
+		t.Fatal(err)
 	}
+	defer srv.Close()
+	svc := NewSMTPOTPService(mailer.NewSMTPMailer(srv.Host, srv.Port, "", "", "from@example.com"))
 	code, err := svc.SendOTP(context.Background(), "to@example.com", "ref123")
 	if err != nil {
 		t.Fatalf("SendOTP returned error: %v", err)
@@ -21,8 +32,9 @@ func TestSMTPSendOTP(t *testing.T) {
 	if len(code) != 6 {
 		t.Errorf("expected code length 6, got %d", len(code))
 	}
-	if !sent {
-		t.Errorf("sendMail not called")
+	mails := srv.Messages()
+	if len(mails) != 1 || len(mails[0].To) != 1 || mails[0].To[0] != "to@example.com" {
+		t.Fatalf("unexpected mails: %+v", mails)
 	}
 	if _, ok := svc.otps["to@example.com"]; !ok {
 		t.Errorf("OTP not stored")
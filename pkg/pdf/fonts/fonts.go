@@ -0,0 +1,26 @@
+// Package fonts bundles the Thai font rendered documents use by default.
+package fonts
+
+import (
+	"embed"
+	"io/fs"
+)
+
+// files holds Sarabun, licensed under the SIL Open Font License 1.1.
+//
+//go:embed sarabun
+var files embed.FS
+
+// Sarabun returns the bundled Sarabun regular and bold faces. A face that
+// is not bundled is returned as nil.
+func Sarabun() (regular, bold []byte) {
+	return read("sarabun/Sarabun-Regular.ttf"), read("sarabun/Sarabun-Bold.ttf")
+}
+
+func read(name string) []byte {
+	b, err := fs.ReadFile(files, name)
+	if err != nil {
+		return nil
+	}
+	return b
+}
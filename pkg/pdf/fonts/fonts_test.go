@@ -0,0 +1,23 @@
+package fonts
+
+import (
+	"testing"
+
+	"invoice_project/pkg/pdf"
+)
+
+func TestSarabun(t *testing.T) {
+	regular, bold := Sarabun()
+	if regular == nil {
+		t.Skip("Sarabun is not bundled")
+	}
+	for _, b := range [][]byte{regular, bold} {
+		f, err := pdf.New().AddFont(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !f.Has("ใบกำกับภาษี") {
+			t.Error("font has no Thai glyphs")
+		}
+	}
+}
@@ -0,0 +1,444 @@
+// Package pdf is a small PDF writer for generating printable documents
+// such as tax invoices. It embeds TrueType fonts as CID fonts with
+// Identity-H encoding so any script the font covers, including Thai, can
+// be drawn. Coordinates are in points with the origin at the top-left
+// corner of the page and y growing downwards.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// A4 page size in points.
+const (
+	A4Width  = 595.28
+	A4Height = 841.89
+)
+
+// Alignment of text within a box.
+type Alignment int
+
+const (
+	AlignLeft Alignment = iota
+	AlignCenter
+	AlignRight
+)
+
+// Color is an RGB color with 0-255 components.
+type Color struct{ R, G, B uint8 }
+
+var (
+	Black = Color{0, 0, 0}
+	White = Color{255, 255, 255}
+)
+
+func (c Color) pdf() string {
+	return fmt.Sprintf("%.3f %.3f %.3f", float64(c.R)/255, float64(c.G)/255, float64(c.B)/255)
+}
+
+// Document is a PDF under construction.
+type Document struct {
+	width, height float64
+	title         string
+	fonts         []*Font
+	pages         []*Page
+	alphas        map[string]float64
+}
+
+// Font is a TrueType font embedded in a Document.
+type Font struct {
+	name string
+	ttf  *ttfFont
+	used map[uint16]rune
+}
+
+// Page is a single page of a Document.
+type Page struct {
+	doc  *Document
+	buf  bytes.Buffer
+	font *Font
+	size float64
+	text Color
+}
+
+// New creates an empty A4 document.
+func New() *Document {
+	return &Document{width: A4Width, height: A4Height, alphas: map[string]float64{}}
+}
+
+// NewLandscape creates an empty A4 document with the long side
+// horizontal, for wide tables such as tax reports.
+func NewLandscape() *Document {
+	return &Document{width: A4Height, height: A4Width, alphas: map[string]float64{}}
+}
+
+// SetTitle sets the document title stored in the PDF metadata.
+func (d *Document) SetTitle(title string) { d.title = title }
+
+// Width returns the page width in points.
+func (d *Document) Width() float64 { return d.width }
+
+// Height returns the page height in points.
+func (d *Document) Height() float64 { return d.height }
+
+// AddFont parses a TrueType font and registers it for embedding.
+func (d *Document) AddFont(ttf []byte) (*Font, error) {
+	f, err := parseTTF(ttf)
+	if err != nil {
+		return nil, err
+	}
+	font := &Font{name: fmt.Sprintf("F%d", len(d.fonts)+1), ttf: f, used: map[uint16]rune{}}
+	d.fonts = append(d.fonts, font)
+	return font, nil
+}
+
+// AddPage appends a new blank page.
+func (d *Document) AddPage() *Page {
+	p := &Page{doc: d, text: Black}
+	d.pages = append(d.pages, p)
+	return p
+}
+
+// Width returns the width of s in points at the given font size.
+func (f *Font) Width(s string, size float64) float64 {
+	total := 0
+	for _, r := range s {
+		total += f.ttf.width(f.ttf.glyph(r))
+	}
+	return float64(total) * size / 1000
+}
+
+// Has reports whether the font has a glyph for every rune of s.
+func (f *Font) Has(s string) bool {
+	for _, r := range s {
+		if r != ' ' && f.ttf.glyph(r) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// WrapText splits s into lines no wider than width. Lines break at spaces
+// where possible and between characters otherwise, never in front of a
+// combining mark.
+func (f *Font) WrapText(s string, size, width float64) []string {
+	var lines []string
+	for _, para := range strings.Split(s, "\n") {
+		lines = append(lines, f.wrapLine(para, size, width)...)
+	}
+	return lines
+}
+
+func (f *Font) wrapLine(s string, size, width float64) []string {
+	runes := []rune(s)
+	if len(runes) == 0 {
+		return []string{""}
+	}
+	var lines []string
+	start := 0
+	for start < len(runes) {
+		end := start
+		lastSpace := -1
+		w := 0.0
+		for end < len(runes) {
+			cw := float64(f.ttf.width(f.ttf.glyph(runes[end]))) * size / 1000
+			if w+cw > width && end > start {
+				break
+			}
+			if runes[end] == ' ' {
+				lastSpace = end
+			}
+			w += cw
+			end++
+		}
+		if end < len(runes) {
+			if lastSpace > start {
+				end = lastSpace + 1
+			}
+			for end > start+1 && end < len(runes) && isCombining(runes[end]) {
+				end--
+			}
+		}
+		lines = append(lines, strings.TrimRight(string(runes[start:end]), " "))
+		start = end
+		for start < len(runes) && runes[start] == ' ' {
+			start++
+		}
+	}
+	return lines
+}
+
+// isCombining reports whether r is a Thai mark that attaches to the
+// preceding consonant.
+func isCombining(r rune) bool {
+	switch {
+	case r == 0x0E31, r >= 0x0E33 && r <= 0x0E3A, r >= 0x0E47 && r <= 0x0E4E:
+		return true
+	}
+	return false
+}
+
+// SetFont selects the font and size for subsequent text.
+func (p *Page) SetFont(f *Font, size float64) {
+	p.font = f
+	p.size = size
+}
+
+// SetTextColor sets the color of subsequent text.
+func (p *Page) SetTextColor(c Color) { p.text = c }
+
+// SetFillColor sets the fill color for rectangles.
+func (p *Page) SetFillColor(c Color) { fmt.Fprintf(&p.buf, "%s rg\n", c.pdf()) }
+
+// SetStrokeColor sets the color of lines and rectangle borders.
+func (p *Page) SetStrokeColor(c Color) { fmt.Fprintf(&p.buf, "%s RG\n", c.pdf()) }
+
+// SetLineWidth sets the width of lines in points.
+func (p *Page) SetLineWidth(w float64) { fmt.Fprintf(&p.buf, "%.2f w\n", w) }
+
+// SetAlpha sets the opacity (0-1) of everything drawn afterwards.
+func (p *Page) SetAlpha(a float64) {
+	name := fmt.Sprintf("GS%d", int(a*100))
+	p.doc.alphas[name] = a
+	fmt.Fprintf(&p.buf, "/%s gs\n", name)
+}
+
+// Line draws a straight line.
+func (p *Page) Line(x1, y1, x2, y2 float64) {
+	fmt.Fprintf(&p.buf, "%.2f %.2f m %.2f %.2f l S\n", x1, p.y(y1), x2, p.y(y2))
+}
+
+// Rect draws a rectangle whose top-left corner is at (x, y). When fill is
+// true the rectangle is filled with the fill color, otherwise only its
+// border is stroked.
+func (p *Page) Rect(x, y, w, h float64, fill bool) {
+	op := "S"
+	if fill {
+		op = "f"
+	}
+	fmt.Fprintf(&p.buf, "%.2f %.2f %.2f %.2f re %s\n", x, p.y(y+h), w, h, op)
+}
+
+// Text draws s with its baseline at y starting at x.
+func (p *Page) Text(x, y float64, s string) {
+	if p.font == nil || s == "" {
+		return
+	}
+	fmt.Fprintf(&p.buf, "BT %s rg /%s %.2f Tf %.2f %.2f Td <%s> Tj ET\n",
+		p.text.pdf(), p.font.name, p.size, x, p.y(y), p.encode(s))
+}
+
+// TextAligned draws s within a box of width w starting at x.
+func (p *Page) TextAligned(x, y, w float64, s string, align Alignment) {
+	if p.font == nil {
+		return
+	}
+	switch align {
+	case AlignCenter:
+		x += (w - p.font.Width(s, p.size)) / 2
+	case AlignRight:
+		x += w - p.font.Width(s, p.size)
+	}
+	p.Text(x, y, s)
+}
+
+// RotatedText draws s centred on (cx, cy) rotated counter-clockwise by
+// the given angle in degrees. It is used for watermarks.
+func (p *Page) RotatedText(cx, cy, degrees float64, s string) {
+	if p.font == nil || s == "" {
+		return
+	}
+	rad := degrees * math.Pi / 180
+	cos, sin := math.Cos(rad), math.Sin(rad)
+	w := p.font.Width(s, p.size)
+	// move the start point back along the rotated baseline by half the width
+	x := cx - cos*w/2
+	y := p.y(cy) - sin*w/2
+	fmt.Fprintf(&p.buf, "BT %s rg /%s %.2f Tf %.4f %.4f %.4f %.4f %.2f %.2f Tm <%s> Tj ET\n",
+		p.text.pdf(), p.font.name, p.size, cos, sin, -sin, cos, x, y, p.encode(s))
+}
+
+func (p *Page) y(y float64) float64 { return p.doc.height - y }
+
+func (p *Page) encode(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		gid := p.font.ttf.glyph(r)
+		if _, ok := p.font.used[gid]; !ok {
+			p.font.used[gid] = r
+		}
+		fmt.Fprintf(&b, "%04X", gid)
+	}
+	return b.String()
+}
+
+// Bytes renders the document.
+func (d *Document) Bytes() ([]byte, error) {
+	w := &writer{}
+	w.buf.WriteString("%PDF-1.7\n%\xE2\xE3\xCF\xD3\n")
+
+	catalogID := w.reserve()
+	pagesID := w.reserve()
+	infoID := w.reserve()
+
+	fontRefs := make([]string, 0, len(d.fonts))
+	for _, f := range d.fonts {
+		id, err := w.writeFont(f)
+		if err != nil {
+			return nil, err
+		}
+		fontRefs = append(fontRefs, fmt.Sprintf("/%s %d 0 R", f.name, id))
+	}
+
+	alphaNames := make([]string, 0, len(d.alphas))
+	for name := range d.alphas {
+		alphaNames = append(alphaNames, name)
+	}
+	sort.Strings(alphaNames)
+	var gs []string
+	for _, name := range alphaNames {
+		a := d.alphas[name]
+		gs = append(gs, fmt.Sprintf("/%s << /Type /ExtGState /ca %.2f /CA %.2f >>", name, a, a))
+	}
+	resources := fmt.Sprintf("<< /Font << %s >> /ExtGState << %s >> >>",
+		strings.Join(fontRefs, " "), strings.Join(gs, " "))
+
+	var kids []string
+	for _, p := range d.pages {
+		contentID := w.stream("", p.buf.Bytes(), true)
+		pageID := w.object(fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %.2f %.2f] /Resources %s /Contents %d 0 R >>",
+			pagesID, d.width, d.height, resources, contentID))
+		kids = append(kids, fmt.Sprintf("%d 0 R", pageID))
+	}
+
+	w.set(pagesID, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids)))
+	w.set(catalogID, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesID))
+	w.set(infoID, fmt.Sprintf("<< /Title <FEFF%s> /Producer (invoice_project) >>", utf16Hex(d.title)))
+	return w.finish(catalogID, infoID), nil
+}
+
+// writeFont embeds f as a Type0 font with a CIDFontType2 descendant.
+func (w *writer) writeFont(f *Font) (int, error) {
+	t := f.ttf
+	fileID := w.stream(fmt.Sprintf("/Length1 %d", len(t.data)), t.data, true)
+	descID := w.object(fmt.Sprintf("<< /Type /FontDescriptor /FontName /%s /Flags 4 /FontBBox [%d %d %d %d] /ItalicAngle %d /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+		t.postScriptNm, t.scale(t.bbox[0]), t.scale(t.bbox[1]), t.scale(t.bbox[2]), t.scale(t.bbox[3]),
+		t.italicAngle, t.scale(t.ascent), t.scale(t.descent), t.scale(t.capHeight), fileID))
+
+	gids := make([]int, 0, len(f.used))
+	for gid := range f.used {
+		gids = append(gids, int(gid))
+	}
+	sort.Ints(gids)
+	var widths strings.Builder
+	for _, gid := range gids {
+		fmt.Fprintf(&widths, "%d [%d] ", gid, t.width(uint16(gid)))
+	}
+	cidID := w.object(fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /FontDescriptor %d 0 R /DW 0 /W [%s] /CIDToGIDMap /Identity >>",
+		t.postScriptNm, descID, widths.String()))
+
+	var cmap strings.Builder
+	cmap.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n")
+	cmap.WriteString("/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n")
+	cmap.WriteString("/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n")
+	cmap.WriteString("1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	for i := 0; i < len(gids); i += 100 {
+		end := i + 100
+		if end > len(gids) {
+			end = len(gids)
+		}
+		fmt.Fprintf(&cmap, "%d beginbfchar\n", end-i)
+		for _, gid := range gids[i:end] {
+			fmt.Fprintf(&cmap, "<%04X> <%s>\n", gid, utf16Hex(string(f.used[uint16(gid)])))
+		}
+		cmap.WriteString("endbfchar\n")
+	}
+	cmap.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	toUnicodeID := w.stream("", []byte(cmap.String()), true)
+
+	return w.object(fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H /DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>",
+		t.postScriptNm, cidID, toUnicodeID)), nil
+}
+
+// writer lays out numbered PDF objects and the cross-reference table.
+type writer struct {
+	buf     bytes.Buffer
+	offsets []int
+	pending map[int]string
+}
+
+func (w *writer) reserve() int {
+	w.offsets = append(w.offsets, -1)
+	return len(w.offsets)
+}
+
+func (w *writer) set(id int, body string) {
+	if w.pending == nil {
+		w.pending = map[int]string{}
+	}
+	w.pending[id] = body
+}
+
+func (w *writer) object(body string) int {
+	id := w.reserve()
+	w.offsets[id-1] = w.buf.Len()
+	fmt.Fprintf(&w.buf, "%d 0 obj\n%s\nendobj\n", id, body)
+	return id
+}
+
+func (w *writer) stream(extra string, data []byte, compress bool) int {
+	filter := ""
+	if compress {
+		var z bytes.Buffer
+		zw := zlib.NewWriter(&z)
+		zw.Write(data)
+		zw.Close()
+		data = z.Bytes()
+		filter = " /Filter /FlateDecode"
+	}
+	id := w.reserve()
+	w.offsets[id-1] = w.buf.Len()
+	fmt.Fprintf(&w.buf, "%d 0 obj\n<< /Length %d%s %s >>\nstream\n", id, len(data), filter, extra)
+	w.buf.Write(data)
+	w.buf.WriteString("\nendstream\nendobj\n")
+	return id
+}
+
+func (w *writer) finish(rootID, infoID int) []byte {
+	ids := make([]int, 0, len(w.pending))
+	for id := range w.pending {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		w.offsets[id-1] = w.buf.Len()
+		fmt.Fprintf(&w.buf, "%d 0 obj\n%s\nendobj\n", id, w.pending[id])
+	}
+	xref := w.buf.Len()
+	fmt.Fprintf(&w.buf, "xref\n0 %d\n0000000000 65535 f \n", len(w.offsets)+1)
+	for _, off := range w.offsets {
+		fmt.Fprintf(&w.buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&w.buf, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(w.offsets)+1, rootID, infoID, xref)
+	return w.buf.Bytes()
+}
+
+// utf16Hex encodes s as big-endian UTF-16 hex.
+func utf16Hex(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= 0x10000 {
+			r -= 0x10000
+			fmt.Fprintf(&b, "%04X%04X", 0xD800+(r>>10), 0xDC00+(r&0x3FF))
+			continue
+		}
+		fmt.Fprintf(&b, "%04X", r)
+	}
+	return b.String()
+}
@@ -0,0 +1,88 @@
+package pdf
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+// testFont loads a TrueType font available on most Linux systems. Tests
+// that need a font are skipped when none is installed.
+func testFont(t *testing.T) []byte {
+	t.Helper()
+	for _, path := range []string{
+		"/usr/share/fonts/truetype/dejavu/DejaVuSans.ttf",
+		"/usr/share/fonts/dejavu/DejaVuSans.ttf",
+	} {
+		if data, err := os.ReadFile(path); err == nil {
+			return data
+		}
+	}
+	t.Skip("no truetype font available")
+	return nil
+}
+
+func TestDocumentBytes(t *testing.T) {
+	doc := New()
+	doc.SetTitle("Invoice")
+	font, err := doc.AddFont(testFont(t))
+	if err != nil {
+		t.Fatalf("AddFont returned error: %v", err)
+	}
+	page := doc.AddPage()
+	page.SetFont(font, 12)
+	page.Text(40, 40, "Hello")
+	page.TextAligned(40, 60, 200, "Right", AlignRight)
+	page.SetAlpha(0.2)
+	page.RotatedText(300, 400, 45, "COPY")
+	page.Rect(40, 80, 100, 20, false)
+	page.Line(40, 120, 300, 120)
+
+	out, err := doc.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes returned error: %v", err)
+	}
+	for _, want := range []string{"%PDF-1.7", "/Type /Catalog", "/Subtype /Type0", "/Identity-H", "/FontFile2", "/ToUnicode", "/GS20", "%%EOF"} {
+		if !bytes.Contains(out, []byte(want)) {
+			t.Errorf("output missing %q", want)
+		}
+	}
+}
+
+func TestInvalidFont(t *testing.T) {
+	if _, err := New().AddFont([]byte("not a font")); err == nil {
+		t.Errorf("expected error for invalid font")
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	doc := New()
+	font, err := doc.AddFont(testFont(t))
+	if err != nil {
+		t.Fatalf("AddFont returned error: %v", err)
+	}
+	lines := font.WrapText("the quick brown fox jumps over the lazy dog", 12, 80)
+	if len(lines) < 2 {
+		t.Fatalf("expected text to wrap, got %v", lines)
+	}
+	for _, l := range lines {
+		if font.Width(l, 12) > 80 {
+			t.Errorf("line %q wider than box", l)
+		}
+	}
+}
+
+func TestLandscape(t *testing.T) {
+	doc := NewLandscape()
+	if doc.Width() <= doc.Height() {
+		t.Fatalf("landscape page is %.2f x %.2f", doc.Width(), doc.Height())
+	}
+	doc.AddPage()
+	out, err := doc.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(out, []byte("/MediaBox [0 0 841.89 595.28]")) {
+		t.Error("page is not landscape")
+	}
+}
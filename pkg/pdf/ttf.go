@@ -0,0 +1,259 @@
+package pdf
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// ErrInvalidFont is returned when a font file cannot be parsed as TrueType.
+var ErrInvalidFont = errors.New("pdf: invalid truetype font")
+
+// ttfFont holds the metrics of a TrueType font needed to lay out text and
+// describe the font in a PDF.
+type ttfFont struct {
+	data         []byte
+	unitsPerEm   int
+	ascent       int
+	descent      int
+	capHeight    int
+	bbox         [4]int
+	italicAngle  int
+	advance      []int
+	cmap         map[rune]uint16
+	numGlyphs    int
+	postScriptNm string
+}
+
+type ttfTable struct {
+	offset uint32
+	length uint32
+}
+
+func parseTTF(data []byte) (*ttfFont, error) {
+	if len(data) < 12 {
+		return nil, ErrInvalidFont
+	}
+	numTables := int(binary.BigEndian.Uint16(data[4:]))
+	tables := make(map[string]ttfTable, numTables)
+	for i := 0; i < numTables; i++ {
+		rec := 12 + i*16
+		if rec+16 > len(data) {
+			return nil, ErrInvalidFont
+		}
+		tag := string(data[rec : rec+4])
+		off := binary.BigEndian.Uint32(data[rec+8:])
+		length := binary.BigEndian.Uint32(data[rec+12:])
+		if int(off)+int(length) > len(data) {
+			return nil, ErrInvalidFont
+		}
+		tables[tag] = ttfTable{offset: off, length: length}
+	}
+	table := func(tag string, min int) ([]byte, error) {
+		t, ok := tables[tag]
+		if !ok || int(t.length) < min {
+			return nil, ErrInvalidFont
+		}
+		return data[t.offset : t.offset+t.length], nil
+	}
+
+	f := &ttfFont{data: data}
+
+	head, err := table("head", 54)
+	if err != nil {
+		return nil, err
+	}
+	f.unitsPerEm = int(binary.BigEndian.Uint16(head[18:]))
+	if f.unitsPerEm == 0 {
+		return nil, ErrInvalidFont
+	}
+	for i := 0; i < 4; i++ {
+		f.bbox[i] = int(int16(binary.BigEndian.Uint16(head[36+i*2:])))
+	}
+
+	hhea, err := table("hhea", 36)
+	if err != nil {
+		return nil, err
+	}
+	f.ascent = int(int16(binary.BigEndian.Uint16(hhea[4:])))
+	f.descent = int(int16(binary.BigEndian.Uint16(hhea[6:])))
+	numHMetrics := int(binary.BigEndian.Uint16(hhea[34:]))
+
+	maxp, err := table("maxp", 6)
+	if err != nil {
+		return nil, err
+	}
+	f.numGlyphs = int(binary.BigEndian.Uint16(maxp[4:]))
+
+	hmtx, err := table("hmtx", numHMetrics*4)
+	if err != nil {
+		return nil, err
+	}
+	f.advance = make([]int, f.numGlyphs)
+	last := 0
+	for i := 0; i < f.numGlyphs; i++ {
+		if i < numHMetrics {
+			last = int(binary.BigEndian.Uint16(hmtx[i*4:]))
+		}
+		f.advance[i] = last
+	}
+
+	f.capHeight = f.ascent
+	if os2, err := table("OS/2", 90); err == nil {
+		if binary.BigEndian.Uint16(os2) >= 2 {
+			f.capHeight = int(int16(binary.BigEndian.Uint16(os2[88:])))
+		}
+	}
+	if post, err := table("post", 8); err == nil {
+		f.italicAngle = int(int16(binary.BigEndian.Uint16(post[4:])))
+	}
+	f.postScriptNm = "EmbeddedFont"
+	if name, err := table("name", 6); err == nil {
+		if n := postScriptName(name); n != "" {
+			f.postScriptNm = n
+		}
+	}
+
+	cmap, err := table("cmap", 4)
+	if err != nil {
+		return nil, err
+	}
+	if f.cmap, err = parseCmap(cmap); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// parseCmap reads the best Unicode subtable: format 12 when present,
+// otherwise format 4.
+func parseCmap(cmap []byte) (map[rune]uint16, error) {
+	n := int(binary.BigEndian.Uint16(cmap[2:]))
+	var fmt4, fmt12 []byte
+	for i := 0; i < n; i++ {
+		rec := 4 + i*8
+		if rec+8 > len(cmap) {
+			return nil, ErrInvalidFont
+		}
+		platform := binary.BigEndian.Uint16(cmap[rec:])
+		encoding := binary.BigEndian.Uint16(cmap[rec+2:])
+		off := int(binary.BigEndian.Uint32(cmap[rec+4:]))
+		if off+4 > len(cmap) {
+			continue
+		}
+		unicode := platform == 0 || (platform == 3 && (encoding == 1 || encoding == 10))
+		if !unicode {
+			continue
+		}
+		switch binary.BigEndian.Uint16(cmap[off:]) {
+		case 4:
+			fmt4 = cmap[off:]
+		case 12:
+			fmt12 = cmap[off:]
+		}
+	}
+	m := make(map[rune]uint16)
+	switch {
+	case fmt12 != nil && len(fmt12) >= 16:
+		groups := int(binary.BigEndian.Uint32(fmt12[12:]))
+		for i := 0; i < groups; i++ {
+			rec := 16 + i*12
+			if rec+12 > len(fmt12) {
+				return nil, ErrInvalidFont
+			}
+			start := binary.BigEndian.Uint32(fmt12[rec:])
+			end := binary.BigEndian.Uint32(fmt12[rec+4:])
+			gid := binary.BigEndian.Uint32(fmt12[rec+8:])
+			for c := start; c <= end && c-start < 0x10000; c++ {
+				m[rune(c)] = uint16(gid + c - start)
+			}
+		}
+	case fmt4 != nil && len(fmt4) >= 14:
+		segX2 := int(binary.BigEndian.Uint16(fmt4[6:]))
+		endOff := 14
+		startOff := endOff + segX2 + 2
+		deltaOff := startOff + segX2
+		rangeOff := deltaOff + segX2
+		if rangeOff+segX2 > len(fmt4) {
+			return nil, ErrInvalidFont
+		}
+		for s := 0; s < segX2/2; s++ {
+			end := int(binary.BigEndian.Uint16(fmt4[endOff+s*2:]))
+			start := int(binary.BigEndian.Uint16(fmt4[startOff+s*2:]))
+			delta := int(binary.BigEndian.Uint16(fmt4[deltaOff+s*2:]))
+			ro := int(binary.BigEndian.Uint16(fmt4[rangeOff+s*2:]))
+			for c := start; c <= end && c != 0xFFFF; c++ {
+				var gid int
+				if ro == 0 {
+					gid = (c + delta) & 0xFFFF
+				} else {
+					idx := rangeOff + s*2 + ro + (c-start)*2
+					if idx+2 > len(fmt4) {
+						continue
+					}
+					gid = int(binary.BigEndian.Uint16(fmt4[idx:]))
+					if gid != 0 {
+						gid = (gid + delta) & 0xFFFF
+					}
+				}
+				if gid != 0 {
+					m[rune(c)] = uint16(gid)
+				}
+			}
+		}
+	default:
+		return nil, ErrInvalidFont
+	}
+	return m, nil
+}
+
+// postScriptName returns name ID 6 from the name table, if present in an
+// ASCII-compatible encoding.
+func postScriptName(name []byte) string {
+	count := int(binary.BigEndian.Uint16(name[2:]))
+	strOff := int(binary.BigEndian.Uint16(name[4:]))
+	for i := 0; i < count; i++ {
+		rec := 6 + i*12
+		if rec+12 > len(name) {
+			return ""
+		}
+		platform := binary.BigEndian.Uint16(name[rec:])
+		nameID := binary.BigEndian.Uint16(name[rec+6:])
+		length := int(binary.BigEndian.Uint16(name[rec+8:]))
+		off := strOff + int(binary.BigEndian.Uint16(name[rec+10:]))
+		if nameID != 6 || off+length > len(name) {
+			continue
+		}
+		raw := name[off : off+length]
+		var out []byte
+		if platform == 3 || platform == 0 {
+			for j := 1; j < len(raw); j += 2 {
+				out = append(out, raw[j])
+			}
+		} else {
+			out = raw
+		}
+		clean := out[:0]
+		for _, b := range out {
+			if b > 32 && b < 127 && b != '/' && b != '(' && b != ')' && b != '[' && b != ']' {
+				clean = append(clean, b)
+			}
+		}
+		if len(clean) > 0 {
+			return string(clean)
+		}
+	}
+	return ""
+}
+
+// glyph returns the glyph index for r, or 0 (.notdef) if the font lacks it.
+func (f *ttfFont) glyph(r rune) uint16 { return f.cmap[r] }
+
+// width returns the advance width of a glyph in 1/1000 em.
+func (f *ttfFont) width(gid uint16) int {
+	if int(gid) >= len(f.advance) {
+		return 0
+	}
+	return f.advance[gid] * 1000 / f.unitsPerEm
+}
+
+// scale converts font units to 1/1000 em.
+func (f *ttfFont) scale(v int) int { return v * 1000 / f.unitsPerEm }
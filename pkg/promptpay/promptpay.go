@@ -0,0 +1,140 @@
+// Package promptpay builds PromptPay payment payloads following the EMVCo
+// merchant-presented QR code specification adopted by the Thai QR Payment
+// standard.
+package promptpay
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"invoice_project/pkg/money"
+)
+
+// Kinds of PromptPay ID.
+const (
+	TypeMobile     = "mobile"
+	TypeNationalID = "national_id"
+	TypeTaxID      = "tax_id"
+)
+
+// ErrInvalidID is returned for PromptPay IDs that are not a Thai mobile
+// number or a 13 digit national or tax ID with a valid check digit.
+var ErrInvalidID = errors.New("promptpay: invalid id")
+
+// ErrInvalidAmount is returned for negative amounts.
+var ErrInvalidAmount = errors.New("promptpay: invalid amount")
+
+const (
+	aid         = "A000000677010111"
+	currencyTHB = "764"
+	countryTH   = "TH"
+	staticQR    = "11"
+	dynamicQR   = "12"
+	tagAccount  = "29"
+	subMobile   = "01"
+	subTaxID    = "02"
+)
+
+// Normalize validates a PromptPay ID of the given kind and returns its
+// digits. Mobile numbers are given in the local form, e.g. 0812345678;
+// spaces and dashes are ignored.
+func Normalize(kind, id string) (string, error) {
+	digits := strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' {
+			return -1
+		}
+		return r
+	}, id)
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return "", ErrInvalidID
+		}
+	}
+	switch kind {
+	case TypeMobile:
+		if len(digits) != 10 || digits[0] != '0' {
+			return "", ErrInvalidID
+		}
+	case TypeNationalID, TypeTaxID:
+		if !validThaiID(digits) {
+			return "", ErrInvalidID
+		}
+	default:
+		return "", ErrInvalidID
+	}
+	return digits, nil
+}
+
+// validThaiID checks the length and mod 11 check digit shared by national
+// IDs and tax IDs.
+func validThaiID(s string) bool {
+	if len(s) != 13 {
+		return false
+	}
+	sum := 0
+	for i := 0; i < 12; i++ {
+		sum += int(s[i]-'0') * (13 - i)
+	}
+	return int(s[12]-'0') == (11-sum%11)%10
+}
+
+// Payload returns the QR payload paying id, a PromptPay ID of the given
+// kind. A positive amount gives a dynamic code for exactly that amount;
+// a zero amount a static one where the payer enters it.
+func Payload(kind, id string, amount money.Amount) (string, error) {
+	digits, err := Normalize(kind, id)
+	if err != nil {
+		return "", err
+	}
+	if amount.IsNegative() {
+		return "", ErrInvalidAmount
+	}
+
+	account := field("00", aid)
+	if kind == TypeMobile {
+		// 0066 followed by the number without its leading zero
+		account += field(subMobile, "0066"+digits[1:])
+	} else {
+		account += field(subTaxID, digits)
+	}
+	initiation := staticQR
+	if !amount.IsZero() {
+		initiation = dynamicQR
+	}
+
+	var b strings.Builder
+	b.WriteString(field("00", "01"))
+	b.WriteString(field("01", initiation))
+	b.WriteString(field(tagAccount, account))
+	b.WriteString(field("58", countryTH))
+	b.WriteString(field("53", currencyTHB))
+	if !amount.IsZero() {
+		b.WriteString(field("54", amount.String()))
+	}
+	b.WriteString("6304")
+	fmt.Fprintf(&b, "%04X", CRC16([]byte(b.String())))
+	return b.String(), nil
+}
+
+// field encodes an EMVCo data object: ID, two digit length and value.
+func field(id, value string) string {
+	return fmt.Sprintf("%s%02d%s", id, len(value), value)
+}
+
+// CRC16 is the CRC-16/CCITT-FALSE checksum (polynomial 0x1021, initial
+// value 0xFFFF) closing every payload.
+func CRC16(data []byte) uint16 {
+	crc := uint16(0xFFFF)
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
@@ -0,0 +1,74 @@
+package promptpay
+
+import (
+	"strings"
+	"testing"
+
+	"invoice_project/pkg/money"
+)
+
+func TestCRC16(t *testing.T) {
+	if got := CRC16([]byte("123456789")); got != 0x29B1 {
+		t.Errorf("CRC16 = %04X, want 29B1", got)
+	}
+}
+
+func TestPayload(t *testing.T) {
+	cases := []struct {
+		kind, id string
+		amount   money.Amount
+		want     string
+	}{
+		// reference payload of a static code for a mobile number
+		{TypeMobile, "089-999-9999", 0, "00020101021129370016A000000677010111011300668999999995802TH53037646304FE29"},
+		{TypeTaxID, "0105550123451", money.MustParse("1070.50"), "00020101021229370016A000000677010111021301055501234515802TH53037645407" + "1070.506304"},
+	}
+	for _, c := range cases {
+		got, err := Payload(c.kind, c.id, c.amount)
+		if err != nil {
+			t.Fatalf("%s: %v", c.id, err)
+		}
+		if !strings.HasPrefix(got, c.want) {
+			t.Errorf("payload = %s, want %s", got, c.want)
+		}
+		// the checksum covers everything up to and including its own tag
+		body, crc := got[:len(got)-4], got[len(got)-4:]
+		if sum := CRC16([]byte(body)); formatCRC(sum) != crc {
+			t.Errorf("crc = %s, want %s", crc, formatCRC(sum))
+		}
+	}
+}
+
+func formatCRC(v uint16) string {
+	const hex = "0123456789ABCDEF"
+	return string([]byte{hex[v>>12], hex[v>>8&0xF], hex[v>>4&0xF], hex[v&0xF]})
+}
+
+func TestNormalize(t *testing.T) {
+	valid := map[string][2]string{
+		"mobile":      {TypeMobile, "0812345678"},
+		"national id": {TypeNationalID, "1-1017-00230-70-8"},
+		"tax id":      {TypeTaxID, "0105550123451"},
+	}
+	for name, v := range valid {
+		if _, err := Normalize(v[0], v[1]); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+	invalid := [][2]string{
+		{TypeMobile, "812345678"},
+		{TypeMobile, "08123456789"},
+		{TypeNationalID, "1101700230707"},
+		{TypeTaxID, "010555012345"},
+		{TypeTaxID, "01055501234a1"},
+		{"email", "a@example.com"},
+	}
+	for _, v := range invalid {
+		if _, err := Normalize(v[0], v[1]); err != ErrInvalidID {
+			t.Errorf("%v: expected ErrInvalidID, got %v", v, err)
+		}
+	}
+	if _, err := Payload(TypeMobile, "0812345678", money.MustParse("-1.00")); err != ErrInvalidAmount {
+		t.Errorf("negative amount: got %v", err)
+	}
+}
@@ -0,0 +1,510 @@
+// Package qr encodes data as a QR code symbol (ISO/IEC 18004) in byte
+// mode, for payloads up to version 10, and renders it as a PNG.
+package qr
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+)
+
+// Level is the error correction level of a symbol.
+type Level int
+
+// Error correction levels, recovering about 7%, 15%, 25% and 30% of the
+// codewords.
+const (
+	L Level = iota
+	M
+	Q
+	H
+)
+
+// MaxVersion is the largest symbol version Encode produces.
+const MaxVersion = 10
+
+// ErrTooLong is returned for data that does not fit a version 10 symbol.
+var ErrTooLong = errors.New("qr: data too long")
+
+// Code is an encoded symbol. Module (x, y) is dark when Modules[y][x] is
+// true.
+type Code struct {
+	Version int
+	Level   Level
+	Size    int
+	Modules [][]bool
+}
+
+// blockLayout describes how the codewords of a version and level are
+// split into error correction blocks: blocks1 blocks of data1 data
+// codewords followed by blocks2 blocks of data1+1, each with ec error
+// correction codewords.
+type blockLayout struct {
+	ec, blocks1, data1, blocks2 int
+}
+
+// layouts is indexed by version-1 and level.
+var layouts = [MaxVersion][4]blockLayout{
+	{{7, 1, 19, 0}, {10, 1, 16, 0}, {13, 1, 13, 0}, {17, 1, 9, 0}},
+	{{10, 1, 34, 0}, {16, 1, 28, 0}, {22, 1, 22, 0}, {28, 1, 16, 0}},
+	{{15, 1, 55, 0}, {26, 1, 44, 0}, {18, 2, 17, 0}, {22, 2, 13, 0}},
+	{{20, 1, 80, 0}, {18, 2, 32, 0}, {26, 2, 24, 0}, {16, 4, 9, 0}},
+	{{26, 1, 108, 0}, {24, 2, 43, 0}, {18, 2, 15, 2}, {22, 2, 11, 2}},
+	{{18, 2, 68, 0}, {16, 4, 27, 0}, {24, 4, 19, 0}, {28, 4, 15, 0}},
+	{{20, 2, 78, 0}, {18, 4, 31, 0}, {18, 2, 14, 4}, {26, 4, 13, 1}},
+	{{24, 2, 97, 0}, {22, 2, 38, 2}, {22, 4, 18, 2}, {26, 4, 14, 2}},
+	{{30, 2, 116, 0}, {22, 3, 36, 2}, {20, 4, 16, 4}, {24, 4, 12, 4}},
+	{{18, 2, 68, 2}, {26, 4, 43, 1}, {24, 6, 19, 2}, {28, 6, 15, 2}},
+}
+
+// alignment holds the alignment pattern centres of each version.
+var alignment = [MaxVersion][]int{
+	nil, {6, 18}, {6, 22}, {6, 26}, {6, 30}, {6, 34},
+	{6, 22, 38}, {6, 24, 42}, {6, 26, 46}, {6, 28, 50},
+}
+
+func (b blockLayout) dataCodewords() int {
+	return b.blocks1*b.data1 + b.blocks2*(b.data1+1)
+}
+
+// Encode encodes data in the smallest symbol of the given level that
+// holds it, choosing the mask with the lowest penalty.
+func Encode(data []byte, level Level) (*Code, error) {
+	if level < L || level > H {
+		return nil, errors.New("qr: invalid level")
+	}
+	version := 0
+	for v := 1; v <= MaxVersion; v++ {
+		if 4+countBits(v)+8*len(data) <= 8*layouts[v-1][level].dataCodewords() {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, ErrTooLong
+	}
+
+	codewords := addErrorCorrection(encodeData(data, version, level), layouts[version-1][level])
+	c := &Code{Version: version, Level: level, Size: 17 + 4*version}
+	function := c.drawFunctionPatterns()
+	c.drawCodewords(codewords, function)
+
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		c.applyMask(mask, function)
+		c.drawFormat(mask)
+		if p := c.penalty(); bestPenalty < 0 || p < bestPenalty {
+			best, bestPenalty = mask, p
+		}
+		c.applyMask(mask, function) // masking twice undoes it
+	}
+	c.applyMask(best, function)
+	c.drawFormat(best)
+	return c, nil
+}
+
+// countBits is the width of the byte mode character count of a version.
+func countBits(version int) int {
+	if version <= 9 {
+		return 8
+	}
+	return 16
+}
+
+// encodeData returns the data codewords: mode, length, data, terminator
+// and padding.
+func encodeData(data []byte, version int, level Level) []byte {
+	capacity := layouts[version-1][level].dataCodewords()
+	var w bitWriter
+	w.write(0x4, 4)
+	w.write(len(data), countBits(version))
+	for _, b := range data {
+		w.write(int(b), 8)
+	}
+	if n := capacity*8 - w.len; n > 0 {
+		if n > 4 {
+			n = 4
+		}
+		w.write(0, n)
+	}
+	if r := w.len % 8; r != 0 {
+		w.write(0, 8-r)
+	}
+	for pad := 0; len(w.bytes) < capacity; pad ^= 1 {
+		w.write([]int{0xEC, 0x11}[pad], 8)
+	}
+	return w.bytes
+}
+
+type bitWriter struct {
+	bytes []byte
+	len   int
+}
+
+func (w *bitWriter) write(v, n int) {
+	for i := n - 1; i >= 0; i-- {
+		if w.len%8 == 0 {
+			w.bytes = append(w.bytes, 0)
+		}
+		if v>>i&1 == 1 {
+			w.bytes[w.len/8] |= 0x80 >> (w.len % 8)
+		}
+		w.len++
+	}
+}
+
+// addErrorCorrection splits data into blocks, computes their error
+// correction codewords and interleaves the result.
+func addErrorCorrection(data []byte, b blockLayout) []byte {
+	var blocks, ecs [][]byte
+	for i, off := 0, 0; i < b.blocks1+b.blocks2; i++ {
+		n := b.data1
+		if i >= b.blocks1 {
+			n++
+		}
+		blocks = append(blocks, data[off:off+n])
+		ecs = append(ecs, reedSolomon(data[off:off+n], b.ec))
+		off += n
+	}
+	var out []byte
+	for i := 0; i <= b.data1; i++ {
+		for _, blk := range blocks {
+			if i < len(blk) {
+				out = append(out, blk[i])
+			}
+		}
+	}
+	for i := 0; i < b.ec; i++ {
+		for _, ec := range ecs {
+			out = append(out, ec[i])
+		}
+	}
+	return out
+}
+
+// GF(256) tables for the polynomial x^8 + x^4 + x^3 + x^2 + 1.
+var gfExp, gfLog [256]int
+
+func init() {
+	x := 1
+	for i := 0; i < 255; i++ {
+		gfExp[i] = x
+		gfLog[x] = i
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= 0x11D
+		}
+	}
+	gfExp[255] = gfExp[0]
+}
+
+func gfMul(a, b int) int {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[(gfLog[a]+gfLog[b])%255]
+}
+
+// reedSolomon returns the n error correction codewords of data.
+func reedSolomon(data []byte, n int) []byte {
+	// generator polynomial (x - a^0)(x - a^1)...(x - a^(n-1)), highest
+	// coefficient first and left out
+	gen := make([]int, n)
+	gen[n-1] = 1
+	root := 1
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			gen[j] = gfMul(gen[j], root)
+			if j+1 < n {
+				gen[j] ^= gen[j+1]
+			}
+		}
+		root = gfMul(root, 2)
+	}
+	rem := make([]int, n)
+	for _, b := range data {
+		factor := int(b) ^ rem[0]
+		copy(rem, rem[1:])
+		rem[n-1] = 0
+		for j := range rem {
+			rem[j] ^= gfMul(gen[j], factor)
+		}
+	}
+	out := make([]byte, n)
+	for i, r := range rem {
+		out[i] = byte(r)
+	}
+	return out
+}
+
+// drawFunctionPatterns draws the finder, timing and alignment patterns
+// and reserves the format and version areas. It returns which modules are
+// function modules.
+func (c *Code) drawFunctionPatterns() [][]bool {
+	c.Modules = make([][]bool, c.Size)
+	function := make([][]bool, c.Size)
+	for y := range c.Modules {
+		c.Modules[y] = make([]bool, c.Size)
+		function[y] = make([]bool, c.Size)
+	}
+	set := func(x, y int, dark bool) {
+		c.Modules[y][x] = dark
+		function[y][x] = true
+	}
+
+	for i := 0; i < c.Size; i++ {
+		set(6, i, i%2 == 0)
+		set(i, 6, i%2 == 0)
+	}
+	for _, f := range [][2]int{{3, 3}, {c.Size - 4, 3}, {3, c.Size - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := f[0]+dx, f[1]+dy
+				if x < 0 || y < 0 || x >= c.Size || y >= c.Size {
+					continue
+				}
+				d := max(abs(dx), abs(dy))
+				set(x, y, d != 2 && d != 4)
+			}
+		}
+	}
+	centres := alignment[c.Version-1]
+	for i, cx := range centres {
+		for j, cy := range centres {
+			last := len(centres) - 1
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					set(cx+dx, cy+dy, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+
+	// reserve the format areas, drawn for real once the mask is chosen
+	c.drawFormatWith(0, set)
+	if c.Version >= 7 {
+		bits := versionBits(c.Version)
+		for i := 0; i < 18; i++ {
+			dark := bits>>i&1 == 1
+			a, b := c.Size-11+i%3, i/3
+			set(a, b, dark)
+			set(b, a, dark)
+		}
+	}
+	return function
+}
+
+// drawCodewords places the codewords in the two-module wide zigzag from
+// the bottom right corner, skipping function modules.
+func (c *Code) drawCodewords(data []byte, function [][]bool) {
+	i := 0
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < c.Size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = c.Size - 1 - vert
+				}
+				if !function[y][x] && i < len(data)*8 {
+					c.Modules[y][x] = data[i>>3]>>(7-i&7)&1 == 1
+					i++
+				}
+			}
+		}
+	}
+}
+
+func maskBit(mask, x, y int) bool {
+	switch mask {
+	case 0:
+		return (x+y)%2 == 0
+	case 1:
+		return y%2 == 0
+	case 2:
+		return x%3 == 0
+	case 3:
+		return (x+y)%3 == 0
+	case 4:
+		return (x/3+y/2)%2 == 0
+	case 5:
+		return x*y%2+x*y%3 == 0
+	case 6:
+		return (x*y%2+x*y%3)%2 == 0
+	default:
+		return ((x+y)%2+x*y%3)%2 == 0
+	}
+}
+
+func (c *Code) applyMask(mask int, function [][]bool) {
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if !function[y][x] && maskBit(mask, x, y) {
+				c.Modules[y][x] = !c.Modules[y][x]
+			}
+		}
+	}
+}
+
+// levelBits are the format information bits of each level.
+var levelBits = [4]int{1, 0, 3, 2}
+
+// formatBits returns the 15 format information bits of a level and mask.
+func formatBits(level Level, mask int) int {
+	data := levelBits[level]<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = rem<<1 ^ (rem>>9)*0x537
+	}
+	return (data<<10 | rem) ^ 0x5412
+}
+
+// versionBits returns the 18 version information bits of a version.
+func versionBits(version int) int {
+	rem := version
+	for i := 0; i < 12; i++ {
+		rem = rem<<1 ^ (rem>>11)*0x1F25
+	}
+	return version<<12 | rem
+}
+
+func (c *Code) drawFormat(mask int) {
+	c.drawFormatWith(mask, func(x, y int, dark bool) { c.Modules[y][x] = dark })
+}
+
+func (c *Code) drawFormatWith(mask int, set func(x, y int, dark bool)) {
+	bits := formatBits(c.Level, mask)
+	bit := func(i int) bool { return bits>>i&1 == 1 }
+	for i := 0; i <= 5; i++ {
+		set(8, i, bit(i))
+	}
+	set(8, 7, bit(6))
+	set(8, 8, bit(7))
+	set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		set(14-i, 8, bit(i))
+	}
+	for i := 0; i < 8; i++ {
+		set(c.Size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		set(8, c.Size-15+i, bit(i))
+	}
+	set(8, c.Size-8, true)
+}
+
+// penalty scores a masked symbol with the four rules of the standard;
+// lower is better.
+func (c *Code) penalty() int {
+	n := c.Size
+	at := func(x, y int, transpose bool) bool {
+		if transpose {
+			return c.Modules[x][y]
+		}
+		return c.Modules[y][x]
+	}
+	score := 0
+	for _, t := range []bool{false, true} {
+		for y := 0; y < n; y++ {
+			run := 1
+			for x := 1; x <= n; x++ {
+				if x < n && at(x, y, t) == at(x-1, y, t) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					score += 3 + run - 5
+				}
+				run = 1
+			}
+			for x := 0; x+11 <= n; x++ {
+				var w [11]bool
+				for k := range w {
+					w[k] = at(x+k, y, t)
+				}
+				if finderLike(w) {
+					score += 40
+				}
+			}
+		}
+	}
+	dark := 0
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			if c.Modules[y][x] {
+				dark++
+			}
+			if x+1 < n && y+1 < n {
+				v := c.Modules[y][x]
+				if c.Modules[y][x+1] == v && c.Modules[y+1][x] == v && c.Modules[y+1][x+1] == v {
+					score += 3
+				}
+			}
+		}
+	}
+	total := n * n
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	return score + k*10
+}
+
+// finderLike reports whether w is 1:1:3:1:1 dark-light pattern with four
+// light modules on either side.
+func finderLike(w [11]bool) bool {
+	core := [7]bool{true, false, true, true, true, false, true}
+	match := func(off int) bool {
+		for i, v := range core {
+			if w[off+i] != v {
+				return false
+			}
+		}
+		return true
+	}
+	light := func(from int) bool {
+		return !w[from] && !w[from+1] && !w[from+2] && !w[from+3]
+	}
+	return (match(0) && light(7)) || (light(0) && match(4))
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// PNG renders the symbol with scale pixels per module and the four
+// module quiet zone the standard requires.
+func (c *Code) PNG(scale int) ([]byte, error) {
+	if scale < 1 {
+		scale = 1
+	}
+	const quiet = 4
+	px := (c.Size + 2*quiet) * scale
+	img := image.NewPaletted(image.Rect(0, 0, px, px), color.Palette{color.White, color.Black})
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if !c.Modules[y][x] {
+				continue
+			}
+			for dy := 0; dy < scale; dy++ {
+				row := ((y+quiet)*scale + dy) * img.Stride
+				for dx := 0; dx < scale; dx++ {
+					img.Pix[row+(x+quiet)*scale+dx] = 1
+				}
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,190 @@
+package qr
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestReedSolomon(t *testing.T) {
+	// 1-M "HELLO WORLD" from the ISO/IEC 18004 walkthrough
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	if got := reedSolomon(data, 10); !bytes.Equal(got, want) {
+		t.Errorf("ec = %v, want %v", got, want)
+	}
+}
+
+func TestFormatAndVersionBits(t *testing.T) {
+	cases := []struct {
+		level Level
+		mask  int
+		want  int
+	}{
+		{L, 0, 0b111011111000100},
+		{M, 0, 0b101010000010010},
+		{Q, 7, 0b010101111101101},
+		{H, 3, 0b001100111010000},
+	}
+	for _, c := range cases {
+		if got := formatBits(c.level, c.mask); got != c.want {
+			t.Errorf("format(%d, %d) = %015b, want %015b", c.level, c.mask, got, c.want)
+		}
+	}
+	if got := versionBits(7); got != 0b000111110010010100 {
+		t.Errorf("version 7 = %018b", got)
+	}
+}
+
+// decode reads a symbol back: format, mask, codewords and data, checking
+// the error correction of every block.
+func decode(t *testing.T, c *Code) []byte {
+	t.Helper()
+	var format int
+	for i := 0; i <= 5; i++ {
+		format |= b2i(c.Modules[i][8]) << i
+	}
+	format |= b2i(c.Modules[7][8])<<6 | b2i(c.Modules[8][8])<<7 | b2i(c.Modules[8][7])<<8
+	for i := 9; i < 15; i++ {
+		format |= b2i(c.Modules[8][14-i]) << i
+	}
+	mask := -1
+	for m := 0; m < 8; m++ {
+		if formatBits(c.Level, m) == format {
+			mask = m
+		}
+	}
+	if mask < 0 {
+		t.Fatalf("format bits %015b do not match level %d", format, c.Level)
+	}
+
+	fresh := &Code{Version: c.Version, Level: c.Level, Size: c.Size}
+	function := fresh.drawFunctionPatterns()
+	var bits []bool
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < c.Size; vert++ {
+			for j := 0; j < 2; j++ {
+				x, y := right-j, vert
+				if (right+1)&2 == 0 {
+					y = c.Size - 1 - vert
+				}
+				if !function[y][x] {
+					bits = append(bits, c.Modules[y][x] != maskBit(mask, x, y))
+				}
+			}
+		}
+	}
+	raw := make([]byte, len(bits)/8)
+	for i := range raw {
+		for k := 0; k < 8; k++ {
+			raw[i] = raw[i]<<1 | byte(b2i(bits[i*8+k]))
+		}
+	}
+
+	b := layouts[c.Version-1][c.Level]
+	n := b.blocks1 + b.blocks2
+	blocks := make([][]byte, n)
+	pos := 0
+	for i := 0; i <= b.data1; i++ {
+		for k := 0; k < n; k++ {
+			if i < b.data1 || k >= b.blocks1 {
+				blocks[k] = append(blocks[k], raw[pos])
+				pos++
+			}
+		}
+	}
+	var data []byte
+	for k := range blocks {
+		var ec []byte
+		for i := 0; i < b.ec; i++ {
+			ec = append(ec, raw[pos+i*n+k])
+		}
+		if !bytes.Equal(reedSolomon(blocks[k], b.ec), ec) {
+			t.Fatalf("block %d: error correction mismatch", k)
+		}
+		data = append(data, blocks[k]...)
+	}
+
+	if data[0]>>4 != 0x4 {
+		t.Fatalf("mode = %x", data[0]>>4)
+	}
+	var length, off int
+	if c.Version <= 9 {
+		length = int(data[0]&0xF)<<4 | int(data[1]>>4)
+		off = 1
+	} else {
+		length = int(data[0]&0xF)<<12 | int(data[1])<<4 | int(data[2]>>4)
+		off = 2
+	}
+	out := make([]byte, length)
+	for i := range out {
+		out[i] = data[off+i]<<4 | data[off+i+1]>>4
+	}
+	return out
+}
+
+func b2i(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	cases := []struct {
+		data    string
+		level   Level
+		version int
+	}{
+		{"hello", M, 1},
+		{"00020101021129370016A000000677010111011300668012345675802TH53037646304FE29", M, 5},
+		{strings.Repeat("x", 150), M, 8},
+		{strings.Repeat("y", 200), L, 9},
+		{strings.Repeat("z", 140), Q, 10},
+	}
+	for _, tc := range cases {
+		c, err := Encode([]byte(tc.data), tc.level)
+		if err != nil {
+			t.Fatalf("%d bytes: %v", len(tc.data), err)
+		}
+		if c.Version != tc.version || c.Size != 17+4*tc.version {
+			t.Errorf("%d bytes at level %d: version %d, want %d", len(tc.data), tc.level, c.Version, tc.version)
+		}
+		if got := decode(t, c); string(got) != tc.data {
+			t.Errorf("decoded %q, want %q", got, tc.data)
+		}
+	}
+
+	if _, err := Encode(bytes.Repeat([]byte("a"), 300), M); err != ErrTooLong {
+		t.Errorf("expected ErrTooLong, got %v", err)
+	}
+}
+
+func TestPNG(t *testing.T) {
+	c, err := Encode([]byte("hello"), M)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := c.PNG(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size := (21 + 8) * 4; img.Bounds().Dx() != size || img.Bounds().Dy() != size {
+		t.Errorf("bounds = %v", img.Bounds())
+	}
+	// the top left finder starts after the quiet zone
+	if r, _, _, _ := img.At(17, 17).RGBA(); r != 0 {
+		t.Errorf("finder module not dark")
+	}
+	if r, _, _, _ := img.At(2, 2).RGBA(); r == 0 {
+		t.Errorf("quiet zone not light")
+	}
+}
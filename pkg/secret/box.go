@@ -0,0 +1,76 @@
+// Package secret encrypts sensitive blobs, such as signing certificates,
+// before they are stored.
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+)
+
+// KeySize is the length of a Box key (AES-256).
+const KeySize = 32
+
+var (
+	// ErrKeySize is returned for keys that are not KeySize bytes long.
+	ErrKeySize = errors.New("secret: key must be 32 bytes")
+	// ErrDecrypt is returned when a sealed value was tampered with or
+	// sealed under another key.
+	ErrDecrypt = errors.New("secret: cannot decrypt value")
+)
+
+// Box seals values with AES-256-GCM. Sealed values carry their random
+// nonce in front of the ciphertext.
+type Box struct {
+	aead cipher.AEAD
+}
+
+// NewBox returns a Box using key.
+func NewBox(key []byte) (*Box, error) {
+	if len(key) != KeySize {
+		return nil, ErrKeySize
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Box{aead: aead}, nil
+}
+
+// NewBoxFromBase64 returns a Box using a base64 encoded key, the form
+// keys take in configuration.
+func NewBoxFromBase64(key string) (*Box, error) {
+	b, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, ErrKeySize
+	}
+	return NewBox(b)
+}
+
+// Seal encrypts plaintext.
+func (b *Box) Seal(plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, b.aead.NonceSize(), b.aead.NonceSize()+len(plaintext)+b.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return b.aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// Open decrypts a value returned by Seal.
+func (b *Box) Open(sealed []byte) ([]byte, error) {
+	n := b.aead.NonceSize()
+	if len(sealed) < n+b.aead.Overhead() {
+		return nil, ErrDecrypt
+	}
+	out, err := b.aead.Open(nil, sealed[:n], sealed[n:], nil)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return out, nil
+}
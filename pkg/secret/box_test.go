@@ -0,0 +1,53 @@
+package secret
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestBoxRoundTrip(t *testing.T) {
+	box, err := NewBox(bytes.Repeat([]byte{7}, KeySize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain := []byte("certificate bytes")
+	sealed, err := box.Seal(plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(sealed, plain) {
+		t.Fatal("sealed value contains the plaintext")
+	}
+	again, _ := box.Seal(plain)
+	if bytes.Equal(sealed, again) {
+		t.Error("sealing twice produced the same output")
+	}
+	got, err := box.Open(sealed)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if !bytes.Equal(got, plain) {
+		t.Errorf("Open = %q", got)
+	}
+
+	sealed[len(sealed)-1] ^= 1
+	if _, err := box.Open(sealed); err != ErrDecrypt {
+		t.Errorf("tampered: err = %v", err)
+	}
+	other, _ := NewBox(bytes.Repeat([]byte{8}, KeySize))
+	if _, err := other.Open(again); err != ErrDecrypt {
+		t.Errorf("other key: err = %v", err)
+	}
+}
+
+func TestNewBoxKeySize(t *testing.T) {
+	if _, err := NewBox([]byte("short")); err != ErrKeySize {
+		t.Errorf("err = %v", err)
+	}
+	if _, err := NewBoxFromBase64("not base64!"); err != ErrKeySize {
+		t.Errorf("err = %v", err)
+	}
+	if _, err := NewBoxFromBase64("BwcHBwcHBwcHBwcHBwcHBwcHBwcHBwcHBwcHBwcHBwc="); err != nil {
+		t.Errorf("err = %v", err)
+	}
+}
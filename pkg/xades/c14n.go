@@ -0,0 +1,286 @@
+package xades
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+)
+
+// xnode is an element parsed with its namespace prefixes intact, as
+// canonicalization needs them.
+type xnode struct {
+	prefix, local string
+	attrs         []xattr
+	ns            map[string]string // declarations made on this element
+	parent        *xnode
+	children      []interface{} // *xnode or string
+}
+
+type xattr struct {
+	prefix, local, value string
+}
+
+// lookup resolves a namespace prefix in the scope of n.
+func (n *xnode) lookup(prefix string) string {
+	if prefix == "xml" {
+		return "http://www.w3.org/XML/1998/namespace"
+	}
+	for e := n; e != nil; e = e.parent {
+		if uri, ok := e.ns[prefix]; ok {
+			return uri
+		}
+	}
+	return ""
+}
+
+// is reports whether n is the element local in namespace uri.
+func (n *xnode) is(uri, local string) bool {
+	return n.local == local && n.lookup(n.prefix) == uri
+}
+
+func (n *xnode) attr(local string) string {
+	for _, a := range n.attrs {
+		if a.prefix == "" && a.local == local {
+			return a.value
+		}
+	}
+	return ""
+}
+
+func (n *xnode) text() string {
+	var sb strings.Builder
+	for _, c := range n.children {
+		if s, ok := c.(string); ok {
+			sb.WriteString(s)
+		}
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// child returns the first child element local in namespace uri.
+func (n *xnode) child(uri, local string) *xnode {
+	for _, c := range n.children {
+		if e, ok := c.(*xnode); ok && e.is(uri, local) {
+			return e
+		}
+	}
+	return nil
+}
+
+func (n *xnode) elements() []*xnode {
+	var out []*xnode
+	for _, c := range n.children {
+		if e, ok := c.(*xnode); ok {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// find returns the first element in the subtree of n matching f.
+func (n *xnode) find(f func(*xnode) bool) *xnode {
+	if f(n) {
+		return n
+	}
+	for _, e := range n.elements() {
+		if m := e.find(f); m != nil {
+			return m
+		}
+	}
+	return nil
+}
+
+func parseTree(data []byte) (*xnode, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	var root, cur *xnode
+	for {
+		tok, err := dec.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			n := &xnode{prefix: t.Name.Space, local: t.Name.Local, parent: cur, ns: map[string]string{}}
+			for _, a := range t.Attr {
+				switch {
+				case a.Name.Space == "xmlns":
+					n.ns[a.Name.Local] = a.Value
+				case a.Name.Space == "" && a.Name.Local == "xmlns":
+					n.ns[""] = a.Value
+				default:
+					n.attrs = append(n.attrs, xattr{prefix: a.Name.Space, local: a.Name.Local, value: a.Value})
+				}
+			}
+			if cur != nil {
+				cur.children = append(cur.children, n)
+			} else if root == nil {
+				root = n
+			} else {
+				return nil, errors.New("xades: multiple root elements")
+			}
+			cur = n
+		case xml.EndElement:
+			if cur == nil || t.Name.Local != cur.local || t.Name.Space != cur.prefix {
+				return nil, errors.New("xades: mismatched end element")
+			}
+			cur = cur.parent
+		case xml.CharData:
+			if cur != nil {
+				cur.children = append(cur.children, string(t))
+			}
+		}
+	}
+	if root == nil || cur != nil {
+		return nil, errors.New("xades: incomplete document")
+	}
+	return root, nil
+}
+
+// canonicalize renders n with Exclusive XML Canonicalization 1.0 without
+// comments, leaving out the element skip (used for the enveloped
+// signature transform).
+func canonicalize(n, skip *xnode) []byte {
+	var buf bytes.Buffer
+	writeCanonical(&buf, n, skip, map[string]string{})
+	return buf.Bytes()
+}
+
+func writeCanonical(buf *bytes.Buffer, n, skip *xnode, rendered map[string]string) {
+	// only namespaces visibly used by the element or its attributes are
+	// rendered, and only when an output ancestor has not already done so
+	used := []string{n.prefix}
+	for _, a := range n.attrs {
+		if a.prefix != "" {
+			used = append(used, a.prefix)
+		}
+	}
+	next := rendered
+	var decls []string
+	for _, p := range used {
+		if p == "xml" || containsString(decls, p) {
+			continue
+		}
+		uri := n.lookup(p)
+		prev, ok := rendered[p]
+		if (ok && prev == uri) || (!ok && p == "" && uri == "") {
+			continue
+		}
+		if len(decls) == 0 {
+			next = make(map[string]string, len(rendered)+1)
+			for k, v := range rendered {
+				next[k] = v
+			}
+		}
+		decls = append(decls, p)
+		next[p] = uri
+	}
+	sort.Strings(decls)
+
+	name := qualified(n.prefix, n.local)
+	buf.WriteByte('<')
+	buf.WriteString(name)
+	for _, p := range decls {
+		if p == "" {
+			buf.WriteString(` xmlns="`)
+		} else {
+			buf.WriteString(` xmlns:` + p + `="`)
+		}
+		escapeAttr(buf, next[p])
+		buf.WriteByte('"')
+	}
+
+	attrs := append([]xattr(nil), n.attrs...)
+	sort.Slice(attrs, func(i, j int) bool {
+		ni, nj := "", ""
+		if attrs[i].prefix != "" {
+			ni = n.lookup(attrs[i].prefix)
+		}
+		if attrs[j].prefix != "" {
+			nj = n.lookup(attrs[j].prefix)
+		}
+		if ni != nj {
+			return ni < nj
+		}
+		return attrs[i].local < attrs[j].local
+	})
+	for _, a := range attrs {
+		buf.WriteByte(' ')
+		buf.WriteString(qualified(a.prefix, a.local))
+		buf.WriteString(`="`)
+		escapeAttr(buf, a.value)
+		buf.WriteByte('"')
+	}
+	buf.WriteByte('>')
+
+	for _, c := range n.children {
+		switch v := c.(type) {
+		case string:
+			escapeText(buf, v)
+		case *xnode:
+			if v != skip {
+				writeCanonical(buf, v, skip, next)
+			}
+		}
+	}
+	buf.WriteString("</" + name + ">")
+}
+
+func qualified(prefix, local string) string {
+	if prefix == "" {
+		return local
+	}
+	return prefix + ":" + local
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func escapeText(buf *bytes.Buffer, s string) {
+	for _, r := range s {
+		switch r {
+		case '&':
+			buf.WriteString("&amp;")
+		case '<':
+			buf.WriteString("&lt;")
+		case '>':
+			buf.WriteString("&gt;")
+		case '\r':
+			buf.WriteString("&#xD;")
+		default:
+			buf.WriteRune(r)
+		}
+	}
+}
+
+func escapeAttr(buf *bytes.Buffer, s string) {
+	for _, r := range s {
+		switch r {
+		case '&':
+			buf.WriteString("&amp;")
+		case '<':
+			buf.WriteString("&lt;")
+		case '"':
+			buf.WriteString("&quot;")
+		case '\t':
+			buf.WriteString("&#x9;")
+		case '\n':
+			buf.WriteString("&#xA;")
+		case '\r':
+			buf.WriteString("&#xD;")
+		default:
+			buf.WriteRune(r)
+		}
+	}
+}
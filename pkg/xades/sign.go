@@ -0,0 +1,279 @@
+// Package xades signs XML documents with enveloped XAdES-BES signatures,
+// as required for ETDA e-Tax Invoice & e-Receipt, and verifies them
+// offline.
+package xades
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/pkcs12"
+)
+
+// Namespaces and algorithm identifiers used by the signature.
+const (
+	NamespaceDSig  = "http://www.w3.org/2000/09/xmldsig#"
+	NamespaceXAdES = "http://uri.etsi.org/01903/v1.3.2#"
+
+	AlgExcC14N      = "http://www.w3.org/2001/10/xml-exc-c14n#"
+	AlgEnveloped    = "http://www.w3.org/2000/09/xmldsig#enveloped-signature"
+	AlgSHA256       = "http://www.w3.org/2001/04/xmlenc#sha256"
+	AlgRSASHA256    = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha256"
+	AlgECDSASHA256  = "http://www.w3.org/2001/04/xmldsig-more#ecdsa-sha256"
+	TypeSignedProps = "http://uri.etsi.org/01903#SignedProperties"
+)
+
+// signingTimeLayout is the xs:dateTime layout of xades:SigningTime.
+const signingTimeLayout = "2006-01-02T15:04:05Z07:00"
+
+var (
+	// ErrUnsupportedKey is returned for private keys other than RSA and
+	// ECDSA.
+	ErrUnsupportedKey = errors.New("xades: unsupported private key type")
+	// ErrNoCertificate is returned when a PKCS #12 file has no certificate
+	// matching its private key.
+	ErrNoCertificate = errors.New("xades: no certificate for the private key")
+)
+
+// Signer holds the key and certificate documents are signed with.
+type Signer struct {
+	Key         crypto.Signer
+	Certificate *x509.Certificate
+}
+
+// LoadPKCS12 unlocks a PKCS #12 (.p12/.pfx) file. Intermediate
+// certificates in the file are ignored; the certificate matching the
+// private key is used for signing.
+func LoadPKCS12(data []byte, password string) (*Signer, error) {
+	blocks, err := pkcs12.ToPEM(data, password)
+	if err != nil {
+		return nil, fmt.Errorf("xades: %w", err)
+	}
+	var key crypto.Signer
+	var certs []*x509.Certificate
+	for _, b := range blocks {
+		switch b.Type {
+		case "PRIVATE KEY":
+			if key, err = parseKey(b); err != nil {
+				return nil, err
+			}
+		case "CERTIFICATE":
+			cert, err := x509.ParseCertificate(b.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("xades: %w", err)
+			}
+			certs = append(certs, cert)
+		}
+	}
+	if key == nil {
+		return nil, ErrUnsupportedKey
+	}
+	for _, cert := range certs {
+		if publicKeyEqual(cert.PublicKey, key.Public()) {
+			return &Signer{Key: key, Certificate: cert}, nil
+		}
+	}
+	return nil, ErrNoCertificate
+}
+
+// parseKey decodes a ToPEM key block, which holds PKCS #1 for RSA and
+// SEC 1 for ECDSA keys.
+func parseKey(b *pem.Block) (crypto.Signer, error) {
+	if k, err := x509.ParsePKCS1PrivateKey(b.Bytes); err == nil {
+		return k, nil
+	}
+	if k, err := x509.ParseECPrivateKey(b.Bytes); err == nil {
+		return k, nil
+	}
+	return nil, ErrUnsupportedKey
+}
+
+func publicKeyEqual(a, b crypto.PublicKey) bool {
+	k, ok := a.(interface{ Equal(crypto.PublicKey) bool })
+	return ok && k.Equal(b)
+}
+
+// Sign returns doc with an enveloped XAdES-BES signature appended as the
+// last child of the root element. The signature covers the whole document
+// and the signed properties (signing time and certificate).
+func (s *Signer) Sign(doc []byte) ([]byte, error) {
+	return s.sign(doc, time.Now())
+}
+
+func (s *Signer) sign(doc []byte, now time.Time) ([]byte, error) {
+	if s.Key == nil || s.Certificate == nil {
+		return nil, errors.New("xades: signer without key or certificate")
+	}
+	sigAlg, err := signatureAlgorithm(s.Key)
+	if err != nil {
+		return nil, err
+	}
+	root, err := parseTree(doc)
+	if err != nil {
+		return nil, fmt.Errorf("xades: %w", err)
+	}
+	end := bytes.LastIndex(doc, []byte("</"))
+	if end < 0 {
+		return nil, errors.New("xades: document has no closing root element")
+	}
+
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	sigID := "xmldsig-" + id
+	propsID := sigID + "-signedprops"
+
+	certDigest := sha256.Sum256(s.Certificate.Raw)
+	props := `<xades:SignedProperties Id="` + propsID + `">` +
+		`<xades:SignedSignatureProperties>` +
+		`<xades:SigningTime>` + now.UTC().Format(signingTimeLayout) + `</xades:SigningTime>` +
+		`<xades:SigningCertificate><xades:Cert>` +
+		`<xades:CertDigest>` +
+		`<ds:DigestMethod Algorithm="` + AlgSHA256 + `"></ds:DigestMethod>` +
+		`<ds:DigestValue>` + base64.StdEncoding.EncodeToString(certDigest[:]) + `</ds:DigestValue>` +
+		`</xades:CertDigest>` +
+		`<xades:IssuerSerial>` +
+		`<ds:X509IssuerName>` + escape(s.Certificate.Issuer.String()) + `</ds:X509IssuerName>` +
+		`<ds:X509SerialNumber>` + s.Certificate.SerialNumber.String() + `</ds:X509SerialNumber>` +
+		`</xades:IssuerSerial>` +
+		`</xades:Cert></xades:SigningCertificate>` +
+		`</xades:SignedSignatureProperties>` +
+		`</xades:SignedProperties>`
+	object := `<ds:Object><xades:QualifyingProperties xmlns:xades="` + NamespaceXAdES + `" Target="#` + sigID + `">` +
+		props + `</xades:QualifyingProperties></ds:Object>`
+
+	propsNode, err := fragment(object, func(n *xnode) bool { return n.is(NamespaceXAdES, "SignedProperties") })
+	if err != nil {
+		return nil, err
+	}
+	docDigest := sha256.Sum256(canonicalize(root, nil))
+	propsDigest := sha256.Sum256(canonicalize(propsNode, nil))
+
+	signedInfo := `<ds:SignedInfo>` +
+		`<ds:CanonicalizationMethod Algorithm="` + AlgExcC14N + `"></ds:CanonicalizationMethod>` +
+		`<ds:SignatureMethod Algorithm="` + sigAlg + `"></ds:SignatureMethod>` +
+		`<ds:Reference Id="` + sigID + `-ref0" URI="">` +
+		`<ds:Transforms>` +
+		`<ds:Transform Algorithm="` + AlgEnveloped + `"></ds:Transform>` +
+		`<ds:Transform Algorithm="` + AlgExcC14N + `"></ds:Transform>` +
+		`</ds:Transforms>` +
+		`<ds:DigestMethod Algorithm="` + AlgSHA256 + `"></ds:DigestMethod>` +
+		`<ds:DigestValue>` + base64.StdEncoding.EncodeToString(docDigest[:]) + `</ds:DigestValue>` +
+		`</ds:Reference>` +
+		`<ds:Reference Type="` + TypeSignedProps + `" URI="#` + propsID + `">` +
+		`<ds:Transforms><ds:Transform Algorithm="` + AlgExcC14N + `"></ds:Transform></ds:Transforms>` +
+		`<ds:DigestMethod Algorithm="` + AlgSHA256 + `"></ds:DigestMethod>` +
+		`<ds:DigestValue>` + base64.StdEncoding.EncodeToString(propsDigest[:]) + `</ds:DigestValue>` +
+		`</ds:Reference>` +
+		`</ds:SignedInfo>`
+
+	infoNode, err := fragment(signedInfo, func(n *xnode) bool { return n.is(NamespaceDSig, "SignedInfo") })
+	if err != nil {
+		return nil, err
+	}
+	value, err := signDigest(s.Key, canonicalize(infoNode, nil))
+	if err != nil {
+		return nil, err
+	}
+
+	signature := `<ds:Signature xmlns:ds="` + NamespaceDSig + `" Id="` + sigID + `">` +
+		signedInfo +
+		`<ds:SignatureValue>` + base64.StdEncoding.EncodeToString(value) + `</ds:SignatureValue>` +
+		`<ds:KeyInfo><ds:X509Data><ds:X509Certificate>` +
+		base64.StdEncoding.EncodeToString(s.Certificate.Raw) +
+		`</ds:X509Certificate></ds:X509Data></ds:KeyInfo>` +
+		object +
+		`</ds:Signature>`
+
+	out := make([]byte, 0, len(doc)+len(signature))
+	out = append(out, doc[:end]...)
+	out = append(out, signature...)
+	out = append(out, doc[end:]...)
+	return out, nil
+}
+
+// fragment parses part of the signature in the namespace context it will
+// have once inserted and returns the element matching f, so digests can
+// be computed before the signature is assembled.
+func fragment(s string, f func(*xnode) bool) (*xnode, error) {
+	root, err := parseTree([]byte(`<ds:Signature xmlns:ds="` + NamespaceDSig + `">` + s + `</ds:Signature>`))
+	if err != nil {
+		return nil, fmt.Errorf("xades: %w", err)
+	}
+	n := root.find(f)
+	if n == nil {
+		return nil, errors.New("xades: signature fragment not found")
+	}
+	return n, nil
+}
+
+func signatureAlgorithm(key crypto.Signer) (string, error) {
+	switch key.Public().(type) {
+	case *rsa.PublicKey:
+		return AlgRSASHA256, nil
+	case *ecdsa.PublicKey:
+		return AlgECDSASHA256, nil
+	}
+	return "", ErrUnsupportedKey
+}
+
+// signDigest signs the SHA-256 digest of data. ECDSA signatures are
+// encoded as the concatenated r and s values XML-DSig expects.
+func signDigest(key crypto.Signer, data []byte) ([]byte, error) {
+	digest := sha256.Sum256(data)
+	if k, ok := key.(*ecdsa.PrivateKey); ok {
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		if err != nil {
+			return nil, err
+		}
+		size := (k.Curve.Params().BitSize + 7) / 8
+		out := make([]byte, 2*size)
+		r.FillBytes(out[:size])
+		s.FillBytes(out[size:])
+		return out, nil
+	}
+	return key.Sign(rand.Reader, digest[:], crypto.SHA256)
+}
+
+func verifyDigest(pub crypto.PublicKey, data, sig []byte) bool {
+	digest := sha256.Sum256(data)
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], sig) == nil
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if len(sig) != 2*size {
+			return false
+		}
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		return ecdsa.Verify(k, digest[:], r, s)
+	}
+	return false
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+var textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func escape(s string) string { return textEscaper.Replace(s) }
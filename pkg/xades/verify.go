@@ -0,0 +1,232 @@
+package xades
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNoSignature is returned when a document carries no ds:Signature.
+	ErrNoSignature = errors.New("xades: document is not signed")
+	// ErrDigestMismatch is returned when signed content was changed after
+	// signing.
+	ErrDigestMismatch = errors.New("xades: reference digest mismatch")
+	// ErrBadSignature is returned when the signature value does not match
+	// the signed info.
+	ErrBadSignature = errors.New("xades: invalid signature value")
+	// ErrUnsupportedAlgorithm is returned for algorithms other than the ones
+	// Sign uses.
+	ErrUnsupportedAlgorithm = errors.New("xades: unsupported algorithm")
+	// ErrCertificateMismatch is returned when the signing certificate in
+	// the signed properties is not the certificate in the key info.
+	ErrCertificateMismatch = errors.New("xades: signing certificate mismatch")
+)
+
+// VerifyOptions controls Verify. Without Roots the certificate chain is
+// not checked, only that the document was signed by the embedded
+// certificate.
+type VerifyOptions struct {
+	Roots         *x509.CertPool
+	Intermediates *x509.CertPool
+	// CurrentTime is the time the chain is validated at; the signing time
+	// is used when zero.
+	CurrentTime time.Time
+}
+
+// Result describes a valid signature.
+type Result struct {
+	Certificate *x509.Certificate
+	SigningTime time.Time
+}
+
+// Verify checks the enveloped XAdES-BES signature of doc without network
+// access: both references must match, the signature value must verify
+// against the embedded certificate, and the signed properties must name
+// that certificate.
+func Verify(doc []byte, opts VerifyOptions) (*Result, error) {
+	root, err := parseTree(doc)
+	if err != nil {
+		return nil, fmt.Errorf("xades: %w", err)
+	}
+	sig := root.find(func(n *xnode) bool { return n.is(NamespaceDSig, "Signature") })
+	if sig == nil {
+		return nil, ErrNoSignature
+	}
+	info := sig.child(NamespaceDSig, "SignedInfo")
+	if info == nil {
+		return nil, errors.New("xades: missing SignedInfo")
+	}
+	if m := info.child(NamespaceDSig, "CanonicalizationMethod"); m == nil || m.attr("Algorithm") != AlgExcC14N {
+		return nil, ErrUnsupportedAlgorithm
+	}
+	method := info.child(NamespaceDSig, "SignatureMethod")
+	if method == nil {
+		return nil, ErrUnsupportedAlgorithm
+	}
+
+	var props *xnode
+	var coversDocument bool
+	for _, ref := range info.elements() {
+		if !ref.is(NamespaceDSig, "Reference") {
+			continue
+		}
+		target, enveloped, err := referenceTarget(root, ref)
+		if err != nil {
+			return nil, err
+		}
+		var skip *xnode
+		if enveloped {
+			skip = sig
+		}
+		digest := sha256.Sum256(canonicalize(target, skip))
+		value, err := base64.StdEncoding.DecodeString(ref.child(NamespaceDSig, "DigestValue").text())
+		if err != nil || !bytes.Equal(value, digest[:]) {
+			return nil, ErrDigestMismatch
+		}
+		switch {
+		case target == root && enveloped:
+			coversDocument = true
+		case ref.attr("Type") == TypeSignedProps && target.is(NamespaceXAdES, "SignedProperties"):
+			props = target
+		}
+	}
+	if !coversDocument || props == nil {
+		return nil, errors.New("xades: signature does not cover the document and its signed properties")
+	}
+
+	certNode := sig.find(func(n *xnode) bool { return n.is(NamespaceDSig, "X509Certificate") })
+	if certNode == nil {
+		return nil, errors.New("xades: missing signing certificate")
+	}
+	der, err := base64.StdEncoding.DecodeString(compact(certNode.text()))
+	if err != nil {
+		return nil, fmt.Errorf("xades: %w", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, fmt.Errorf("xades: %w", err)
+	}
+
+	sigValue := sig.child(NamespaceDSig, "SignatureValue")
+	if sigValue == nil {
+		return nil, ErrBadSignature
+	}
+	value, err := base64.StdEncoding.DecodeString(compact(sigValue.text()))
+	if err != nil {
+		return nil, ErrBadSignature
+	}
+	switch method.attr("Algorithm") {
+	case AlgRSASHA256, AlgECDSASHA256:
+	default:
+		return nil, ErrUnsupportedAlgorithm
+	}
+	if !verifyDigest(cert.PublicKey, canonicalize(info, nil), value) {
+		return nil, ErrBadSignature
+	}
+
+	res := &Result{Certificate: cert}
+	if err := checkSignedProperties(props, cert, res); err != nil {
+		return nil, err
+	}
+
+	if opts.Roots != nil {
+		at := opts.CurrentTime
+		if at.IsZero() {
+			at = res.SigningTime
+		}
+		_, err := cert.Verify(x509.VerifyOptions{
+			Roots:         opts.Roots,
+			Intermediates: opts.Intermediates,
+			CurrentTime:   at,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("xades: %w", err)
+		}
+	}
+	return res, nil
+}
+
+// referenceTarget resolves a same-document reference and checks its
+// transforms. enveloped reports whether the signature itself is excluded.
+func referenceTarget(root *xnode, ref *xnode) (target *xnode, enveloped bool, err error) {
+	if m := ref.child(NamespaceDSig, "DigestMethod"); m == nil || m.attr("Algorithm") != AlgSHA256 {
+		return nil, false, ErrUnsupportedAlgorithm
+	}
+	if ref.child(NamespaceDSig, "DigestValue") == nil {
+		return nil, false, errors.New("xades: missing DigestValue")
+	}
+	if transforms := ref.child(NamespaceDSig, "Transforms"); transforms != nil {
+		for _, t := range transforms.elements() {
+			switch t.attr("Algorithm") {
+			case AlgEnveloped:
+				enveloped = true
+			case AlgExcC14N:
+			default:
+				return nil, false, ErrUnsupportedAlgorithm
+			}
+		}
+	}
+
+	uri := ref.attr("URI")
+	switch {
+	case uri == "":
+		return root, enveloped, nil
+	case strings.HasPrefix(uri, "#"):
+		id := uri[1:]
+		target = root.find(func(n *xnode) bool { return n.attr("Id") == id })
+		if target == nil {
+			return nil, false, fmt.Errorf("xades: reference %q not found", uri)
+		}
+		return target, false, nil
+	}
+	return nil, false, fmt.Errorf("xades: external reference %q", uri)
+}
+
+func checkSignedProperties(props *xnode, cert *x509.Certificate, res *Result) error {
+	signed := props.child(NamespaceXAdES, "SignedSignatureProperties")
+	if signed == nil {
+		return errors.New("xades: missing SignedSignatureProperties")
+	}
+	if t := signed.child(NamespaceXAdES, "SigningTime"); t != nil {
+		at, err := time.Parse(signingTimeLayout, t.text())
+		if err != nil {
+			return fmt.Errorf("xades: signing time: %w", err)
+		}
+		res.SigningTime = at
+	}
+
+	digest := sha256.Sum256(cert.Raw)
+	certs := signed.child(NamespaceXAdES, "SigningCertificate")
+	if certs == nil {
+		return ErrCertificateMismatch
+	}
+	for _, c := range certs.elements() {
+		d := c.child(NamespaceXAdES, "CertDigest")
+		if d == nil {
+			continue
+		}
+		if m := d.child(NamespaceDSig, "DigestMethod"); m == nil || m.attr("Algorithm") != AlgSHA256 {
+			continue
+		}
+		v := d.child(NamespaceDSig, "DigestValue")
+		if v == nil {
+			continue
+		}
+		if value, err := base64.StdEncoding.DecodeString(v.text()); err == nil && bytes.Equal(value, digest[:]) {
+			return nil
+		}
+	}
+	return ErrCertificateMismatch
+}
+
+// compact strips the line breaks some signers put in base64 content.
+func compact(s string) string {
+	return strings.Join(strings.Fields(s), "")
+}
@@ -0,0 +1,176 @@
+package xades
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+)
+
+const testPassphrase = "test-passphrase"
+
+const testDoc = `<?xml version="1.0" encoding="UTF-8"?>
+<rsm:TaxInvoice_CrossIndustryInvoice xmlns:rsm="urn:etda:uncefact:data:standard:TaxInvoice_CrossIndustryInvoice:2" xmlns:ram="urn:etda:uncefact:data:standard:TaxInvoice_ReusableAggregateBusinessInformationEntity:2">
+  <rsm:ExchangedDocument>
+    <ram:ID>INV2567-000001</ram:ID>
+    <ram:Name schemeID="x" a="1">ใบแจ้งหนี้ &amp; ใบกำกับภาษี</ram:Name>
+  </rsm:ExchangedDocument>
+  <rsm:SupplyChainTradeTransaction>
+    <ram:GrandTotalAmount>1070.00</ram:GrandTotalAmount>
+  </rsm:SupplyChainTradeTransaction>
+</rsm:TaxInvoice_CrossIndustryInvoice>
+`
+
+func loadTestSigner(t *testing.T) *Signer {
+	t.Helper()
+	data, err := os.ReadFile("testdata/signer.p12")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := LoadPKCS12(data, testPassphrase)
+	if err != nil {
+		t.Fatalf("LoadPKCS12: %v", err)
+	}
+	return s
+}
+
+func TestLoadPKCS12WrongPassphrase(t *testing.T) {
+	data, err := os.ReadFile("testdata/signer.p12")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadPKCS12(data, "wrong"); err == nil {
+		t.Fatal("expected an error for a wrong passphrase")
+	}
+}
+
+func TestSignAndVerify(t *testing.T) {
+	s := loadTestSigner(t)
+	signed, err := s.Sign([]byte(testDoc))
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	if !bytes.HasPrefix(signed, []byte(`<?xml`)) || !bytes.Contains(signed, []byte(`</ds:Signature></rsm:TaxInvoice_CrossIndustryInvoice>`)) {
+		t.Fatalf("signature not enveloped in the root element:\n%s", signed)
+	}
+
+	res, err := Verify(signed, VerifyOptions{})
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if res.Certificate.Subject.CommonName != "Example e-Tax Signer" {
+		t.Errorf("certificate = %q", res.Certificate.Subject.CommonName)
+	}
+	if d := time.Since(res.SigningTime); d < 0 || d > time.Minute {
+		t.Errorf("signing time = %v", res.SigningTime)
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(s.Certificate)
+	if _, err := Verify(signed, VerifyOptions{Roots: roots}); err != nil {
+		t.Errorf("Verify with roots: %v", err)
+	}
+	if _, err := Verify(signed, VerifyOptions{Roots: x509.NewCertPool()}); err == nil {
+		t.Error("expected chain verification to fail against an empty pool")
+	}
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	s := loadTestSigner(t)
+	signed, err := s.Sign([]byte(testDoc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]struct {
+		old, new string
+		want     error
+	}{
+		"content":      {"1070.00", "1.00", ErrDigestMismatch},
+		"signing time": {"<xades:SigningTime>20", "<xades:SigningTime>19", ErrDigestMismatch},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			tampered := bytes.Replace(signed, []byte(tc.old), []byte(tc.new), 1)
+			if bytes.Equal(tampered, signed) {
+				t.Fatal("nothing replaced")
+			}
+			if _, err := Verify(tampered, VerifyOptions{}); !errors.Is(err, tc.want) {
+				t.Errorf("err = %v, want %v", err, tc.want)
+			}
+		})
+	}
+
+	if _, err := Verify([]byte(testDoc), VerifyOptions{}); !errors.Is(err, ErrNoSignature) {
+		t.Errorf("unsigned: err = %v", err)
+	}
+}
+
+func TestVerifyRejectsOtherKey(t *testing.T) {
+	s := loadTestSigner(t)
+	other := newECDSASigner(t)
+	signed, err := other.Sign([]byte(testDoc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Verify(signed, VerifyOptions{}); err != nil {
+		t.Fatalf("ECDSA signature: %v", err)
+	}
+
+	// swapping in another certificate must break the signature
+	swapped := bytes.Replace(signed, []byte(b64(other.Certificate.Raw)), []byte(b64(s.Certificate.Raw)), 1)
+	if _, err := Verify(swapped, VerifyOptions{}); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("err = %v, want %v", err, ErrBadSignature)
+	}
+}
+
+func TestCanonicalize(t *testing.T) {
+	doc := `<a:root xmlns:a="urn:a" xmlns:b="urn:b" z="1" b:y="2" a:x="3"><b:child/><c xmlns="urn:c">x &gt; y</c></a:root>`
+	want := `<a:root xmlns:a="urn:a" xmlns:b="urn:b" z="1" a:x="3" b:y="2"><b:child></b:child><c xmlns="urn:c">x &gt; y</c></a:root>`
+	root, err := parseTree([]byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(canonicalize(root, nil)); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+
+	// exclusive c14n drops declarations the subtree does not use
+	child := root.elements()[0]
+	if got := string(canonicalize(child, nil)); got != `<b:child xmlns:b="urn:b"></b:child>` {
+		t.Errorf("subtree = %s", got)
+	}
+}
+
+func newECDSASigner(t *testing.T) *Signer {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "Other Signer"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Signer{Key: key, Certificate: cert}
+}
+
+func b64(b []byte) string { return base64.StdEncoding.EncodeToString(b) }
@@ -0,0 +1,232 @@
+// Package xlsx writes simple Office Open XML spreadsheets: one or more
+// sheets of text and number cells with a bold and an amount style, enough
+// for reports to be opened in Excel, LibreOffice or Google Sheets. Text is
+// stored inline, so any script, including Thai, round-trips unchanged.
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Style of a cell.
+type Style int
+
+const (
+	StyleNone Style = iota
+	// StyleBold is used for headings and totals.
+	StyleBold
+	// StyleAmount shows a number with thousands separators and two
+	// decimals.
+	StyleAmount
+	// StyleBoldAmount is StyleAmount in bold.
+	StyleBoldAmount
+)
+
+// Cell is one cell of a row. Number holds a decimal number in its
+// canonical form, e.g. "-1234.50"; cells without one are text.
+type Cell struct {
+	Text   string
+	Number string
+	Style  Style
+}
+
+// Text returns a text cell.
+func Text(s string) Cell { return Cell{Text: s} }
+
+// Bold returns a bold text cell.
+func Bold(s string) Cell { return Cell{Text: s, Style: StyleBold} }
+
+// Number returns a number cell from its decimal representation.
+func Number(s string) Cell { return Cell{Number: s} }
+
+// Int returns an integer number cell.
+func Int(n int) Cell { return Cell{Number: strconv.Itoa(n)} }
+
+// Amount returns a number cell shown as an amount.
+func Amount(s string) Cell { return Cell{Number: s, Style: StyleAmount} }
+
+// Sheet is a worksheet of a Workbook.
+type Sheet struct {
+	name   string
+	rows   [][]Cell
+	widths []float64
+}
+
+// Workbook is a spreadsheet under construction.
+type Workbook struct {
+	sheets []*Sheet
+}
+
+// New creates an empty workbook.
+func New() *Workbook { return &Workbook{} }
+
+// AddSheet appends a sheet. Names are cut to the 31 characters Excel
+// allows.
+func (w *Workbook) AddSheet(name string) *Sheet {
+	if r := []rune(name); len(r) > 31 {
+		name = string(r[:31])
+	}
+	s := &Sheet{name: name}
+	w.sheets = append(w.sheets, s)
+	return s
+}
+
+// AddRow appends a row of cells.
+func (s *Sheet) AddRow(cells ...Cell) {
+	s.rows = append(s.rows, cells)
+}
+
+// SetWidths sets the widths of the first columns in characters.
+func (s *Sheet) SetWidths(widths ...float64) {
+	s.widths = widths
+}
+
+// Bytes returns the workbook as an .xlsx file.
+func (w *Workbook) Bytes() ([]byte, error) {
+	if len(w.sheets) == 0 {
+		return nil, errors.New("xlsx: workbook has no sheets")
+	}
+	var buf bytes.Buffer
+	z := zip.NewWriter(&buf)
+	files := []struct{ name, body string }{
+		{"[Content_Types].xml", w.contentTypes()},
+		{"_rels/.rels", rootRels},
+		{"xl/workbook.xml", w.workbook()},
+		{"xl/_rels/workbook.xml.rels", w.workbookRels()},
+		{"xl/styles.xml", styles},
+	}
+	for i, s := range w.sheets {
+		body, err := s.xml()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, struct{ name, body string }{fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), body})
+	}
+	for _, f := range files {
+		fw, err := z.Create(f.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := fw.Write([]byte(xml.Header + f.body)); err != nil {
+			return nil, err
+		}
+	}
+	if err := z.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+const rootRels = `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+// styles defines the cell formats in the order of the Style constants.
+// Number format 4 is the built-in "#,##0.00".
+const styles = `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="4">` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+	`<xf numFmtId="4" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="4" fontId="1" fillId="0" borderId="0" xfId="0" applyNumberFormat="1" applyFont="1"/>` +
+	`</cellXfs>` +
+	`</styleSheet>`
+
+func (w *Workbook) contentTypes() string {
+	var b strings.Builder
+	b.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	b.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	b.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	b.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	b.WriteString(`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	for i := range w.sheets {
+		fmt.Fprintf(&b, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i+1)
+	}
+	b.WriteString(`</Types>`)
+	return b.String()
+}
+
+func (w *Workbook) workbook() string {
+	var b strings.Builder
+	b.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	for i, s := range w.sheets {
+		fmt.Fprintf(&b, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, escape(s.name), i+1, i+1)
+	}
+	b.WriteString(`</sheets></workbook>`)
+	return b.String()
+}
+
+func (w *Workbook) workbookRels() string {
+	var b strings.Builder
+	b.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i := range w.sheets {
+		fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, i+1)
+	}
+	fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, len(w.sheets)+1)
+	b.WriteString(`</Relationships>`)
+	return b.String()
+}
+
+func (s *Sheet) xml() (string, error) {
+	var b strings.Builder
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	if len(s.widths) > 0 {
+		b.WriteString(`<cols>`)
+		for i, w := range s.widths {
+			fmt.Fprintf(&b, `<col min="%d" max="%d" width="%s" customWidth="1"/>`, i+1, i+1, strconv.FormatFloat(w, 'f', -1, 64))
+		}
+		b.WriteString(`</cols>`)
+	}
+	b.WriteString(`<sheetData>`)
+	for r, row := range s.rows {
+		fmt.Fprintf(&b, `<row r="%d">`, r+1)
+		for c, cell := range row {
+			ref := ColumnName(c) + strconv.Itoa(r+1)
+			style := ""
+			if cell.Style != StyleNone {
+				style = fmt.Sprintf(` s="%d"`, cell.Style)
+			}
+			if cell.Number != "" {
+				if _, err := strconv.ParseFloat(cell.Number, 64); err != nil {
+					return "", fmt.Errorf("xlsx: cell %s: invalid number %q", ref, cell.Number)
+				}
+				fmt.Fprintf(&b, `<c r="%s"%s><v>%s</v></c>`, ref, style, cell.Number)
+				continue
+			}
+			if cell.Text == "" && style == "" {
+				continue
+			}
+			fmt.Fprintf(&b, `<c r="%s"%s t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, style, escape(cell.Text))
+		}
+		b.WriteString(`</row>`)
+	}
+	b.WriteString(`</sheetData></worksheet>`)
+	return b.String(), nil
+}
+
+// ColumnName returns the letters of the zero based column i: A, B, ...,
+// Z, AA, AB and so on.
+func ColumnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+func escape(s string) string {
+	var b strings.Builder
+	// xml.EscapeText only fails when the writer does
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
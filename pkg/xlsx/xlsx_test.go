@@ -0,0 +1,88 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+)
+
+func readZip(t *testing.T, b []byte) map[string]string {
+	t.Helper()
+	z, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{}
+	for _, f := range z.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[f.Name] = string(data)
+	}
+	return files
+}
+
+func TestWorkbookBytes(t *testing.T) {
+	w := New()
+	s := w.AddSheet("ภาษีขาย ตุลาคม 2569")
+	s.SetWidths(8, 30)
+	s.AddRow(Bold("ลำดับ"), Bold("ชื่อผู้ซื้อ <& co>"))
+	s.AddRow(Int(1), Text("บริษัท ตัวอย่าง จำกัด"), Amount("-1234.50"))
+	b, err := w.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := readZip(t, b)
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml", "xl/worksheets/sheet1.xml"} {
+		body, ok := files[name]
+		if !ok {
+			t.Fatalf("missing %s", name)
+		}
+		if err := xml.Unmarshal([]byte(body), new(struct{})); err != nil {
+			t.Errorf("%s is not well-formed: %v", name, err)
+		}
+	}
+	sheet := files["xl/worksheets/sheet1.xml"]
+	for _, want := range []string{
+		`<c r="B1" s="1" t="inlineStr"><is><t xml:space="preserve">ชื่อผู้ซื้อ &lt;&amp; co&gt;</t></is></c>`,
+		`<c r="A2"><v>1</v></c>`,
+		`<c r="C2" s="2"><v>-1234.50</v></c>`,
+		`<col min="2" max="2" width="30" customWidth="1"/>`,
+	} {
+		if !strings.Contains(sheet, want) {
+			t.Errorf("sheet missing %s", want)
+		}
+	}
+	if !strings.Contains(files["xl/workbook.xml"], `name="ภาษีขาย ตุลาคม 2569"`) {
+		t.Error("sheet name not written")
+	}
+}
+
+func TestInvalidNumber(t *testing.T) {
+	w := New()
+	w.AddSheet("a").AddRow(Number("1,000"))
+	if _, err := w.Bytes(); err == nil {
+		t.Fatal("expected an error for a formatted number")
+	}
+	if _, err := New().Bytes(); err == nil {
+		t.Fatal("expected an error for a workbook without sheets")
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	for i, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"} {
+		if got := ColumnName(i); got != want {
+			t.Errorf("ColumnName(%d) = %s, want %s", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,703 @@
+// Package xsd validates XML documents against a practical subset of XML
+// Schema 1.0: global and local elements with minOccurs/maxOccurs,
+// sequences, attributes, simple content, simple type restrictions
+// (enumeration, pattern, length and fractionDigits facets), xs:import and
+// chameleon xs:include. Schemas using other constructs fail to load rather
+// than being validated partially.
+package xsd
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Namespace is the XML Schema namespace.
+const Namespace = "http://www.w3.org/2001/XMLSchema"
+
+const xsiNamespace = "http://www.w3.org/2001/XMLSchema-instance"
+
+// maxErrors bounds the number of problems reported for one document.
+const maxErrors = 50
+
+// ValidationError lists the problems found in a document.
+type ValidationError struct {
+	Errors []string
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Errors) == 1 {
+		return "xsd: " + e.Errors[0]
+	}
+	return fmt.Sprintf("xsd: %s (and %d more)", e.Errors[0], len(e.Errors)-1)
+}
+
+// Schema is a compiled set of schema documents.
+type Schema struct {
+	elements map[xml.Name]*element
+	types    map[xml.Name]*typeDef
+	loaded   map[string]bool
+	fsys     fs.FS
+	unres    []func() error
+}
+
+type element struct {
+	name     xml.Name
+	typeName xml.Name
+	typ      *typeDef
+	min, max int // max < 0 means unbounded
+}
+
+type attribute struct {
+	name     string
+	typeName xml.Name
+	typ      *typeDef
+	required bool
+}
+
+type typeDef struct {
+	name    xml.Name
+	simple  bool
+	builtin string
+
+	// simple type restriction
+	baseName       xml.Name
+	base           *typeDef
+	enums          []string
+	patterns       []*regexp.Regexp
+	minLen, maxLen int
+	fractionDigits int
+
+	// complex type
+	sequence []*element
+	attrs    []*attribute
+	content  *typeDef // simple content
+}
+
+// Load reads and compiles the schema documents at paths within fsys,
+// following their imports and includes.
+func Load(fsys fs.FS, paths ...string) (*Schema, error) {
+	s := &Schema{
+		elements: make(map[xml.Name]*element),
+		types:    make(map[xml.Name]*typeDef),
+		loaded:   make(map[string]bool),
+		fsys:     fsys,
+	}
+	for _, p := range paths {
+		if err := s.loadFile(p, "", false); err != nil {
+			return nil, err
+		}
+	}
+	for _, f := range s.unres {
+		if err := f(); err != nil {
+			return nil, err
+		}
+	}
+	s.unres = nil
+	return s, nil
+}
+
+// node is a parsed XML element with its in-scope namespace prefixes.
+type node struct {
+	name     xml.Name
+	attrs    []xml.Attr
+	children []*node
+	text     strings.Builder
+	prefixes map[string]string
+}
+
+func (n *node) attr(local string) (string, bool) {
+	for _, a := range n.attrs {
+		if a.Name.Space == "" && a.Name.Local == local {
+			return a.Value, true
+		}
+	}
+	return "", false
+}
+
+func parse(r io.Reader) (*node, error) {
+	dec := xml.NewDecoder(r)
+	var stack []*node
+	var root *node
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			n := &node{name: t.Name, prefixes: make(map[string]string)}
+			if len(stack) > 0 {
+				for k, v := range stack[len(stack)-1].prefixes {
+					n.prefixes[k] = v
+				}
+			}
+			for _, a := range t.Attr {
+				switch {
+				case a.Name.Space == "xmlns":
+					n.prefixes[a.Name.Local] = a.Value
+				case a.Name.Space == "" && a.Name.Local == "xmlns":
+					n.prefixes[""] = a.Value
+				default:
+					n.attrs = append(n.attrs, a)
+				}
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, n)
+			} else if root == nil {
+				root = n
+			}
+			stack = append(stack, n)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(t)
+			}
+		}
+	}
+	if root == nil {
+		return nil, errors.New("xsd: empty document")
+	}
+	return root, nil
+}
+
+// schemaDoc holds the context needed to compile one schema document.
+type schemaDoc struct {
+	path      string
+	tns       string
+	qualified bool
+}
+
+func (s *Schema) loadFile(p, chameleonNS string, chameleon bool) error {
+	key := p + "\x00" + chameleonNS
+	if s.loaded[key] {
+		return nil
+	}
+	s.loaded[key] = true
+
+	f, err := s.fsys.Open(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	root, err := parse(f)
+	if err != nil {
+		return fmt.Errorf("xsd: %s: %w", p, err)
+	}
+	if root.name != (xml.Name{Space: Namespace, Local: "schema"}) {
+		return fmt.Errorf("xsd: %s: not a schema document", p)
+	}
+	doc := &schemaDoc{path: p}
+	doc.tns, _ = root.attr("targetNamespace")
+	if chameleon && doc.tns == "" {
+		doc.tns = chameleonNS
+	}
+	efd, _ := root.attr("elementFormDefault")
+	doc.qualified = efd == "qualified"
+
+	for _, c := range root.children {
+		if c.name.Space != Namespace {
+			continue
+		}
+		var err error
+		switch c.name.Local {
+		case "annotation":
+		case "import":
+			if loc, ok := c.attr("schemaLocation"); ok {
+				err = s.loadFile(path.Join(path.Dir(p), loc), "", false)
+			}
+		case "include":
+			loc, _ := c.attr("schemaLocation")
+			err = s.loadFile(path.Join(path.Dir(p), loc), doc.tns, true)
+		case "element":
+			var e *element
+			if e, err = s.element(doc, c, true); err == nil {
+				s.elements[e.name] = e
+			}
+		case "complexType", "simpleType":
+			name, _ := c.attr("name")
+			var t *typeDef
+			if t, err = s.typeDef(doc, c); err == nil {
+				t.name = xml.Name{Space: doc.tns, Local: name}
+				s.types[t.name] = t
+			}
+		default:
+			err = fmt.Errorf("xsd: %s: unsupported top-level %s", p, c.name.Local)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// qname resolves a prefixed name from a schema attribute. Unprefixed names
+// without a default namespace belong to the target namespace, which makes
+// chameleon includes work.
+func (s *Schema) qname(doc *schemaDoc, n *node, v string) xml.Name {
+	prefix, local := "", v
+	if i := strings.IndexByte(v, ':'); i >= 0 {
+		prefix, local = v[:i], v[i+1:]
+	}
+	if ns, ok := n.prefixes[prefix]; ok {
+		if ns == "" {
+			ns = doc.tns
+		}
+		return xml.Name{Space: ns, Local: local}
+	}
+	return xml.Name{Space: doc.tns, Local: local}
+}
+
+func occurs(n *node, attr string, def int) (int, error) {
+	v, ok := n.attr(attr)
+	if !ok {
+		return def, nil
+	}
+	if v == "unbounded" {
+		return -1, nil
+	}
+	return strconv.Atoi(v)
+}
+
+func (s *Schema) element(doc *schemaDoc, n *node, global bool) (*element, error) {
+	e := &element{}
+	var err error
+	if e.min, err = occurs(n, "minOccurs", 1); err != nil {
+		return nil, err
+	}
+	if e.max, err = occurs(n, "maxOccurs", 1); err != nil {
+		return nil, err
+	}
+
+	if ref, ok := n.attr("ref"); ok {
+		refName := s.qname(doc, n, ref)
+		e.name = refName
+		s.unres = append(s.unres, func() error {
+			g, ok := s.elements[refName]
+			if !ok {
+				return fmt.Errorf("xsd: %s: unknown element %s", doc.path, ref)
+			}
+			if g.typ == nil {
+				// the global element's own type may not be resolved yet
+				typ, err := s.lookup(g.typeName)
+				if err != nil {
+					return err
+				}
+				g.typ = typ
+			}
+			e.typ = g.typ
+			return nil
+		})
+		return e, nil
+	}
+
+	name, _ := n.attr("name")
+	e.name = xml.Name{Local: name}
+	if global || doc.qualified {
+		e.name.Space = doc.tns
+	}
+	if t, ok := n.attr("type"); ok {
+		e.typeName = s.qname(doc, n, t)
+		s.unres = append(s.unres, func() error {
+			typ, err := s.lookup(e.typeName)
+			e.typ = typ
+			return err
+		})
+		return e, nil
+	}
+	for _, c := range n.children {
+		if c.name.Space == Namespace && (c.name.Local == "complexType" || c.name.Local == "simpleType") {
+			if e.typ, err = s.typeDef(doc, c); err != nil {
+				return nil, err
+			}
+			return e, nil
+		}
+	}
+	// an element without a type accepts any text
+	e.typ = &typeDef{simple: true, builtin: "string", minLen: -1, maxLen: -1, fractionDigits: -1}
+	return e, nil
+}
+
+func (s *Schema) lookup(name xml.Name) (*typeDef, error) {
+	if name.Space == Namespace {
+		if !builtinTypes[name.Local] {
+			return nil, fmt.Errorf("xsd: unsupported built-in type %s", name.Local)
+		}
+		return &typeDef{name: name, simple: true, builtin: name.Local, minLen: -1, maxLen: -1, fractionDigits: -1}, nil
+	}
+	t, ok := s.types[name]
+	if !ok {
+		return nil, fmt.Errorf("xsd: unknown type {%s}%s", name.Space, name.Local)
+	}
+	return t, nil
+}
+
+func (s *Schema) typeDef(doc *schemaDoc, n *node) (*typeDef, error) {
+	t := &typeDef{minLen: -1, maxLen: -1, fractionDigits: -1}
+	if n.name.Local == "simpleType" {
+		t.simple = true
+		for _, c := range n.children {
+			if c.name.Space != Namespace || c.name.Local == "annotation" {
+				continue
+			}
+			if c.name.Local != "restriction" {
+				return nil, fmt.Errorf("xsd: %s: unsupported simpleType %s", doc.path, c.name.Local)
+			}
+			if err := s.restriction(doc, c, t); err != nil {
+				return nil, err
+			}
+		}
+		return t, nil
+	}
+
+	for _, c := range n.children {
+		if c.name.Space != Namespace {
+			continue
+		}
+		var err error
+		switch c.name.Local {
+		case "annotation":
+		case "sequence":
+			err = s.sequence(doc, c, t)
+		case "attribute":
+			err = s.attribute(doc, c, t)
+		case "simpleContent":
+			err = s.simpleContent(doc, c, t)
+		default:
+			err = fmt.Errorf("xsd: %s: unsupported complexType content %s", doc.path, c.name.Local)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+func (s *Schema) restriction(doc *schemaDoc, n *node, t *typeDef) error {
+	base, _ := n.attr("base")
+	t.baseName = s.qname(doc, n, base)
+	s.unres = append(s.unres, func() error {
+		b, err := s.lookup(t.baseName)
+		t.base = b
+		return err
+	})
+	for _, f := range n.children {
+		if f.name.Space != Namespace {
+			continue
+		}
+		v, _ := f.attr("value")
+		var err error
+		switch f.name.Local {
+		case "annotation":
+		case "enumeration":
+			t.enums = append(t.enums, v)
+		case "pattern":
+			var re *regexp.Regexp
+			if re, err = regexp.Compile("^(?:" + v + ")$"); err == nil {
+				t.patterns = append(t.patterns, re)
+			}
+		case "length":
+			t.minLen, err = strconv.Atoi(v)
+			t.maxLen = t.minLen
+		case "minLength":
+			t.minLen, err = strconv.Atoi(v)
+		case "maxLength":
+			t.maxLen, err = strconv.Atoi(v)
+		case "fractionDigits":
+			t.fractionDigits, err = strconv.Atoi(v)
+		default:
+			err = fmt.Errorf("xsd: %s: unsupported facet %s", doc.path, f.name.Local)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Schema) sequence(doc *schemaDoc, n *node, t *typeDef) error {
+	for _, c := range n.children {
+		if c.name.Space != Namespace || c.name.Local == "annotation" {
+			continue
+		}
+		if c.name.Local != "element" {
+			return fmt.Errorf("xsd: %s: unsupported sequence particle %s", doc.path, c.name.Local)
+		}
+		e, err := s.element(doc, c, false)
+		if err != nil {
+			return err
+		}
+		t.sequence = append(t.sequence, e)
+	}
+	return nil
+}
+
+func (s *Schema) attribute(doc *schemaDoc, n *node, t *typeDef) error {
+	name, _ := n.attr("name")
+	use, _ := n.attr("use")
+	a := &attribute{name: name, required: use == "required"}
+	if typ, ok := n.attr("type"); ok {
+		a.typeName = s.qname(doc, n, typ)
+		s.unres = append(s.unres, func() error {
+			at, err := s.lookup(a.typeName)
+			a.typ = at
+			return err
+		})
+	} else {
+		a.typ = &typeDef{simple: true, builtin: "string", minLen: -1, maxLen: -1, fractionDigits: -1}
+		for _, c := range n.children {
+			if c.name.Space == Namespace && c.name.Local == "simpleType" {
+				st, err := s.typeDef(doc, c)
+				if err != nil {
+					return err
+				}
+				a.typ = st
+			}
+		}
+	}
+	t.attrs = append(t.attrs, a)
+	return nil
+}
+
+func (s *Schema) simpleContent(doc *schemaDoc, n *node, t *typeDef) error {
+	for _, c := range n.children {
+		if c.name.Space != Namespace || c.name.Local == "annotation" {
+			continue
+		}
+		if c.name.Local != "extension" {
+			return fmt.Errorf("xsd: %s: unsupported simpleContent %s", doc.path, c.name.Local)
+		}
+		base, _ := c.attr("base")
+		baseName := s.qname(doc, c, base)
+		s.unres = append(s.unres, func() error {
+			b, err := s.lookup(baseName)
+			if err != nil {
+				return err
+			}
+			if b.simple {
+				t.content = b
+				return nil
+			}
+			if b.content == nil {
+				return fmt.Errorf("xsd: %s: simpleContent base %s has no simple content", doc.path, base)
+			}
+			// extending a complex type with simple content inherits its
+			// attributes too
+			t.content = b.content
+			t.attrs = append(append([]*attribute(nil), b.attrs...), t.attrs...)
+			return nil
+		})
+		for _, a := range c.children {
+			if a.name.Space == Namespace && a.name.Local == "attribute" {
+				if err := s.attribute(doc, a, t); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Validate checks the document read from r against the schema.
+func (s *Schema) Validate(r io.Reader) error {
+	root, err := parse(r)
+	if err != nil {
+		return &ValidationError{Errors: []string{err.Error()}}
+	}
+	v := &validator{}
+	decl, ok := s.elements[root.name]
+	if !ok {
+		v.fail("/"+root.name.Local, "unexpected root element {%s}%s", root.name.Space, root.name.Local)
+	} else {
+		v.element(root, decl.typ, "/"+root.name.Local)
+	}
+	if len(v.errs) > 0 {
+		return &ValidationError{Errors: v.errs}
+	}
+	return nil
+}
+
+type validator struct {
+	errs []string
+}
+
+func (v *validator) fail(p, format string, args ...interface{}) {
+	if len(v.errs) < maxErrors {
+		v.errs = append(v.errs, p+": "+fmt.Sprintf(format, args...))
+	}
+}
+
+func (v *validator) element(n *node, t *typeDef, p string) {
+	if t.simple {
+		if len(n.children) > 0 {
+			v.fail(p, "element must not have child elements")
+		}
+		for _, a := range n.attrs {
+			if a.Name.Space != xsiNamespace {
+				v.fail(p, "unexpected attribute %s", a.Name.Local)
+			}
+		}
+		v.value(n.text.String(), t, p)
+		return
+	}
+
+	v.attributes(n, t, p)
+	if t.content != nil {
+		if len(n.children) > 0 {
+			v.fail(p, "element must not have child elements")
+		}
+		v.value(n.text.String(), t.content, p)
+		return
+	}
+	if strings.TrimSpace(n.text.String()) != "" {
+		v.fail(p, "unexpected text content")
+	}
+
+	i := 0
+	for _, decl := range t.sequence {
+		count := 0
+		for i < len(n.children) && n.children[i].name == decl.name && (decl.max < 0 || count < decl.max) {
+			c := n.children[i]
+			v.element(c, decl.typ, p+"/"+c.name.Local)
+			i++
+			count++
+		}
+		if count < decl.min {
+			v.fail(p, "missing element %s", decl.name.Local)
+		}
+	}
+	if i < len(n.children) {
+		v.fail(p, "unexpected element %s", n.children[i].name.Local)
+	}
+}
+
+func (v *validator) attributes(n *node, t *typeDef, p string) {
+	seen := make(map[string]bool)
+	for _, a := range n.attrs {
+		if a.Name.Space == xsiNamespace {
+			continue
+		}
+		var decl *attribute
+		for _, d := range t.attrs {
+			if a.Name.Space == "" && d.name == a.Name.Local {
+				decl = d
+				break
+			}
+		}
+		if decl == nil {
+			v.fail(p, "unexpected attribute %s", a.Name.Local)
+			continue
+		}
+		seen[decl.name] = true
+		v.value(a.Value, decl.typ, p+"/@"+decl.name)
+	}
+	for _, d := range t.attrs {
+		if d.required && !seen[d.name] {
+			v.fail(p, "missing attribute %s", d.name)
+		}
+	}
+}
+
+// value checks a lexical value against a simple type and its bases.
+func (v *validator) value(s string, t *typeDef, p string) {
+	for ; t != nil; t = t.base {
+		if t.builtin != "" {
+			if t.builtin != "string" {
+				s = strings.TrimSpace(s)
+			}
+			if !builtinValid(t.builtin, s) {
+				v.fail(p, "%q is not a valid %s", s, t.builtin)
+			}
+			return
+		}
+		if len(t.enums) > 0 && !contains(t.enums, strings.TrimSpace(s)) {
+			v.fail(p, "%q is not one of %s", s, strings.Join(t.enums, ", "))
+		}
+		for _, re := range t.patterns {
+			if !re.MatchString(strings.TrimSpace(s)) {
+				v.fail(p, "%q does not match pattern %s", s, re.String())
+			}
+		}
+		l := len([]rune(s))
+		if t.minLen >= 0 && l < t.minLen {
+			v.fail(p, "value is shorter than %d characters", t.minLen)
+		}
+		if t.maxLen >= 0 && l > t.maxLen {
+			v.fail(p, "value is longer than %d characters", t.maxLen)
+		}
+		if t.fractionDigits >= 0 {
+			if i := strings.IndexByte(s, '.'); i >= 0 && len(strings.TrimRight(strings.TrimSpace(s[i+1:]), "0")) > t.fractionDigits {
+				v.fail(p, "%q has more than %d fraction digits", s, t.fractionDigits)
+			}
+		}
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+var builtinTypes = map[string]bool{
+	"string": true, "normalizedString": true, "token": true, "anyURI": true,
+	"decimal": true, "integer": true, "nonNegativeInteger": true, "positiveInteger": true,
+	"boolean": true, "date": true, "dateTime": true, "base64Binary": true,
+}
+
+var (
+	decimalRe  = regexp.MustCompile(`^[+-]?(\d+(\.\d*)?|\.\d+)$`)
+	integerRe  = regexp.MustCompile(`^[+-]?\d+$`)
+	dateRe     = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})(Z|[+-]\d{2}:\d{2})?$`)
+	dateTimeRe = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2})(\.\d+)?(Z|[+-]\d{2}:\d{2})?$`)
+	base64Re   = regexp.MustCompile(`^[A-Za-z0-9+/=\s]*$`)
+)
+
+func builtinValid(typ, s string) bool {
+	switch typ {
+	case "decimal":
+		return decimalRe.MatchString(s)
+	case "integer":
+		return integerRe.MatchString(s)
+	case "nonNegativeInteger":
+		return integerRe.MatchString(s) && !strings.HasPrefix(s, "-")
+	case "positiveInteger":
+		n, err := strconv.ParseUint(strings.TrimPrefix(s, "+"), 10, 64)
+		return err == nil && n > 0
+	case "boolean":
+		return s == "true" || s == "false" || s == "1" || s == "0"
+	case "date":
+		m := dateRe.FindStringSubmatch(s)
+		if m == nil {
+			return false
+		}
+		_, err := time.Parse("2006-01-02", m[1])
+		return err == nil
+	case "dateTime":
+		m := dateTimeRe.FindStringSubmatch(s)
+		if m == nil {
+			return false
+		}
+		_, err := time.Parse("2006-01-02T15:04:05", m[1])
+		return err == nil
+	case "base64Binary":
+		return base64Re.MatchString(s)
+	default:
+		return true
+	}
+}
@@ -0,0 +1,111 @@
+package xsd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+var testFS = fstest.MapFS{
+	"root.xsd": {Data: []byte(`<?xml version="1.0"?>
+<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" xmlns:c="urn:test:common"
+  targetNamespace="urn:test:root" elementFormDefault="qualified">
+  <xs:import namespace="urn:test:common" schemaLocation="common.xsd"/>
+  <xs:element name="Order">
+    <xs:complexType>
+      <xs:sequence>
+        <xs:element name="Header" type="c:HeaderType"/>
+        <xs:element name="Line" type="c:LineType" maxOccurs="unbounded"/>
+      </xs:sequence>
+    </xs:complexType>
+  </xs:element>
+</xs:schema>`)},
+	"common.xsd": {Data: []byte(`<?xml version="1.0"?>
+<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" targetNamespace="urn:test:common" elementFormDefault="qualified">
+  <xs:include schemaLocation="types.xsd"/>
+</xs:schema>`)},
+	"types.xsd": {Data: []byte(`<?xml version="1.0"?>
+<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" elementFormDefault="qualified">
+  <xs:simpleType name="CodeType">
+    <xs:restriction base="xs:token">
+      <xs:enumeration value="A"/>
+      <xs:enumeration value="B"/>
+    </xs:restriction>
+  </xs:simpleType>
+  <xs:complexType name="AmountType">
+    <xs:simpleContent>
+      <xs:extension base="AmountValueType">
+        <xs:attribute name="currencyID" type="xs:string" use="required"/>
+      </xs:extension>
+    </xs:simpleContent>
+  </xs:complexType>
+  <xs:simpleType name="AmountValueType">
+    <xs:restriction base="xs:decimal">
+      <xs:fractionDigits value="2"/>
+    </xs:restriction>
+  </xs:simpleType>
+  <xs:complexType name="HeaderType">
+    <xs:sequence>
+      <xs:element name="ID">
+        <xs:simpleType>
+          <xs:restriction base="xs:string">
+            <xs:pattern value="[A-Z]{3}-[0-9]+"/>
+          </xs:restriction>
+        </xs:simpleType>
+      </xs:element>
+      <xs:element name="Code" type="CodeType"/>
+      <xs:element name="Issued" type="xs:dateTime" minOccurs="0"/>
+    </xs:sequence>
+  </xs:complexType>
+  <xs:complexType name="LineType">
+    <xs:sequence>
+      <xs:element name="Amount" type="AmountType"/>
+    </xs:sequence>
+  </xs:complexType>
+</xs:schema>`)},
+}
+
+const validOrder = `<o:Order xmlns:o="urn:test:root" xmlns:c="urn:test:common">
+  <o:Header><c:ID>ORD-1</c:ID><c:Code>A</c:Code><c:Issued>2026-01-02T10:00:00</c:Issued></o:Header>
+  <o:Line><c:Amount currencyID="THB">10.50</c:Amount></o:Line>
+  <o:Line><c:Amount currencyID="THB">3</c:Amount></o:Line>
+</o:Order>`
+
+func TestValidate(t *testing.T) {
+	s, err := Load(testFS, "root.xsd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Validate(strings.NewReader(validOrder)); err != nil {
+		t.Fatalf("valid document rejected: %v", err)
+	}
+
+	invalid := map[string]string{
+		"enumeration":  strings.Replace(validOrder, "<c:Code>A", "<c:Code>C", 1),
+		"pattern":      strings.Replace(validOrder, "ORD-1", "ord-1", 1),
+		"fraction":     strings.Replace(validOrder, "10.50", "10.505", 1),
+		"dateTime":     strings.Replace(validOrder, "2026-01-02T10:00:00", "2026-13-02T10:00:00", 1),
+		"missing attr": strings.Replace(validOrder, ` currencyID="THB">3`, `>3`, 1),
+		"missing line": strings.Replace(strings.Replace(validOrder, `<o:Line><c:Amount currencyID="THB">10.50</c:Amount></o:Line>`, "", 1), `<o:Line><c:Amount currencyID="THB">3</c:Amount></o:Line>`, "", 1),
+		"order":        strings.Replace(validOrder, "<c:ID>ORD-1</c:ID><c:Code>A</c:Code>", "<c:Code>A</c:Code><c:ID>ORD-1</c:ID>", 1),
+		"namespace":    strings.Replace(validOrder, "<c:Code>A</c:Code>", "<o:Code>A</o:Code>", 1),
+		"root":         `<Order/>`,
+	}
+	for name, doc := range invalid {
+		err := s.Validate(strings.NewReader(doc))
+		var verr *ValidationError
+		if !errors.As(err, &verr) {
+			t.Errorf("%s: expected a validation error, got %v", name, err)
+		}
+	}
+}
+
+func TestLoadUnsupported(t *testing.T) {
+	fsys := fstest.MapFS{"s.xsd": {Data: []byte(`<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+  <xs:complexType name="T"><xs:choice/></xs:complexType>
+</xs:schema>`)}}
+	if _, err := Load(fsys, "s.xsd"); err == nil {
+		t.Fatal("expected an error for an unsupported construct")
+	}
+}